@@ -0,0 +1,138 @@
+package goplin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Kinds of anomalies.
+const (
+	AnomalyDeletions = "deletions"
+	AnomalyUpdates   = "updates"
+)
+
+// DefaultAnomalyThresholds flag 20 deletions within a minute; mass updates
+// are not checked.
+var DefaultAnomalyThresholds = AnomalyThresholds{Deletions: 20, Window: time.Minute}
+
+// AnomalyThresholds are the numbers of changes within a window of time that
+// are unusual enough to be reported. Zero disables a check.
+type AnomalyThresholds struct {
+	Deletions int           `mapstructure:"deletions" json:"deletions"`
+	Updates   int           `mapstructure:"updates" json:"updates"`
+	Window    time.Duration `mapstructure:"window" json:"window"`
+}
+
+// Anomaly is an unusual burst of changes, such as a sync wiping notes.
+type Anomaly struct {
+	Kind  string    `json:"kind"`
+	Count int       `json:"count"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	// NoteIDs are the notes changed, in the order of the changes.
+	NoteIDs []string `json:"note_ids"`
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%d %s within %s (%s to %s)", a.Count, a.Kind, a.To.Sub(a.From).Round(time.Second),
+		a.From.Format("15:04:05"), a.To.Format("15:04:05"))
+}
+
+// AnomalyDetector finds bursts of changes in the change events fed to it, so
+// a burst spread over several polls is found too. A burst is reported once,
+// however long it lasts.
+type AnomalyDetector struct {
+	thresholds AnomalyThresholds
+	// recent are the events of each kind within the window of the newest.
+	recent map[string][]Event
+	// quietUntil is the end of the last burst reported of each kind, in
+	// milliseconds.
+	quietUntil map[string]int
+}
+
+// NewAnomalyDetector returns a detector of changes over thresholds. The
+// window defaults to DefaultAnomalyThresholds.Window.
+func NewAnomalyDetector(thresholds AnomalyThresholds) *AnomalyDetector {
+	if thresholds.Window <= 0 {
+		thresholds.Window = DefaultAnomalyThresholds.Window
+	}
+
+	return &AnomalyDetector{
+		thresholds: thresholds,
+		recent:     make(map[string][]Event),
+		quietUntil: make(map[string]int),
+	}
+}
+
+// Add feeds events, oldest first, and returns the bursts they complete.
+func (d *AnomalyDetector) Add(events []Event) []Anomaly {
+	var anomalies []Anomaly
+
+	checks := []struct {
+		kind      string
+		eventType ChangeType
+		threshold int
+	}{
+		{AnomalyDeletions, EventDeleted, d.thresholds.Deletions},
+		{AnomalyUpdates, EventUpdated, d.thresholds.Updates},
+	}
+
+	window := int(d.thresholds.Window.Milliseconds())
+
+	for _, check := range checks {
+		if check.threshold <= 0 {
+			continue
+		}
+
+		recent := d.recent[check.kind]
+
+		for _, event := range events {
+			if event.Type != check.eventType {
+				continue
+			}
+
+			recent = append(recent, event)
+
+			// Keep the events within the window ending with this one.
+			start := 0
+			for start < len(recent) && event.CreatedTime-recent[start].CreatedTime > window {
+				start++
+			}
+
+			recent = recent[start:]
+
+			if len(recent) < check.threshold || recent[0].CreatedTime < d.quietUntil[check.kind] {
+				continue
+			}
+
+			a := Anomaly{Kind: check.kind}
+
+			for _, e := range recent {
+				a.NoteIDs = append(a.NoteIDs, e.ItemID)
+			}
+
+			a.Count = len(recent)
+			a.From = time.UnixMilli(int64(recent[0].CreatedTime))
+			a.To = time.UnixMilli(int64(event.CreatedTime))
+
+			anomalies = append(anomalies, a)
+
+			// Changes up to a window after this one belong to the same
+			// burst.
+			d.quietUntil[check.kind] = event.CreatedTime + window
+		}
+
+		d.recent[check.kind] = recent
+	}
+
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].From.Before(anomalies[j].From) })
+
+	return anomalies
+}
+
+// DetectAnomalies returns the bursts of changes over thresholds in events,
+// oldest first.
+func DetectAnomalies(events []Event, thresholds AnomalyThresholds) []Anomaly {
+	return NewAnomalyDetector(thresholds).Add(events)
+}
@@ -0,0 +1,93 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// parseAppData decodes the application_data field as a JSON object. Notes
+// whose field holds something else are left alone rather than overwritten.
+func parseAppData(s string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	if len(s) == 0 {
+		return data, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &data)
+	if err != nil {
+		return nil, fmt.Errorf("application_data is not a JSON object: %w", err)
+	}
+
+	return data, nil
+}
+
+// mergeAppData merges value into data[key]. Objects are merged recursively so
+// tools sharing a key only replace the members they set; a nil value deletes.
+func mergeAppData(data map[string]interface{}, key string, value interface{}) {
+	if value == nil {
+		delete(data, key)
+		return
+	}
+
+	current, currentIsObject := data[key].(map[string]interface{})
+	update, updateIsObject := value.(map[string]interface{})
+
+	if !currentIsObject || !updateIsObject {
+		data[key] = value
+		return
+	}
+
+	for k, v := range update {
+		mergeAppData(current, k, v)
+	}
+}
+
+// GetAppData returns the value stored under key in the application_data of
+// note, or nil when it is not set. The note must have been fetched with the
+// application_data field.
+func GetAppData(note Note, key string) (interface{}, error) {
+	data, err := parseAppData(note.ApplicationData)
+	if err != nil {
+		return nil, err
+	}
+
+	return data[key], nil
+}
+
+// SetAppData stores value under key in the application_data of a note,
+// treated as a JSON object. Other keys are kept and object values are merged
+// into existing ones; a nil value removes the key.
+func (c *Client) SetAppData(noteID string, key string, value interface{}) error {
+	note, err := c.GetNote(noteID, "id", "application_data")
+	if err != nil {
+		return err
+	}
+
+	data, err := parseAppData(note.ApplicationData)
+	if err != nil {
+		return fmt.Errorf("note '%s': %w", noteID, err)
+	}
+
+	// Round-trip the value so structs merge like the maps they encode to.
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var decoded interface{}
+
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		return err
+	}
+
+	mergeAppData(data, key, decoded)
+
+	encoded, err = json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return c.SetNoteFields(noteID, map[string]interface{}{"application_data": string(encoded)})
+}
@@ -0,0 +1,59 @@
+package goplin
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidToken is returned when Joplin rejects the API token.
+	ErrInvalidToken = errors.New("invalid API token")
+	// ErrNotConnected is returned when Joplin cannot be reached.
+	ErrNotConnected = errors.New("could not connect to Joplin")
+)
+
+// ValidateToken performs a minimal authorized request. It returns nil for a
+// valid token, an error wrapping ErrInvalidToken when Joplin rejects it and an
+// error wrapping ErrNotConnected when Joplin cannot be reached.
+func (c *Client) ValidateToken() error {
+	resp, err := c.r().
+		SetQueryParam("token", c.apiToken).
+		SetQueryParam("fields", "id").
+		SetQueryParam("limit", "1").
+		Get(fmt.Sprintf("http://%s:%d/folders", c.host, c.port))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotConnected, redactError(err))
+	}
+
+	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		return ErrInvalidToken
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
+
+// RenewToken runs the interactive approval flow again, asking the user to
+// grant access in the Joplin application, and switches the client to the
+// returned token.
+func (c *Client) RenewToken() (string, error) {
+	authToken, err := c.getAuthToken()
+	if err != nil {
+		return "", err
+	}
+
+	apiToken, err := c.getApiToken(authToken)
+	if err != nil {
+		return "", err
+	}
+
+	c.apiToken = apiToken
+
+	return apiToken, nil
+}
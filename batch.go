@@ -0,0 +1,403 @@
+package goplin
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Batch actions.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// DefaultBatchConcurrency is the number of requests a batch runs at once.
+const DefaultBatchConcurrency = 4
+
+// BatchOp describes a queued operation.
+type BatchOp struct {
+	Action string                 `json:"action"`
+	Type   string                 `json:"type"`
+	ID     string                 `json:"id"`
+	Title  string                 `json:"title,omitempty"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	run   func(c *Client) error
+	stage int
+	// deps are the IDs of the items the operation needs, skipped when the
+	// creation of one of them failed.
+	deps []string
+}
+
+func (op BatchOp) String() string {
+	s := fmt.Sprintf("%-6s %-8s %-32s", op.Action, op.Type, op.ID)
+
+	if len(op.Title) != 0 {
+		s += " " + op.Title
+	}
+
+	if len(op.Fields) != 0 {
+		names := make([]string, 0, len(op.Fields))
+		for name := range op.Fields {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		s += " (" + strings.Join(names, ", ") + ")"
+	}
+
+	return s
+}
+
+type BatchFailure struct {
+	Op  BatchOp `json:"op"`
+	Err string  `json:"error"`
+}
+
+// BatchReport is the consolidated outcome of running a batch. Skipped holds
+// the operations not sent because an item they need could not be created.
+type BatchReport struct {
+	Planned   int            `json:"planned"`
+	Succeeded int            `json:"succeeded"`
+	Failed    []BatchFailure `json:"failed,omitempty"`
+	Skipped   []BatchFailure `json:"skipped,omitempty"`
+	DryRun    bool           `json:"dry_run,omitempty"`
+}
+
+// Batch queues creations, updates and deletions and runs them with bounded
+// concurrency. Items created by a batch get their ID when queued, so later
+// operations can refer to them. Operations queued after Barrier only start
+// once all earlier ones are done, e.g. to create folders before their notes.
+// Operations may be queued from several goroutines.
+type Batch struct {
+	client *Client
+	mu     sync.Mutex
+	ops    []*BatchOp
+	stage  int
+	errs   []string
+
+	// Concurrency limits the number of requests in flight, at least one.
+	Concurrency int
+	// DryRun makes Run validate the batch without sending anything.
+	DryRun bool
+}
+
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c, Concurrency: DefaultBatchConcurrency}
+}
+
+func (b *Batch) add(op BatchOp) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	op.stage = b.stage
+	b.ops = append(b.ops, &op)
+}
+
+func (b *Batch) invalid(format string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
+
+// Barrier makes the operations queued from now on wait for the earlier ones.
+func (b *Batch) Barrier() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stage++
+}
+
+// CreateNote queues the creation of a note and returns its ID.
+func (b *Batch) CreateNote(note Note) string {
+	if len(note.ID) == 0 {
+		note.ID = GenerateID()
+	}
+
+	if len(note.ParentID) == 0 {
+		b.invalid("note '%s' has no parent folder", note.Title)
+	}
+
+	b.add(BatchOp{Action: BatchCreate, Type: "note", ID: note.ID, Title: note.Title, deps: []string{note.ParentID}, run: func(c *Client) error {
+		_, err := c.CreateNote(note)
+		return err
+	}})
+
+	return note.ID
+}
+
+// CreateFolder queues the creation of a folder and returns its ID.
+func (b *Batch) CreateFolder(folder Folder) string {
+	if len(folder.ID) == 0 {
+		folder.ID = GenerateID()
+	}
+
+	if len(strings.TrimSpace(folder.Title)) == 0 {
+		b.invalid("folder '%s' has no title", folder.ID)
+	}
+
+	b.add(BatchOp{Action: BatchCreate, Type: ItemTypeFolder, ID: folder.ID, Title: folder.Title, deps: []string{folder.ParentID}, run: func(c *Client) error {
+		_, err := c.CreateFolderItem(folder)
+		return err
+	}})
+
+	return folder.ID
+}
+
+// CreateTag queues the creation of a tag and returns its ID.
+func (b *Batch) CreateTag(tag Tag) string {
+	if len(tag.ID) == 0 {
+		tag.ID = GenerateID()
+	}
+
+	if len(strings.TrimSpace(tag.Title)) == 0 {
+		b.invalid("tag '%s' has no title", tag.ID)
+	}
+
+	b.add(BatchOp{Action: BatchCreate, Type: ItemTypeTag, ID: tag.ID, Title: tag.Title, run: func(c *Client) error {
+		_, err := c.CreateTagItem(tag)
+		return err
+	}})
+
+	return tag.ID
+}
+
+// CreateResource queues the upload of a resource and returns its ID. Its
+// file is read from open when the operation runs.
+func (b *Batch) CreateResource(filename string, open func() (io.ReadCloser, error), resource Resource) string {
+	if len(resource.ID) == 0 {
+		resource.ID = GenerateID()
+	}
+
+	b.add(BatchOp{Action: BatchCreate, Type: ItemTypeResource, ID: resource.ID, Title: filename, run: func(c *Client) error {
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		_, err = c.CreateResource(filename, rc, resource)
+		return err
+	}})
+
+	return resource.ID
+}
+
+// TagNote queues attaching a tag to a note.
+func (b *Batch) TagNote(noteID string, tagID string) {
+	b.add(BatchOp{Action: BatchCreate, Type: ItemTypeNoteTag, ID: tagID, Fields: map[string]interface{}{"note_id": noteID}, deps: []string{noteID, tagID}, run: func(c *Client) error {
+		return c.CreateTagsNotes(noteID, tagID)
+	}})
+}
+
+// UntagNote queues removing a tag from a note.
+func (b *Batch) UntagNote(noteID string, tagID string) {
+	b.add(BatchOp{Action: BatchDelete, Type: ItemTypeNoteTag, ID: tagID, Fields: map[string]interface{}{"note_id": noteID}, deps: []string{noteID, tagID}, run: func(c *Client) error {
+		return c.DeleteTagFromNote(tagID, noteID)
+	}})
+}
+
+// UpdateNote queues a note update. Its fields are validated when queued.
+func (b *Batch) UpdateNote(u *NoteUpdate) {
+	fields := u.Fields()
+
+	for name, value := range fields {
+		_, err := CoerceNoteField(name, value)
+		if err != nil {
+			b.invalid("note '%s': %v", u.id, err)
+		}
+	}
+
+	deps := []string{u.id}
+	if parentID, ok := fields["parent_id"].(string); ok {
+		deps = append(deps, parentID)
+	}
+
+	b.add(BatchOp{Action: BatchUpdate, Type: "note", ID: u.id, Fields: fields, deps: deps, run: func(c *Client) error {
+		return c.ApplyNoteUpdate(u)
+	}})
+}
+
+// UpdateFolder queues a folder update; empty values are left unchanged.
+func (b *Batch) UpdateFolder(id string, title string, parentID string, icon string) {
+	fields := make(map[string]interface{})
+
+	for name, value := range map[string]string{"title": title, "parent_id": parentID, "icon": icon} {
+		if len(value) != 0 {
+			fields[name] = value
+		}
+	}
+
+	b.add(BatchOp{Action: BatchUpdate, Type: ItemTypeFolder, ID: id, Fields: fields, deps: []string{id, parentID}, run: func(c *Client) error {
+		return c.UpdateFolder(id, title, parentID, icon)
+	}})
+}
+
+// DeleteFolder queues the deletion of a folder, moved to the trash on Joplin
+// versions having one; title is only reported.
+func (b *Batch) DeleteFolder(id string, title string) {
+	b.add(BatchOp{Action: BatchDelete, Type: ItemTypeFolder, ID: id, Title: title, deps: []string{id}, run: func(c *Client) error {
+		return c.DeleteFolder(id, false)
+	}})
+}
+
+// DeleteTag queues the deletion of a tag; title is only reported.
+func (b *Batch) DeleteTag(id string, title string) {
+	b.add(BatchOp{Action: BatchDelete, Type: ItemTypeTag, ID: id, Title: title, deps: []string{id}, run: func(c *Client) error {
+		return c.DeleteTag(id)
+	}})
+}
+
+// snapshot returns the operations and problems queued so far.
+func (b *Batch) snapshot() ([]*BatchOp, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*BatchOp(nil), b.ops...), append([]string(nil), b.errs...)
+}
+
+// Ops returns the queued operations in order.
+func (b *Batch) Ops() []BatchOp {
+	queued, _ := b.snapshot()
+
+	ops := make([]BatchOp, 0, len(queued))
+	for _, op := range queued {
+		ops = append(ops, *op)
+	}
+
+	return ops
+}
+
+// Validate reports the problems found while queueing operations.
+func (b *Batch) Validate() error {
+	_, errs := b.snapshot()
+
+	return validateBatch(errs)
+}
+
+func validateBatch(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid batch:\n  %s", strings.Join(errs, "\n  "))
+}
+
+// Plan writes one line per queued operation to w, for dry runs.
+func (b *Batch) Plan(w io.Writer) {
+	ops, _ := b.snapshot()
+
+	for i, op := range ops {
+		if i != 0 && op.stage != ops[i-1].stage {
+			fmt.Fprintln(w, "-- then")
+		}
+
+		fmt.Fprintln(w, op.String())
+	}
+}
+
+// Run validates the batch and, unless DryRun is set, runs the operations
+// queued so far. Nothing is sent when validation fails. Failed operations do
+// not stop the others, except those of later stages needing an item whose
+// creation failed: they are skipped, and so are the operations needing the
+// items they would have created. Both are listed in the report.
+func (b *Batch) Run() (BatchReport, error) {
+	ops, errs := b.snapshot()
+
+	report := BatchReport{Planned: len(ops), DryRun: b.DryRun}
+
+	err := validateBatch(errs)
+	if err != nil || b.DryRun {
+		return report, err
+	}
+
+	concurrency := b.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+
+	// The IDs of the items not created, by a failure or a skip.
+	missing := make(map[string]bool)
+
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end].stage == ops[start].stage {
+			end++
+		}
+
+		var wg sync.WaitGroup
+		var lost []string
+
+		slots := make(chan struct{}, concurrency)
+
+		for _, op := range ops[start:end] {
+			if dep := missingDep(op, missing); len(dep) != 0 {
+				report.Skipped = append(report.Skipped, BatchFailure{Op: *op, Err: fmt.Sprintf("item '%s' was not created", dep)})
+
+				if op.creates() {
+					lost = append(lost, op.ID)
+				}
+
+				continue
+			}
+
+			wg.Add(1)
+			slots <- struct{}{}
+
+			go func(op *BatchOp) {
+				defer wg.Done()
+
+				err := op.run(b.client)
+
+				mu.Lock()
+				if err != nil {
+					report.Failed = append(report.Failed, BatchFailure{Op: *op, Err: err.Error()})
+
+					if op.creates() {
+						lost = append(lost, op.ID)
+					}
+				} else {
+					report.Succeeded++
+				}
+				mu.Unlock()
+
+				<-slots
+			}(op)
+		}
+
+		wg.Wait()
+
+		for _, id := range lost {
+			missing[id] = true
+		}
+
+		start = end
+	}
+
+	return report, nil
+}
+
+// creates reports whether op creates the item of its ID, a tag being only
+// attached by a creation of type note_tag.
+func (op *BatchOp) creates() bool {
+	return op.Action == BatchCreate && op.Type != ItemTypeNoteTag
+}
+
+// missingDep returns the first item op needs that was not created.
+func missingDep(op *BatchOp, missing map[string]bool) string {
+	for _, id := range op.deps {
+		if missing[id] {
+			return id
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,154 @@
+package goplin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// newTestClient returns a client of a Joplin API served by handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("JoplinClipperServer"))
+	})
+	mux.Handle("/", handler)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewWithOptions(WithAPIToken("token"), WithHost(u.Hostname()), WithPort(port), WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestBatchSkipsAfterFailedCreation(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var item struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		}
+
+		json.NewDecoder(r.Body).Decode(&item)
+
+		mu.Lock()
+		sent = append(sent, r.Method+" "+r.URL.Path+" "+item.Title)
+		mu.Unlock()
+
+		if item.Title == "broken" {
+			http.Error(w, `{"error":"failed"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(item)
+	}))
+
+	batch := client.NewBatch()
+
+	good := batch.CreateFolder(Folder{Title: "good"})
+	broken := batch.CreateFolder(Folder{Title: "broken"})
+	batch.Barrier()
+
+	batch.CreateNote(Note{Title: "kept", ParentID: good})
+	lost := batch.CreateNote(Note{Title: "lost", ParentID: broken})
+	child := batch.CreateFolder(Folder{Title: "child", ParentID: broken})
+	batch.Barrier()
+
+	batch.CreateNote(Note{Title: "grandchild", ParentID: child})
+	batch.TagNote(lost, batch.CreateTag(Tag{Title: "tag"}))
+
+	report, err := batch.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Planned != 8 || report.Succeeded != 3 || len(report.Failed) != 1 {
+		t.Fatalf("got %d planned, %d succeeded and %d failed, want 8, 3 and 1", report.Planned, report.Succeeded, len(report.Failed))
+	}
+
+	if report.Failed[0].Op.ID != broken {
+		t.Errorf("failed %s, want the folder 'broken'", report.Failed[0].Op)
+	}
+
+	var skipped []string
+	for _, s := range report.Skipped {
+		skipped = append(skipped, s.Op.Type+" "+s.Op.Title)
+	}
+
+	sort.Strings(skipped)
+
+	want := []string{"folder child", "note grandchild", "note lost", "note_tag "}
+	if len(skipped) != len(want) {
+		t.Fatalf("skipped %q, want %q", skipped, want)
+	}
+
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Fatalf("skipped %q, want %q", skipped, want)
+		}
+	}
+
+	sort.Strings(sent)
+
+	wantSent := []string{"POST /folders broken", "POST /folders good", "POST /notes kept", "POST /tags tag"}
+	if len(sent) != len(wantSent) {
+		t.Fatalf("sent %q, want %q", sent, wantSent)
+	}
+
+	for i := range wantSent {
+		if sent[i] != wantSent[i] {
+			t.Fatalf("sent %q, want %q", sent, wantSent)
+		}
+	}
+}
+
+func TestBatchDryRun(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+
+	batch := client.NewBatch()
+	batch.DryRun = true
+	batch.CreateNote(Note{Title: "orphan"})
+
+	_, err := batch.Run()
+	if err == nil {
+		t.Fatal("ran a batch with a note without a folder")
+	}
+
+	batch = client.NewBatch()
+	batch.DryRun = true
+	batch.CreateFolder(Folder{Title: "folder"})
+
+	report, err := batch.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Planned != 1 || report.Succeeded != 0 || !report.DryRun {
+		t.Errorf("got %+v, want one planned operation and nothing run", report)
+	}
+}
@@ -0,0 +1,185 @@
+package goplin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// DefaultLargeBodySize is the size of a note body, in bytes, from which
+// sending it is handled as described by LargeBodyOpts.
+const DefaultLargeBodySize = 1 << 20
+
+// LargeBodyTimeout is the time allowed per started MiB of a large body, on
+// top of the usual request timeout.
+const LargeBodyTimeout = 10 * time.Second
+
+// requestTimeout is the timeout of the requests of a client by default.
+const requestTimeout = 5 * time.Second
+
+// LargeBodyOpts select how note bodies of Threshold bytes or more, such as
+// clipped pages, are sent: they are streamed in chunks, with a timeout
+// extended by LargeBodyTimeout per MiB, after calling Warn.
+type LargeBodyOpts struct {
+	// Threshold is DefaultLargeBodySize when zero.
+	Threshold int
+	// Warn, when set, is called before sending a large body. The note ID is
+	// empty for a note being created.
+	Warn func(noteID string, size int)
+}
+
+// SetLargeBodyOpts sets how the client sends large note bodies.
+func (c *Client) SetLargeBodyOpts(opts LargeBodyOpts) {
+	c.largeBody = opts
+}
+
+// LargeBodyThreshold returns the size from which note bodies are large.
+func (c *Client) LargeBodyThreshold() int {
+	if c.largeBody.Threshold <= 0 {
+		return DefaultLargeBodySize
+	}
+
+	return c.largeBody.Threshold
+}
+
+// bodyRequest returns a request sending params, a note with a body of size
+// bytes. Large bodies are encoded up front and sent without a length, so in
+// chunks, with a longer timeout.
+func (c *Client) bodyRequest(noteID string, size int, params interface{}) (*req.Request, error) {
+	if size < c.LargeBodyThreshold() {
+		return c.r().SetBody(params), nil
+	}
+
+	if c.largeBody.Warn != nil {
+		c.largeBody.Warn(noteID, size)
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.timeout + time.Duration(size/(1<<20)+1)*LargeBodyTimeout
+
+	// Hiding the length of the reader makes the body chunked.
+	body := io.MultiReader(bytes.NewReader(data))
+
+	return c.r().SetContext(context.WithValue(c.Context(), timeoutKey{}, timeout)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(body), nil
+}
+
+// timeoutKey is the context key of the timeout of a request, overriding the
+// one of its client.
+type timeoutKey struct{}
+
+// timeoutTransport gives each request sent to next a deadline, the timeout
+// of the client unless its context overrides it. Like the timeout of an
+// http.Client, it covers reading the response body.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	timeout := t.timeout
+	if d, ok := r.Context().Value(timeoutKey{}).(time.Duration); ok {
+		timeout = d
+	}
+
+	if timeout <= 0 {
+		return t.next.RoundTrip(r)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+
+	resp, err := t.next.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelBody ends the deadline of a request once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// NoteSize is the size of the body of a note.
+type NoteSize struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
+	Title    string `json:"title"`
+	// Size is the length of the body in bytes.
+	Size int `json:"size"`
+}
+
+// NoteSizes returns the size of the bodies of notes, fetched with their
+// body, of min bytes or more, largest first.
+func NoteSizes(notes []Note, min int) []NoteSize {
+	var sizes []NoteSize
+
+	for _, note := range notes {
+		if len(note.Body) < min {
+			continue
+		}
+
+		sizes = append(sizes, NoteSize{ID: note.ID, ParentID: note.ParentID, Title: note.Title, Size: len(note.Body)})
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Size > sizes[j].Size })
+
+	return sizes
+}
+
+// ParseSize parses a size in bytes with an optional unit: B, K, KB, KiB, M,
+// MB, MiB, G, GB or GiB, without regard to case. Units are powers of 1024,
+// e.g. "1MB" is 1048576 bytes.
+func ParseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+
+	number := strings.TrimRight(s, "BbIiKkMmGg ")
+	unit := strings.ToUpper(strings.TrimSpace(s[len(number):]))
+
+	multiplier := 1
+
+	switch unit {
+	case "", "B":
+	case "K", "KB", "KIB":
+		multiplier = 1 << 10
+	case "M", "MB", "MIB":
+		multiplier = 1 << 20
+	case "G", "GB", "GIB":
+		multiplier = 1 << 30
+	default:
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+
+	return int(n * float64(multiplier)), nil
+}
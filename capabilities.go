@@ -0,0 +1,107 @@
+package goplin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnsupported is returned by the features the connected Joplin does not
+// support, wrapped with the name of the feature.
+var ErrUnsupported = errors.New("not supported by this version of Joplin")
+
+// Capabilities are the features of the Data API of the connected Joplin.
+type Capabilities struct {
+	// Version is the range of Joplin versions having these capabilities, as
+	// the Data API does not report the version itself.
+	Version string `json:"version"`
+	// Trash is set when deleted notes go to the trash and carry a
+	// deleted_time, Joplin 3.0 and later.
+	Trash bool `json:"trash"`
+	// UserData is set when notes have the user_data field of plugins,
+	// Joplin 2.13 and later.
+	UserData bool `json:"user_data"`
+	// Events is set when the /events endpoint lists changes.
+	Events bool `json:"events"`
+	// EventsCursor is set when the events endpoint pages changes with a
+	// cursor, which Watcher, GetSyncStatus and incremental mirrors need.
+	EventsCursor bool `json:"events_cursor"`
+}
+
+type capabilitiesCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+// Capabilities detects the features of the connected Joplin, once per client,
+// by probing its endpoints and fields.
+func (c *Client) Capabilities() (Capabilities, error) {
+	c.caps.mu.Lock()
+	defer c.caps.mu.Unlock()
+
+	if c.caps.caps != nil {
+		return *c.caps.caps, nil
+	}
+
+	var caps Capabilities
+
+	hasNoteField := func(field string) (bool, error) {
+		status, _, err := c.probe("notes", map[string]string{"fields": "id," + field, "limit": "1"})
+
+		return err == nil && status < 400, err
+	}
+
+	var err error
+
+	caps.Trash, err = hasNoteField("deleted_time")
+	if err != nil {
+		return caps, err
+	}
+
+	caps.UserData, err = hasNoteField("user_data")
+	if err != nil {
+		return caps, err
+	}
+
+	status, body, err := c.probe("events", map[string]string{"cursor": "0"})
+	if err != nil {
+		return caps, err
+	}
+
+	caps.Events = status < 400
+
+	if caps.Events {
+		var result eventsResult
+
+		caps.EventsCursor = json.Unmarshal(body, &result) == nil && len(result.Cursor) != 0
+	}
+
+	switch {
+	case caps.Trash:
+		caps.Version = "3.0 or later"
+	case caps.UserData:
+		caps.Version = "2.13 to 2.14"
+	default:
+		caps.Version = "older than 2.13"
+	}
+
+	c.caps.caps = &caps
+
+	return caps, nil
+}
+
+// require returns ErrUnsupported, wrapped with feature, unless has tells the
+// connected Joplin has the capability.
+func (c *Client) require(feature string, has func(Capabilities) bool) error {
+	caps, err := c.Capabilities()
+	if err != nil {
+		return err
+	}
+
+	if !has(caps) {
+		return fmt.Errorf("%s: %w (detected Joplin %s)", feature, ErrUnsupported, caps.Version)
+	}
+
+	return nil
+}
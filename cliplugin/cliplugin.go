@@ -0,0 +1,168 @@
+// Package cliplugin lets third parties add commands to goplin.
+//
+// External plugins are executables named goplin-<name> on PATH, run git-style
+// by "goplin <name> args...". They inherit goplin's environment plus:
+//
+//	GOPLIN_TOKEN       the API token goplin resolved from its config and flags
+//	GOPLIN_CONFIG      the config file goplin read, if any
+//	GOPLIN_SCRIPT_URL  the sandboxed JSON API of the script package
+//
+// Plugins written in Go get a ready client from Client. Builds of goplin that
+// import a package calling Register get its commands compiled in instead.
+package cliplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/momo182/goplin"
+)
+
+// Prefix is the file name prefix of external plugins.
+const Prefix = "goplin-"
+
+// Environment variables set for external plugins.
+const (
+	EnvToken  = "GOPLIN_TOKEN"
+	EnvConfig = "GOPLIN_CONFIG"
+)
+
+// Command is a command compiled into goplin. Cmd is a kong command struct; its
+// Run method may take a *goplin.Client argument to reuse goplin's client.
+type Command struct {
+	Name string
+	Help string
+	Cmd  interface{}
+}
+
+var (
+	mu       sync.Mutex
+	commands = make(map[string]Command)
+)
+
+// Register adds a command to goplin, typically from the init function of a
+// plugin package. Registering the same name twice panics.
+func Register(name string, help string, cmd interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := commands[name]; ok {
+		panic(fmt.Sprintf("cliplugin: command '%s' registered twice", name))
+	}
+
+	commands[name] = Command{Name: name, Help: help, Cmd: cmd}
+}
+
+// Commands returns the registered commands sorted by name.
+func Commands() []Command {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := make([]Command, 0, len(commands))
+	for _, cmd := range commands {
+		result = append(result, cmd)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+// External is an external plugin found on PATH.
+type External struct {
+	Name string
+	Path string
+}
+
+// Discover lists the external plugins on PATH. When the same name appears in
+// several directories the first one wins, as it would for the shell.
+func Discover() []External {
+	seen := make(map[string]bool)
+
+	var result []External
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] || entry.IsDir() {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			if !executable(path) {
+				continue
+			}
+
+			seen[name] = true
+			result = append(result, External{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result
+}
+
+// Find returns the external plugin for name.
+func Find(name string) (External, bool) {
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "-") {
+		return External{}, false
+	}
+
+	for _, plugin := range Discover() {
+		if plugin.Name == name {
+			return plugin, true
+		}
+	}
+
+	return External{}, false
+}
+
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, Prefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(file, Prefix)
+
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return "", false
+		}
+
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	return name, len(name) != 0
+}
+
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
+
+// Client returns a client for an external plugin written in Go, using the
+// token goplin passed in the environment.
+func Client() (*goplin.Client, error) {
+	token := os.Getenv(EnvToken)
+	if len(token) == 0 {
+		return nil, fmt.Errorf("%s is not set, run the plugin through goplin", EnvToken)
+	}
+
+	return goplin.New(token)
+}
@@ -0,0 +1,395 @@
+// Package clipper turns web pages into notes, like the Joplin Web Clipper but
+// without a browser: pages are fetched over HTTP and handed to Joplin as HTML.
+package clipper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/momo182/goplin"
+	"golang.org/x/net/html"
+)
+
+// DefaultConcurrency is the number of pages fetched at once.
+const DefaultConcurrency = 4
+
+type Options struct {
+	// FolderID is the folder the notes are created in.
+	FolderID string
+	// Concurrency limits the pages fetched at once, DefaultConcurrency if 0.
+	Concurrency int
+	// Retries is the number of extra attempts for pages failing to load.
+	Retries int
+	// Extractor, if set, reduces pages to their main content.
+	Extractor Extractor
+	// Enrich turns media URLs known to a registered oEmbed provider into a
+	// note with their thumbnail and metadata instead of clipping the page.
+	Enrich bool
+}
+
+type Failure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// Result summarizes a batch of clips.
+type Result struct {
+	Clipped    int       `json:"clipped"`
+	Duplicates int       `json:"duplicates"`
+	Failed     []Failure `json:"failed,omitempty"`
+}
+
+// ReadURLs reads the URLs to clip from a browser bookmarks export (HTML) or a
+// text file with one URL per line. Only http and https URLs are returned.
+func ReadURLs(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".html" || ext == ".htm" || bytes.Contains(data[:min(len(data), 512)], []byte("NETSCAPE-Bookmark-file")) {
+		doc, err := html.Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		walkHTML(doc, func(n *html.Node) {
+			if n.Data == "a" {
+				urls = append(urls, attr(n, "href"))
+			}
+		})
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) != 0 && !strings.HasPrefix(line, "#") {
+				urls = append(urls, line)
+			}
+		}
+	}
+
+	var valid []string
+
+	for _, u := range urls {
+		if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+			valid = append(valid, u)
+		}
+	}
+
+	return valid, nil
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// NormalizeURL returns the form of a URL used to detect duplicates: lower
+// case scheme and host, no fragment and no trailing slash.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	return u.String()
+}
+
+// ClipURLs clips every URL into a note, skipping URLs already clipped, i.e.
+// present as source_url of a note, and duplicates within urls. Failed pages
+// are retried and reported in the result.
+func ClipURLs(client *goplin.Client, urls []string, opts Options) (Result, error) {
+	var result Result
+
+	notes, err := client.GetAllNotes("id,source_url", "", "")
+	if err != nil {
+		return result, err
+	}
+
+	seen := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		if len(note.SourceURL) != 0 {
+			seen[NormalizeURL(note.SourceURL)] = true
+		}
+	}
+
+	var todo []string
+
+	for _, u := range urls {
+		key := NormalizeURL(u)
+		if seen[key] {
+			result.Duplicates++
+			continue
+		}
+
+		seen[key] = true
+		todo = append(todo, u)
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// The pages are fetched first, the notes created by one batch after.
+	batch := client.NewBatch()
+	batch.Concurrency = concurrency
+
+	// URLs by ID of the items queued for them.
+	urlsByID := make(map[string]string)
+
+	queue := make(chan string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for u := range queue {
+				var ids []string
+
+				note, err := clipNote(batch, u, opts, func(id string) { ids = append(ids, id) })
+				if err == nil {
+					ids = append(ids, batch.CreateNote(note))
+				}
+
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, Failure{URL: u, Error: err.Error()})
+				}
+
+				for _, id := range ids {
+					urlsByID[id] = u
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, u := range todo {
+		queue <- u
+	}
+
+	close(queue)
+	wg.Wait()
+
+	report, err := batch.Run()
+	if err != nil {
+		return result, err
+	}
+
+	failed := make(map[string]bool)
+
+	for _, failure := range append(report.Failed, report.Skipped...) {
+		u := urlsByID[failure.Op.ID]
+		if !failed[u] {
+			failed[u] = true
+			result.Failed = append(result.Failed, Failure{URL: u, Error: failure.Err})
+		}
+	}
+
+	for _, op := range batch.Ops() {
+		if op.Type == goplin.ItemTypeNote && !failed[urlsByID[op.ID]] {
+			result.Clipped++
+		}
+	}
+
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].URL < result.Failed[j].URL
+	})
+
+	return result, nil
+}
+
+// ClipURL fetches a page and creates a note from it in opts.FolderID.
+func ClipURL(client *goplin.Client, pageURL string, opts Options) (goplin.Note, error) {
+	batch := client.NewBatch()
+
+	note, err := clipNote(batch, pageURL, opts, func(string) {})
+	if err != nil {
+		return note, err
+	}
+
+	note.ID = batch.CreateNote(note)
+
+	report, err := batch.Run()
+	if err != nil {
+		return note, err
+	}
+
+	if len(report.Failed) != 0 {
+		return note, fmt.Errorf("could not %s %s: %s", report.Failed[0].Op.Action, report.Failed[0].Op.Type, report.Failed[0].Err)
+	}
+
+	return note, nil
+}
+
+// clipNote fetches a page and returns the note to create from it, queueing
+// the resources it links to on batch and passing their IDs to queued.
+func clipNote(batch *goplin.Batch, pageURL string, opts Options, queued func(id string)) (goplin.Note, error) {
+	if opts.Enrich {
+		embed, ok, err := FetchOEmbed(pageURL)
+		if err != nil {
+			return goplin.Note{}, err
+		}
+
+		if ok {
+			return enrichedNote(batch, pageURL, embed, opts, queued), nil
+		}
+	}
+
+	page, err := fetch(pageURL, opts.Retries)
+	if err != nil {
+		return goplin.Note{}, err
+	}
+
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return goplin.Note{}, err
+	}
+
+	title := htmlText(findElement(doc, "title"))
+	body := page
+
+	if opts.Extractor != nil {
+		var extracted string
+
+		extracted, body, err = opts.Extractor.Extract(page, pageURL)
+		if err != nil {
+			return goplin.Note{}, fmt.Errorf("could not extract content: %w", err)
+		}
+
+		if len(extracted) != 0 {
+			title = extracted
+		}
+	}
+
+	if len(title) == 0 {
+		title = pageURL
+	}
+
+	return goplin.Note{
+		ParentID:  opts.FolderID,
+		Title:     title,
+		BodyHTML:  body,
+		BaseURL:   pageURL,
+		SourceURL: pageURL,
+	}, nil
+}
+
+var httpClient = req.C().
+	SetTimeout(30 * time.Second).
+	SetUserAgent("Mozilla/5.0 (compatible; goplin)")
+
+// fetch downloads a page, retrying network errors and server side failures.
+func fetch(pageURL string, retries int) (string, error) {
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt != 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+
+		var resp *req.Response
+
+		resp, err = httpClient.R().Get(pageURL)
+		if err != nil {
+			continue
+		}
+
+		if resp.IsSuccess() {
+			return resp.String(), nil
+		}
+
+		err = fmt.Errorf("got status %d", resp.StatusCode)
+
+		if resp.StatusCode < 500 && resp.StatusCode != 429 {
+			break
+		}
+	}
+
+	return "", err
+}
+
+func walkHTML(n *html.Node, fn func(n *html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walkHTML(child, fn)
+	}
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+
+	var collect func(n *html.Node)
+
+	collect = func(n *html.Node) {
+		if n == nil {
+			return
+		}
+
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+
+	collect(n)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
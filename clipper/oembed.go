@@ -0,0 +1,191 @@
+package clipper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/importer"
+)
+
+// OEmbed is the metadata an oEmbed provider returns for a media URL.
+type OEmbed struct {
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	AuthorName   string `json:"author_name"`
+	AuthorURL    string `json:"author_url"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Duration     int    `json:"duration"`
+}
+
+// Provider is an oEmbed endpoint together with the URLs it describes.
+type Provider struct {
+	Name     string
+	Endpoint string
+	URLs     []*regexp.Regexp
+}
+
+var (
+	providersMu sync.Mutex
+	providers   = []Provider{
+		{
+			Name:     "YouTube",
+			Endpoint: "https://www.youtube.com/oembed",
+			URLs: []*regexp.Regexp{
+				regexp.MustCompile(`^https?://(www\.|m\.)?youtube\.com/(watch|shorts/|live/)`),
+				regexp.MustCompile(`^https?://youtu\.be/`),
+			},
+		},
+		{
+			Name:     "Vimeo",
+			Endpoint: "https://vimeo.com/api/oembed.json",
+			URLs:     []*regexp.Regexp{regexp.MustCompile(`^https?://(www\.|player\.)?vimeo\.com/`)},
+		},
+		{
+			Name:     "SoundCloud",
+			Endpoint: "https://soundcloud.com/oembed",
+			URLs:     []*regexp.Regexp{regexp.MustCompile(`^https?://(www\.|m\.)?soundcloud\.com/`)},
+		},
+		{
+			Name:     "Spotify",
+			Endpoint: "https://open.spotify.com/oembed",
+			URLs:     []*regexp.Regexp{regexp.MustCompile(`^https?://open\.spotify\.com/(episode|show|track|album|playlist)/`)},
+		},
+	}
+)
+
+// RegisterProvider adds an oEmbed provider, checked before the built-in ones.
+func RegisterProvider(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers = append([]Provider{p}, providers...)
+}
+
+// FindProvider returns the provider describing pageURL.
+func FindProvider(pageURL string) (Provider, bool) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	for _, p := range providers {
+		for _, re := range p.URLs {
+			if re.MatchString(pageURL) {
+				return p, true
+			}
+		}
+	}
+
+	return Provider{}, false
+}
+
+// FetchOEmbed asks the provider of pageURL for its metadata. It reports false
+// when no registered provider handles the URL.
+func FetchOEmbed(pageURL string) (OEmbed, bool, error) {
+	var embed OEmbed
+
+	p, ok := FindProvider(pageURL)
+	if !ok {
+		return embed, false, nil
+	}
+
+	resp, err := httpClient.R().
+		SetQueryParam("url", pageURL).
+		SetQueryParam("format", "json").
+		SetResult(&embed).
+		Get(p.Endpoint)
+	if err != nil {
+		return embed, true, err
+	}
+
+	if !resp.IsSuccess() {
+		return embed, true, fmt.Errorf("%s oEmbed returned status %d", p.Name, resp.StatusCode)
+	}
+
+	if len(embed.ProviderName) == 0 {
+		embed.ProviderName = p.Name
+	}
+
+	return embed, true, nil
+}
+
+// formatDuration renders seconds as h:mm:ss or m:ss.
+func formatDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+
+	if d >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, seconds%60)
+	}
+
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), seconds%60)
+}
+
+// enrichedNote composes a note describing a media URL from its oEmbed data,
+// with the upload of the thumbnail as a resource queued on batch.
+func enrichedNote(batch *goplin.Batch, pageURL string, embed OEmbed, opts Options, queued func(id string)) goplin.Note {
+	var body strings.Builder
+
+	if len(embed.ThumbnailURL) != 0 {
+		resp, err := httpClient.R().Get(embed.ThumbnailURL)
+		if err == nil && resp.IsSuccess() {
+			name := path.Base(strings.SplitN(embed.ThumbnailURL, "?", 2)[0])
+			data := resp.Bytes()
+
+			id := batch.CreateResource(name, func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}, goplin.Resource{Title: embed.Title})
+			queued(id)
+
+			fmt.Fprintf(&body, "![%s](:/%s)\n\n", embed.Title, id)
+		}
+	}
+
+	var rows [][]string
+
+	add := func(name string, value string) {
+		if len(value) != 0 {
+			rows = append(rows, []string{name, value})
+		}
+	}
+
+	add("Title", embed.Title)
+
+	if len(embed.AuthorURL) != 0 {
+		add("Author", fmt.Sprintf("[%s](%s)", embed.AuthorName, embed.AuthorURL))
+	} else {
+		add("Author", embed.AuthorName)
+	}
+
+	add("Provider", embed.ProviderName)
+
+	if embed.Duration != 0 {
+		add("Duration", formatDuration(embed.Duration))
+	}
+
+	add("URL", pageURL)
+
+	body.WriteString(importer.MarkdownTable([]string{"Field", "Value"}, rows))
+
+	if len(embed.Description) != 0 {
+		body.WriteString("\n" + embed.Description + "\n")
+	}
+
+	title := embed.Title
+	if len(title) == 0 {
+		title = pageURL
+	}
+
+	return goplin.Note{
+		ParentID:  opts.FolderID,
+		Title:     title,
+		Body:      body.String(),
+		SourceURL: pageURL,
+	}
+}
@@ -0,0 +1,175 @@
+package clipper
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Extractor reduces a fetched page to its main content before it is handed to
+// Joplin. It returns the title of the article, empty if unknown, and the HTML
+// of its content.
+type Extractor interface {
+	Extract(page string, pageURL string) (title string, content string, err error)
+}
+
+// Readability is an Extractor keeping the article of a page without menus,
+// sidebars, comments and ads, using scoring rules in the spirit of Mozilla's
+// Readability.
+type Readability struct{}
+
+// unlikelyRe matches class names and IDs of page elements which are no part of
+// an article.
+var unlikelyRe = regexp.MustCompile(`(?i)\b(ad|ads|advert\w*|banner|breadcrumbs?|combx|comments?|cookie\w*|disqus|footer|header|masthead|menu|modal|nav\w*|newsletter|outbrain|popup|promo\w*|related|share|sharing|sidebar|skyscraper|social|sponsor\w*|subscribe|taboola|widget)\b`)
+
+// likelyRe matches class names and IDs of article containers.
+var likelyRe = regexp.MustCompile(`(?i)\b(article|body|content|entry|main|page|post|story|text)\b`)
+
+var strippedElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"nav":      true,
+	"header":   true,
+	"footer":   true,
+	"aside":    true,
+	"form":     true,
+	"iframe":   true,
+	"button":   true,
+	"svg":      true,
+}
+
+func (Readability) Extract(page string, pageURL string) (string, string, error) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", "", err
+	}
+
+	title := metaContent(doc, "og:title")
+	if len(title) == 0 {
+		title = htmlText(findElement(doc, "title"))
+	}
+
+	body := findElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+
+	stripClutter(body)
+
+	content := findElement(body, "article")
+	if content == nil {
+		content = findElement(body, "main")
+	}
+
+	if content == nil {
+		content = bestCandidate(body)
+	}
+
+	var b bytes.Buffer
+
+	for child := content.FirstChild; child != nil; child = child.NextSibling {
+		err = html.Render(&b, child)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return title, b.String(), nil
+}
+
+// stripClutter removes the elements which never belong to an article.
+func stripClutter(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if child.Type == html.ElementNode {
+			hints := attr(child, "class") + " " + attr(child, "id") + " " + attr(child, "role")
+
+			if strippedElements[child.Data] || (unlikelyRe.MatchString(hints) && !likelyRe.MatchString(hints)) {
+				n.RemoveChild(child)
+			} else {
+				stripClutter(child)
+			}
+		}
+
+		child = next
+	}
+}
+
+// bestCandidate scores the parents of paragraphs by the text they hold and
+// returns the best one, or n when there are no paragraphs.
+func bestCandidate(n *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+
+	walkHTML(n, func(p *html.Node) {
+		if p.Data != "p" && p.Data != "pre" && p.Data != "td" {
+			return
+		}
+
+		text := htmlText(p)
+		if len(text) < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+
+		if parent := p.Parent; parent != nil {
+			scores[parent] += score
+
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score / 2
+			}
+		}
+	})
+
+	best := n
+	bestScore := 0.0
+
+	for candidate, score := range scores {
+		hints := attr(candidate, "class") + " " + attr(candidate, "id")
+		if likelyRe.MatchString(hints) {
+			score *= 1.25
+		}
+
+		// Prefer containers made of text over containers made of links.
+		score *= 1 - linkDensity(candidate)
+
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	text := len(htmlText(n))
+	if text == 0 {
+		return 0
+	}
+
+	links := 0
+
+	walkHTML(n, func(a *html.Node) {
+		if a.Data == "a" {
+			links += len(htmlText(a))
+		}
+	})
+
+	return float64(links) / float64(text)
+}
+
+func metaContent(doc *html.Node, property string) string {
+	content := ""
+
+	walkHTML(doc, func(n *html.Node) {
+		if n.Data == "meta" && len(content) == 0 && (attr(n, "property") == property || attr(n, "name") == property) {
+			content = strings.TrimSpace(attr(n, "content"))
+		}
+	})
+
+	return content
+}
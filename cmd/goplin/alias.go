@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// aliasPrefix marks an argument naming an alias instead of an ID.
+const aliasPrefix = "@"
+
+type AliasSetCmd struct {
+	Name string `arg name:"name" help:"Name of the alias, used as @name."`
+	ID   string `arg name:"id" help:"ID of the note or folder."`
+}
+
+type AliasListCmd struct{}
+
+type AliasRemoveCmd struct {
+	Name string `arg name:"name" help:"Name of the alias to remove."`
+}
+
+// loadAliases returns the aliases of the config file, by lowercase name.
+func loadAliases() map[string]string {
+	return viper.GetStringMapString("aliases")
+}
+
+// resolveAlias returns the ID an "@name" argument stands for, or arg itself
+// when it does not name an alias.
+func resolveAlias(arg string, aliases map[string]string) string {
+	if !strings.HasPrefix(arg, aliasPrefix) {
+		return arg
+	}
+
+	id, ok := aliases[strings.ToLower(strings.TrimPrefix(arg, aliasPrefix))]
+	if !ok {
+		return arg
+	}
+
+	return id
+}
+
+// expandAliases replaces the arguments and flag values naming an alias, as
+// in "@inbox" or "--in=@inbox", with the ID of the alias, so aliases can be
+// used wherever an ID is expected. Arguments starting with '@' that are not
+// aliases are kept as is, and so are the arguments of the alias commands,
+// which name aliases themselves.
+func expandAliases(args []string) []string {
+	aliases := loadAliases()
+	if len(aliases) == 0 {
+		return args
+	}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			if arg == "alias" {
+				return args
+			}
+
+			break
+		}
+	}
+
+	expanded := make([]string, len(args))
+
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			if flag, value, ok := strings.Cut(arg, "="); ok {
+				expanded[i] = flag + "=" + resolveAlias(value, aliases)
+				continue
+			}
+		}
+
+		expanded[i] = resolveAlias(arg, aliases)
+	}
+
+	return expanded
+}
+
+func (cmd *AliasSetCmd) Run(ctx *Globals) error {
+	name := strings.ToLower(strings.TrimPrefix(cmd.Name, aliasPrefix))
+	if len(name) == 0 || strings.ContainsAny(name, " \t=,"+aliasPrefix) {
+		return fmt.Errorf("invalid alias name '%s'", cmd.Name)
+	}
+
+	kind := "note"
+
+	note, err := client.GetNote(cmd.ID, "id,title")
+	title := note.Title
+
+	if err != nil {
+		folder, folderErr := client.GetFolder(cmd.ID, "id,title")
+		if folderErr != nil {
+			return fmt.Errorf("no note or folder with ID '%s'", cmd.ID)
+		}
+
+		kind, title = "folder", folder.Title
+	}
+
+	aliases := loadAliases()
+	if aliases == nil {
+		aliases = make(map[string]string)
+	}
+
+	aliases[name] = cmd.ID
+	viper.Set("aliases", aliases)
+
+	err = SaveConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias @%s set to %s '%s'.\n", name, kind, title)
+
+	return nil
+}
+
+func (cmd *AliasListCmd) Run(ctx *Globals) error {
+	aliases := loadAliases()
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Printf("%-16s \u2502 %s\n", "Alias", "ID")
+
+	for _, name := range names {
+		fmt.Printf("%-16s \u2502 %s\n", aliasPrefix+name, aliases[name])
+	}
+
+	return nil
+}
+
+func (cmd *AliasRemoveCmd) Run(ctx *Globals) error {
+	name := strings.ToLower(strings.TrimPrefix(cmd.Name, aliasPrefix))
+
+	aliases := loadAliases()
+	if _, ok := aliases[name]; !ok {
+		return fmt.Errorf("no alias named '%s'", cmd.Name)
+	}
+
+	delete(aliases, name)
+	viper.Set("aliases", aliases)
+
+	err := SaveConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias @%s removed.\n", name)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type AppendCmd struct {
+	ID   string   `arg name:"id" help:"ID or @alias of the note."`
+	Text []string `arg optional name:"text" help:"Text to append as a new line; read from stdin when omitted."`
+}
+
+func (cmd *AppendCmd) Run(ctx *Globals) error {
+	text := strings.Join(cmd.Text, " ")
+
+	if len(cmd.Text) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		text = string(data)
+	}
+
+	text = strings.TrimRight(text, "\r\n")
+	if len(strings.TrimSpace(text)) == 0 {
+		return fmt.Errorf("nothing to append")
+	}
+
+	note, err := client.GetNote(cmd.ID, "id,title,body")
+	if err != nil {
+		return err
+	}
+
+	body := strings.TrimRight(note.Body, "\r\n")
+	if len(body) != 0 {
+		body += "\n"
+	}
+
+	err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(note.ID).SetBody(body + text + "\n"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Appended to note '%s'.\n", note.Title)
+
+	return nil
+}
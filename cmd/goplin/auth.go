@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+type AuthCheckCmd struct{}
+
+type AuthRenewCmd struct{}
+
+type AuthListCmd struct{}
+
+type AuthAddCmd struct {
+	Name  string `arg name:"name" help:"Name of the token, e.g. scripts."`
+	Token string `arg optional name:"token" help:"Token to store; when omitted, a new token is requested from Joplin."`
+}
+
+type AuthRemoveCmd struct {
+	Name string `arg name:"name" help:"Name of the token to remove."`
+}
+
+type AuthTokenCmd struct {
+	Set        string `xor:"source" help:"Token to store."`
+	TokenFile  string `name:"token-file" xor:"source" type:"existingfile" help:"Read the token to store from a file, e.g. a mounted secret."`
+	TokenStdin bool   `name:"token-stdin" xor:"source" help:"Read the token to store from stdin."`
+}
+
+// maskToken hides all but the last characters of a token.
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+
+	return strings.Repeat("*", 8) + token[len(token)-4:]
+}
+
+func (cmd *AuthCheckCmd) Run(ctx *Globals) error {
+	err := client.ValidateToken()
+
+	switch {
+	case err == nil:
+		fmt.Println("Token is valid.")
+	case errors.Is(err, goplin.ErrInvalidToken):
+		return fmt.Errorf("token was rejected by Joplin, run 'goplin auth renew' to request a new one")
+	default:
+		return err
+	}
+
+	return nil
+}
+
+func (cmd *AuthRenewCmd) Run(ctx *Globals) error {
+	fmt.Println("Please grant access in the Joplin application.")
+
+	token, err := client.RenewToken()
+	if err != nil {
+		return err
+	}
+
+	err = SaveAPIToken(strings.ToLower(ctx.TokenName), token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Token renewed and saved.")
+
+	return nil
+}
+
+func (cmd *AuthListCmd) Run(ctx *Globals) error {
+	fmt.Printf("%-16s \u2502 %s\n", "Name", "Token")
+	fmt.Printf("%-16s \u2502 %s\n", "(default)", maskToken(viper.GetString("api_token")))
+
+	tokens := viper.GetStringMapString("tokens")
+
+	names := make([]string, 0, len(tokens))
+	for name := range tokens {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%-16s \u2502 %s\n", name, maskToken(tokens[name]))
+	}
+
+	return nil
+}
+
+func (cmd *AuthAddCmd) Run(ctx *Globals) error {
+	token := cmd.Token
+
+	if len(token) == 0 {
+		fmt.Println("Please grant access in the Joplin application.")
+
+		var err error
+
+		token, err = client.RenewToken()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := SaveAPIToken(strings.ToLower(cmd.Name), token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Token '%s' saved.\n", cmd.Name)
+
+	return nil
+}
+
+func (cmd *AuthRemoveCmd) Run(ctx *Globals) error {
+	name := strings.ToLower(cmd.Name)
+
+	tokens := viper.GetStringMapString("tokens")
+	if _, ok := tokens[name]; !ok {
+		return fmt.Errorf("no token named '%s'", cmd.Name)
+	}
+
+	delete(tokens, name)
+	viper.Set("tokens", tokens)
+
+	err := SaveConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Token '%s' removed.\n", cmd.Name)
+
+	return nil
+}
+
+// Run stores a token without connecting to Joplin, so it works before Joplin
+// is started and without the interactive approval.
+func (cmd *AuthTokenCmd) Run(ctx *Globals) error {
+	var token string
+
+	switch {
+	case len(cmd.Set) != 0:
+		token = cmd.Set
+	case len(cmd.TokenFile) != 0:
+		data, err := os.ReadFile(cmd.TokenFile)
+		if err != nil {
+			return err
+		}
+
+		token = string(data)
+	case cmd.TokenStdin:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		token = string(data)
+	default:
+		return fmt.Errorf("one of --set, --token-file or --token-stdin is required")
+	}
+
+	token = strings.TrimSpace(token)
+
+	if len(token) == 0 {
+		return fmt.Errorf("token is empty")
+	}
+
+	if strings.ContainsAny(token, " \t\r\n") {
+		return fmt.Errorf("token must be a single word")
+	}
+
+	err := SaveAPIToken(strings.ToLower(ctx.TokenName), token)
+	if err != nil {
+		return err
+	}
+
+	if len(ctx.TokenName) == 0 {
+		fmt.Println("Token saved, run 'goplin auth check' to verify it.")
+	} else {
+		fmt.Printf("Token '%s' saved, run 'goplin --token-name %s auth check' to verify it.\n", ctx.TokenName, ctx.TokenName)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin/export"
+	"github.com/momo182/goplin/sink"
+	"github.com/spf13/viper"
+)
+
+type BackupCreateCmd struct {
+	To          string   `required help:"Backup destination, a local directory or an S3 or WebDAV URL: s3://bucket/path, webdav://host/path or webdavs://host/path."`
+	Incremental bool     `help:"Only read the notes changed since the last backup to the destination."`
+	Encrypt     bool     `help:"Encrypt to the recipients of backup.encrypt_to in the config file, or of --encrypt-to."`
+	EncryptTo   []string `name:"encrypt-to" help:"Encrypt to this recipient: an age or SSH public key or a file of them (age), or a gpg key ID or e-mail (gpg). Repeatable, implies --encrypt."`
+}
+
+// backupStatePath returns the local state file of the backups to dest.
+func backupStatePath(dest string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(dest))
+
+	return filepath.Join(cacheDir, "goplin", "backups", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func (cmd *BackupCreateCmd) Run(ctx *Globals) error {
+	statePath, err := backupStatePath(cmd.To)
+	if err != nil {
+		return err
+	}
+
+	opts := export.BackupOptions{Incremental: cmd.Incremental, StatePath: statePath}
+
+	recipients := cmd.EncryptTo
+	if cmd.Encrypt && len(recipients) == 0 {
+		recipients = viper.GetStringSlice("backup.encrypt_to")
+		if len(recipients) == 0 {
+			return fmt.Errorf("no recipients to encrypt to, set backup.encrypt_to in the config file or pass --encrypt-to")
+		}
+	}
+
+	if len(recipients) != 0 {
+		opts.Encryptor, err = export.NewEncryptor(recipients)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := export.Backup(client, cmd.To, opts)
+	if err != nil {
+		return err
+	}
+
+	mode := "full"
+	if result.Incremental {
+		mode = "incremental"
+	}
+
+	fmt.Printf("Backed up generation %d of %d items to '%s' (%s run): %d changed, %d removed, %d chunks of %d bytes uploaded.\n",
+		result.Generation, result.Items, cmd.To, mode, result.Changed, result.Removed, result.Chunks, result.Bytes)
+
+	return nil
+}
+
+type BackupListCmd struct {
+	NoHeader bool   `name:"no-header" help:"Do not print the header line."`
+	From     string `required help:"Backup to list, a local directory or an S3 or WebDAV URL."`
+}
+
+func (cmd *BackupListCmd) Run(ctx *Globals) error {
+	generations, err := export.BackupGenerations(cmd.From)
+	if err != nil {
+		return err
+	}
+
+	if len(generations) == 0 {
+		fmt.Fprintf(os.Stderr, "No backup in '%s'.\n", cmd.From)
+		return nil
+	}
+
+	width := dates.Width()
+
+	if !cmd.NoHeader {
+		fmt.Printf("%-10s \u2502 %-*s \u2502 %8s \u2502 %s\n", "generation", width, "time", "items", "encrypted")
+	}
+
+	for _, gen := range generations {
+		encrypted := "no"
+		if !strings.HasSuffix(gen.Catalog, ".json") {
+			encrypted = "yes"
+		}
+
+		fmt.Printf("%-10d \u2502 %-*s \u2502 %8d \u2502 %s\n", gen.Number, width, dates.FormatTime(gen.Time, time.Now()), gen.Items, encrypted)
+	}
+
+	return nil
+}
+
+type BackupRestoreCmd struct {
+	From       string   `required help:"Backup to restore from, a local directory or an S3 or WebDAV URL."`
+	Generation int      `help:"Generation to restore, the last one by default."`
+	Identity   []string `short:"i" help:"Age identity file to decrypt the backup with. Repeatable. Gpg backups use the keyring."`
+	Out        string   `required help:"JEX archive to write, to import in Joplin with File > Import > JEX."`
+}
+
+func (cmd *BackupRestoreCmd) Run(ctx *Globals) error {
+	f, err := export.CreateOutput(cmd.Out, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.RestoreBackup(cmd.From, cmd.Generation, f, cmd.Identity)
+	if err != nil {
+		sink.Abort(f)
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %d notes, %d folders, %d tags and %d resources to '%s'.\n",
+		result.Notes, result.Folders, result.Tags, result.Resources, cmd.Out)
+
+	return nil
+}
+
+type BackupPruneCmd struct {
+	To   string `required help:"Backup to prune, a local directory or an S3 or WebDAV URL."`
+	Keep int    `required help:"Number of generations to keep, the most recent ones."`
+}
+
+func (cmd *BackupPruneCmd) Run(ctx *Globals) error {
+	statePath, err := backupStatePath(cmd.To)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.PruneBackup(cmd.To, cmd.Keep, statePath)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Generations) == 0 {
+		fmt.Println("Nothing to prune.")
+		return nil
+	}
+
+	fmt.Printf("Removed %d generations and %d chunks.\n", len(result.Generations), result.Chunks)
+
+	if result.ChunksKept {
+		fmt.Fprintln(os.Stderr, "The chunks were kept: some generations were backed up from another machine, whose chunks are unknown here.")
+	}
+
+	return nil
+}
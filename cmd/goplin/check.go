@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+type CheckInternalLinksCmd struct {
+	Fix     bool     `help:"Rewrite broken links to the conflict copy of the missing note when there is one."`
+	Replace []string `help:"Rewrite links to a missing ID to another item, as OLD=NEW. Repeatable."`
+}
+
+type CheckAnomaliesCmd struct {
+	Since     time.Duration `default:"1h" help:"How far back to look at changes."`
+	Deletions int           `help:"Deletions within the window that are unusual (default from the anomalies section of the config file, else 20)."`
+	Updates   int           `help:"Updates within the window that are unusual (default from the anomalies section of the config file, else not checked)."`
+	Window    time.Duration `help:"Window of time the changes are counted in (default from the anomalies section of the config file, else 1m)."`
+	Webhook   string        `help:"URL receiving a JSON POST for each anomaly."`
+}
+
+// loadAnomalyThresholds returns the thresholds of the anomalies section of
+// the config file, DefaultAnomalyThresholds for the ones not set.
+func loadAnomalyThresholds() (goplin.AnomalyThresholds, error) {
+	thresholds := goplin.DefaultAnomalyThresholds
+
+	err := viper.UnmarshalKey("anomalies", &thresholds)
+	if err != nil {
+		return thresholds, fmt.Errorf("invalid anomalies in the config file: %w", err)
+	}
+
+	return thresholds, nil
+}
+
+func (cmd *CheckInternalLinksCmd) Run(ctx *Globals) error {
+	replacements, err := parseReplacements(cmd.Replace)
+	if err != nil {
+		return err
+	}
+
+	graph, err := client.GetLinkGraph()
+	if err != nil {
+		return err
+	}
+
+	broken := graph.Broken()
+	if len(broken) == 0 {
+		fmt.Println("No broken internal links.")
+		return nil
+	}
+
+	// Replacement target per missing ID, from --replace or conflict copies.
+	targets := make(map[string]string)
+
+	for _, link := range broken {
+		conflict, hasConflict := graph.ConflictCopy(link.TargetID)
+		to, replaced := replacements[link.TargetID]
+
+		suggestion := ""
+
+		switch {
+		case replaced:
+			if !graph.Exists(to) {
+				return fmt.Errorf("replacement '%s' for '%s' is not a note or resource", to, link.TargetID)
+			}
+
+			targets[link.TargetID] = to
+			suggestion = "-> " + to
+		case hasConflict && cmd.Fix:
+			targets[link.TargetID] = conflict.ID
+			suggestion = "-> conflict copy " + conflict.ID
+		case hasConflict:
+			suggestion = "conflict copy " + conflict.ID + ", use --fix"
+		}
+
+		fmt.Printf("%-32s \u2502 %5d \u2502 %-32s \u2502 %-24s \u2502 %s\n",
+			link.SourceID, link.Line, link.TargetID, graph.Notes[link.SourceID].Title, suggestion)
+	}
+
+	fmt.Printf("Found %d broken internal links.\n", len(broken))
+
+	if len(targets) == 0 {
+		return nil
+	}
+
+	rewritten := 0
+
+	for sourceID, links := range graph.Outgoing {
+		note := graph.Notes[sourceID]
+		body := note.Body
+
+		for _, link := range links {
+			if to, ok := targets[link.TargetID]; ok {
+				body = goplin.RewriteLinkTarget(body, link.TargetID, to)
+			}
+		}
+
+		if body == note.Body {
+			continue
+		}
+
+		err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(sourceID).SetBody(body))
+		if err != nil {
+			return err
+		}
+
+		rewritten++
+	}
+
+	fmt.Printf("Rewrote links in %d notes.\n", rewritten)
+
+	return nil
+}
+
+// parseReplacements parses OLD=NEW pairs into a map keyed by lower case
+// ID.
+func parseReplacements(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid replacement '%s', expected OLD=NEW", pair)
+		}
+
+		result[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+
+	return result, nil
+}
+
+func (cmd *CheckAnomaliesCmd) Run(ctx *Globals) error {
+	thresholds, err := loadAnomalyThresholds()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Deletions > 0 {
+		thresholds.Deletions = cmd.Deletions
+	}
+
+	if cmd.Updates > 0 {
+		thresholds.Updates = cmd.Updates
+	}
+
+	if cmd.Window > 0 {
+		thresholds.Window = cmd.Window
+	}
+
+	events, _, err := client.GetEvents("0")
+	if err != nil {
+		return err
+	}
+
+	since := int(time.Now().Add(-cmd.Since).UnixMilli())
+
+	var recent []goplin.Event
+
+	for _, event := range events {
+		if event.CreatedTime >= since {
+			recent = append(recent, event)
+		}
+	}
+
+	anomalies := goplin.DetectAnomalies(recent, thresholds)
+	if len(anomalies) == 0 {
+		fmt.Printf("No unusual changes in the last %s.\n", cmd.Since)
+		return nil
+	}
+
+	for _, a := range anomalies {
+		fmt.Println(a)
+
+		if len(cmd.Webhook) != 0 {
+			err = postWebhook(cmd.Webhook, struct {
+				Anomaly goplin.Anomaly `json:"anomaly"`
+			}{a})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Failing lets scripts stop before acting on the vault, e.g. with
+	// 'goplin check anomalies && goplin mirror dir ...'.
+	return fmt.Errorf("%d unusual bursts of changes found", len(anomalies))
+}
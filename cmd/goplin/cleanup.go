@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+type CleanupTagsCmd struct {
+	Unused    bool   `required help:"Remove tags attached to no note."`
+	OlderThan string `name:"older-than" help:"Only remove tags not modified within the given duration (e.g. 90d)."`
+	DryRun    bool   `name:"dry-run" help:"Only list the tags that would be removed."`
+	Yes       bool   `short:"y" help:"Do not ask for confirmation."`
+}
+
+type CleanupFoldersCmd struct {
+	Empty  bool     `required help:"Remove folders with no notes in their entire subtree."`
+	Keep   []string `help:"Folder IDs or paths to never remove, in addition to cleanup.keep_folders from the config file."`
+	DryRun bool     `name:"dry-run" help:"Only list the folders that would be removed."`
+	Yes    bool     `short:"y" help:"Do not ask for confirmation."`
+}
+
+// Confirm asks the user a yes/no question on the terminal, defaulting to no.
+func Confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+func (cmd *CleanupTagsCmd) Run(ctx *Globals) error {
+	var olderThan time.Duration
+	var err error
+
+	if len(cmd.OlderThan) != 0 {
+		olderThan, err = goplin.ParseDuration(cmd.OlderThan)
+		if err != nil {
+			return err
+		}
+	}
+
+	tags, err := client.FindUnusedTags(olderThan)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No unused tags found.")
+		return nil
+	}
+
+	fmt.Println("Unused tags:")
+
+	for _, tag := range tags {
+		PrintRow(tag, "id,title", &goplin.TagFormats)
+	}
+
+	if cmd.DryRun {
+		return nil
+	}
+
+	if !cmd.Yes && !Confirm(fmt.Sprintf("Delete %d unused tags?", len(tags))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	batch := client.NewBatch()
+	for _, tag := range tags {
+		batch.DeleteTag(tag.ID, tag.Title)
+	}
+
+	report, err := batch.Run()
+	if err != nil {
+		return err
+	}
+
+	client.InvalidateCache()
+
+	printBatchReport(report)
+
+	fmt.Printf("Deleted %d of %d unused tags.\n", report.Succeeded, len(tags))
+
+	return nil
+}
+
+func printBatchReport(report goplin.BatchReport) {
+	for _, failure := range report.Failed {
+		fmt.Printf("Could not %s %s with ID '%s': %s\n", failure.Op.Action, failure.Op.Type, failure.Op.ID, failure.Err)
+	}
+
+	for _, skipped := range report.Skipped {
+		fmt.Printf("Skipped %s %s with ID '%s': %s\n", skipped.Op.Action, skipped.Op.Type, skipped.Op.ID, skipped.Err)
+	}
+}
+
+func (cmd *CleanupFoldersCmd) Run(ctx *Globals) error {
+	keep := make(map[string]bool)
+	for _, folder := range append(viper.GetStringSlice("cleanup.keep_folders"), cmd.Keep...) {
+		keep[folder] = true
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return err
+	}
+
+	notes, err := client.CountNotesPerFolder()
+	if err != nil {
+		return err
+	}
+
+	empty := goplin.FindEmptyFolders(tree, notes, func(node *goplin.FolderNode) bool {
+		return keep[node.Folder.ID] || keep[node.Path()]
+	})
+
+	if len(empty) == 0 {
+		fmt.Println("No empty folders found.")
+		return nil
+	}
+
+	fmt.Println("Empty folders:")
+
+	for _, node := range empty {
+		fmt.Printf("%-32s \u2502 %s\n", node.Folder.ID, node.Path())
+	}
+
+	if cmd.DryRun {
+		return nil
+	}
+
+	if !cmd.Yes && !Confirm(fmt.Sprintf("Delete %d empty folders and their sub-folders?", len(empty))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	batch := client.NewBatch()
+	for _, node := range empty {
+		batch.DeleteFolder(node.Folder.ID, node.Path())
+	}
+
+	report, err := batch.Run()
+	if err != nil {
+		return err
+	}
+
+	printBatchReport(report)
+
+	fmt.Printf("Deleted %d of %d empty folders.\n", report.Succeeded, len(empty))
+
+	return nil
+}
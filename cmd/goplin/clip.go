@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/clipper"
+)
+
+type ClipCmd struct {
+	From        string `type:"existingfile" help:"Bookmarks export (HTML) or text file with one URL per line."`
+	Folder      string `default:"Reading" help:"ID or title of the folder to clip into, created when missing."`
+	Concurrency int    `default:"4" help:"Number of pages fetched at once."`
+	Retries     int    `default:"2" help:"Extra attempts for pages failing to load."`
+	Readability bool   `help:"Keep only the article of each page, without menus, sidebars and ads."`
+	Enrich      bool   `help:"Describe video and podcast links (YouTube, Vimeo, SoundCloud, Spotify) with their thumbnail and metadata."`
+
+	URLs []string `arg optional name:"url" help:"URLs to clip."`
+}
+
+// resolveFolder returns the ID of the folder with the given ID or title,
+// creating a top-level folder with that title when there is none.
+func resolveFolder(idOrTitle string) (string, error) {
+	folders, err := client.GetAllFolders("id,parent_id,title", "", "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, folder := range folders {
+		if folder.ID == idOrTitle {
+			return folder.ID, nil
+		}
+	}
+
+	for _, folder := range folders {
+		if strings.EqualFold(folder.Title, idOrTitle) {
+			return folder.ID, nil
+		}
+	}
+
+	folder, err := client.CreateFolderItem(goplin.Folder{Title: idOrTitle})
+	if err != nil {
+		return "", err
+	}
+
+	return folder.ID, nil
+}
+
+func (cmd *ClipCmd) Run(ctx *Globals) error {
+	urls := cmd.URLs
+
+	if len(cmd.From) != 0 {
+		fromFile, err := clipper.ReadURLs(cmd.From)
+		if err != nil {
+			return err
+		}
+
+		urls = append(urls, fromFile...)
+	}
+
+	if len(urls) == 0 {
+		return fmt.Errorf("no URLs to clip, pass them as arguments or with --from")
+	}
+
+	folderID, err := resolveFolder(cmd.Folder)
+	if err != nil {
+		return err
+	}
+
+	opts := clipper.Options{
+		FolderID:    folderID,
+		Concurrency: cmd.Concurrency,
+		Retries:     cmd.Retries,
+		Enrich:      cmd.Enrich,
+	}
+
+	if cmd.Readability {
+		opts.Extractor = clipper.Readability{}
+	}
+
+	result, err := clipper.ClipURLs(client, urls, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, failure := range result.Failed {
+		fmt.Printf("FAILED: %s: %s\n", failure.URL, failure.Error)
+	}
+
+	fmt.Printf("Clipped %d pages, skipped %d already clipped, %d failed.\n",
+		result.Clipped, result.Duplicates, len(result.Failed))
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/markup"
+)
+
+type ConvertNoteCmd struct {
+	To     string `required enum:"markdown,html" help:"Markup language to convert to: markdown or html."`
+	Scope  string `help:"Convert the notes of this folder (ID, title or path) and its sub-folders instead of the given IDs."`
+	DryRun bool   `name:"dry-run" help:"Only list the notes that would be converted."`
+
+	IDs []string `arg optional name:"id" help:"IDs of the notes to convert, \"-\" reads IDs from stdin."`
+}
+
+func (cmd *ConvertNoteCmd) Run(ctx *Globals) error {
+	to, err := markup.ParseLanguage(cmd.To)
+	if err != nil {
+		return err
+	}
+
+	ids, err := ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 && len(cmd.Scope) == 0 {
+		return fmt.Errorf("give the IDs of the notes to convert or a --scope")
+	}
+
+	fields := "id,title,body,markup_language"
+
+	var notes []goplin.Note
+
+	if len(ids) != 0 {
+		for _, id := range ids {
+			note, err := client.GetNote(id, fields)
+			if err != nil {
+				return err
+			}
+
+			notes = append(notes, note)
+		}
+	} else {
+		notes, err = client.GetNotesInScope(cmd.Scope, fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	converted := 0
+
+	for _, note := range notes {
+		from := note.MarkupLanguage
+		if from == 0 {
+			from = markup.Markdown
+		}
+
+		if from == to {
+			continue
+		}
+
+		fmt.Printf("%-32s \u2502 %-8s \u2502 %s\n", note.ID, markup.Name(from), note.Title)
+
+		if cmd.DryRun {
+			continue
+		}
+
+		body, err := markup.Convert(note.Body, from, to)
+		if err != nil {
+			return fmt.Errorf("could not convert note '%s': %w", note.ID, err)
+		}
+
+		err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(note.ID).SetBody(body).Set("markup_language", to))
+		if err != nil {
+			return err
+		}
+
+		converted++
+	}
+
+	if cmd.DryRun {
+		return nil
+	}
+
+	fmt.Printf("Converted %d of %d notes to %s.\n", converted, len(notes), markup.Name(to))
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type CreateNoteCmd struct {
+	Title  string   `help:"Title of the note; by default the first line of the Markdown, without its heading marks, or the name of --file."`
+	File   string   `type:"existingfile" help:"Markdown file to read the body from, instead of stdin."`
+	Folder string   `help:"Folder to create the note in (ID, path or title); Joplin's default folder otherwise."`
+	Tags   []string `help:"Tags of the note, by ID or title, comma-separated; missing tags are created."`
+	Todo   bool     `help:"Create a to-do instead of a note."`
+}
+
+func (cmd *CreateNoteCmd) Run(ctx *Globals) error {
+	var data []byte
+	var err error
+
+	if len(cmd.File) != 0 {
+		data, err = os.ReadFile(cmd.File)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	body := string(data)
+
+	title := strings.TrimSpace(cmd.Title)
+	if len(title) == 0 {
+		title, body = splitTitle(body)
+	}
+
+	if len(title) == 0 && len(cmd.File) != 0 {
+		title = strings.TrimSuffix(filepath.Base(cmd.File), filepath.Ext(cmd.File))
+	}
+
+	if len(title) == 0 {
+		return fmt.Errorf("the note needs a title, give --title or start the Markdown with one")
+	}
+
+	note := goplin.Note{Title: title, Body: body}
+
+	if cmd.Todo {
+		note.IsTodo = 1
+	}
+
+	created, err := createNote(note, cmd.Folder, cmd.Tags)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(created.ID)
+
+	return nil
+}
+
+// createNote creates note in the folder given by ID, path or title, Joplin's
+// default folder when empty, and tags it, creating the missing tags.
+func createNote(note goplin.Note, folder string, tags []string) (goplin.Note, error) {
+	if len(folder) != 0 {
+		tree, err := client.GetFolderTree()
+		if err != nil {
+			return note, err
+		}
+
+		node, err := goplin.FindFolderNode(tree, folder)
+		if err != nil {
+			return note, err
+		}
+
+		note.ParentID = node.Folder.ID
+	}
+
+	var tagIDs []string
+	var err error
+
+	if len(tags) != 0 {
+		// Tags are resolved first, so a failure leaves no untagged note.
+		tagIDs, err = client.EnsureTags(tags)
+		if err != nil {
+			return note, err
+		}
+	}
+
+	created, err := client.CreateNote(note)
+	if err != nil {
+		return created, err
+	}
+
+	for _, tagID := range tagIDs {
+		err = client.CreateTagsNotes(created.ID, tagID)
+		if err != nil {
+			return created, fmt.Errorf("created note '%s' but could not tag it: %w", created.ID, err)
+		}
+	}
+
+	return created, nil
+}
+
+// splitTitle takes the title from the first non-empty line of a Markdown
+// body. A heading line is removed from the body, any other line stays.
+func splitTitle(body string) (string, string) {
+	rest := body
+
+	for len(rest) != 0 {
+		line := rest
+		next := ""
+
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			line, next = rest[:i], rest[i+1:]
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 {
+			rest = next
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			return strings.TrimSpace(strings.TrimLeft(trimmed, "#")), strings.TrimLeft(next, "\r\n")
+		}
+
+		return trimmed, body
+	}
+
+	return "", body
+}
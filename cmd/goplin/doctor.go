@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type DoctorCmd struct {
+	JSON bool `name:"json" help:"Print the report as JSON."`
+}
+
+func (cmd *DoctorCmd) Run(ctx *Globals) error {
+	report := client.Doctor()
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(report)
+		if err != nil {
+			return err
+		}
+	} else {
+		caps := report.Capabilities
+
+		fmt.Printf("Joplin %s on port %d: trash %s, user data %s, events %s, events cursor %s.\n", caps.Version, report.Port,
+			yesNo(caps.Trash), yesNo(caps.UserData), yesNo(caps.Events), yesNo(caps.EventsCursor))
+
+		for _, check := range report.Checks {
+			var details []string
+
+			switch {
+			case !check.Supported && len(check.Error) == 0:
+				details = append(details, "endpoint not supported")
+			case len(check.Error) != 0:
+				details = append(details, check.Error)
+			}
+
+			if len(check.Missing) != 0 {
+				details = append(details, "missing fields: "+strings.Join(check.Missing, ", "))
+			}
+
+			if len(check.Unknown) != 0 {
+				details = append(details, "unknown fields: "+strings.Join(check.Unknown, ", "))
+			}
+
+			status := "ok"
+			if !check.OK() {
+				status = "PROBLEM"
+			}
+
+			fmt.Printf("%-10s \u2502 %-7s \u2502 %s\n", check.Endpoint, status, strings.Join(details, "; "))
+		}
+	}
+
+	if problems := report.Problems(); problems != 0 {
+		return fmt.Errorf("found %d problems, the Joplin version may not match what goplin supports", problems)
+	}
+
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin/download"
+)
+
+type DownloadResourcesCmd struct {
+	Out         string `required help:"Directory to download the resources to, created if missing."`
+	Layout      string `enum:"flat,by-notebook,by-mime" default:"flat" help:"Where files go: flat, by-notebook (folder of the first note linking to them) or by-mime (a directory per MIME type)."`
+	Concurrency int    `default:"4" help:"Number of resources downloaded at once."`
+}
+
+func (cmd *DownloadResourcesCmd) Run(ctx *Globals) error {
+	names, err := loadFileNameOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := download.Resources(client, cmd.Out, download.Options{
+		Layout:      cmd.Layout,
+		Concurrency: cmd.Concurrency,
+		Names:       names,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, failure := range result.Failed {
+		fmt.Printf("FAILED: %s: %s\n", failure.ID, failure.Error)
+	}
+
+	fmt.Printf("Downloaded %d resources to '%s', %d already up to date, %d failed.\n",
+		result.Downloaded, cmd.Out, result.Skipped, len(result.Failed))
+
+	if len(result.Failed) != 0 {
+		return fmt.Errorf("could not download %d resources, run again to retry them", len(result.Failed))
+	}
+
+	return nil
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin/export"
+	"github.com/spf13/viper"
+)
+
+type ExportVaultCmd struct {
+	Profile string `short:"p" help:"Run the export profile of this name from export_profiles in the config file. The other flags override its settings."`
+	Format  string `help:"Export format: jex (default), hugo, markdown, obsidian or dendron."`
+	Scope   string `help:"Export only this folder (ID or path) and its sub-folders."`
+	Out     string `help:"Output file or directory, a local path or an S3 or WebDAV URL: s3://bucket/path, webdav://host/path or webdavs://host/path."`
+
+	SkipResources bool     `name:"skip-resources" help:"Leave the attachments out of the export."`
+	EncryptTo     []string `name:"encrypt-to" help:"Encrypt the export to this recipient: an age or SSH public key or a file of them (age), or a gpg key ID or e-mail (gpg). Repeatable."`
+}
+
+// exporters run a validated profile, by format.
+var exporters = map[string]func(profile export.Profile, out string) error{
+	"jex":      exportJEX,
+	"hugo":     exportHugo,
+	"markdown": exportMarkdown,
+	"obsidian": exportWiki,
+	"dendron":  exportWiki,
+}
+
+func loadExportProfiles() (map[string]export.Profile, error) {
+	profiles := make(map[string]export.Profile)
+
+	err := viper.UnmarshalKey("export_profiles", &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export_profiles section in the config file: %w", err)
+	}
+
+	return profiles, nil
+}
+
+func (cmd *ExportVaultCmd) Run(ctx *Globals) error {
+	var profile export.Profile
+
+	if len(cmd.Profile) != 0 {
+		profiles, err := loadExportProfiles()
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+
+		// Viper lowercases the keys of the config file.
+		profile, ok = profiles[strings.ToLower(cmd.Profile)]
+		if !ok {
+			return fmt.Errorf("no export profile named '%s' in the config file", cmd.Profile)
+		}
+	}
+
+	if len(cmd.Format) != 0 {
+		profile.Format = cmd.Format
+	}
+
+	if len(profile.Format) == 0 {
+		profile.Format = "jex"
+	}
+
+	if len(cmd.Scope) != 0 {
+		profile.Scope = cmd.Scope
+	}
+
+	if len(cmd.Out) != 0 {
+		profile.Out = cmd.Out
+	}
+
+	if cmd.SkipResources {
+		profile.Resources = export.ResourcesSkip
+	}
+
+	if len(cmd.EncryptTo) != 0 {
+		profile.EncryptTo = cmd.EncryptTo
+	}
+
+	err := profile.Validate()
+	if err != nil {
+		if len(cmd.Profile) != 0 {
+			return fmt.Errorf("export profile '%s': %w", cmd.Profile, err)
+		}
+
+		return err
+	}
+
+	out, err := profile.Output(time.Now())
+	if err != nil {
+		return err
+	}
+
+	run, ok := exporters[profile.Format]
+	if !ok {
+		return fmt.Errorf("the %s format cannot be exported from the command line", profile.Format)
+	}
+
+	return run(profile, out)
+}
+
+// profileEncryptor returns the encryptor of the recipients of a profile, nil
+// when it is not encrypted.
+func profileEncryptor(profile export.Profile) (export.Encryptor, error) {
+	if len(profile.EncryptTo) == 0 {
+		return nil, nil
+	}
+
+	return export.NewEncryptor(profile.EncryptTo)
+}
+
+func exportJEX(profile export.Profile, out string) error {
+	enc, err := profileEncryptor(profile)
+	if err != nil {
+		return err
+	}
+
+	opts := export.JEXOptions{
+		Scope:         profile.Scope,
+		SkipResources: profile.Resources == export.ResourcesSkip,
+	}
+
+	if enc != nil {
+		if !strings.HasSuffix(out, enc.Extension()) {
+			out += enc.Extension()
+		}
+
+		opts.Encryptor = enc
+	}
+
+	result, err := export.ExportJEX(client, out, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d notes, %d folders, %d tags and %d resources to '%s'.\n",
+		result.Notes, result.Folders, result.Tags, result.Resources, out)
+
+	return nil
+}
+
+type ExportHugoCmd struct {
+	Folder      string   `required help:"Folder of the pages (ID or path), its sub-folders become sections."`
+	Out         string   `required help:"Content directory to write the page bundles to, e.g. content/posts."`
+	DraftTag    string   `name:"draft-tag" default:"draft" help:"Notes with this tag are drafts."`
+	PublishTag  string   `name:"publish-tag" help:"Only notes with this tag are published, the others are drafts."`
+	SkipDrafts  bool     `name:"skip-drafts" help:"Leave drafts out instead of exporting them with draft: true."`
+	NoResources bool     `name:"no-resources" help:"Do not copy the attachments into the bundles."`
+	FrontMatter []string `name:"front-matter" help:"Extra note fields for the front matter: id, created_time, updated_time, source_url, author or location. Repeatable."`
+}
+
+func (cmd *ExportHugoCmd) Run(ctx *Globals) error {
+	profile := export.Profile{
+		Format:      "hugo",
+		Scope:       cmd.Folder,
+		FrontMatter: cmd.FrontMatter,
+		Out:         cmd.Out,
+		DraftTag:    cmd.DraftTag,
+		PublishTag:  cmd.PublishTag,
+		SkipDrafts:  cmd.SkipDrafts,
+	}
+
+	if cmd.NoResources {
+		profile.Resources = export.ResourcesSkip
+	}
+
+	err := profile.Validate()
+	if err != nil {
+		return err
+	}
+
+	return exportHugo(profile, cmd.Out)
+}
+
+// loadFileNameOptions reads the naming of exported files from the filenames
+// section of the config file.
+func loadFileNameOptions() (export.SluggerOptions, error) {
+	var opts export.SluggerOptions
+
+	err := viper.UnmarshalKey("filenames", &opts)
+	if err != nil {
+		return opts, fmt.Errorf("invalid filenames in the config file: %w", err)
+	}
+
+	return opts, nil
+}
+
+func exportHugo(profile export.Profile, out string) error {
+	names, err := loadFileNameOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := profileEncryptor(profile)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.WriteHugo(client, out, export.HugoOptions{
+		Folder:        profile.Scope,
+		DraftTag:      profile.DraftTag,
+		PublishTag:    profile.PublishTag,
+		SkipDrafts:    profile.SkipDrafts,
+		SkipResources: profile.Resources == export.ResourcesSkip,
+		FrontMatter:   profile.FrontMatter,
+		Names:         names,
+		Encryptor:     enc,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d pages (%d drafts, %d skipped) and %d resources to '%s'.\n",
+		result.Pages, result.Drafts, result.Skipped, result.Resources, out)
+
+	return nil
+}
+
+type ExportWikiFlags struct {
+	Scope       string   `help:"Export only this folder (ID or path) and its sub-folders."`
+	Out         string   `required help:"Directory to write the vault to, a local path or an S3 or WebDAV URL."`
+	NoResources bool     `name:"no-resources" help:"Do not copy the attachments."`
+	FrontMatter []string `name:"front-matter" default:"tags" help:"Note fields for the front matter: id, title, created_time, updated_time, tags, source_url, author or location. Repeatable."`
+}
+
+func (cmd *ExportWikiFlags) run(ctx *Globals, format string) error {
+	profile := export.Profile{
+		Format:      format,
+		Scope:       cmd.Scope,
+		FrontMatter: cmd.FrontMatter,
+		Out:         cmd.Out,
+	}
+
+	if cmd.NoResources {
+		profile.Resources = export.ResourcesSkip
+	}
+
+	err := profile.Validate()
+	if err != nil {
+		return err
+	}
+
+	return exportWiki(profile, cmd.Out)
+}
+
+type ExportMarkdownCmd struct {
+	Scope       string   `help:"Export only this folder (ID or path) and its sub-folders."`
+	Out         string   `required help:"Directory to write the notes to, a local path or an S3 or WebDAV URL."`
+	NoResources bool     `name:"no-resources" help:"Do not download the attachments."`
+	FrontMatter []string `name:"front-matter" default:"title,created_time,updated_time,tags,location" help:"Note fields for the front matter: id, title, created_time, updated_time, tags, source_url, author or location. Repeatable."`
+}
+
+func (cmd *ExportMarkdownCmd) Run(ctx *Globals) error {
+	profile := export.Profile{
+		Format:      "markdown",
+		Scope:       cmd.Scope,
+		FrontMatter: cmd.FrontMatter,
+		Out:         cmd.Out,
+	}
+
+	if cmd.NoResources {
+		profile.Resources = export.ResourcesSkip
+	}
+
+	err := profile.Validate()
+	if err != nil {
+		return err
+	}
+
+	return exportMarkdown(profile, cmd.Out)
+}
+
+func exportMarkdown(profile export.Profile, out string) error {
+	names, err := loadFileNameOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := profileEncryptor(profile)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.WriteMarkdown(client, out, export.MarkdownOptions{
+		Scope:         profile.Scope,
+		SkipResources: profile.Resources == export.ResourcesSkip,
+		FrontMatter:   profile.FrontMatter,
+		Names:         names,
+		Encryptor:     enc,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d notes and %d resources to '%s'.\n", result.Notes, result.Resources, out)
+
+	return nil
+}
+
+type ExportObsidianCmd struct {
+	ExportWikiFlags
+}
+
+func (cmd *ExportObsidianCmd) Run(ctx *Globals) error {
+	return cmd.run(ctx, export.WikiObsidian)
+}
+
+type ExportDendronCmd struct {
+	ExportWikiFlags
+}
+
+func (cmd *ExportDendronCmd) Run(ctx *Globals) error {
+	return cmd.run(ctx, export.WikiDendron)
+}
+
+func exportWiki(profile export.Profile, out string) error {
+	names, err := loadFileNameOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := profileEncryptor(profile)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.WriteWiki(client, out, export.WikiOptions{
+		Style:         profile.Format,
+		Scope:         profile.Scope,
+		SkipResources: profile.Resources == export.ResourcesSkip,
+		FrontMatter:   profile.FrontMatter,
+		Names:         names,
+		Encryptor:     enc,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d notes and %d resources to '%s'.\n", result.Notes, result.Resources, out)
+
+	return nil
+}
+
+type ExportAnkiCmd struct {
+	Tag  string `help:"Export only the cards of notes with this tag (ID or title)."`
+	Deck string `help:"Deck to import the cards into, by default the tag title or Goplin."`
+	Out  string `required help:"Text file to write, \"-\" for stdout. Import it in Anki with File > Import."`
+}
+
+func (cmd *ExportAnkiCmd) Run(ctx *Globals) error {
+	if strings.HasSuffix(strings.ToLower(cmd.Out), ".apkg") {
+		return fmt.Errorf("goplin cannot write Anki packages (.apkg), write a .txt file and import it with File > Import in Anki")
+	}
+
+	tagID, err := resolveTag(cmd.Tag)
+	if err != nil {
+		return err
+	}
+
+	deck := cmd.Deck
+	if len(deck) == 0 && len(tagID) != 0 {
+		tag, err := client.GetTag(tagID)
+		if err != nil {
+			return err
+		}
+
+		deck = tag.Title
+	}
+
+	opts := export.AnkiOptions{TagID: tagID, Deck: deck}
+
+	if cmd.Out == "-" {
+		_, err = export.WriteAnki(client, os.Stdout, opts)
+		return err
+	}
+
+	f, err := os.Create(cmd.Out)
+	if err != nil {
+		return err
+	}
+
+	result, err := export.WriteAnki(client, f, opts)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d cards from %d notes to '%s'.\n", result.Cards, result.Notes, cmd.Out)
+
+	return nil
+}
+
+type ExportTagmapCmd struct {
+	Out string `required help:"JSON file to write, \"-\" for stdout."`
+}
+
+func (cmd *ExportTagmapCmd) Run(ctx *Globals) error {
+	m, err := client.GetTagMap()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+
+	if cmd.Out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	err = os.WriteFile(cmd.Out, data, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported the tags of %d notes to '%s'.\n", len(m.Notes), cmd.Out)
+
+	return nil
+}
+
+type ExportProfilesCmd struct {
+	NoHeader bool `short:"n" help:"Do not print the header."`
+}
+
+func (cmd *ExportProfilesCmd) Run(ctx *Globals) error {
+	profiles, err := loadExportProfiles()
+	if err != nil {
+		return err
+	}
+
+	if len(profiles) == 0 {
+		fmt.Fprintln(os.Stderr, "No export profiles, define them under export_profiles in the config file.")
+		return nil
+	}
+
+	var names []string
+
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if !cmd.NoHeader {
+		fmt.Printf("%-16s \u2502 %-8s \u2502 %-24s \u2502 %s\n", "name", "format", "scope", "out")
+	}
+
+	for _, name := range names {
+		profile := profiles[name]
+
+		format := profile.Format
+		if len(format) == 0 {
+			format = "jex"
+		}
+
+		status := profile.Out
+		if err := profile.Validate(); err != nil {
+			status = "invalid: " + err.Error()
+		}
+
+		fmt.Printf("%-16s \u2502 %-8s \u2502 %-24s \u2502 %s\n", name, format, profile.Scope, status)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type CreateFolderCmd struct {
+	Title  string `arg name:"title" help:"Title of the folder."`
+	Parent string `help:"Folder to create the folder in (ID, path or title); top level otherwise."`
+	Icon   string `help:"Icon, as Joplin icon JSON (e.g. {\"emoji\":\"📁\"})."`
+}
+
+type DeleteFolderCmd struct {
+	Force     bool `help:"Delete folders even when they hold notes or sub-folders, which are deleted with them."`
+	Permanent bool `help:"Delete the folders permanently instead of moving them to the trash (Joplin 3.0 and later; older versions have no trash)."`
+
+	IDs []string `arg name:"id" help:"Delete folders with the specified IDs, paths or titles, \"-\" reads them from stdin."`
+}
+
+type MoveFolderCmd struct {
+	ID string `arg name:"id" help:"ID of the folder to move, \"-\" reads IDs from stdin."`
+	To string `arg optional name:"parent-id" help:"ID of the new parent folder (top level when omitted)."`
+}
+
+type RenameFolderCmd struct {
+	ID    string `arg name:"id" help:"ID of the folder to rename, \"-\" reads IDs from stdin."`
+	Title string `arg name:"title" help:"New title."`
+	Icon  string `help:"New icon, as Joplin icon JSON (e.g. {\"emoji\":\"📁\"})."`
+}
+
+func (cmd *MoveFolderCmd) Run(ctx *Globals) error {
+	return EachID([]string{cmd.ID}, func(id string) error {
+		err := client.MoveFolder(id, cmd.To)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Folder with ID '%s' moved.\n", id)
+
+		return nil
+	})
+}
+
+func (cmd *RenameFolderCmd) Run(ctx *Globals) error {
+	return EachID([]string{cmd.ID}, func(id string) error {
+		err := client.UpdateFolder(id, cmd.Title, "", cmd.Icon)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Folder with ID '%s' renamed.\n", id)
+
+		return nil
+	})
+}
+
+func (cmd *CreateFolderCmd) Run(ctx *Globals) error {
+	folder := goplin.Folder{Title: cmd.Title, Icon: cmd.Icon}
+
+	if len(cmd.Parent) != 0 {
+		tree, err := client.GetFolderTree()
+		if err != nil {
+			return err
+		}
+
+		parent, err := goplin.FindFolderNode(tree, cmd.Parent)
+		if err != nil {
+			return err
+		}
+
+		folder.ParentID = parent.Folder.ID
+	}
+
+	created, err := client.CreateFolderItem(folder)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(created.ID)
+
+	return nil
+}
+
+func (cmd *DeleteFolderCmd) Run(ctx *Globals) error {
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return err
+	}
+
+	return EachID(cmd.IDs, func(id string) error {
+		node, err := goplin.FindFolderNode(tree, id)
+		if err != nil {
+			return err
+		}
+
+		if !cmd.Force {
+			if len(node.Children) != 0 {
+				return fmt.Errorf("folder '%s' has %d sub-folders, use --force to delete them with it", node.Path(), len(node.Children))
+			}
+
+			notes, err := client.GetNotesInFolder(node.Folder.ID, "id", "", "")
+			if err != nil {
+				return err
+			}
+
+			if len(notes) != 0 {
+				return fmt.Errorf("folder '%s' has %d notes, use --force to delete them with it", node.Path(), len(notes))
+			}
+		}
+
+		err = client.DeleteFolder(node.Folder.ID, cmd.Permanent)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Permanent {
+			fmt.Printf("Folder with ID '%s' deleted permanently.\n", node.Folder.ID)
+		} else {
+			fmt.Printf("Folder with ID '%s' deleted.\n", node.Folder.ID)
+		}
+
+		return nil
+	})
+}
+
+// listTree prints the folders as a tree, the subtrees of the given folders
+// when there are some.
+func (cmd *ListFoldersCmd) listTree() error {
+	if cmd.Limit != 0 || cmd.Page != 1 {
+		return fmt.Errorf("--limit and --page cannot be combined with --tree")
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.IDs) != 0 {
+		var roots []*goplin.FolderNode
+
+		for _, id := range cmd.IDs {
+			node, err := goplin.FindFolderNode(tree, id)
+			if err != nil {
+				return err
+			}
+
+			roots = append(roots, node)
+		}
+
+		tree = roots
+	}
+
+	switch cmd.Output {
+	case "json":
+		if tree == nil {
+			tree = []*goplin.FolderNode{}
+		}
+
+		return printJSON(tree)
+	case "yaml", "csv":
+		return fmt.Errorf("--tree supports table, ids and json output")
+	case "ids":
+		goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+			fmt.Println(node.Folder.ID)
+		})
+
+		return nil
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Folders:")
+		fmt.Printf("%-32s \u2502 %s\n", "ID", "Title")
+	}
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		fmt.Printf("%-32s \u2502 %s%s\n", node.Folder.ID, strings.Repeat("  ", depth), node.Folder.Title)
+	})
+
+	return nil
+}
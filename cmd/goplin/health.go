@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// readiness answers /healthz and /readyz. The process is healthy as long as
+// it answers; it is ready when Joplin is reachable and accepts the token.
+// Checks are cached for a few seconds, so probes do not load Joplin.
+type readiness struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	status  int
+	body    []byte
+	expires time.Time
+}
+
+type readyStatus struct {
+	Status string `json:"status"`
+	Joplin string `json:"joplin"`
+	Token  string `json:"token"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (rd *readiness) check() (int, []byte) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	if rd.body != nil && time.Now().Before(rd.expires) {
+		return rd.status, rd.body
+	}
+
+	rs := readyStatus{Status: "ready", Joplin: "ok", Token: "ok"}
+	rd.status = http.StatusOK
+
+	err := client.ValidateToken()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, goplin.ErrInvalidToken):
+		rs.Status, rs.Token, rs.Error = "not ready", "rejected", err.Error()
+		rd.status = http.StatusServiceUnavailable
+	case errors.Is(err, goplin.ErrNotConnected):
+		rs.Status, rs.Joplin, rs.Token, rs.Error = "not ready", "unreachable", "unknown", err.Error()
+		rd.status = http.StatusServiceUnavailable
+	default:
+		rs.Status, rs.Joplin, rs.Token, rs.Error = "not ready", "error", "unknown", err.Error()
+		rd.status = http.StatusServiceUnavailable
+	}
+
+	if rd.status != http.StatusOK {
+		logger.Warn("not ready", goplin.F("joplin", rs.Joplin), goplin.F("token", rs.Token), goplin.F("error", rs.Error))
+	}
+
+	rd.body, _ = json.Marshal(rs)
+	rd.expires = time.Now().Add(rd.ttl)
+
+	return rd.status, rd.body
+}
+
+// handleHealth adds /healthz and /readyz to mux.
+func handleHealth(mux *http.ServeMux) {
+	rd := &readiness{ttl: 5 * time.Second}
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status, body := rd.check()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+// signalContext returns a context canceled on SIGINT or SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// serveUntilDone serves handler on addr until ctx is done, then shuts down,
+// letting in-flight requests finish for up to shutdownTimeout.
+func serveUntilDone(ctx context.Context, addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", goplin.F("url", "http://"+addr))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
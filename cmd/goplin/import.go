@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/importer"
+)
+
+type ImportNotionCmd struct {
+	Folder string `default:"Notion Import" help:"Title of the folder created for the imported pages."`
+	CSV    string `name:"csv" enum:"table,notes" default:"table" help:"Import databases as a table note or as one note per row."`
+
+	Path string `arg type:"existingfile" name:"export.zip" help:"Notion Markdown & CSV export archive."`
+}
+
+type ImportHTMLDirCmd struct {
+	Folder string `help:"Title of the folder created for the imported files (defaults to the directory name)."`
+	Images string `enum:"inline,attach" default:"inline" help:"Embed local images as data URLs or upload them as resources."`
+
+	Path string `arg type:"existingdir" name:"path" help:"Directory of HTML files."`
+}
+
+type ImportStandardNotesCmd struct {
+	Folder         string `default:"Standard Notes Import" help:"Title of the folder created for the imported notes."`
+	IncludeTrashed bool   `name:"include-trashed" help:"Also import trashed notes."`
+
+	Path string `arg type:"existingfile" name:"backup.txt" help:"Decrypted Standard Notes backup file."`
+}
+
+type ImportSimplenoteCmd struct {
+	Folder         string `default:"Simplenote Import" help:"Title of the folder created for the imported notes."`
+	IncludeTrashed bool   `name:"include-trashed" help:"Also import trashed notes."`
+
+	Path string `arg type:"existingfile" name:"notes.json" help:"Simplenote notes.json export file."`
+}
+
+type ImportBibTeXCmd struct {
+	Folder string `default:"Papers" help:"Title of the top-level folder the references are added to, created when missing."`
+
+	Path string `arg type:"existingfile" name:"file.bib" help:"BibTeX file, e.g. exported from Zotero with its files."`
+}
+
+type ImportTagmapCmd struct {
+	Replace bool `help:"Also detach from the matched notes the tags missing from the map."`
+	DryRun  bool `name:"dry-run" help:"Only print the changes."`
+
+	Path string `arg type:"existingfile" name:"tags.json" help:"Tag map written by 'goplin export tagmap'."`
+}
+
+func printImportResult(result importer.Result) {
+	for _, warning := range result.Warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
+
+	fmt.Printf("Imported %d notes, %d folders and %d resources.\n", result.Notes, result.Folders, result.Resources)
+}
+
+func (cmd *ImportNotionCmd) Run(ctx *Globals) error {
+	result, err := importer.ImportNotion(client, cmd.Path, importer.NotionOptions{
+		Folder: cmd.Folder,
+		CSV:    cmd.CSV,
+	})
+
+	printImportResult(result)
+
+	return err
+}
+
+func (cmd *ImportHTMLDirCmd) Run(ctx *Globals) error {
+	result, err := importer.ImportHTMLDir(client, cmd.Path, importer.HTMLDirOptions{
+		Folder: cmd.Folder,
+		Images: cmd.Images,
+	})
+
+	printImportResult(result)
+
+	return err
+}
+
+func (cmd *ImportStandardNotesCmd) Run(ctx *Globals) error {
+	result, err := importer.ImportStandardNotes(client, cmd.Path, importer.JSONBackupOptions{
+		Folder:         cmd.Folder,
+		IncludeTrashed: cmd.IncludeTrashed,
+	})
+
+	printImportResult(result)
+
+	return err
+}
+
+func (cmd *ImportSimplenoteCmd) Run(ctx *Globals) error {
+	result, err := importer.ImportSimplenote(client, cmd.Path, importer.JSONBackupOptions{
+		Folder:         cmd.Folder,
+		IncludeTrashed: cmd.IncludeTrashed,
+	})
+
+	printImportResult(result)
+
+	return err
+}
+
+func (cmd *ImportBibTeXCmd) Run(ctx *Globals) error {
+	result, err := importer.ImportBibTeX(client, cmd.Path, importer.BibTeXOptions{
+		Folder: cmd.Folder,
+	})
+
+	printImportResult(result)
+
+	return err
+}
+
+func (cmd *ImportTagmapCmd) Run(ctx *Globals) error {
+	data, err := os.ReadFile(cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	var m goplin.TagMap
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return fmt.Errorf("invalid tag map '%s': %w", cmd.Path, err)
+	}
+
+	plan, err := client.PlanTagMap(m, cmd.Replace)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range plan.Unmatched {
+		fmt.Printf("WARNING: no note '%s' in '%s'\n", entry.Title, entry.Folder)
+	}
+
+	for _, entry := range plan.Ambiguous {
+		fmt.Printf("WARNING: several notes '%s', none tagged\n", entry.Title)
+	}
+
+	batch, err := client.TagMapBatch(plan)
+	if err != nil {
+		return err
+	}
+
+	batch.DryRun = cmd.DryRun
+
+	if cmd.DryRun {
+		batch.Plan(os.Stdout)
+	}
+
+	report, err := batch.Run()
+	if err != nil {
+		return err
+	}
+
+	printBatchReport(report)
+
+	fmt.Printf("Tagged %d notes, created %d tags; %d entries unmatched, %d ambiguous.\n",
+		len(plan.Attach), len(plan.CreateTags), len(plan.Unmatched), len(plan.Ambiguous))
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/lint"
+	"github.com/spf13/viper"
+)
+
+type LintNotesCmd struct {
+	Scope   string   `help:"Lint only the notes of this folder (ID, title or path) and its sub-folders."`
+	Disable []string `help:"Do not run this rule, in addition to lint.disable in the config file. Repeatable."`
+	Fix     bool     `help:"Apply the safe corrections (whitespace, heading spaces, blank lines) to the notes."`
+
+	IDs []string `arg optional name:"id" help:"Lint only the notes with these IDs, \"-\" reads IDs from stdin."`
+}
+
+func (cmd *LintNotesCmd) Run(ctx *Globals) error {
+	var cfg lint.Config
+
+	err := viper.UnmarshalKey("lint", &cfg)
+	if err != nil {
+		return fmt.Errorf("invalid lint section in the config file: %w", err)
+	}
+
+	cfg.Disable = append(cfg.Disable, cmd.Disable...)
+
+	linter, err := lint.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if !linter.Spelling() {
+		fmt.Println("Spell checking is off: neither aspell nor hunspell found and lint.spell_command not set.")
+	}
+
+	var notes []goplin.Note
+
+	ids, err := ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != 0 {
+		for _, id := range ids {
+			note, err := client.GetNote(id, "id,title,body")
+			if err != nil {
+				return err
+			}
+
+			notes = append(notes, note)
+		}
+	} else {
+		notes, err = client.GetNotesInScope(cmd.Scope, "id,title,body")
+		if err != nil {
+			return err
+		}
+	}
+
+	total, fixed := 0, 0
+
+	for _, note := range notes {
+		issues := linter.LintLanguage(note.Body, goplin.DetectLanguage(note.Body))
+		if len(issues) == 0 {
+			continue
+		}
+
+		total += len(issues)
+
+		fmt.Printf("%s %s\n", note.ID, note.Title)
+
+		for _, issue := range issues {
+			fixable := ""
+			if issue.Fixable {
+				fixable = " (fixable)"
+			}
+
+			fmt.Printf("  %5d \u2502 %-19s \u2502 %s%s\n", issue.Line, issue.Rule, issue.Message, fixable)
+		}
+
+		if !cmd.Fix {
+			continue
+		}
+
+		body, changed := linter.Fix(note.Body)
+		if !changed {
+			continue
+		}
+
+		err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(note.ID).SetBody(body))
+		if err != nil {
+			return err
+		}
+
+		fixed++
+	}
+
+	fmt.Printf("Found %d issues in %d notes checked.\n", total, len(notes))
+
+	if cmd.Fix {
+		fmt.Printf("Fixed %d notes.\n", fixed)
+	}
+
+	return nil
+}
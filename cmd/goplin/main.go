@@ -5,52 +5,86 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
-	"github.com/imroc/req/v3"
 	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/cliplugin"
 	"github.com/spf13/viper"
 )
 
 type Globals struct {
-	Debug bool `help:"Enable debug output."`
+	Debug       bool   `help:"Dump the Data API requests and responses to stderr, with the token hidden."`
+	TokenName   string `name:"token-name" help:"Use the named token from the config file instead of the default one."`
+	Explain     bool   `help:"Print the Data API requests the command makes to stderr, without sending creations, updates or deletions."`
+	OnDuplicate string `name:"on-duplicate" help:"What to do when a created note has the title of a note in the same folder: allow, fail, suffix or update (default from on_duplicate in the config file, else allow)."`
+	LogFormat   string `name:"log-format" enum:"text,json" default:"text" help:"Format of the logs of long running commands (serve, notify daemon): text or json."`
+	LogLevel    string `name:"log-level" enum:"debug,info,warn,error" default:"info" help:"Lowest level of the logs of long running commands: debug, info, warn or error."`
+	Timings     bool   `help:"Print to stderr, after the command, the time spent in port discovery, API calls, decoding and rendering."`
+	DateFormat  string `name:"date-format" help:"How to show dates: iso (ISO 8601), relative (e.g. 2 days ago), locale (from LC_TIME or LANG), a locale such as de_DE, or a Go time layout (default from date_format in the config file, else iso)."`
 }
 
 type ListTagsCmd struct {
 	NoHeader       bool   `help:"Do not print header."`
 	Fields         string `help:"Show only the specified fields."`
+	Output         string `enum:"table,ids,json,yaml,csv" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json, yaml or csv for other programs."`
 	DuplicatesOnly bool   `name:"duplicates-only" help:"List only duplicate tags."`
 	OrphansOnly    bool   `name:"orphans-only" help:"List only orphan tags."`
 	OrderBy        string `name:"order-by" help:"Order by specified field."`
 	OrderDir       string `name:"order-dir" help:"Order by specified direction: ASC or DESC."`
+	Limit          int    `name:"limit" help:"With --output json, list a page of this many tags (up to 100), telling the page that follows."`
+	Page           int    `name:"page" default:"1" help:"With --limit, the page to list, to resume a listing."`
 
-	IDs []string `arg optional name:"id" help:"List tags with the specified IDs."`
+	IDs []string `arg optional name:"id" help:"List tags with the specified IDs, \"-\" reads IDs from stdin."`
 }
 
 type ListNotesCmd struct {
-	NoHeader bool   `help:"Do not print header."`
-	Fields   string `help:"Show only the specified fields."`
-	By       string `name:"by" help:"Find by ID or tag."`
-	In       string `name:"in" help:"Find notes in specified folder"`
-	OrderBy  string `name:"order-by" help:"Order by specified field."`
-	OrderDir string `name:"order-dir" help:"Order by specified direction: ASC or DESC."`
-
-	IDs []string `arg optional name:"id" help:"List notes with the specified IDs or tag IDs."`
+	NoHeader     bool     `help:"Do not print header."`
+	Fields       string   `help:"Show only the specified fields."`
+	Output       string   `enum:"table,ids,json,yaml,csv" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json, yaml or csv for other programs."`
+	By           string   `name:"by" help:"What the arguments select notes by: id (default), tag (ID), folder (ID, path or title), author, source (source application) or source-url (URL prefix)."`
+	In           string   `name:"in" help:"Find notes in specified folder"`
+	Folder       string   `name:"folder" help:"List only notes of this folder (ID, path or title) and its sub-folders."`
+	Tag          []string `name:"tag" help:"List only notes carrying this tag (ID or title); repeat to require several tags."`
+	UpdatedSince string   `name:"updated-since" help:"List only notes updated within the given duration (e.g. 7d) or since the given date."`
+	Lang         string   `name:"lang" help:"List only notes written in this language, an ISO 639-1 code such as de, as detected from their bodies."`
+	OrderBy      string   `name:"order-by" help:"Order by specified field."`
+	OrderDir     string   `name:"order-dir" help:"Order by specified direction: ASC or DESC."`
+	Todo         bool     `name:"todo" help:"List only open to-dos."`
+	Done         bool     `name:"done" help:"List only completed to-dos."`
+	Overdue      bool     `name:"overdue" help:"List only open to-dos past their due date."`
+	DueWithin    string   `name:"due-within" help:"List only open to-dos due within the given duration (e.g. 3d)."`
+	LargerThan   string   `name:"larger-than" help:"List only notes whose body is at least this size (e.g. 1MB), largest first, with their size."`
+	Limit        int      `name:"limit" help:"With --output json, list a page of this many notes (up to 100), telling the page that follows."`
+	Page         int      `name:"page" default:"1" help:"With --limit, the page to list, to resume a listing."`
+
+	IDs []string `arg optional name:"id" help:"List notes with the specified IDs, or the values of the --by selector, \"-\" reads them from stdin."`
 }
 
 type ListFoldersCmd struct {
 	NoHeader bool   `help:"Do not print header."`
 	Fields   string `help:"Show only the specified fields."`
+	Output   string `enum:"table,ids,json,yaml,csv" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json, yaml or csv for other programs."`
 	OrderBy  string `name:"order-by" help:"Order by specified field."`
 	OrderDir string `name:"order-dir" help:"Order by specified direction: ASC or DESC."`
+	Limit    int    `name:"limit" help:"With --output json, list a page of this many folders (up to 100), telling the page that follows."`
+	Page     int    `name:"page" default:"1" help:"With --limit, the page to list, to resume a listing."`
+	Tree     bool   `name:"tree" help:"Show the notebook hierarchy, sub-folders indented under their parent."`
 
-	IDs []string `arg optional name:"id" help:"List folders with the specified IDs or tag IDs."`
+	IDs []string `arg optional name:"id" help:"List folders with the specified IDs, \"-\" reads IDs from stdin."`
 }
 
 type DeleteTagsCmd struct {
-	IDs []string `arg name:"id" help:"Delete tags with the specified IDs."`
+	IDs []string `arg name:"id" help:"Delete tags with the specified IDs, \"-\" reads IDs from stdin."`
+}
+
+type DeleteNotesCmd struct {
+	Permanent bool `help:"Delete the notes permanently instead of moving them to the trash (Joplin 3.0 and later; older versions have no trash)."`
+
+	IDs []string `arg name:"id" help:"Delete notes with the specified IDs, \"-\" reads IDs from stdin."`
 }
 
 type DeleteTagFromNoteCmd struct {
@@ -67,40 +101,273 @@ type DeleteTagFromNoteCmd struct {
 type SearchCmd struct {
 	NoHeader bool   `help:"Do not print header."`
 	Fields   string `help:"Show only the specified fields."`
-	Type     string `help:"Search for specified type"`
+	Output   string `enum:"table,ids,json,yaml,csv" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json, yaml or csv for other programs."`
+	Type     string `enum:"note,folder,tag,resource" default:"note" help:"Type of the items to search for: note, folder, tag or resource."`
+
+	AllProfiles bool `name:"all-profiles" help:"Search every Joplin instance of the profiles section of the config file at once, labeling results by profile."`
 
-	Query string `arg name:"query" help:"Search query (for details see https://joplinapp.org/help/#searching)."`
+	Query string `arg name:"query" help:"Search query in Joplin's syntax, e.g. \"tag:work created:20240101\" (for details see https://joplinapp.org/help/#searching)."`
 }
 
 type CLI struct {
 	Globals
 
 	List struct {
-		Tags    ListTagsCmd    `cmd requires help:"List tags."`
-		Notes   ListNotesCmd   `cmd requires help:"List notes."`
-		Folders ListFoldersCmd `cmd requires help:"List folders."`
+		Tags      ListTagsCmd      `cmd requires help:"List tags."`
+		Notes     ListNotesCmd     `cmd requires help:"List notes."`
+		Folders   ListFoldersCmd   `cmd requires help:"List folders."`
+		Resources ListResourcesCmd `cmd help:"List resources (attachments)."`
 	} `cmd help:"Joplin list commands."`
 
+	Create struct {
+		Note   CreateNoteCmd   `cmd help:"Create a note from Markdown read from stdin or --file and print its ID."`
+		Folder CreateFolderCmd `cmd help:"Create a folder and print its ID."`
+	} `cmd help:"Joplin create commands."`
+
+	New struct {
+		Contact NewContactCmd `cmd help:"Create a contact note and print its ID."`
+		Book    NewBookCmd    `cmd help:"Create a book note and print its ID."`
+		Recipe  NewRecipeCmd  `cmd help:"Create a recipe note and print its ID."`
+	} `cmd help:"Create structured notes, whose fields are kept for 'goplin find'."`
+
+	Find FindCmd `cmd help:"Find structured notes by their fields, e.g. 'goplin find contact email=ada@example.com'."`
+
 	Delete struct {
-		Tags DeleteTagsCmd        `cmd requires help:"Delete tags."`
-		Tag  DeleteTagFromNoteCmd `cmd requires help:"Delete tag from note."`
+		Tags   DeleteTagsCmd        `cmd requires help:"Delete tags."`
+		Tag    DeleteTagFromNoteCmd `cmd requires help:"Delete tag from note."`
+		Notes  DeleteNotesCmd       `cmd requires help:"Delete notes, moving them to the trash unless --permanent."`
+		Folder DeleteFolderCmd      `cmd help:"Delete folders, refusing those with notes or sub-folders unless --force."`
 	} `cmd help:"Joplin delete commands."`
 
 	Search SearchCmd `cmd help:"Joplin search command."`
+
+	Move struct {
+		Folder MoveFolderCmd `cmd help:"Move a folder under another folder."`
+	} `cmd help:"Joplin move commands."`
+
+	Rename struct {
+		Folder RenameFolderCmd `cmd help:"Rename a folder."`
+	} `cmd help:"Joplin rename commands."`
+
+	Set struct {
+		Note SetNoteCmd `cmd help:"Set fields of a note."`
+	} `cmd help:"Joplin update commands."`
+
+	Export struct {
+		Vault    ExportVaultCmd    `cmd default:"withargs" help:"Export the vault, a folder or a saved export profile (default)."`
+		Hugo     ExportHugoCmd     `cmd help:"Export a folder as Hugo page bundles."`
+		Markdown ExportMarkdownCmd `cmd help:"Export notes as Markdown files in directories mirroring the folders."`
+		Obsidian ExportObsidianCmd `cmd help:"Export notes as an Obsidian vault with wikilinks."`
+		Dendron  ExportDendronCmd  `cmd help:"Export notes as a Dendron vault with dotted hierarchies and wikilinks."`
+		Anki     ExportAnkiCmd     `cmd help:"Export the flashcards of notes as a text file importable by Anki."`
+		Tagmap   ExportTagmapCmd   `cmd help:"Export which notes carry which tags, by folder path and title, as JSON."`
+		Profiles ExportProfilesCmd `cmd help:"List the export profiles of the config file."`
+	} `cmd help:"Joplin export commands."`
+
+	Import struct {
+		Notion        ImportNotionCmd        `cmd help:"Import a Notion export."`
+		HTMLDir       ImportHTMLDirCmd       `cmd name:"html-dir" help:"Import a directory of HTML files."`
+		StandardNotes ImportStandardNotesCmd `cmd name:"standardnotes" help:"Import a Standard Notes backup."`
+		Simplenote    ImportSimplenoteCmd    `cmd name:"simplenote" help:"Import a Simplenote export."`
+		BibTeX        ImportBibTeXCmd        `cmd name:"bibtex" help:"Import the references of a BibTeX file, one note each."`
+		Tagmap        ImportTagmapCmd        `cmd help:"Tag notes again from a tag map written by 'goplin export tagmap'."`
+	} `cmd help:"Joplin import commands."`
+
+	Auth struct {
+		Check  AuthCheckCmd  `cmd help:"Check that the stored token is accepted by Joplin."`
+		Renew  AuthRenewCmd  `cmd help:"Request a new token from Joplin and store it."`
+		List   AuthListCmd   `cmd help:"List the tokens stored in the config file."`
+		Add    AuthAddCmd    `cmd help:"Store a named token."`
+		Remove AuthRemoveCmd `cmd help:"Remove a named token."`
+		Token  AuthTokenCmd  `cmd help:"Store a token given on the command line, in a file or on stdin, without connecting to Joplin."`
+	} `cmd help:"Joplin authorization commands."`
+
+	Cleanup struct {
+		Tags    CleanupTagsCmd    `cmd help:"Remove unused tags."`
+		Folders CleanupFoldersCmd `cmd help:"Remove empty folders."`
+	} `cmd help:"Joplin cleanup commands."`
+
+	Normalize struct {
+		Tags NormalizeTagsCmd `cmd help:"Merge tags differing by case, diacritics or synonyms."`
+	} `cmd help:"Joplin normalization commands."`
+
+	Stats struct {
+		Tags      StatsTagsCmd      `cmd help:"Show tag usage and co-occurrence."`
+		Heatmap   StatsHeatmapCmd   `cmd help:"Export a GitHub-style heatmap of notes created and updated per day."`
+		Resources StatsResourcesCmd `cmd help:"Show resource counts and sizes per type and the largest resources."`
+		Languages StatsLanguagesCmd `cmd help:"Show the number of notes per language, as detected from their bodies."`
+	} `cmd help:"Joplin statistics commands."`
+
+	Serve struct {
+		API ServeAPICmd `cmd name:"api" help:"Serve vault statistics (/stats.json) over HTTP."`
+		Web ServeWebCmd `cmd name:"web" help:"Serve a web UI to browse, search and capture notes from the LAN."`
+	} `cmd help:"Joplin serve commands."`
+
+	Sync struct {
+		Dir SyncDirCmd `cmd help:"Two-way sync of notes with a directory of Markdown files."`
+	} `cmd help:"Joplin sync commands."`
+
+	SyncStatus SyncStatusCmd `cmd name:"sync-status" help:"Estimate whether the vault is synced: pending local changes, conflicts and the last remote change."`
+
+	Doctor DoctorCmd `cmd help:"Probe the endpoints of Joplin and report the fields and endpoints that differ from what goplin expects."`
+
+	Manifest ManifestCmd `cmd help:"Write the checksums of every note body and resource file, for 'goplin verify'."`
+
+	Backup struct {
+		Create  BackupCreateCmd  `cmd default:"withargs" help:"Back up the vault, uploading only what changed since the last backup (default)."`
+		List    BackupListCmd    `cmd help:"List the generations of a backup."`
+		Restore BackupRestoreCmd `cmd help:"Write a generation of a backup as a JEX archive to import in Joplin."`
+		Prune   BackupPruneCmd   `cmd help:"Remove the oldest generations of a backup and the chunks only they use."`
+	} `cmd help:"Incremental, deduplicated and optionally encrypted backups to a directory, S3 or WebDAV."`
+
+	Verify VerifyCmd `cmd help:"Compare the vault with a manifest to find corrupted, missing, modified and added items after a restore or sync."`
+
+	Mirror struct {
+		Dir MirrorDirCmd `cmd help:"Mirror a folder to a directory of Markdown files, one way."`
+	} `cmd help:"Joplin mirror commands."`
+
+	Mount MountCmd `cmd help:"Mount the vault as a directory of Markdown files, kept up to date from the change events of Joplin. Linux and macOS only."`
+
+	Clip ClipCmd `cmd help:"Clip web pages into notes."`
+
+	Run RunCmd `cmd help:"Run a named pipeline from the config file."`
+
+	Notify struct {
+		Daemon NotifyDaemonCmd `cmd help:"Raise notifications when to-dos are due and when notes matching the watch_rules of the config file change."`
+		Snooze NotifySnoozeCmd `cmd help:"Postpone the reminder of a to-do."`
+	} `cmd help:"Joplin reminder commands."`
+
+	Reading struct {
+		List ReadingListCmd `cmd help:"List notes being read, most recent first."`
+		Mark ReadingMarkCmd `cmd help:"Record how far a note has been read."`
+	} `cmd help:"Joplin reading progress commands."`
+
+	Timeline TimelineCmd `cmd help:"Show note activity day by day."`
+
+	Related RelatedCmd `cmd help:"List the notes most related to a note by shared tags, links and title."`
+
+	TocNote TocNoteCmd `cmd name:"toc-note" help:"Generate or refresh an index note linking every note of a notebook and its sub-notebooks."`
+
+	Repair struct {
+		Orphans RepairOrphansCmd `cmd help:"Move notes whose folder no longer exists into a recovery folder."`
+	} `cmd help:"Joplin repair commands."`
+
+	Check struct {
+		InternalLinks CheckInternalLinksCmd `cmd name:"internal-links" help:"Find :/id links to notes or resources that do not exist."`
+		Anomalies     CheckAnomaliesCmd     `cmd help:"Find unusual bursts of changes, such as mass deletions, and fail when there are any."`
+	} `cmd help:"Joplin consistency checks."`
+
+	Lint struct {
+		Notes LintNotesCmd `cmd help:"Check note bodies for Markdown problems and spelling mistakes."`
+	} `cmd help:"Joplin lint commands."`
+
+	SRS struct {
+		Review SRSReviewCmd `cmd help:"Review the flashcards due in an interactive session."`
+		List   SRSListCmd   `cmd help:"List the flashcards found in notes and when they are due."`
+	} `cmd name:"srs" help:"Spaced repetition of flashcards written in notes (Q:/A: lines or {{c1::cloze}} deletions)."`
+
+	Script struct {
+		Run     ScriptRunCmd     `cmd help:"Run a Starlark automation script, or with --exec a program, with access to the vault."`
+		Methods ScriptMethodsCmd `cmd help:"List the methods scripts can call."`
+	} `cmd help:"Joplin scripting commands."`
+
+	Convert struct {
+		Note ConvertNoteCmd `cmd help:"Convert notes between Markdown and HTML and update their markup language."`
+	} `cmd help:"Joplin conversion commands."`
+
+	Download struct {
+		Resources DownloadResourcesCmd `cmd help:"Download every resource (attachment) with a JSON sidecar of its metadata; runs can be resumed."`
+	} `cmd help:"Joplin download commands."`
+
+	Slim struct {
+		Note SlimNoteCmd `cmd help:"Make bloated notes smaller: HTML to Markdown, links without tracking parameters and optionally recompressed images, with the sizes before and after."`
+	} `cmd help:"Joplin note slimming commands."`
+
+	Preview struct {
+		Resource PreviewResourceCmd `cmd help:"Show an image attachment in the terminal (kitty, iTerm2 or sixel) or save its thumbnail."`
+	} `cmd help:"Joplin preview commands."`
+
+	Process struct {
+		Resources ProcessResourcesCmd `cmd help:"Process attachments, such as transcribing voice memos into their notes."`
+	} `cmd help:"Joplin processing commands."`
+
+	Table struct {
+		List      TableListCmd      `cmd help:"List the Markdown tables of a note."`
+		Get       TableGetCmd       `cmd help:"Print a table of a note as CSV, TSV, JSON or Markdown."`
+		Update    TableUpdateCmd    `cmd help:"Replace the rows of a table of a note from CSV."`
+		Summarize TableSummarizeCmd `cmd help:"Sum and average a column of a table, optionally per group or per period."`
+	} `cmd help:"Use the Markdown tables of notes as small databases."`
+
+	Track TrackCmd `cmd help:"Append a row of values to a tracking table in a note, creating the table if missing."`
+
+	Append AppendCmd `cmd help:"Append a line of text to a note."`
+
+	Alias struct {
+		Set  AliasSetCmd    `cmd help:"Name a note or folder, so @name can be used wherever its ID is expected."`
+		List AliasListCmd   `cmd help:"List the aliases of the config file."`
+		Rm   AliasRemoveCmd `cmd help:"Remove an alias."`
+	} `cmd help:"Joplin alias commands."`
+
+	Service struct {
+		Install   ServiceInstallCmd   `cmd help:"Install a goplin daemon as a user service (systemd on Linux, launchd on macOS)."`
+		Status    ServiceStatusCmd    `cmd help:"Show the status of an installed service, or list them."`
+		Uninstall ServiceUninstallCmd `cmd help:"Stop and remove an installed service."`
+	} `cmd help:"Run goplin daemons as user services."`
+
+	Plugins PluginsCmd `cmd help:"List built-in and external (goplin-* on PATH) plugin commands."`
 }
 
 var (
 	client *goplin.Client
+	logger goplin.Logger
+	dates  goplin.DateFormat
 )
 
-func (cmd *ListTagsCmd) Run(ctx *Globals) error {
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
+// offlineCommands run without connecting to Joplin, client is nil for them.
+var offlineCommands = map[string]bool{
+	"auth token":        true,
+	"service install":   true,
+	"service status":    true,
+	"service uninstall": true,
+}
+
+// commandName returns the command path of ctx without its arguments, e.g.
+// "service status" for "service status <name>".
+func commandName(ctx *kong.Context) string {
+	var words []string
+
+	for _, word := range strings.Fields(ctx.Command()) {
+		if !strings.HasPrefix(word, "<") {
+			words = append(words, word)
+		}
 	}
 
+	return strings.Join(words, " ")
+}
+
+func (cmd *ListTagsCmd) Run(ctx *Globals) error {
+	var err error
+
 	if len(cmd.Fields) == 0 {
-		cmd.Fields = "id,parent_id,title"
+		cmd.Fields = goplin.DefaultTagFields
+	}
+
+	if cmd.Output == "ids" {
+		cmd.Fields = "id"
+		cmd.NoHeader = true
+	}
+
+	cmd.IDs, err = ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if isStructured(cmd.Output) {
+		return cmd.listData()
+	}
+
+	if cmd.Limit != 0 || cmd.Page != 1 {
+		return fmt.Errorf("--limit and --page need --output json")
 	}
 
 	if !cmd.NoHeader {
@@ -183,17 +450,129 @@ func (cmd *ListTagsCmd) Run(ctx *Globals) error {
 	return nil
 }
 
+// listData lists the tags as json, yaml or csv; as json, a page of --limit
+// tags at a time when set.
+func (cmd *ListTagsCmd) listData() error {
+	if err := checkPaging(cmd.Limit, cmd.Page); err != nil {
+		return err
+	}
+
+	if cmd.DuplicatesOnly || cmd.OrphansOnly {
+		return fmt.Errorf("--output %s cannot be combined with --duplicates-only or --orphans-only", cmd.Output)
+	}
+
+	if cmd.Output != "json" && (cmd.Limit != 0 || cmd.Page != 1) {
+		return fmt.Errorf("--limit and --page need --output json")
+	}
+
+	if cmd.Limit != 0 {
+		if len(cmd.IDs) != 0 {
+			return fmt.Errorf("--limit cannot be combined with IDs")
+		}
+
+		page, err := client.TagsPage(cmd.Fields, cmd.OrderBy, cmd.OrderDir, cmd.Page, cmd.Limit)
+		if err != nil {
+			return err
+		}
+
+		return printPage(page, page.Items, cmd.Limit)
+	}
+
+	var tags []goplin.Tag
+
+	if len(cmd.IDs) == 0 {
+		var err error
+
+		tags, err = client.GetAllTagsWithFields(cmd.Fields, cmd.OrderBy, cmd.OrderDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range cmd.IDs {
+		tag, err := client.GetTag(id, cmd.Fields)
+		if err != nil {
+			return err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if cmd.Output != "json" {
+		return printRecords(tags, cmd.Fields, &goplin.TagFormats, cmd.Output)
+	}
+
+	return printAll(tags, len(tags))
+}
+
 func (cmd *ListNotesCmd) Run(ctx *Globals) error {
 	var err error
 	var notes []goplin.Note
 
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
+	if len(cmd.Fields) == 0 {
+		cmd.Fields = goplin.DefaultNoteFields
 	}
 
-	if len(cmd.Fields) == 0 {
-		cmd.Fields = "id,parent_id,title"
+	if cmd.Output == "ids" {
+		cmd.Fields = "id"
+		cmd.NoHeader = true
+	}
+
+	cmd.IDs, err = ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Todo && cmd.Done {
+		return fmt.Errorf("--todo and --done are mutually exclusive")
+	}
+
+	if cmd.Output != "json" && (cmd.Limit != 0 || cmd.Page != 1) {
+		return fmt.Errorf("--limit and --page need --output json")
+	}
+
+	if len(cmd.By) != 0 && !goplin.IsSelector(cmd.By) {
+		return fmt.Errorf("unknown --by '%s', expected one of %s", cmd.By, strings.Join(goplin.Selectors, ", "))
+	}
+
+	filter := goplin.TodoFilter{
+		Open:    cmd.Todo,
+		Done:    cmd.Done,
+		Overdue: cmd.Overdue,
+	}
+
+	if len(cmd.DueWithin) != 0 {
+		filter.DueWithin, err = goplin.ParseDuration(cmd.DueWithin)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := goplin.NoteQuery{Folder: cmd.Folder, Tags: cmd.Tag, Todo: filter, Language: strings.ToLower(cmd.Lang)}
+
+	if len(query.Language) != 0 && goplin.LanguageName(query.Language) == query.Language {
+		return fmt.Errorf("unknown --lang '%s', expected one of %s", cmd.Lang, strings.Join(goplin.Languages(), ", "))
+	}
+
+	if len(cmd.UpdatedSince) != 0 {
+		query.UpdatedSince, err = parseSince(cmd.UpdatedSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cmd.LargerThan) != 0 {
+		return cmd.listLarge(query)
+	}
+
+	// Fetch the fields the filter needs on top of the displayed ones.
+	fetchFields := cmd.Fields
+	if !filter.IsZero() {
+		fetchFields = WithFields(cmd.Fields, goplin.TodoFields...)
+	}
+
+	if isStructured(cmd.Output) {
+		return cmd.listData(query, fetchFields)
 	}
 
 	if !cmd.NoHeader {
@@ -201,40 +580,43 @@ func (cmd *ListNotesCmd) Run(ctx *Globals) error {
 	}
 
 	if len(cmd.IDs) == 0 {
-		if len(cmd.In) == 0 {
-			notes, err = client.GetAllNotes(cmd.Fields, cmd.OrderBy, cmd.OrderDir)
+		if len(cmd.Folder) != 0 || len(cmd.Tag) != 0 || !query.UpdatedSince.IsZero() || len(query.Language) != 0 {
+			if len(cmd.In) != 0 {
+				return fmt.Errorf("--in cannot be combined with --folder, --tag, --updated-since or --lang, use --folder")
+			}
+
+			notes, err = client.QueryNotes(query, fetchFields, cmd.OrderBy, cmd.OrderDir)
+		} else if len(cmd.In) == 0 {
+			// Streamed, rows show up as the pages arrive.
+			it := client.NotesIter(fetchFields, cmd.OrderBy, cmd.OrderDir)
+			for it.Next() {
+				if filter.Match(it.Item()) {
+					PrintRow(it.Item(), cmd.Fields, &goplin.NoteFormats)
+				}
+			}
+
+			return it.Err()
 		} else {
-			notes, err = client.GetNotesInFolder(cmd.In, cmd.Fields, cmd.OrderBy, cmd.OrderDir)
+			notes, err = client.GetNotesInFolder(cmd.In, fetchFields, cmd.OrderBy, cmd.OrderDir)
 		}
 
 		if err != nil {
 			return err
 		}
 
-		for _, note := range notes {
+		for _, note := range filter.Apply(notes) {
 			PrintRow(note, cmd.Fields, &goplin.NoteFormats)
 		}
 	} else {
-		if strings.ToLower(cmd.By) == "tag" {
-			for _, id := range cmd.IDs {
-				notes, err := client.GetNotesByTag(id, cmd.OrderBy, cmd.OrderDir)
-				if err != nil {
-					fmt.Printf("%-32s <= ERROR: note not found\n", id)
-				} else {
-					for _, note := range notes {
-						PrintRow(note, cmd.Fields, &goplin.NoteFormats)
-					}
-				}
+		for _, value := range cmd.IDs {
+			notes, err := client.GetNotesBy(cmd.By, value, fetchFields, cmd.OrderBy, cmd.OrderDir)
+			if err != nil {
+				fmt.Printf("%-32s <= ERROR: note not found\n", value)
+				continue
 			}
-		} else {
-			for _, id := range cmd.IDs {
-				note, err := client.GetNote(id, cmd.Fields)
-				if err != nil {
-					fmt.Printf("%-32s <= ERROR: note not found\n", id)
-				} else {
-					PrintRow(note, cmd.Fields, &goplin.NoteFormats)
-				}
 
+			for _, note := range filter.Apply(notes) {
+				PrintRow(note, cmd.Fields, &goplin.NoteFormats)
 			}
 		}
 	}
@@ -242,14 +624,157 @@ func (cmd *ListNotesCmd) Run(ctx *Globals) error {
 	return nil
 }
 
-func (cmd *ListFoldersCmd) Run(ctx *Globals) error {
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
+// listLarge lists the notes whose body is --larger-than, largest first. The
+// bodies have to be fetched, the Data API does not report their size.
+func (cmd *ListNotesCmd) listLarge(query goplin.NoteQuery) error {
+	min, err := goplin.ParseSize(cmd.LargerThan)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Limit != 0 {
+		return fmt.Errorf("--limit cannot be combined with --larger-than")
+	}
+
+	fields := WithFields("id,parent_id,title,body", goplin.TodoFields...)
+
+	var notes []goplin.Note
+
+	switch {
+	case len(cmd.IDs) != 0:
+		for _, value := range cmd.IDs {
+			selected, err := client.GetNotesBy(cmd.By, value, fields, "", "")
+			if err != nil {
+				return err
+			}
+
+			notes = append(notes, selected...)
+		}
+	case len(cmd.In) != 0:
+		notes, err = client.GetNotesInFolder(cmd.In, fields, "", "")
+	default:
+		notes, err = client.QueryNotes(query, fields, "", "")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sizes := goplin.NoteSizes(query.Todo.Apply(notes), min)
+
+	switch cmd.Output {
+	case "json":
+		return printAll(sizes, len(notes))
+	case "yaml", "csv":
+		return fmt.Errorf("--larger-than supports table, ids and json output")
+	}
+
+	if cmd.Output == "ids" {
+		for _, size := range sizes {
+			fmt.Println(size.ID)
+		}
+
+		return nil
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Notes:")
+		fmt.Printf("%-32s \u2502 %10s \u2502 %s\n", "ID", "Size", "Title")
 	}
 
+	for _, size := range sizes {
+		fmt.Printf("%-32s \u2502 %10s \u2502 %s\n", size.ID, formatSize(size.Size), size.Title)
+	}
+
+	return nil
+}
+
+// listData lists the notes as json, yaml or csv; as json, a page of --limit
+// notes at a time when set. Pages are of all the notes, the to-do filters
+// then apply to each.
+func (cmd *ListNotesCmd) listData(query goplin.NoteQuery, fetchFields string) error {
+	if err := checkPaging(cmd.Limit, cmd.Page); err != nil {
+		return err
+	}
+
+	filtered := len(cmd.Folder) != 0 || len(cmd.Tag) != 0 || !query.UpdatedSince.IsZero() || len(query.Language) != 0
+
+	if cmd.Limit != 0 {
+		if len(cmd.IDs) != 0 || len(cmd.In) != 0 || filtered {
+			return fmt.Errorf("--limit cannot be combined with IDs, --in, --folder, --tag, --updated-since or --lang")
+		}
+
+		page, err := client.NotesPage(fetchFields, cmd.OrderBy, cmd.OrderDir, cmd.Page, cmd.Limit)
+		if err != nil {
+			return err
+		}
+
+		return printPage(page, query.Todo.Apply(page.Items), cmd.Limit)
+	}
+
+	var notes []goplin.Note
+	var err error
+
+	switch {
+	case len(cmd.IDs) != 0:
+		for _, value := range cmd.IDs {
+			selected, err := client.GetNotesBy(cmd.By, value, fetchFields, cmd.OrderBy, cmd.OrderDir)
+			if err != nil {
+				return err
+			}
+
+			notes = append(notes, selected...)
+		}
+	case filtered:
+		if len(cmd.In) != 0 {
+			return fmt.Errorf("--in cannot be combined with --folder, --tag, --updated-since or --lang, use --folder")
+		}
+
+		notes, err = client.QueryNotes(query, fetchFields, cmd.OrderBy, cmd.OrderDir)
+	case len(cmd.In) != 0:
+		notes, err = client.GetNotesInFolder(cmd.In, fetchFields, cmd.OrderBy, cmd.OrderDir)
+	default:
+		notes, err = client.GetAllNotes(fetchFields, cmd.OrderBy, cmd.OrderDir)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output != "json" {
+		return printRecords(query.Todo.Apply(notes), cmd.Fields, &goplin.NoteFormats, cmd.Output)
+	}
+
+	return printAll(query.Todo.Apply(notes), len(notes))
+}
+
+func (cmd *ListFoldersCmd) Run(ctx *Globals) error {
+	var err error
+
 	if len(cmd.Fields) == 0 {
-		cmd.Fields = "id,parent_id,title"
+		cmd.Fields = goplin.DefaultFolderFields
+	}
+
+	if cmd.Output == "ids" {
+		cmd.Fields = "id"
+		cmd.NoHeader = true
+	}
+
+	cmd.IDs, err = ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Tree {
+		return cmd.listTree()
+	}
+
+	if isStructured(cmd.Output) {
+		return cmd.listData()
+	}
+
+	if cmd.Limit != 0 || cmd.Page != 1 {
+		return fmt.Errorf("--limit and --page need --output json")
 	}
 
 	if !cmd.NoHeader {
@@ -263,15 +788,15 @@ func (cmd *ListFoldersCmd) Run(ctx *Globals) error {
 		}
 
 		for _, folder := range folders {
-			PrintRow(folder, cmd.Fields, &goplin.NoteFormats)
+			PrintRow(folder, cmd.Fields, &goplin.FolderFormats)
 		}
 	} else {
 		for _, id := range cmd.IDs {
-			note, err := client.GetFolder(id, cmd.Fields)
+			folder, err := client.GetFolder(id, cmd.Fields)
 			if err != nil {
 				fmt.Printf("%-32s <= ERROR: folder not found\n", id)
 			} else {
-				PrintRow(note, cmd.Fields, &goplin.NoteFormats)
+				PrintRow(folder, cmd.Fields, &goplin.FolderFormats)
 			}
 
 		}
@@ -280,73 +805,234 @@ func (cmd *ListFoldersCmd) Run(ctx *Globals) error {
 	return nil
 }
 
-func (cmd *DeleteTagsCmd) Run(ctx *Globals) error {
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
+// listData lists the folders as json, yaml or csv; as json, a page of
+// --limit folders at a time when set.
+func (cmd *ListFoldersCmd) listData() error {
+	if err := checkPaging(cmd.Limit, cmd.Page); err != nil {
+		return err
+	}
+
+	if cmd.Output != "json" && (cmd.Limit != 0 || cmd.Page != 1) {
+		return fmt.Errorf("--limit and --page need --output json")
+	}
+
+	if cmd.Limit != 0 {
+		if len(cmd.IDs) != 0 {
+			return fmt.Errorf("--limit cannot be combined with IDs")
+		}
+
+		page, err := client.FoldersPage(cmd.Fields, cmd.OrderBy, cmd.OrderDir, cmd.Page, cmd.Limit)
+		if err != nil {
+			return err
+		}
+
+		return printPage(page, page.Items, cmd.Limit)
+	}
+
+	var folders []goplin.Folder
+
+	if len(cmd.IDs) == 0 {
+		var err error
+
+		folders, err = client.GetAllFolders(cmd.Fields, cmd.OrderBy, cmd.OrderDir)
+		if err != nil {
+			return err
+		}
 	}
 
 	for _, id := range cmd.IDs {
+		folder, err := client.GetFolder(id, cmd.Fields)
+		if err != nil {
+			return err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	if cmd.Output != "json" {
+		return printRecords(folders, cmd.Fields, &goplin.FolderFormats, cmd.Output)
+	}
+
+	return printAll(folders, len(folders))
+}
+
+func (cmd *DeleteTagsCmd) Run(ctx *Globals) error {
+	return EachID(cmd.IDs, func(id string) error {
 		err := client.DeleteTag(id)
 		if err != nil {
 			fmt.Printf("Could not find tag with ID '%s'\n", id)
 		} else {
 			fmt.Printf("Tag with ID '%s' deleted'\n", id)
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
-func (cmd *DeleteTagFromNoteCmd) Run(ctx *Globals) error {
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
-	}
+func (cmd *DeleteNotesCmd) Run(ctx *Globals) error {
+	failed := 0
+
+	err := EachID(cmd.IDs, func(id string) error {
+		err := client.DeleteNote(id, cmd.Permanent)
+		if err != nil {
+			failed++
+			fmt.Printf("Could not delete note with ID '%s': %v\n", id, err)
+		} else if cmd.Permanent {
+			fmt.Printf("Note with ID '%s' deleted permanently\n", id)
+		} else {
+			fmt.Printf("Note with ID '%s' deleted\n", id)
+		}
 
-	err := client.DeleteTagFromNote(cmd.TagID.TagID, cmd.TagID.From.NoteID.NoteID)
+		return nil
+	})
 	if err != nil {
-		fmt.Printf("Could not find tag with ID '%s'\n", cmd.TagID)
-	} else {
-		fmt.Printf("Tag with ID '%s' deleted'\n", cmd.TagID)
+		return err
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("could not delete %d notes", failed)
 	}
 
 	return nil
 }
 
+func (cmd *DeleteTagFromNoteCmd) Run(ctx *Globals) error {
+	return EachID([]string{cmd.TagID.From.NoteID.NoteID}, func(noteID string) error {
+		err := client.DeleteTagFromNote(cmd.TagID.TagID, noteID)
+		if err != nil {
+			fmt.Printf("Could not find tag with ID '%s'\n", cmd.TagID.TagID)
+		} else {
+			fmt.Printf("Tag with ID '%s' deleted'\n", cmd.TagID.TagID)
+		}
+
+		return nil
+	})
+}
+
 func (cmd *SearchCmd) Run(ctx *Globals) error {
-	if ctx.Debug {
-		req.EnableDumpAll()
-		req.EnableDebugLog()
+	if cmd.AllProfiles {
+		if isStructured(cmd.Output) {
+			return fmt.Errorf("--all-profiles supports table and ids output")
+		}
+
+		if len(cmd.Fields) == 0 {
+			cmd.Fields = goplin.DefaultSearchFields
+		}
+
+		if cmd.Output == "ids" {
+			cmd.Fields = "id"
+			cmd.NoHeader = true
+		}
+
+		return cmd.searchProfiles()
+	}
+
+	switch cmd.Type {
+	case goplin.SearchFolder:
+		return searchItems(cmd, "Folders", goplin.DefaultFolderFields, client.SearchFolders, &goplin.FolderFormats)
+	case goplin.SearchTag:
+		return searchItems(cmd, "Tags", goplin.DefaultTagFields, client.SearchTags, &goplin.TagFormats)
+	case goplin.SearchResource:
+		return searchItems(cmd, "Resources", listResourceFields, client.SearchResources, &goplin.ResourceFormats)
+	default:
+		return searchItems(cmd, "Notes", goplin.DefaultSearchFields, client.SearchNotes, &goplin.NoteFormats)
 	}
+}
 
+// searchItems runs the search of cmd with search, fetching every page, and
+// prints the items found with the fields and formats of their type.
+func searchItems[T any](cmd *SearchCmd, title string, fields string, search func(query string, fields string) ([]T, error), format *map[string]goplin.CellFormat) error {
 	if len(cmd.Fields) == 0 {
-		cmd.Fields = "id,parent_id,title"
+		cmd.Fields = fields
 	}
 
-	if !cmd.NoHeader {
-		PrintHeader("Search", cmd.Fields, &goplin.SearchFormats)
+	if cmd.Output == "ids" {
+		cmd.Fields = "id"
+		cmd.NoHeader = true
 	}
 
-	items, err := client.Search(cmd.Query, cmd.Type, cmd.Fields)
+	items, err := search(cmd.Query, cmd.Fields)
 	if err != nil {
-		return fmt.Errorf("could not execute query '%s'\n", cmd.Query)
+		return fmt.Errorf("could not execute query '%s': %w", cmd.Query, err)
+	}
+
+	switch cmd.Output {
+	case "json":
+		return printAll(items, len(items))
+	case "yaml", "csv":
+		return printRecords(items, cmd.Fields, format, cmd.Output)
+	}
+
+	if !cmd.NoHeader {
+		PrintHeader(title, cmd.Fields, format)
 	}
 
 	for _, item := range items {
-		PrintRow(item, cmd.Fields, &goplin.SearchFormats)
+		PrintRow(item, cmd.Fields, format)
 	}
 
 	return nil
 }
 
+// WithFields returns the comma separated field list with the extra fields
+// appended when missing.
+func WithFields(fields string, extra ...string) string {
+	columns := strings.Split(fields, ",")
+
+	for _, field := range extra {
+		found := false
+
+		for _, column := range columns {
+			if column == field {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			columns = append(columns, field)
+		}
+	}
+
+	return strings.Join(columns, ",")
+}
+
+// localeName returns the locale of dates, from the environment.
+func localeName() string {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if value := os.Getenv(name); len(value) != 0 {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// cellFormat returns the format of column, which for timestamps is the one
+// of their rendering by dates.
+func cellFormat(column string, format *map[string]goplin.CellFormat) goplin.CellFormat {
+	cf := (*format)[column]
+
+	if goplin.IsTimeField(column) {
+		width := dates.Width()
+		cf.Format = fmt.Sprintf("%%-%d.%ds", width, width)
+	}
+
+	return cf
+}
+
 func PrintHeader(title string, fields string, format *map[string]goplin.CellFormat) {
 	fmt.Printf("%s:\n", title)
 
+	PrintHeaderColumns(fields, format)
+}
+
+// PrintHeaderColumns prints the names of the columns of a table.
+func PrintHeaderColumns(fields string, format *map[string]goplin.CellFormat) {
 	columns := strings.Split(fields, ",")
 
 	for i, column := range columns {
-		cf := (*format)[column]
+		cf := cellFormat(column, format)
 		if i == 0 {
 			fmt.Printf(cf.Format, cf.Name)
 		} else {
@@ -363,9 +1049,13 @@ func PrintRow(cell interface{}, fields string, format *map[string]goplin.CellFor
 
 	for i, column := range columns {
 		value := reflect.ValueOf(cell)
-		cf := (*format)[column]
+		cf := cellFormat(column, format)
 		vof := value.FieldByName(cf.Field)
 
+		if goplin.IsTimeField(column) && vof.Kind() == reflect.Int {
+			vof = reflect.ValueOf(dates.Format(int(vof.Int())))
+		}
+
 		var s string
 
 		if i == 0 {
@@ -384,9 +1074,43 @@ func PrintRow(cell interface{}, fields string, format *map[string]goplin.CellFor
 	fmt.Println()
 }
 
+// SaveAPIToken stores the token in the configuration file, as the default
+// token when name is empty or as a named token otherwise.
+func SaveAPIToken(name string, token string) error {
+	if len(name) == 0 {
+		viper.Set("api_token", token)
+	} else {
+		tokens := viper.GetStringMapString("tokens")
+		if tokens == nil {
+			tokens = make(map[string]string)
+		}
+
+		tokens[name] = token
+		viper.Set("tokens", tokens)
+	}
+
+	return SaveConfig()
+}
+
+// SaveConfig writes the current configuration to the configuration file.
+func SaveConfig() error {
+	return viper.WriteConfigAs(path.Join(os.Getenv("HOME"), ".goplin"))
+}
+
 func main() {
 	var err error
 
+	cli := CLI{
+		Globals: Globals{},
+	}
+
+	var options []kong.Option
+	for _, plugin := range cliplugin.Commands() {
+		options = append(options, kong.DynamicCommand(plugin.Name, plugin.Help, "Plugins", plugin.Cmd))
+	}
+
+	parser := kong.Must(&cli, options...)
+
 	viper.SetDefault("api_token", "")
 	viper.SetConfigName(".goplin") // name of config file (without extension)
 	viper.SetConfigType("yaml")    // REQUIRED if the config file does not have the extension in the name
@@ -401,26 +1125,133 @@ func main() {
 		}
 	}
 
+	// External plugins are looked up before parsing, their arguments are
+	// theirs to interpret.
+	plugin, isPlugin := findPlugin(parser, os.Args[1:])
+
+	var ctx *kong.Context
+
+	if !isPlugin {
+		ctx, err = parser.Parse(expandAliases(os.Args[1:]))
+		parser.FatalIfErrorf(err)
+	}
+
+	level, err := goplin.ParseLevel(cli.LogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err = goplin.NewLogger(os.Stderr, cli.LogFormat, level)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dateFormat := cli.DateFormat
+	if len(dateFormat) == 0 {
+		dateFormat = viper.GetString("date_format")
+	}
+
+	dates, err = goplin.ParseDateFormat(dateFormat, localeName())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !isPlugin && offlineCommands[commandName(ctx)] {
+		err = ctx.Run(&cli.Globals, client)
+		ctx.FatalIfErrorf(err)
+
+		return
+	}
+
 	apiToken := viper.GetString("api_token")
 
+	if len(cli.TokenName) != 0 {
+		var ok bool
+
+		apiToken, ok = viper.GetStringMapString("tokens")[strings.ToLower(cli.TokenName)]
+		if !ok {
+			log.Fatalf("no token named '%s' in the config file, see 'goplin auth list'", cli.TokenName)
+		}
+	}
+
+	discoveryStart := time.Now()
+
 	client, err = goplin.New(apiToken)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	discovery := time.Since(discoveryStart)
+
+	var timings *goplin.Timings
+	if cli.Timings {
+		timings = client.EnableTimings()
+	}
+
 	if len(apiToken) == 0 {
-		viper.Set("api_token", client.GetApiToken())
-		err = viper.WriteConfigAs(path.Join(os.Getenv("HOME"), ".goplin"))
+		err = SaveAPIToken(cli.TokenName, client.GetApiToken())
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 
-	cli := CLI{
-		Globals: Globals{},
+	if cli.Debug {
+		client.EnableDump(os.Stderr)
+	}
+
+	if cli.Explain {
+		client.EnableExplain(os.Stderr)
+	}
+
+	onDuplicate := cli.OnDuplicate
+	if len(onDuplicate) == 0 {
+		onDuplicate = viper.GetString("on_duplicate")
+	}
+
+	err = client.SetCreateNoteDefaults(goplin.CreateNoteOpts{OnDuplicate: onDuplicate})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	largeBody := goplin.LargeBodyOpts{
+		Warn: func(noteID string, size int) {
+			logger.Warn("sending a large note body, this may take a while", goplin.F("note_id", noteID), goplin.F("size", formatSize(size)))
+		},
+	}
+
+	if value := viper.GetString("large_body_size"); len(value) != 0 {
+		largeBody.Threshold, err = goplin.ParseSize(value)
+		if err != nil {
+			log.Fatalf("invalid large_body_size in the config file: %v", err)
+		}
+	}
+
+	client.SetLargeBodyOpts(largeBody)
+
+	var writeQueue goplin.WriteQueueOpts
+
+	err = viper.UnmarshalKey("write_queue", &writeQueue)
+	if err != nil {
+		log.Fatalf("invalid write_queue in the config file: %v", err)
+	}
+
+	client.SetWriteQueue(writeQueue)
+
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		client.SetLanguageCache(filepath.Join(cacheDir, "goplin", "languages.json"))
+	}
+
+	if isPlugin {
+		os.Exit(runPlugin(plugin, os.Args[2:]))
+	}
+
+	runStart := time.Now()
+
+	err = ctx.Run(&cli.Globals, client)
+
+	if timings != nil {
+		printTimings(os.Stderr, discovery, time.Since(runStart), timings.Summary())
 	}
 
-	ctx := kong.Parse(&cli)
-	err = ctx.Run(&cli.Globals)
 	ctx.FatalIfErrorf(err)
 }
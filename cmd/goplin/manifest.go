@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/momo182/goplin/manifest"
+)
+
+type ManifestCmd struct {
+	Out string `default:"manifest.json" help:"File to write the manifest to."`
+}
+
+func (cmd *ManifestCmd) Run(ctx *Globals) error {
+	m, err := manifest.Build(client)
+	if err != nil {
+		return err
+	}
+
+	err = m.Save(cmd.Out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote the checksums of %d notes and %d resources to '%s'.\n", len(m.Notes), len(m.Resources), cmd.Out)
+
+	return nil
+}
+
+type VerifyCmd struct {
+	Manifest string `arg type:"existingfile" help:"Manifest written by 'goplin manifest'."`
+	JSON     bool   `name:"json" help:"Print the report as JSON."`
+}
+
+func (cmd *VerifyCmd) Run(ctx *Globals) error {
+	m, err := manifest.Load(cmd.Manifest)
+	if err != nil {
+		return err
+	}
+
+	report, err := manifest.Verify(client, m)
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(report)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, d := range report.Differences {
+			fmt.Printf("%-9s \u2502 %-8s \u2502 %s \u2502 %s\n", d.Problem, d.Kind, d.ID, d.Title)
+		}
+
+		fmt.Printf("Checked %d items: %d corrupted, %d missing, %d modified, %d added.\n", report.Checked,
+			report.Count(manifest.ProblemCorrupted), report.Count(manifest.ProblemMissing),
+			report.Count(manifest.ProblemModified), report.Count(manifest.ProblemAdded))
+	}
+
+	// Edits and new items are expected drift, lost or damaged data is not.
+	if problems := report.Count(manifest.ProblemCorrupted) + report.Count(manifest.ProblemMissing); problems != 0 {
+		return fmt.Errorf("%d items are corrupted or missing", problems)
+	}
+
+	return nil
+}
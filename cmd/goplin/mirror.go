@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin/mirror"
+)
+
+type MirrorDirCmd struct {
+	Delete bool `help:"Also remove the files of notes deleted or moved out of the folder, and any other file not written by goplin. Local directories only."`
+	DryRun bool `name:"dry-run" help:"Only print the changes."`
+	Full   bool `help:"Read every note instead of the changes since the last run."`
+
+	Folder string `arg help:"Folder to mirror (ID or path), with its sub-folders."`
+	Path   string `arg name:"path" help:"Directory to mirror to, created if missing, or an S3 or WebDAV URL: s3://bucket/path, webdav://host/path or webdavs://host/path."`
+}
+
+func (cmd *MirrorDirCmd) Run(ctx *Globals) error {
+	names, err := loadFileNameOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := mirror.Dir(client, cmd.Folder, cmd.Path, mirror.Options{
+		Delete: cmd.Delete,
+		DryRun: cmd.DryRun,
+		Full:   cmd.Full,
+		Names:  names,
+	})
+
+	prefix := ""
+	if cmd.DryRun {
+		prefix = "Would "
+	}
+
+	for _, p := range result.Written {
+		fmt.Printf("%swrite %s\n", prefix, p)
+	}
+
+	for _, p := range result.Removed {
+		fmt.Printf("%sremove %s\n", prefix, p)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	mode := "full"
+	if result.Incremental {
+		mode = "incremental"
+	}
+
+	fmt.Printf("Wrote %d, removed %d and left %d files unchanged (%s run).\n",
+		len(result.Written), len(result.Removed), result.Unchanged, mode)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/vaultfs"
+)
+
+type MountCmd struct {
+	Interval time.Duration `default:"5s" help:"How often to check the change events of Joplin for changes to show."`
+
+	Dir string `arg help:"Empty directory to mount the vault on."`
+}
+
+func (cmd *MountCmd) Run(ctx *Globals) error {
+	fsys := vaultfs.New(client)
+
+	server, err := vaultfs.Mount(fsys, cmd.Dir)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("mounted", goplin.F("dir", cmd.Dir))
+
+	sigCtx, stop := signalContext()
+	defer stop()
+
+	unmounted := make(chan struct{})
+
+	go func() {
+		server.Wait()
+		close(unmounted)
+	}()
+
+	signals := sigCtx.Done()
+
+	ticker := time.NewTicker(cmd.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := fsys.Update()
+			if err != nil {
+				logger.Error("could not check changes", goplin.F("error", err))
+				continue
+			}
+
+			if changed {
+				logger.Debug("vault changed")
+			}
+		case <-unmounted:
+			logger.Info("unmounted", goplin.F("dir", cmd.Dir))
+			return nil
+		case <-signals:
+			// Serving goes on until the unmount is done, by the user when
+			// files are still open.
+			signals = nil
+
+			err := server.Unmount()
+			if err != nil {
+				logger.Error("could not unmount, unmount the directory to stop", goplin.F("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+type NormalizeTagsCmd struct {
+	Lowercase bool   `help:"Lowercase the titles of the remaining tags."`
+	Map       string `type:"existingfile" help:"YAML file mapping synonyms to canonical tag titles (e.g. js: javascript)."`
+	DryRun    bool   `name:"dry-run" help:"Only report the changes that would be made."`
+	Yes       bool   `short:"y" help:"Do not ask for confirmation."`
+}
+
+func (cmd *NormalizeTagsCmd) Run(ctx *Globals) error {
+	synonyms := make(map[string]string)
+
+	if len(cmd.Map) != 0 {
+		data, err := os.ReadFile(cmd.Map)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(data, &synonyms)
+		if err != nil {
+			return fmt.Errorf("could not parse synonyms file '%s': %w", cmd.Map, err)
+		}
+	}
+
+	plan, err := client.PlanTagNormalization(cmd.Lowercase, synonyms)
+	if err != nil {
+		return err
+	}
+
+	if len(plan.Merges) == 0 && len(plan.Renames) == 0 {
+		fmt.Println("Tags are already normalized.")
+		return nil
+	}
+
+	for _, merge := range plan.Merges {
+		fmt.Printf("merge  %-32s %q -> %q (%d notes)\n", merge.From.ID, merge.From.Title, merge.To.Title, len(merge.Notes))
+	}
+
+	for _, rename := range plan.Renames {
+		fmt.Printf("rename %-32s %q -> %q\n", rename.Tag.ID, rename.Tag.Title, rename.Title)
+	}
+
+	if cmd.DryRun {
+		return nil
+	}
+
+	if !cmd.Yes && !Confirm(fmt.Sprintf("Apply %d merges and %d renames?", len(plan.Merges), len(plan.Renames))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	err = client.ApplyTagNormalization(plan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %d tags, renamed %d tags.\n", len(plan.Merges), len(plan.Renames))
+
+	return nil
+}
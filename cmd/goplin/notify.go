@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+type NotifyDaemonCmd struct {
+	Interval  time.Duration `default:"1m" help:"How often to check for due to-dos."`
+	Grace     time.Duration `default:"15m" help:"On the first run, how long before the start to-dos may have come due and still be raised; older ones are recorded without a notification. Later runs raise every to-do come due since the last check."`
+	Hook      string        `help:"Command run through the shell for each reminder, with GOPLIN_NOTE_ID, GOPLIN_NOTE_TITLE and GOPLIN_DUE set."`
+	Webhook   string        `help:"URL receiving a JSON POST for each reminder."`
+	NoDesktop bool          `name:"no-desktop" help:"Do not raise desktop notifications."`
+	Once      bool          `help:"Check once and exit."`
+	Health    string        `help:"Address to serve /healthz and /readyz on, e.g. localhost:41201; disabled when empty."`
+
+	// since is the last check of a previous run, or the start of the daemon
+	// less the grace period.
+	since time.Time
+	// statePath is the local file remembering the last check.
+	statePath string
+}
+
+// notifyState is what the notify daemon remembers between runs.
+type notifyState struct {
+	// LastCheck is the start of the last check which raised every reminder
+	// it found.
+	LastCheck time.Time `json:"last_check"`
+}
+
+// notifyStatePath returns the local state file of the notify daemon for the
+// token named tokenName, the default token when empty.
+func notifyStatePath(tokenName string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	if len(tokenName) == 0 {
+		tokenName = "default"
+	}
+
+	return filepath.Join(cacheDir, "goplin", "notify", tokenName+".json"), nil
+}
+
+func loadNotifyState(statePath string) (notifyState, error) {
+	var state notifyState
+
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return state, fmt.Errorf("invalid notify state %s, remove it to start over: %w", statePath, err)
+	}
+
+	return state, nil
+}
+
+func saveNotifyState(statePath string, state notifyState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(statePath), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(statePath, data, 0o600)
+}
+
+type NotifySnoozeCmd struct {
+	ID  string `arg name:"id" help:"ID of the to-do, \"-\" reads IDs from stdin."`
+	For string `arg name:"duration" help:"How long to snooze, e.g. 10m, 2h or 1d."`
+}
+
+// desktopNotify raises a desktop notification with the tools of the system.
+func desktopNotify(title string, message string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		return exec.Command("osascript", "-e", script).Run()
+	case "windows":
+		script := fmt.Sprintf("[reflection.assembly]::loadwithpartialname('System.Windows.Forms') | Out-Null; "+
+			"$n = New-Object System.Windows.Forms.NotifyIcon; $n.Icon = [System.Drawing.SystemIcons]::Information; "+
+			"$n.Visible = $true; $n.ShowBalloonTip(10000, %q, %q, 'Info'); Start-Sleep -Seconds 10", title, message)
+		return exec.Command("powershell", "-NoProfile", "-Command", script).Run()
+	default:
+		return exec.Command("notify-send", "--app-name=goplin", title, message).Run()
+	}
+}
+
+// watchRule is a rule of the watch_rules section of the config file: which
+// changes to report and how.
+type watchRule struct {
+	goplin.WatchRule `mapstructure:",squash"`
+	// Desktop raises a desktop notification, the default for rules without
+	// a hook.
+	Desktop bool `mapstructure:"desktop"`
+	// Hook is a command run through the shell with GOPLIN_RULE,
+	// GOPLIN_EVENT, GOPLIN_NOTE_ID and GOPLIN_NOTE_TITLE set.
+	Hook string `mapstructure:"hook"`
+}
+
+// runHook runs command through the shell with env added to the environment.
+func runHook(command string, env ...string) error {
+	hook := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		hook = exec.Command("cmd", "/C", command)
+	}
+
+	hook.Env = append(os.Environ(), env...)
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+
+	err := hook.Run()
+	if err != nil {
+		return fmt.Errorf("hook failed: %w", err)
+	}
+
+	return nil
+}
+
+// postWebhook posts body as JSON to url.
+func postWebhook(url string, body interface{}) error {
+	resp, err := req.C().SetTimeout(30 * time.Second).R().
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return fmt.Errorf("webhook failed: %w", err)
+	}
+
+	if !resp.IsSuccess() {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (cmd *NotifyDaemonCmd) notify(r goplin.Reminder) error {
+	due := dates.FormatTime(r.Due, time.Now())
+
+	if !cmd.NoDesktop {
+		err := desktopNotify("Joplin reminder", fmt.Sprintf("%s (due %s)", r.Note.Title, due))
+		if err != nil {
+			return fmt.Errorf("desktop notification failed: %w", err)
+		}
+	}
+
+	if len(cmd.Hook) != 0 {
+		err := runHook(cmd.Hook,
+			"GOPLIN_NOTE_ID="+r.Note.ID,
+			"GOPLIN_NOTE_TITLE="+r.Note.Title,
+			"GOPLIN_DUE="+r.Due.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cmd.Webhook) != 0 {
+		err := postWebhook(cmd.Webhook, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cmd *NotifyDaemonCmd) check() {
+	start := time.Now()
+
+	reminders, err := client.DueReminders(start, cmd.since)
+	if err != nil {
+		logger.Error("could not check reminders", goplin.F("error", err))
+		return
+	}
+
+	// The last check only moves on when every reminder was raised, so that
+	// the next run retries the others instead of taking them as missed.
+	raised := true
+
+	for _, r := range reminders {
+		l := logger.With(goplin.F("note_id", r.Note.ID))
+
+		if r.Missed {
+			l.Info("missed reminder of note", goplin.F("title", r.Note.Title), goplin.F("due", r.Due.Format(time.RFC3339)))
+
+			err = client.MarkReminderNotified(r)
+			if err != nil {
+				l.Error("could not record reminder", goplin.F("error", err))
+			}
+
+			continue
+		}
+
+		err = cmd.notify(r)
+		if err != nil {
+			l.Error("reminder failed", goplin.F("error", err))
+			raised = false
+
+			continue
+		}
+
+		l.Info("reminded of note", goplin.F("title", r.Note.Title), goplin.F("due", r.Due.Format(time.RFC3339)))
+
+		err = client.MarkReminderNotified(r)
+		if err != nil {
+			l.Error("could not record reminder", goplin.F("error", err))
+			raised = false
+		}
+	}
+
+	if raised {
+		err = saveNotifyState(cmd.statePath, notifyState{LastCheck: start})
+		if err != nil {
+			logger.Error("could not save the last check", goplin.F("error", err))
+		}
+	}
+
+	logger.Debug("reminders checked", goplin.F("due", len(reminders)), goplin.F("duration_ms", time.Since(start)))
+}
+
+// alert reports an anomaly on the desktop and to the webhook.
+func (cmd *NotifyDaemonCmd) alert(a goplin.Anomaly) {
+	logger.Warn("anomaly", goplin.F("kind", a.Kind), goplin.F("count", a.Count),
+		goplin.F("from", a.From.Format(time.RFC3339)), goplin.F("to", a.To.Format(time.RFC3339)))
+
+	if !cmd.NoDesktop {
+		err := desktopNotify("Joplin: unusual changes", a.String())
+		if err != nil {
+			logger.Error("desktop notification failed", goplin.F("error", err))
+		}
+	}
+
+	if len(cmd.Webhook) != 0 {
+		err := postWebhook(cmd.Webhook, struct {
+			Anomaly goplin.Anomaly `json:"anomaly"`
+		}{a})
+		if err != nil {
+			logger.Error("anomaly webhook failed", goplin.F("error", err))
+		}
+	}
+}
+
+// watch reports the anomalies and the changes matching the watch rules since
+// the last call, anomalies first.
+func (cmd *NotifyDaemonCmd) watch(watcher *goplin.Watcher, detector *goplin.AnomalyDetector, rules map[string]watchRule) {
+	events, err := watcher.Next()
+	if err != nil {
+		logger.Error("could not check changes", goplin.F("error", err))
+		return
+	}
+
+	for _, a := range detector.Add(events) {
+		cmd.alert(a)
+	}
+
+	matches, err := watcher.Match(events)
+	if err != nil {
+		logger.Error("could not check changes", goplin.F("error", err))
+		return
+	}
+
+	for _, m := range matches {
+		l := logger.With(goplin.F("rule", m.Rule), goplin.F("note_id", m.Note.ID))
+		rule := rules[m.Rule]
+
+		if (rule.Desktop || len(rule.Hook) == 0) && !cmd.NoDesktop {
+			err = desktopNotify("Joplin: "+m.Rule, fmt.Sprintf("%s (%s)", m.Note.Title, m.Event))
+			if err != nil {
+				l.Error("desktop notification failed", goplin.F("error", err))
+			}
+		}
+
+		if len(rule.Hook) != 0 {
+			err = runHook(rule.Hook,
+				"GOPLIN_RULE="+m.Rule,
+				"GOPLIN_EVENT="+m.Event,
+				"GOPLIN_NOTE_ID="+m.Note.ID,
+				"GOPLIN_NOTE_TITLE="+m.Note.Title)
+			if err != nil {
+				l.Error("watch rule hook failed", goplin.F("error", err))
+			}
+		}
+
+		l.Info("note "+m.Event, goplin.F("title", m.Note.Title))
+	}
+}
+
+func (cmd *NotifyDaemonCmd) Run(ctx *Globals) error {
+	statePath, err := notifyStatePath(ctx.TokenName)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadNotifyState(statePath)
+	if err != nil {
+		return err
+	}
+
+	cmd.statePath = statePath
+	cmd.since = state.LastCheck
+
+	if cmd.since.IsZero() {
+		cmd.since = time.Now().Add(-cmd.Grace)
+	}
+
+	cmd.check()
+
+	if cmd.Once {
+		return nil
+	}
+
+	var configRules []watchRule
+
+	err = viper.UnmarshalKey("watch_rules", &configRules)
+	if err != nil {
+		return fmt.Errorf("invalid watch_rules in the config file: %w", err)
+	}
+
+	var watchRules []goplin.WatchRule
+
+	rules := make(map[string]watchRule)
+
+	for i, rule := range configRules {
+		if len(rule.Name) == 0 {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		rules[rule.Name] = rule
+		watchRules = append(watchRules, rule.WatchRule)
+	}
+
+	thresholds, err := loadAnomalyThresholds()
+	if err != nil {
+		return err
+	}
+
+	// Change events are followed for the watch rules and to catch unusual
+	// bursts of changes, such as a sync wiping notes.
+	watcher, err := client.NewWatcher(watchRules)
+	if err != nil {
+		return err
+	}
+
+	detector := goplin.NewAnomalyDetector(thresholds)
+
+	logger.Info("watching changes", goplin.F("rules", len(watchRules)),
+		goplin.F("max_deletions", thresholds.Deletions), goplin.F("max_updates", thresholds.Updates), goplin.F("window", thresholds.Window.String()))
+
+	sigCtx, stop := signalContext()
+	defer stop()
+
+	errs := make(chan error, 1)
+
+	if len(cmd.Health) != 0 {
+		mux := http.NewServeMux()
+		handleHealth(mux)
+
+		go func() {
+			errs <- serveUntilDone(sigCtx, cmd.Health, mux)
+		}()
+	}
+
+	ticker := time.NewTicker(cmd.Interval)
+	defer ticker.Stop()
+
+	// A check in progress is finished before a signal is handled.
+	for {
+		select {
+		case <-ticker.C:
+			cmd.check()
+			cmd.watch(watcher, detector, rules)
+		case err := <-errs:
+			return err
+		case <-sigCtx.Done():
+			logger.Info("stopped")
+
+			if len(cmd.Health) != 0 {
+				return <-errs
+			}
+
+			return nil
+		}
+	}
+}
+
+func (cmd *NotifySnoozeCmd) Run(ctx *Globals) error {
+	d, err := goplin.ParseDuration(cmd.For)
+	if err != nil {
+		return err
+	}
+
+	until := time.Now().Add(d)
+
+	return EachID([]string{cmd.ID}, func(id string) error {
+		err := client.SnoozeReminder(id, until)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Reminder of note '%s' snoozed until %s.\n", id, dates.FormatTime(until, time.Now()))
+
+		return nil
+	})
+}
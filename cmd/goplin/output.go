@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"gopkg.in/yaml.v3"
+)
+
+// isStructured tells whether the --output of a list command is meant for
+// other programs rather than a table.
+func isStructured(output string) bool {
+	return output == "json" || output == "yaml" || output == "csv"
+}
+
+// printRecords prints items as yaml or csv, with the given fields of each.
+// Yaml keeps the values of the Data API, csv renders timestamps as dates for
+// spreadsheets.
+func printRecords[T any](items []T, fields string, format *map[string]goplin.CellFormat, output string) error {
+	columns := strings.Split(fields, ",")
+
+	for _, column := range columns {
+		if _, ok := (*format)[column]; !ok {
+			return fmt.Errorf("unknown field '%s'", column)
+		}
+	}
+
+	if output == "csv" {
+		w := csv.NewWriter(os.Stdout)
+
+		err := w.Write(columns)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			row := make([]string, len(columns))
+
+			for i, column := range columns {
+				value := reflect.ValueOf(item).FieldByName((*format)[column].Field)
+
+				if goplin.IsTimeField(column) && value.Kind() == reflect.Int {
+					row[i] = dates.Format(int(value.Int()))
+				} else {
+					row[i] = fmt.Sprint(value.Interface())
+				}
+			}
+
+			err = w.Write(row)
+			if err != nil {
+				return err
+			}
+		}
+
+		w.Flush()
+
+		return w.Error()
+	}
+
+	records := make([]map[string]interface{}, 0, len(items))
+
+	for _, item := range items {
+		record := make(map[string]interface{}, len(columns))
+
+		for _, column := range columns {
+			record[column] = reflect.ValueOf(item).FieldByName((*format)[column].Field).Interface()
+		}
+
+		records = append(records, record)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+
+	err := enc.Encode(records)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/momo182/goplin"
+)
+
+// listPage is the json output of the list commands. With --limit it is one
+// page of the items, and next_page is the --page that resumes the listing.
+type listPage struct {
+	Items    interface{} `json:"items"`
+	Page     int         `json:"page"`
+	Limit    int         `json:"limit,omitempty"`
+	HasMore  bool        `json:"has_more"`
+	NextPage int         `json:"next_page,omitempty"`
+	// Fetched is the number of items fetched up to this page, before
+	// filtering.
+	Fetched int `json:"fetched"`
+}
+
+// checkPaging validates --limit and --page.
+func checkPaging(limit int, page int) error {
+	if limit < 0 || limit > goplin.MaxPageSize {
+		return fmt.Errorf("--limit must be between 1 and %d", goplin.MaxPageSize)
+	}
+
+	if page < 1 {
+		return fmt.Errorf("--page must be 1 or more")
+	}
+
+	if page > 1 && limit == 0 {
+		return fmt.Errorf("--page needs --limit")
+	}
+
+	return nil
+}
+
+// printPage prints items, the ones of page left by filtering, as json.
+func printPage[T any](page goplin.Page[T], items []T, limit int) error {
+	if items == nil {
+		items = []T{}
+	}
+
+	return printJSON(listPage{
+		Items:    items,
+		Page:     page.Number,
+		Limit:    limit,
+		HasMore:  page.HasMore,
+		NextPage: page.Next(),
+		Fetched:  (page.Number-1)*limit + len(page.Items),
+	})
+}
+
+// printAll prints every item as json, as a single page.
+func printAll[T any](items []T, fetched int) error {
+	if items == nil {
+		items = []T{}
+	}
+
+	return printJSON(listPage{Items: items, Page: 1, Fetched: fetched})
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
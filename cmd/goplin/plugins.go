@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/alecthomas/kong"
+	"github.com/momo182/goplin/cliplugin"
+	"github.com/momo182/goplin/script"
+	"github.com/spf13/viper"
+)
+
+type PluginsCmd struct{}
+
+func (cmd *PluginsCmd) Run(ctx *Globals) error {
+	for _, plugin := range cliplugin.Commands() {
+		fmt.Printf("%-24s \u2502 built in \u2502 %s\n", plugin.Name, plugin.Help)
+	}
+
+	for _, plugin := range cliplugin.Discover() {
+		fmt.Printf("%-24s \u2502 external \u2502 %s\n", plugin.Name, plugin.Path)
+	}
+
+	return nil
+}
+
+// findPlugin returns the external plugin named by the first argument, unless
+// a command of goplin itself has that name.
+func findPlugin(parser *kong.Kong, args []string) (cliplugin.External, bool) {
+	if len(args) == 0 {
+		return cliplugin.External{}, false
+	}
+
+	for _, node := range parser.Model.Children {
+		if node.Name == args[0] {
+			return cliplugin.External{}, false
+		}
+	}
+
+	return cliplugin.Find(args[0])
+}
+
+// runPlugin runs an external plugin with goplin's token, config and script
+// API and returns its exit code.
+func runPlugin(plugin cliplugin.External, args []string) int {
+	command := exec.Command(plugin.Path, args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	command.Env = append(os.Environ(),
+		cliplugin.EnvToken+"="+client.GetApiToken(),
+		cliplugin.EnvConfig+"="+viper.ConfigFileUsed(),
+	)
+
+	err := script.NewAPI(client, false).Run(command)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+
+		fmt.Fprintf(os.Stderr, "goplin: plugin '%s': %v\n", plugin.Name, err)
+
+		return 1
+	}
+
+	return 0
+}
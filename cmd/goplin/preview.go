@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/momo182/goplin/preview"
+)
+
+type PreviewResourceCmd struct {
+	Protocol string `enum:"auto,kitty,iterm,sixel" default:"auto" help:"Inline image protocol of the terminal: auto, kitty, iterm or sixel."`
+	Width    int    `default:"640" help:"Maximum width of the thumbnail in pixels."`
+	Height   int    `default:"480" help:"Maximum height of the thumbnail in pixels."`
+	Out      string `short:"o" type:"path" help:"Save the thumbnail to this file (PNG, or JPEG for .jpg) instead of showing it."`
+	NoCache  bool   `name:"no-cache" help:"Do not read or write the thumbnail cache."`
+	ID       string `arg help:"ID of the image resource."`
+}
+
+func (cmd *PreviewResourceCmd) Run(ctx *Globals) error {
+	protocol := cmd.Protocol
+	if protocol == "auto" {
+		protocol = preview.DetectProtocol()
+	}
+
+	if len(cmd.Out) == 0 && protocol == preview.ProtocolNone {
+		return fmt.Errorf("the terminal does not seem to support inline images, use --protocol or save the thumbnail with --out")
+	}
+
+	thumbnailer := &preview.Thumbnailer{MaxWidth: cmd.Width, MaxHeight: cmd.Height}
+
+	if cacheDir, err := os.UserCacheDir(); err == nil && !cmd.NoCache {
+		thumbnailer.CacheDir = filepath.Join(cacheDir, "goplin", "thumbnails")
+	}
+
+	img, err := thumbnailer.Resource(client, cmd.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.Out) != 0 {
+		err = preview.WriteFile(cmd.Out, img)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Saved %dx%d thumbnail to %s.\n", img.Bounds().Dx(), img.Bounds().Dy(), cmd.Out)
+
+		return nil
+	}
+
+	return preview.Write(os.Stdout, img, protocol)
+}
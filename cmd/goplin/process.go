@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/transcribe"
+	"github.com/spf13/viper"
+)
+
+type ProcessResourcesCmd struct {
+	Transcribe bool   `help:"Transcribe audio attachments into the notes they are attached to."`
+	Backend    string `help:"Transcription backend: whisper (local binary) or http (OpenAI compatible API), overriding transcribe.backend in the config file."`
+	Force      bool   `help:"Transcribe again resources whose notes already hold a transcript."`
+	DryRun     bool   `name:"dry-run" help:"Only list the resources that would be processed."`
+
+	IDs []string `arg optional name:"id" help:"Process only the resources with these IDs, \"-\" reads IDs from stdin."`
+}
+
+func (cmd *ProcessResourcesCmd) Run(ctx *Globals) error {
+	if !cmd.Transcribe {
+		return fmt.Errorf("nothing to do, use --transcribe")
+	}
+
+	var cfg transcribe.Config
+
+	err := viper.UnmarshalKey("transcribe", &cfg)
+	if err != nil {
+		return fmt.Errorf("invalid transcribe section in the config file: %w", err)
+	}
+
+	if len(cmd.Backend) != 0 {
+		cfg.Backend = cmd.Backend
+	}
+
+	transcriber, err := transcribe.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	ids, err := ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	fields := "id,title,mime,file_extension"
+
+	var resources []goplin.Resource
+
+	if len(ids) != 0 {
+		for _, id := range ids {
+			resource, err := client.GetResource(id, fields)
+			if err != nil {
+				return err
+			}
+
+			resources = append(resources, resource)
+		}
+	} else {
+		resources, err = client.GetAllResources(fields, "", "")
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := transcribe.Process(client, transcriber, resources, transcribe.Options{
+		Force:  cmd.Force,
+		DryRun: cmd.DryRun,
+	})
+
+	transcribed, failed := 0, 0
+
+	for _, result := range results {
+		status := fmt.Sprintf("%d notes", len(result.Notes))
+
+		switch {
+		case result.Skipped:
+			status = "skipped"
+		case len(result.Error) != 0:
+			status = "error: " + result.Error
+			failed++
+		case !cmd.DryRun:
+			transcribed++
+		}
+
+		fmt.Printf("%-32s \u2502 %-24.24s \u2502 %s\n", result.Resource.ID, result.Resource.Title, status)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !cmd.DryRun {
+		fmt.Printf("Transcribed %d audio resources, %d failed.\n", transcribed, failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+// VaultProfile is a Joplin instance of the profiles section of the config
+// file, for users running several instances side by side:
+//
+//	profiles:
+//	  work:
+//	    port: 41184
+//	    token_name: work
+//	  personal:
+//	    port: 41185
+//	    token: 0123abcd...
+type VaultProfile struct {
+	Port int `mapstructure:"port"`
+	// Token is the API token, or TokenName names one of the tokens section.
+	Token     string `mapstructure:"token"`
+	TokenName string `mapstructure:"token_name"`
+}
+
+// loadVaultProfiles returns the profiles of the config file by name.
+func loadVaultProfiles() (map[string]VaultProfile, error) {
+	profiles := make(map[string]VaultProfile)
+
+	err := viper.UnmarshalKey("profiles", &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profiles section in the config file: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// sortedProfileNames returns the names of profiles in order.
+func sortedProfileNames(profiles map[string]VaultProfile) []string {
+	names := make([]string, 0, len(profiles))
+
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Client connects to the instance of the profile. Profiles must carry their
+// token, new tokens are not requested for them.
+func (p VaultProfile) Client() (*goplin.Client, error) {
+	token := p.Token
+
+	if len(token) == 0 && len(p.TokenName) != 0 {
+		var ok bool
+
+		token, ok = viper.GetStringMapString("tokens")[strings.ToLower(p.TokenName)]
+		if !ok {
+			return nil, fmt.Errorf("no token named '%s' in the config file", p.TokenName)
+		}
+	}
+
+	if len(token) == 0 {
+		return nil, fmt.Errorf("no token or token_name set")
+	}
+
+	if p.Port == 0 {
+		return goplin.New(token)
+	}
+
+	return goplin.NewWithPort(token, p.Port)
+}
+
+type profileSearch struct {
+	items []goplin.Item
+	err   error
+}
+
+// searchProfiles runs the search on every profile concurrently and prints
+// the results of each profile in turn, prefixed by its name. Failing profiles
+// are reported and skipped.
+func (cmd *SearchCmd) searchProfiles() error {
+	profiles, err := loadVaultProfiles()
+	if err != nil {
+		return err
+	}
+
+	if len(profiles) == 0 {
+		return fmt.Errorf("no profiles in the config file, see the profiles section")
+	}
+
+	names := sortedProfileNames(profiles)
+	results := make([]profileSearch, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+
+		go func(i int, profile VaultProfile) {
+			defer wg.Done()
+
+			c, err := profile.Client()
+			if err != nil {
+				results[i].err = err
+				return
+			}
+
+			results[i].items, results[i].err = c.Search(cmd.Query, cmd.Type, cmd.Fields)
+		}(i, profiles[name])
+	}
+
+	wg.Wait()
+
+	if !cmd.NoHeader {
+		fmt.Println("Search:")
+		fmt.Printf("%-12s \u2502 ", "Profile")
+		PrintHeaderColumns(cmd.Fields, &goplin.SearchFormats)
+	}
+
+	// IDs are printed after the profile name and a tab.
+	prefix := "%-12s \u2502 "
+	if cmd.Output == "ids" {
+		prefix = "%s\t"
+	}
+
+	failed := 0
+
+	for i, name := range names {
+		if results[i].err != nil {
+			fmt.Fprintf(os.Stderr, "profile %s: %v\n", name, results[i].err)
+			failed++
+
+			continue
+		}
+
+		for _, item := range results[i].items {
+			fmt.Printf(prefix, name)
+			PrintRow(item, cmd.Fields, &goplin.SearchFormats)
+		}
+	}
+
+	if failed == len(names) {
+		return fmt.Errorf("the search failed on every profile")
+	}
+
+	return nil
+}
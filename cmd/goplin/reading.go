@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+)
+
+type ReadingListCmd struct {
+	All bool `help:"Also list finished notes."`
+}
+
+type ReadingMarkCmd struct {
+	ID       string `arg name:"id" help:"ID of the note, \"-\" reads IDs from stdin."`
+	Progress string `arg name:"progress" help:"Progress in percent (e.g. 45%), or done."`
+}
+
+func (cmd *ReadingListCmd) Run(ctx *Globals) error {
+	entries, err := client.ListReadingProgress(cmd.All)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Nothing being read.")
+		return nil
+	}
+
+	for _, entry := range entries {
+		fmt.Printf("%-32s \u2502 %4d%% \u2502 %-*s \u2502 %s\n",
+			entry.Note.ID,
+			entry.Progress.Percent,
+			dates.Width(),
+			dates.Format(entry.Progress.UpdatedTime),
+			entry.Note.Title)
+	}
+
+	return nil
+}
+
+func (cmd *ReadingMarkCmd) Run(ctx *Globals) error {
+	percent, err := goplin.ParsePercent(cmd.Progress)
+	if err != nil {
+		return err
+	}
+
+	return EachID([]string{cmd.ID}, func(id string) error {
+		err := client.SetReadingProgress(id, percent)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Note with ID '%s' marked as %d%% read.\n", id, percent)
+
+		return nil
+	})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+// RecordPlacement are the options of the new commands telling where the
+// note goes.
+type RecordPlacement struct {
+	Folder string   `help:"Folder to create the note in (ID, path or title); Joplin's default folder otherwise."`
+	Tags   []string `help:"Tags of the note, by ID or title, comma-separated; missing tags are created."`
+}
+
+type NewContactCmd struct {
+	RecordPlacement
+
+	Name     string `required help:"Name of the contact, the title of the note."`
+	Email    string `help:"Email address."`
+	Phone    string `help:"Phone number."`
+	Company  string `help:"Company."`
+	Address  string `help:"Postal address."`
+	Birthday string `help:"Birthday."`
+	Website  string `help:"Website."`
+}
+
+type NewBookCmd struct {
+	RecordPlacement
+
+	Title     string `required help:"Title of the book, the title of the note."`
+	Author    string `help:"Author."`
+	ISBN      string `name:"isbn" help:"ISBN."`
+	Year      string `help:"Year of publication."`
+	Publisher string `help:"Publisher."`
+	Status    string `help:"Reading status, such as to-read, reading or read."`
+	Rating    string `help:"Rating."`
+}
+
+type NewRecipeCmd struct {
+	RecordPlacement
+
+	Name        string   `required help:"Name of the recipe, the title of the note."`
+	Servings    string   `help:"Number of servings."`
+	PrepTime    string   `name:"prep-time" help:"Preparation time."`
+	CookTime    string   `name:"cook-time" help:"Cooking time."`
+	Source      string   `help:"Where the recipe comes from."`
+	Ingredients []string `sep:";" help:"Ingredients, separated by semicolons."`
+	Steps       []string `sep:";" help:"Steps, separated by semicolons."`
+}
+
+type FindCmd struct {
+	NoHeader bool   `help:"Do not print header."`
+	Output   string `enum:"table,ids,json" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json."`
+
+	Type    string   `arg name:"type" help:"Type of the notes: contact, book, recipe, or any."`
+	Filters []string `arg optional name:"filter" help:"Filters field=value, matching the whole value, or field~value, matching a part of it, ignoring case; notes must pass all of them."`
+}
+
+func (cmd *NewContactCmd) Run(ctx *Globals) error {
+	return newRecord(goplin.ContactType, cmd.RecordPlacement, map[string]string{
+		"name":     cmd.Name,
+		"email":    cmd.Email,
+		"phone":    cmd.Phone,
+		"company":  cmd.Company,
+		"address":  cmd.Address,
+		"birthday": cmd.Birthday,
+		"website":  cmd.Website,
+	})
+}
+
+func (cmd *NewBookCmd) Run(ctx *Globals) error {
+	return newRecord(goplin.BookType, cmd.RecordPlacement, map[string]string{
+		"title":     cmd.Title,
+		"author":    cmd.Author,
+		"isbn":      cmd.ISBN,
+		"year":      cmd.Year,
+		"publisher": cmd.Publisher,
+		"status":    cmd.Status,
+		"rating":    cmd.Rating,
+	})
+}
+
+func (cmd *NewRecipeCmd) Run(ctx *Globals) error {
+	return newRecord(goplin.RecipeType, cmd.RecordPlacement, map[string]string{
+		"name":        cmd.Name,
+		"servings":    cmd.Servings,
+		"prep_time":   cmd.PrepTime,
+		"cook_time":   cmd.CookTime,
+		"source":      cmd.Source,
+		"ingredients": strings.Join(cmd.Ingredients, "\n"),
+		"steps":       strings.Join(cmd.Steps, "\n"),
+	})
+}
+
+// newRecord creates the structured note of fields and prints its ID.
+func newRecord(t goplin.RecordType, placement RecordPlacement, fields map[string]string) error {
+	record, err := t.NewRecord(fields)
+	if err != nil {
+		return err
+	}
+
+	created, err := createNote(t.Note(record), placement.Folder, placement.Tags)
+	if err != nil {
+		return err
+	}
+
+	err = client.SetRecord(created.ID, record)
+	if err != nil {
+		return fmt.Errorf("created note '%s' but could not store its fields: %w", created.ID, err)
+	}
+
+	fmt.Println(created.ID)
+
+	return nil
+}
+
+func (cmd *FindCmd) Run(ctx *Globals) error {
+	typ := strings.ToLower(cmd.Type)
+	if typ == "any" {
+		typ = ""
+	}
+
+	var filters []goplin.RecordFilter
+
+	for _, s := range cmd.Filters {
+		filter, err := goplin.ParseRecordFilter(s)
+		if err != nil {
+			return err
+		}
+
+		filters = append(filters, filter)
+	}
+
+	found, err := client.FindRecords(typ, filters)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Output {
+	case "json":
+		return printAll(found, len(found))
+	case "ids":
+		for _, item := range found {
+			fmt.Println(item.Note.ID)
+		}
+
+		return nil
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Notes:")
+		fmt.Printf("%-32s \u2502 %-8s \u2502 %-40s \u2502 %s\n", "ID", "Type", "Title", "Fields")
+	}
+
+	for _, item := range found {
+		var fields []string
+
+		t := goplin.RecordTypes[item.Record.Type]
+
+		for _, field := range t.Fields {
+			value := item.Record.Fields[field.Name]
+			if len(value) == 0 || field.List || field.Name == t.TitleField {
+				continue
+			}
+
+			fields = append(fields, fmt.Sprintf("%s=%s", field.Name, value))
+		}
+
+		fmt.Printf("%-32s \u2502 %-8s \u2502 %-40.40s \u2502 %s\n", item.Note.ID, item.Record.Type, item.Note.Title, strings.Join(fields, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type RelatedCmd struct {
+	Limit  int    `default:"10" help:"Maximum number of notes to show."`
+	Output string `enum:"text,json" default:"text" help:"Output format: text or json."`
+	ID     string `arg help:"ID of the note to find related notes for."`
+}
+
+func (cmd *RelatedCmd) Run(ctx *Globals) error {
+	related, err := client.RelatedNotes(cmd.ID, goplin.RelatedOptions{Limit: cmd.Limit})
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(related)
+	}
+
+	if len(related) == 0 {
+		fmt.Println("No related notes found.")
+		return nil
+	}
+
+	for _, note := range related {
+		var why []string
+
+		if len(note.SharedTags) > 0 {
+			why = append(why, "tags: "+strings.Join(note.SharedTags, ", "))
+		}
+
+		switch note.Distance {
+		case 1:
+			why = append(why, "linked")
+		case 2:
+			why = append(why, "linked via another note")
+		}
+
+		if note.TitleSim > 0 {
+			why = append(why, "similar title")
+		}
+
+		fmt.Printf("%5.2f \u2502 %-32s \u2502 %s (%s)\n", note.Score, note.ID, note.Title, strings.Join(why, "; "))
+	}
+
+	return nil
+}
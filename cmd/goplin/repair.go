@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+)
+
+type RepairOrphansCmd struct {
+	MoveTo string `name:"move-to" default:"Recovered" help:"Folder (ID or title) the orphaned notes are moved to, created at the top level when missing."`
+	DryRun bool   `name:"dry-run" help:"Only list the orphaned notes."`
+	Yes    bool   `short:"y" help:"Do not ask for confirmation."`
+}
+
+func (cmd *RepairOrphansCmd) Run(ctx *Globals) error {
+	orphans, err := client.GetOrphanNotes()
+	if err != nil {
+		return err
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned notes found.")
+		return nil
+	}
+
+	fmt.Println("Notes in missing folders:")
+
+	for _, note := range orphans {
+		fmt.Printf("%-32s \u2502 %-32s \u2502 %s\n", note.ID, note.ParentID, note.Title)
+	}
+
+	if cmd.DryRun {
+		return nil
+	}
+
+	if !cmd.Yes && !Confirm(fmt.Sprintf("Move %d notes to '%s'?", len(orphans), cmd.MoveTo)) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	folderID, err := resolveFolder(cmd.MoveTo)
+	if err != nil {
+		return err
+	}
+
+	batch := client.NewBatch()
+	for _, note := range orphans {
+		batch.UpdateNote(goplin.NewNoteUpdate(note.ID).SetParent(folderID))
+	}
+
+	report, err := batch.Run()
+	if err != nil {
+		return err
+	}
+
+	printBatchReport(report)
+
+	fmt.Printf("Moved %d of %d orphaned notes.\n", report.Succeeded, len(orphans))
+
+	return nil
+}
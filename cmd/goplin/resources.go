@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/media"
+)
+
+const listResourceFields = "id,title,mime,size"
+
+type ListResourcesCmd struct {
+	NoHeader bool   `help:"Do not print header."`
+	Fields   string `help:"Show only the specified fields."`
+	Output   string `enum:"table,ids,json,yaml,csv" default:"table" help:"Output format: table, ids to print one ID per line for piping into other commands, or json, yaml or csv for other programs."`
+	OrderBy  string `name:"order-by" help:"Order by specified field."`
+	OrderDir string `name:"order-dir" help:"Order by specified direction: ASC or DESC."`
+	Media    bool   `help:"List only audio and video resources with their duration, codecs and dimensions."`
+	Refresh  bool   `help:"With --media, extract the metadata again instead of using the stored one."`
+	Prober   string `default:"ffprobe" help:"ffprobe command used to extract media metadata."`
+}
+
+func (cmd *ListResourcesCmd) Run(ctx *Globals) error {
+	if len(cmd.Fields) == 0 {
+		cmd.Fields = listResourceFields
+	}
+
+	if cmd.Output == "ids" {
+		cmd.Fields = "id"
+		cmd.NoHeader = true
+	}
+
+	if !cmd.Media {
+		resources, err := client.GetAllResources(cmd.Fields, cmd.OrderBy, cmd.OrderDir)
+		if err != nil {
+			return err
+		}
+
+		switch cmd.Output {
+		case "json":
+			return printAll(resources, len(resources))
+		case "yaml", "csv":
+			return printRecords(resources, cmd.Fields, &goplin.ResourceFormats, cmd.Output)
+		}
+
+		if !cmd.NoHeader {
+			PrintHeader("Resources", cmd.Fields, &goplin.ResourceFormats)
+		}
+
+		for _, resource := range resources {
+			PrintRow(resource, cmd.Fields, &goplin.ResourceFormats)
+		}
+
+		return nil
+	}
+
+	if isStructured(cmd.Output) {
+		return fmt.Errorf("--media supports table and ids output")
+	}
+
+	_, entries, err := collectMedia(cmd.Prober, cmd.Refresh, cmd.OrderBy, cmd.OrderDir)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "ids" {
+		for _, entry := range entries {
+			fmt.Println(entry.Resource.ID)
+		}
+
+		return nil
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Media resources:")
+		fmt.Printf("%-32s \u2502 %-8s \u2502 %-12s \u2502 %-9s \u2502 %s\n", "ID", "Duration", "Codecs", "Size", "Title")
+	}
+
+	for _, entry := range entries {
+		if entry.Info == nil {
+			fmt.Printf("%-32s \u2502 %-8s \u2502 %-12s \u2502 %-9s \u2502 %s (%s)\n", entry.Resource.ID, "?", "", "", entry.Resource.Title, entry.Error)
+			continue
+		}
+
+		info := entry.Info
+
+		codecs := info.VideoCodec
+		if len(info.AudioCodec) != 0 {
+			if len(codecs) != 0 {
+				codecs += "/"
+			}
+
+			codecs += info.AudioCodec
+		}
+
+		size := ""
+		if info.Width != 0 {
+			size = fmt.Sprintf("%dx%d", info.Width, info.Height)
+		}
+
+		fmt.Printf("%-32s \u2502 %8s \u2502 %-12s \u2502 %-9s \u2502 %s\n",
+			entry.Resource.ID, media.FormatDuration(info.Duration), codecs, size, entry.Resource.Title)
+	}
+
+	return nil
+}
+
+// collectMedia returns all resources and the media ones with their metadata.
+func collectMedia(prober string, refresh bool, orderBy string, orderDir string) ([]goplin.Resource, []media.Entry, error) {
+	resources, err := client.GetAllResources("id,title,mime,file_extension,size,updated_time", orderBy, orderDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := media.Collect(client, media.FFProbe{Command: prober}, resources, refresh)
+
+	return resources, entries, err
+}
+
+type StatsResourcesCmd struct {
+	NoHeader bool   `help:"Do not print header."`
+	Output   string `enum:"table,json" default:"table" help:"Output format: table or json."`
+	Largest  int    `default:"10" help:"Number of largest resources to show."`
+	Media    bool   `help:"Include the total duration of audio and video resources."`
+	Prober   string `default:"ffprobe" help:"ffprobe command used to extract media metadata."`
+}
+
+func (cmd *StatsResourcesCmd) Run(ctx *Globals) error {
+	var report goplin.ResourceStatsReport
+	var err error
+
+	if cmd.Media {
+		resources, entries, err := collectMedia(cmd.Prober, false, "", "")
+		if err != nil {
+			return err
+		}
+
+		durations := make(map[string]float64)
+		for _, entry := range entries {
+			if entry.Info != nil {
+				durations[entry.Resource.ID] = entry.Info.Duration
+			}
+		}
+
+		report = goplin.BuildResourceStats(resources, durations, cmd.Largest)
+	} else {
+		report, err = client.ResourceStats(cmd.Largest)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cmd.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	fmt.Printf("%d resources, %s", report.Resources, formatSize(report.Size))
+	if cmd.Media {
+		fmt.Printf(", %s of audio and video", media.FormatDuration(report.Duration))
+	}
+
+	fmt.Println()
+	fmt.Println()
+
+	if !cmd.NoHeader {
+		fmt.Println("Resources per type:")
+		fmt.Printf("%-32s \u2502 %9s \u2502 %10s", "Mime", "Resources", "Size")
+
+		if cmd.Media {
+			fmt.Printf(" \u2502 %9s", "Duration")
+		}
+
+		fmt.Println()
+	}
+
+	for _, count := range report.ByMime {
+		fmt.Printf("%-32.32s \u2502 %9d \u2502 %10s", count.Mime, count.Resources, formatSize(count.Size))
+
+		if cmd.Media {
+			duration := ""
+			if count.Duration > 0 {
+				duration = media.FormatDuration(count.Duration)
+			}
+
+			fmt.Printf(" \u2502 %9s", duration)
+		}
+
+		fmt.Println()
+	}
+
+	fmt.Println()
+
+	if !cmd.NoHeader {
+		fmt.Println("Largest resources:")
+	}
+
+	for _, resource := range report.Largest {
+		fmt.Printf("%-32s \u2502 %10s \u2502 %s\n", resource.ID, formatSize(resource.Size), resource.Title)
+	}
+
+	return nil
+}
+
+// formatSize renders a byte count with a binary unit.
+func formatSize(size int) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	suffix := "KiB"
+
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+
+		value /= unit
+		suffix = next
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/momo182/goplin"
+	"github.com/spf13/viper"
+)
+
+type RunCmd struct {
+	DryRun bool `name:"dry-run" help:"Only show the selected notes and the changes that would be made."`
+	List   bool `help:"List the pipelines defined in the config file."`
+
+	Name string `arg optional name:"pipeline" help:"Name of a pipeline defined under pipelines in the config file."`
+}
+
+func (cmd *RunCmd) Run(ctx *Globals) error {
+	pipelines := make(map[string][]map[string]interface{})
+
+	err := viper.UnmarshalKey("pipelines", &pipelines)
+	if err != nil {
+		return fmt.Errorf("invalid pipelines in the config file: %w", err)
+	}
+
+	if cmd.List || len(cmd.Name) == 0 {
+		names := make([]string, 0, len(pipelines))
+		for name := range pipelines {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Printf("%-24s \u2502 %d steps\n", name, len(pipelines[name]))
+		}
+
+		return nil
+	}
+
+	raw, ok := pipelines[cmd.Name]
+	if !ok {
+		return fmt.Errorf("no pipeline named '%s' in the config file, see 'goplin run --list'", cmd.Name)
+	}
+
+	steps, err := goplin.ParsePipelineSteps(raw)
+	if err != nil {
+		return fmt.Errorf("pipeline '%s': %w", cmd.Name, err)
+	}
+
+	result, err := client.RunPipeline(steps, cmd.DryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Selected %d notes:\n", len(result.Selected))
+
+	for _, note := range result.Selected {
+		PrintRow(note, "id,title", &goplin.NoteFormats)
+	}
+
+	if cmd.DryRun {
+		fmt.Printf("Would run %d operations:\n", len(result.Ops))
+
+		for _, op := range result.Ops {
+			fmt.Println(op.String())
+		}
+
+		return nil
+	}
+
+	printBatchReport(result.Report)
+
+	fmt.Printf("Ran %d of %d operations.\n", result.Report.Succeeded, result.Report.Planned)
+
+	return nil
+}
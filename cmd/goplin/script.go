@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/momo182/goplin/script"
+)
+
+type ScriptRunCmd struct {
+	ReadOnly bool `name:"read-only" help:"Reject calls that change the vault."`
+	Exec     bool `help:"Run a program other than a Starlark script as a child process, calling the vault through GOPLIN_SCRIPT_URL. It runs with your rights, unlike Starlark scripts."`
+
+	Path string   `arg name:"script" help:"Starlark script (.star) to run. With --exec, an executable or a file run by the interpreter for its extension (.sh, .py, .js, .rb, .lua)."`
+	Args []string `arg optional name:"args" help:"Arguments passed to the script."`
+}
+
+func (cmd *ScriptRunCmd) Run(ctx *Globals) error {
+	api := script.NewAPI(client, cmd.ReadOnly)
+
+	if strings.ToLower(filepath.Ext(cmd.Path)) == script.StarlarkExt {
+		return api.RunStarlark(cmd.Path, cmd.Args, os.Stdout)
+	}
+
+	if !cmd.Exec {
+		return fmt.Errorf("'%s' is not a Starlark script (%s), pass --exec to run it as a program with your rights", cmd.Path, script.StarlarkExt)
+	}
+
+	command, err := script.Command(cmd.Path, cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	return api.Run(command)
+}
+
+type ScriptMethodsCmd struct{}
+
+func (cmd *ScriptMethodsCmd) Run(ctx *Globals) error {
+	for _, name := range script.Methods() {
+		fmt.Println(name)
+	}
+
+	return nil
+}
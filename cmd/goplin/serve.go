@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+type ServeAPICmd struct {
+	Listen   string        `default:"localhost:41200" help:"Address to listen on."`
+	CacheTTL time.Duration `name:"cache-ttl" default:"30s" help:"How long computed responses are cached."`
+}
+
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+func (sc *statsCache) get() ([]byte, string, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.body != nil && time.Now().Before(sc.expires) {
+		return sc.body, sc.etag, nil
+	}
+
+	stats, err := client.GetStats()
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The generation time changes on every refresh, keep it out of the ETag so
+	// unchanged vaults keep answering 304.
+	stats.GeneratedTime = 0
+	stable, _ := json.Marshal(stats)
+
+	sc.body = body
+	sc.etag = fmt.Sprintf("\"%x\"", sha1.Sum(stable))
+	sc.expires = time.Now().Add(sc.ttl)
+
+	return sc.body, sc.etag, nil
+}
+
+func (sc *statsCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, etag, err := sc.get()
+	if err != nil {
+		requestLogger(r).Error("could not compute stats", goplin.F("error", err))
+		http.Error(w, "could not compute stats", http.StatusBadGateway)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(sc.ttl.Seconds())))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = w.Write(body)
+}
+
+type requestLoggerKey struct{}
+
+// statusRecorder keeps the status written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// requestLogger returns the logger of a request served by logRequests.
+func requestLogger(r *http.Request) goplin.Logger {
+	l, ok := r.Context().Value(requestLoggerKey{}).(goplin.Logger)
+	if !ok {
+		return logger
+	}
+
+	return l
+}
+
+// logRequests logs every request served by next with its status and
+// duration. Requests get an ID, from their X-Request-ID header if set, which
+// is sent back and logged with every entry of the request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get("X-Request-ID")
+		if len(id) == 0 {
+			b := make([]byte, 8)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		w.Header().Set("X-Request-ID", id)
+
+		l := logger.With(goplin.F("request_id", id))
+		r = r.WithContext(context.WithValue(r.Context(), requestLoggerKey{}, l))
+
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sr, r)
+
+		l.Info("request served",
+			goplin.F("method", r.Method),
+			goplin.F("path", r.URL.Path),
+			goplin.F("status", sr.status),
+			goplin.F("duration_ms", time.Since(start)))
+	})
+}
+
+func (cmd *ServeAPICmd) Run(ctx *Globals) error {
+	mux := http.NewServeMux()
+	mux.Handle("/stats.json", &statsCache{ttl: cmd.CacheTTL})
+	handleHealth(mux)
+
+	sigCtx, stop := signalContext()
+	defer stop()
+
+	logger.Info("serving", goplin.F("url", "http://"+cmd.Listen))
+
+	return serveUntilDone(sigCtx, cmd.Listen, logRequests(mux))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin/service"
+)
+
+type ServiceInstallCmd struct {
+	Mode    string        `required enum:"watch,cron,serve" help:"What the service runs: watch (notify daemon), cron (a command run periodically) or serve (HTTP API)."`
+	Name    string        `help:"Name of the service, goplin-<mode> by default."`
+	Every   time.Duration `default:"1h" help:"Interval between runs in cron mode."`
+	NoStart bool          `name:"no-start" help:"Only write the service files, do not enable or start the service."`
+	DryRun  bool          `name:"dry-run" help:"Print the service files instead of writing them."`
+
+	Args []string `arg optional passthrough help:"goplin command and flags to run, e.g. 'run nightly' in cron mode; put -- before flags. Watch and serve modes run 'notify daemon' and 'serve api' by default."`
+}
+
+type ServiceStatusCmd struct {
+	Name string `arg optional help:"Name of the service; all services installed by goplin are listed when omitted."`
+}
+
+type ServiceUninstallCmd struct {
+	Name string `arg help:"Name of the service."`
+}
+
+func (cmd *ServiceInstallCmd) Run(ctx *Globals) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return err
+	}
+
+	args := cmd.Args
+	if len(args) == 0 {
+		args = service.DefaultArgs(cmd.Mode)
+	}
+
+	// The service uses the same token as this command.
+	if len(ctx.TokenName) != 0 && len(args) != 0 {
+		args = append([]string{"--token-name", ctx.TokenName}, args...)
+	}
+
+	spec := service.Spec{
+		Name:       cmd.Name,
+		Mode:       cmd.Mode,
+		Executable: executable,
+		Args:       args,
+		Interval:   cmd.Every,
+	}
+
+	if cmd.DryRun {
+		files, err := service.Files(spec)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			fmt.Printf("# %s\n%s\n", file.Path, file.Content)
+		}
+
+		return nil
+	}
+
+	files, err := service.Install(spec, cmd.NoStart)
+
+	for _, file := range files {
+		fmt.Printf("Wrote %s\n", file.Path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cmd.NoStart {
+		fmt.Println("Service installed, not started.")
+	} else {
+		fmt.Println("Service installed and started.")
+	}
+
+	return nil
+}
+
+func (cmd *ServiceStatusCmd) Run(ctx *Globals) error {
+	if len(cmd.Name) != 0 {
+		status, err := service.Status(cmd.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(status)
+
+		return nil
+	}
+
+	names, err := service.Installed()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No goplin services installed.")
+		return nil
+	}
+
+	fmt.Println(strings.Join(names, "\n"))
+
+	return nil
+}
+
+func (cmd *ServiceUninstallCmd) Run(ctx *Globals) error {
+	files, err := service.Uninstall(cmd.Name)
+
+	for _, file := range files {
+		fmt.Printf("Removed %s\n", file)
+	}
+
+	return err
+}
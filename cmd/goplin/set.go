@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+)
+
+type SetNoteCmd struct {
+	ID          string   `arg name:"id" help:"ID of the note to update, \"-\" reads IDs from stdin."`
+	Assignments []string `arg name:"field=value" help:"Fields to set, e.g. author=Jane todo_due=2024-05-01 is_todo=true."`
+}
+
+func (cmd *SetNoteCmd) Run(ctx *Globals) error {
+	fields, err := goplin.ParseFieldAssignments(cmd.Assignments)
+	if err != nil {
+		return err
+	}
+
+	return EachID([]string{cmd.ID}, func(id string) error {
+		err := client.SetNoteFields(id, fields)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Note with ID '%s' updated.\n", id)
+
+		return nil
+	})
+}
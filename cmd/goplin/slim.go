@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/slim"
+)
+
+type SlimNoteCmd struct {
+	Scope        string `help:"Slim the notes of this folder (ID, title or path) and its sub-folders instead of the given IDs."`
+	NoMarkdown   bool   `name:"no-markdown" help:"Keep HTML notes in HTML."`
+	NoLinks      bool   `name:"no-links" help:"Keep the tracking parameters of links."`
+	Images       bool   `help:"Also scale down and compress again the large JPEG and PNG images the notes link to, when that makes them smaller."`
+	MinImageSize string `name:"min-image-size" default:"512KB" help:"With --images, only recompress images of this size or more."`
+	MaxWidth     int    `name:"max-width" default:"1600" help:"With --images, width to scale images down to, in pixels."`
+	Quality      int    `default:"80" help:"With --images, quality of JPEG images, 1 to 100."`
+	DryRun       bool   `name:"dry-run" help:"Only report the sizes the notes would have."`
+
+	IDs []string `arg optional name:"id" help:"IDs of the notes to slim, \"-\" reads IDs from stdin."`
+}
+
+func (cmd *SlimNoteCmd) Run(ctx *Globals) error {
+	ids, err := ExpandIDs(cmd.IDs)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 && len(cmd.Scope) == 0 {
+		return fmt.Errorf("give the IDs of the notes to slim or a --scope")
+	}
+
+	if cmd.Quality < 1 || cmd.Quality > 100 {
+		return fmt.Errorf("invalid quality %d, expected 1 to 100", cmd.Quality)
+	}
+
+	minImageSize, err := goplin.ParseSize(cmd.MinImageSize)
+	if err != nil {
+		return err
+	}
+
+	opts := slim.Options{
+		Markdown:      !cmd.NoMarkdown,
+		Links:         !cmd.NoLinks,
+		Images:        cmd.Images,
+		MinImageSize:  minImageSize,
+		MaxImageWidth: cmd.MaxWidth,
+		Quality:       cmd.Quality,
+		DryRun:        cmd.DryRun,
+	}
+
+	var notes []goplin.Note
+
+	if len(ids) != 0 {
+		for _, id := range ids {
+			note, err := client.GetNote(id, slim.NoteFields)
+			if err != nil {
+				return err
+			}
+
+			notes = append(notes, note)
+		}
+	} else {
+		notes, err = client.GetNotesInScope(cmd.Scope, slim.NoteFields)
+		if err != nil {
+			return err
+		}
+	}
+
+	var total slim.Report
+
+	changed := 0
+
+	for _, note := range notes {
+		report, err := slim.Note(client, note, opts)
+		if err != nil {
+			return err
+		}
+
+		if !report.Changed() {
+			continue
+		}
+
+		changed++
+
+		total.BodyBefore += report.BodyBefore
+		total.BodyAfter += report.BodyAfter
+		total.ImagesBefore += report.ImagesBefore
+		total.ImagesAfter += report.ImagesAfter
+
+		fmt.Printf("%-32s \u2502 %10s -> %-10s \u2502 %10s -> %-10s \u2502 %s\n", report.NoteID,
+			formatSize(report.BodyBefore), formatSize(report.BodyAfter),
+			formatSize(report.ImagesBefore), formatSize(report.ImagesAfter), report.Title)
+	}
+
+	verb := "Slimmed"
+	if cmd.DryRun {
+		verb = "Would slim"
+	}
+
+	fmt.Printf("%s %d of %d notes: bodies %s -> %s, images %s -> %s.\n", verb, changed, len(notes),
+		formatSize(total.BodyBefore), formatSize(total.BodyAfter),
+		formatSize(total.ImagesBefore), formatSize(total.ImagesAfter))
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+type SRSReviewCmd struct {
+	Tag   string `help:"Review only the cards of notes with this tag (ID or title)."`
+	New   int    `default:"20" help:"Maximum number of new cards to introduce, -1 for all."`
+	Limit int    `default:"0" help:"Stop after this many reviews, 0 for no limit."`
+}
+
+type SRSListCmd struct {
+	Tag string `help:"List only the cards of notes with this tag (ID or title)."`
+	Due bool   `help:"List only the cards due now."`
+}
+
+// resolveTag returns the ID of the tag with the given ID or title.
+func resolveTag(idOrTitle string) (string, error) {
+	if len(idOrTitle) == 0 {
+		return "", nil
+	}
+
+	tags, err := client.GetAllTags("", "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, tag := range tags {
+		if tag.ID == idOrTitle || goplin.FoldTagTitle(tag.Title) == goplin.FoldTagTitle(idOrTitle) {
+			return tag.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find tag '%s'", idOrTitle)
+}
+
+func (cmd *SRSReviewCmd) Run(ctx *Globals) error {
+	tagID, err := resolveTag(cmd.Tag)
+	if err != nil {
+		return err
+	}
+
+	cards, err := client.DueCards(tagID, time.Now(), cmd.New)
+	if err != nil {
+		return err
+	}
+
+	if len(cards) == 0 {
+		fmt.Println("No cards due.")
+		return nil
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	reviewed := 0
+
+	// Cards answered with "again" come back at the end of the session.
+	for len(cards) != 0 && (cmd.Limit == 0 || reviewed < cmd.Limit) {
+		card := cards[0]
+		cards = cards[1:]
+
+		fmt.Printf("\n[%d left] %s\n\n%s\n\n", len(cards)+1, card.NoteTitle, card.Question)
+		fmt.Print("Press Enter to show the answer, q to quit. ")
+
+		line, err := input.ReadString('\n')
+		if err != nil || strings.TrimSpace(line) == "q" {
+			break
+		}
+
+		fmt.Printf("\n%s\n\n", card.Answer)
+
+		grade, ok := readGrade(input)
+		if !ok {
+			break
+		}
+
+		state, err := client.ReviewCard(card, grade, time.Now())
+		if err != nil {
+			return err
+		}
+
+		reviewed++
+
+		if grade == goplin.GradeAgain {
+			card.State = state
+			cards = append(cards, card)
+
+			continue
+		}
+
+		fmt.Printf("Next review on %s.\n", time.UnixMilli(int64(state.Due)).Format("2006-01-02"))
+	}
+
+	fmt.Printf("\nReviewed %d cards.\n", reviewed)
+
+	return nil
+}
+
+// readGrade asks for the answer grade until a valid one or q is entered.
+func readGrade(input *bufio.Reader) (goplin.CardGrade, bool) {
+	for {
+		fmt.Print("1 again \u2502 2 hard \u2502 3 good \u2502 4 easy \u2502 q quit: ")
+
+		line, err := input.ReadString('\n')
+		if err != nil {
+			return 0, false
+		}
+
+		switch strings.TrimSpace(line) {
+		case "1":
+			return goplin.GradeAgain, true
+		case "2":
+			return goplin.GradeHard, true
+		case "3", "":
+			return goplin.GradeGood, true
+		case "4":
+			return goplin.GradeEasy, true
+		case "q":
+			return 0, false
+		}
+	}
+}
+
+func (cmd *SRSListCmd) Run(ctx *Globals) error {
+	tagID, err := resolveTag(cmd.Tag)
+	if err != nil {
+		return err
+	}
+
+	cards, err := client.GetCards(tagID)
+	if err != nil {
+		return err
+	}
+
+	now := int(time.Now().UnixMilli())
+
+	for _, card := range cards {
+		due := "new"
+		if !card.New() {
+			if cmd.Due && card.State.Due > now {
+				continue
+			}
+
+			due = time.UnixMilli(int64(card.State.Due)).Format("2006-01-02")
+		}
+
+		question := []rune(strings.Join(strings.Fields(card.Question), " "))
+		if len(question) > 60 {
+			question = append(question[:57], []rune("...")...)
+		}
+
+		fmt.Printf("%-16s \u2502 %-10s \u2502 %-24s \u2502 %s\n", card.ID, due, card.NoteTitle, string(question))
+	}
+
+	return nil
+}
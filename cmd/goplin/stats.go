@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type StatsTagsCmd struct {
+	NoHeader bool   `help:"Do not print header."`
+	Output   string `enum:"table,json" default:"table" help:"Output format: table or json."`
+	Limit    int    `default:"20" help:"Maximum number of co-occurring tag pairs to show (0 for all)."`
+}
+
+func (cmd *StatsTagsCmd) Run(ctx *Globals) error {
+	report, err := client.TagStats()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Limit > 0 && len(report.CoOccurrence) > cmd.Limit {
+		report.CoOccurrence = report.CoOccurrence[:cmd.Limit]
+	}
+
+	if cmd.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Notes per tag:")
+		fmt.Printf("%-8s \u2502 %-32s \u2502 %s\n", "Notes", "ID", "Title")
+	}
+
+	for _, count := range report.Counts {
+		fmt.Printf("%8d \u2502 %-32s \u2502 %s\n", count.Notes, count.ID, count.Title)
+	}
+
+	fmt.Println()
+
+	if !cmd.NoHeader {
+		fmt.Println("Unused tags:")
+	}
+
+	if len(report.Unused) == 0 {
+		fmt.Println("No unused tags found.")
+	}
+
+	for _, tag := range report.Unused {
+		fmt.Printf("%-32s \u2502 %s\n", tag.ID, tag.Title)
+	}
+
+	fmt.Println()
+
+	if !cmd.NoHeader {
+		fmt.Println("Tags used together:")
+		fmt.Printf("%-8s \u2502 %s\n", "Notes", "Tags")
+	}
+
+	if len(report.CoOccurrence) == 0 {
+		fmt.Println("No co-occurring tags found.")
+	}
+
+	for _, pair := range report.CoOccurrence {
+		fmt.Printf("%8d \u2502 %s + %s\n", pair.Notes, pair.A.Title, pair.B.Title)
+	}
+
+	return nil
+}
+
+type StatsHeatmapCmd struct {
+	Year   int    `help:"Year to show (default: the current year)."`
+	Output string `enum:"svg,json" default:"svg" help:"Output format: svg (GitHub-style calendar) or json."`
+	Out    string `help:"Write to this file instead of standard output."`
+}
+
+func (cmd *StatsHeatmapCmd) Run(ctx *Globals) error {
+	if cmd.Year == 0 {
+		cmd.Year = time.Now().Year()
+	}
+
+	heatmap, err := client.GetHeatmap(cmd.Year)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+
+	if len(cmd.Out) != 0 {
+		out, err = os.Create(cmd.Out)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	if cmd.Output == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(heatmap)
+	}
+
+	err = heatmap.WriteSVG(out)
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.Out) != 0 {
+		fmt.Printf("%d notes created or updated on %d days, longest streak %d days, current streak %d days.\n",
+			heatmap.Total, heatmap.ActiveDays, heatmap.LongestStreak, heatmap.CurrentStreak)
+	}
+
+	return nil
+}
+
+type StatsLanguagesCmd struct {
+	NoHeader bool   `help:"Do not print header."`
+	Output   string `enum:"table,json" default:"table" help:"Output format: table or json."`
+}
+
+func (cmd *StatsLanguagesCmd) Run(ctx *Globals) error {
+	stats, err := client.LanguageStats()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(stats)
+	}
+
+	if !cmd.NoHeader {
+		fmt.Println("Notes per language:")
+		fmt.Printf("%-8s \u2502 %-8s \u2502 %s\n", "Notes", "Code", "Language")
+	}
+
+	for _, count := range stats {
+		fmt.Printf("%8d \u2502 %-8s \u2502 %s\n", count.Notes, count.Language, count.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// EachID calls fn for every ID in args, replacing the argument "-" with the
+// IDs read from stdin as they arrive, one per line. Only the first word of a
+// line is used, so list output can be piped as is; empty lines and lines
+// starting with '#' are skipped and @aliases are resolved. It stops at the
+// first error returned by fn.
+func EachID(args []string, fn func(id string) error) error {
+	aliases := loadAliases()
+
+	for _, arg := range args {
+		if arg != "-" {
+			err := fn(arg)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		scanner := bufio.NewScanner(os.Stdin)
+
+		for scanner.Scan() {
+			words := strings.Fields(scanner.Text())
+			if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+				continue
+			}
+
+			err := fn(resolveAlias(words[0], aliases))
+			if err != nil {
+				return err
+			}
+		}
+
+		err := scanner.Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpandIDs returns args with "-" replaced by the IDs read from stdin.
+func ExpandIDs(args []string) ([]string, error) {
+	var ids []string
+
+	err := EachID(args, func(id string) error {
+		ids = append(ids, id)
+		return nil
+	})
+
+	return ids, err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/momo182/goplin/dirsync"
+)
+
+type SyncDirCmd struct {
+	Path string `arg type:"existingdir" name:"path" help:"Directory of Markdown files."`
+}
+
+type SyncStatusCmd struct {
+	Cursor string        `help:"Count local changes after this events cursor, as printed by an earlier run, instead of after the last remote change."`
+	JSON   bool          `name:"json" help:"Print the status as JSON."`
+	Check  bool          `help:"Fail unless there are no pending local changes and no conflicts and a remote change was seen within --max-age."`
+	MaxAge time.Duration `name:"max-age" default:"24h" help:"How old the last remote change may be with --check."`
+}
+
+func (cmd *SyncDirCmd) Run(ctx *Globals) error {
+	result, err := dirsync.Sync(client, cmd.Path)
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
+
+	for _, conflict := range result.Conflicts {
+		fmt.Printf("CONFLICT: %s\n", conflict)
+	}
+
+	fmt.Printf("Pulled %d, pushed %d, created %d and removed %d notes, %d conflicts.\n",
+		result.Pulled, result.Pushed, result.Created, result.Removed, len(result.Conflicts))
+
+	return err
+}
+
+func (cmd *SyncStatusCmd) Run(ctx *Globals) error {
+	status, err := client.GetSyncStatus(cmd.Cursor)
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(status)
+		if err != nil {
+			return err
+		}
+	} else {
+		if status.LastRemoteChange.IsZero() {
+			fmt.Println("Last remote change:  none recorded")
+		} else {
+			fmt.Printf("Last remote change:  %s (%s ago)\n", dates.FormatTime(status.LastRemoteChange, time.Now()),
+				time.Since(status.LastRemoteChange).Round(time.Minute))
+		}
+
+		fmt.Printf("Pending local notes: %d\n", len(status.Pending))
+		fmt.Printf("Conflicts:           %d\n", status.Conflicts)
+		fmt.Printf("Cursor:              %s\n", status.Cursor)
+	}
+
+	if !cmd.Check {
+		return nil
+	}
+
+	switch {
+	case len(status.Pending) != 0:
+		return fmt.Errorf("%d notes changed locally since the last sync", len(status.Pending))
+	case status.Conflicts != 0:
+		return fmt.Errorf("%d conflicts to resolve", status.Conflicts)
+	case status.LastRemoteChange.IsZero() || time.Since(status.LastRemoteChange) > cmd.MaxAge:
+		return fmt.Errorf("no remote change seen within %s", cmd.MaxAge)
+	}
+
+	return nil
+}
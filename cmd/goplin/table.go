@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/momo182/goplin/table"
+)
+
+type TableListCmd struct {
+	NoHeader bool `short:"n" help:"Do not print the header."`
+
+	ID string `arg name:"id" help:"ID of the note."`
+}
+
+type TableGetCmd struct {
+	Index  int    `short:"i" default:"0" help:"Index of the table in the note, from 0."`
+	Output string `enum:"csv,tsv,json,markdown" default:"csv" help:"Output format: csv, tsv, json or markdown."`
+
+	ID string `arg name:"id" help:"ID of the note."`
+}
+
+type TableUpdateCmd struct {
+	Index int    `short:"i" default:"0" help:"Index of the table in the note, from 0."`
+	Input string `default:"-" help:"CSV file whose first row is the header, \"-\" for stdin."`
+	TSV   bool   `name:"tsv" help:"Read tab separated values instead of CSV."`
+
+	ID string `arg name:"id" help:"ID of the note."`
+}
+
+type TableSummarizeCmd struct {
+	Index      int    `short:"i" default:"0" help:"Index of the table in the note, from 0."`
+	Column     string `required help:"Column of the numbers to summarize."`
+	GroupBy    string `name:"group-by" help:"Group rows by day, week, month or year of the date column, or by the values of a column."`
+	DateColumn string `name:"date-column" help:"Column of the dates for time groups (default: date)."`
+	Output     string `enum:"table,json" default:"table" help:"Output format: table or json."`
+	NoHeader   bool   `short:"n" help:"Do not print the header."`
+
+	ID string `arg name:"id" help:"ID of the note."`
+}
+
+func (cmd *TableListCmd) Run(ctx *Globals) error {
+	tables, err := table.ParseTables(client, cmd.ID)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.NoHeader {
+		fmt.Printf("%-5s \u2502 %-5s \u2502 %-24s \u2502 %s\n", "index", "rows", "heading", "columns")
+	}
+
+	for i, t := range tables {
+		fmt.Printf("%-5d \u2502 %-5d \u2502 %-24s \u2502 %s\n", i, len(t.Rows), t.Heading, strings.Join(t.Header, ", "))
+	}
+
+	return nil
+}
+
+func (cmd *TableGetCmd) Run(ctx *Globals) error {
+	t, err := table.Get(client, cmd.ID, cmd.Index)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Output {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(t)
+	case "markdown":
+		_, err = fmt.Print(t.Markdown())
+		return err
+	case "tsv":
+		return t.WriteCSV(os.Stdout, '\t')
+	}
+
+	return t.WriteCSV(os.Stdout, ',')
+}
+
+func (cmd *TableUpdateCmd) Run(ctx *Globals) error {
+	var in io.Reader = os.Stdin
+
+	if cmd.Input != "-" {
+		f, err := os.Open(cmd.Input)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		in = f
+	}
+
+	comma := ','
+	if cmd.TSV {
+		comma = '\t'
+	}
+
+	header, rows, err := table.ReadCSV(in, comma)
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", cmd.Input, err)
+	}
+
+	err = table.Update(client, cmd.ID, cmd.Index, header, rows)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated table %d of note '%s' with %d rows.\n", cmd.Index, cmd.ID, len(rows))
+
+	return nil
+}
+
+func (cmd *TableSummarizeCmd) Run(ctx *Globals) error {
+	t, err := table.Get(client, cmd.ID, cmd.Index)
+	if err != nil {
+		return err
+	}
+
+	summaries, err := table.Summarize(t, table.SummarizeOptions{
+		Column:     cmd.Column,
+		GroupBy:    cmd.GroupBy,
+		DateColumn: cmd.DateColumn,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(summaries)
+	}
+
+	if !cmd.NoHeader {
+		fmt.Printf("%-16s \u2502 %6s \u2502 %12s \u2502 %12s \u2502 %12s \u2502 %12s\n", "group", "count", "sum", "average", "min", "max")
+	}
+
+	for _, s := range summaries {
+		fmt.Printf("%-16s \u2502 %6d \u2502 %12.2f \u2502 %12.2f \u2502 %12.2f \u2502 %12.2f\n", s.Group, s.Count, s.Sum, s.Average, s.Min, s.Max)
+
+		if s.Skipped != 0 {
+			fmt.Fprintf(os.Stderr, "%s: skipped %d rows without a number\n", s.Group, s.Skipped)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+type TimelineCmd struct {
+	Since  string `default:"30d" help:"How far back to go, as a duration (e.g. 30d, 2w) or a date."`
+	Output string `enum:"text,json" default:"text" help:"Output format: text, or json for plotting."`
+}
+
+var timelineMarks = map[string]string{
+	goplin.TimelineCreated:   "+",
+	goplin.TimelineUpdated:   "~",
+	goplin.TimelineCompleted: "x",
+	goplin.TimelineDeleted:   "-",
+}
+
+func (cmd *TimelineCmd) Run(ctx *Globals) error {
+	since, err := parseSince(cmd.Since)
+	if err != nil {
+		return err
+	}
+
+	days, err := client.GetTimeline(since)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(days)
+	}
+
+	if len(days) == 0 {
+		fmt.Println("No activity.")
+		return nil
+	}
+
+	for _, day := range days {
+		date, _ := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+
+		fmt.Printf("%s %-9s %d created, %d updated, %d completed, %d deleted\n",
+			day.Date, date.Format("Monday"),
+			day.Counts[goplin.TimelineCreated], day.Counts[goplin.TimelineUpdated],
+			day.Counts[goplin.TimelineCompleted], day.Counts[goplin.TimelineDeleted])
+
+		for _, entry := range day.Entries {
+			fmt.Printf("  \u2502 %s %s %s\n",
+				time.UnixMilli(int64(entry.Time)).Format("15:04"), timelineMarks[entry.Kind], entry.Title)
+		}
+	}
+
+	return nil
+}
+
+// parseSince accepts a duration back from now, such as 30d, or a date.
+func parseSince(s string) (time.Time, error) {
+	d, err := goplin.ParseDuration(s)
+	if err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return goplin.ParseTime(s)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// printTimings prints where the time of a command went. Rendering is what is
+// left of the run once API calls and decoding are taken out, so it includes
+// any other work of the command.
+func printTimings(w io.Writer, discovery time.Duration, run time.Duration, summary goplin.TimingSummary) {
+	rendering := run - summary.Total - summary.Decoding
+	if rendering < 0 {
+		// Concurrent calls overlap.
+		rendering = 0
+	}
+
+	round := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+
+	fmt.Fprintln(w, "Timings:")
+	fmt.Fprintf(w, "  %-16s \u2502 %s\n", "port discovery", round(discovery))
+	fmt.Fprintf(w, "  %-16s \u2502 %s in %d calls, p50 %s, p95 %s\n", "API calls", round(summary.Total), summary.Calls,
+		round(summary.P50), round(summary.P95))
+	fmt.Fprintf(w, "  %-16s \u2502 %s\n", "decoding", round(summary.Decoding))
+	fmt.Fprintf(w, "  %-16s \u2502 %s\n", "rendering", round(rendering))
+	fmt.Fprintf(w, "  %-16s \u2502 %s\n", "total", round(discovery+run))
+}
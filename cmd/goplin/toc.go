@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/momo182/goplin"
+)
+
+type TocNoteCmd struct {
+	Title   string `default:"Index" help:"Title of the index note when it has to be created."`
+	OrderBy string `name:"order-by" enum:"title,updated_time,created_time" default:"title" help:"Order of the notes in each notebook: title, updated_time or created_time."`
+	Desc    bool   `help:"Reverse the order."`
+	Folder  string `arg help:"Notebook (ID or path) to index."`
+}
+
+func (cmd *TocNoteCmd) Run(ctx *Globals) error {
+	note, changed, err := client.UpdateTOCNote(cmd.Folder, goplin.TOCOptions{
+		Title:   cmd.Title,
+		OrderBy: cmd.OrderBy,
+		Desc:    cmd.Desc,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		fmt.Printf("Index note '%s' (%s) is up to date.\n", note.Title, note.ID)
+		return nil
+	}
+
+	fmt.Printf("Updated index note '%s' (%s).\n", note.Title, note.ID)
+
+	return nil
+}
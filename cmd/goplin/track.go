@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/momo182/goplin/table"
+)
+
+type TrackCmd struct {
+	Columns []string `required help:"Columns of the tracking table, comma separated. Empty date and time columns are filled with the current date and time."`
+	Values  string   `help:"Values of the row, comma separated in the order of --columns. Quote values containing commas as in CSV."`
+	Heading string   `help:"Add to the table under this heading, created with the table if missing."`
+
+	ID string `arg name:"id" help:"ID of the note."`
+}
+
+func (cmd *TrackCmd) Run(ctx *Globals) error {
+	var values []string
+
+	if len(strings.TrimSpace(cmd.Values)) != 0 {
+		r := csv.NewReader(strings.NewReader(cmd.Values))
+		r.TrimLeadingSpace = true
+
+		var err error
+
+		values, err = r.Read()
+		if err != nil {
+			return fmt.Errorf("invalid --values: %w", err)
+		}
+	}
+
+	result, err := table.Track(client, cmd.ID, cmd.Columns, values, table.TrackOptions{Heading: cmd.Heading})
+	if err != nil {
+		return err
+	}
+
+	action := "Added"
+	if result.Created {
+		action = "Created table with"
+	}
+
+	fmt.Printf("%s row %s in table %d of note '%s'.\n", action, strings.Join(result.Row, " \u2502 "), result.Index, cmd.ID)
+
+	return nil
+}
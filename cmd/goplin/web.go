@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+//go:embed web
+var webAssets embed.FS
+
+// webNoteFields are the fields of the notes listed by the web UI.
+const webNoteFields = "id,parent_id,title,updated_time"
+
+type ServeWebCmd struct {
+	Listen   string `default:"localhost:41201" help:"Address to listen on, e.g. 0.0.0.0:41201 to reach the UI from other devices on the LAN."`
+	Token    string `help:"Token the web UI has to present, a random one by default."`
+	ReadOnly bool   `name:"read-only" help:"Disable the quick capture form."`
+}
+
+// webAPI serves the JSON API behind the web UI.
+type webAPI struct {
+	token    string
+	readOnly bool
+}
+
+// authorized tells whether the request carries the token, as a bearer token
+// or a token parameter.
+func (api *webAPI) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(token) == 0 {
+		token = r.URL.Query().Get("token")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) == 1
+}
+
+// handle serves fn on the API, returning its value as JSON. Errors of the
+// Data API are logged and hidden behind a generic message.
+func (api *webAPI) handle(method string, fn func(r *http.Request) (interface{}, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if !api.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		value, status, err := fn(r)
+		if err != nil {
+			if status == 0 {
+				status = http.StatusBadGateway
+				if errors.Is(err, goplin.ErrNotFound) {
+					status = http.StatusNotFound
+				}
+
+				requestLogger(r).Error("web API request failed", goplin.F("error", err))
+				err = errors.New(strings.ToLower(http.StatusText(status)))
+			}
+
+			http.Error(w, err.Error(), status)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		_ = json.NewEncoder(w).Encode(value)
+	}
+}
+
+func (api *webAPI) folders(r *http.Request) (interface{}, int, error) {
+	tree, err := client.GetFolderTree()
+	if tree == nil {
+		tree = []*goplin.FolderNode{}
+	}
+
+	return tree, http.StatusOK, err
+}
+
+func (api *webAPI) notes(r *http.Request) (interface{}, int, error) {
+	folder := r.URL.Query().Get("folder")
+	if len(folder) == 0 {
+		return nil, http.StatusBadRequest, errors.New("missing folder")
+	}
+
+	notes, err := client.GetNotesInFolder(folder, webNoteFields, "updated_time", "DESC")
+	if notes == nil {
+		notes = []goplin.Note{}
+	}
+
+	return notes, http.StatusOK, err
+}
+
+func (api *webAPI) note(r *http.Request) (interface{}, int, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/notes/")
+	if len(id) == 0 || strings.Contains(id, "/") {
+		return nil, http.StatusNotFound, errors.New("not found")
+	}
+
+	note, err := client.GetNote(id, "id,parent_id,title,body,updated_time,is_todo,todo_completed")
+
+	return note, http.StatusOK, err
+}
+
+func (api *webAPI) search(r *http.Request) (interface{}, int, error) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(query) == 0 {
+		return []goplin.Note{}, http.StatusOK, nil
+	}
+
+	notes, err := client.SearchNotes(query, webNoteFields)
+	if notes == nil {
+		notes = []goplin.Note{}
+	}
+
+	return notes, http.StatusOK, err
+}
+
+// capture creates a note from the quick capture form.
+func (api *webAPI) capture(r *http.Request) (interface{}, int, error) {
+	if api.readOnly {
+		return nil, http.StatusForbidden, errors.New("quick capture is disabled")
+	}
+
+	var form struct {
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		Folder string `json:"folder"`
+	}
+
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&form)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid note")
+	}
+
+	title := strings.TrimSpace(form.Title)
+	body := form.Body
+
+	if len(title) == 0 {
+		title, body = splitTitle(body)
+	}
+
+	if len(title) == 0 {
+		return nil, http.StatusBadRequest, errors.New("the note needs a title")
+	}
+
+	created, err := client.CreateNote(goplin.Note{Title: title, Body: body, ParentID: form.Folder})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	requestLogger(r).Info("note captured", goplin.F("note_id", created.ID))
+
+	return map[string]string{"id": created.ID}, http.StatusCreated, nil
+}
+
+// config tells the UI whether capture is enabled.
+func (api *webAPI) config(r *http.Request) (interface{}, int, error) {
+	return map[string]bool{"read_only": api.readOnly}, http.StatusOK, nil
+}
+
+func (cmd *ServeWebCmd) Run(ctx *Globals) error {
+	api := &webAPI{token: cmd.Token, readOnly: cmd.ReadOnly}
+
+	if len(api.token) == 0 {
+		b := make([]byte, 16)
+
+		_, err := rand.Read(b)
+		if err != nil {
+			return err
+		}
+
+		api.token = hex.EncodeToString(b)
+	}
+
+	assets, err := fs.Sub(webAssets, "web")
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/api/config", api.handle(http.MethodGet, api.config))
+	mux.HandleFunc("/api/folders", api.handle(http.MethodGet, api.folders))
+	mux.HandleFunc("/api/search", api.handle(http.MethodGet, api.search))
+	mux.HandleFunc("/api/notes/", api.handle(http.MethodGet, api.note))
+	mux.HandleFunc("/api/notes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			api.handle(http.MethodPost, api.capture)(w, r)
+		} else {
+			api.handle(http.MethodGet, api.notes)(w, r)
+		}
+	})
+	handleHealth(mux)
+
+	sigCtx, stop := signalContext()
+	defer stop()
+
+	logger.Info("serving", goplin.F("url", "http://"+cmd.Listen))
+
+	// The token is only shown on the terminal, never logged.
+	fmt.Fprintf(os.Stderr, "Open http://%s/#token=%s\n", cmd.Listen, api.token)
+
+	return serveUntilDone(sigCtx, cmd.Listen, logRequests(mux))
+}
@@ -0,0 +1,127 @@
+// The web UI of goplin serve web. The token is taken from the URL fragment
+// the server prints, then kept in the browser.
+(function () {
+  "use strict";
+
+  var match = location.hash.match(/token=([^&]+)/);
+  if (match) {
+    localStorage.setItem("goplin-token", decodeURIComponent(match[1]));
+    history.replaceState(null, "", location.pathname);
+  }
+
+  var token = localStorage.getItem("goplin-token") || "";
+
+  function el(tag, text) {
+    var e = document.createElement(tag);
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  function api(path, options) {
+    options = options || {};
+    options.headers = Object.assign({ Authorization: "Bearer " + token }, options.headers);
+    return fetch(path, options).then(function (resp) {
+      if (!resp.ok) {
+        return resp.text().then(function (text) {
+          throw new Error(text.trim() || resp.statusText);
+        });
+      }
+      return resp.json();
+    });
+  }
+
+  function fail(err) {
+    var note = document.getElementById("note");
+    note.replaceChildren(el("p", "Error: " + err.message));
+  }
+
+  function date(ms) {
+    return ms ? new Date(ms).toLocaleString() : "";
+  }
+
+  function showNotes(notes) {
+    var list = document.getElementById("notes");
+    list.replaceChildren();
+    if (notes.length === 0) {
+      list.appendChild(el("p", "No notes"));
+    }
+    notes.forEach(function (n) {
+      var a = el("a", n.title || "(untitled)");
+      a.onclick = function () { openNote(n.id); };
+      a.appendChild(el("br"));
+      a.appendChild(el("small", date(n.updated_time)));
+      list.appendChild(a);
+    });
+  }
+
+  function openFolder(id, link) {
+    document.querySelectorAll("nav a.active").forEach(function (a) { a.classList.remove("active"); });
+    link.classList.add("active");
+    document.querySelector("#capture select").value = id;
+    api("/api/notes?folder=" + encodeURIComponent(id)).then(showNotes).catch(fail);
+  }
+
+  function openNote(id) {
+    api("/api/notes/" + encodeURIComponent(id)).then(function (n) {
+      var note = document.getElementById("note");
+      // The body is shown as text, never as HTML.
+      note.replaceChildren(el("h2", n.title), el("small", date(n.updated_time)), el("pre", n.body));
+    }).catch(fail);
+  }
+
+  function folderList(nodes, select, depth) {
+    var ul = el("ul");
+    nodes.forEach(function (node) {
+      var f = node.folder;
+      var li = el("li");
+      var a = el("a", f.title);
+      a.onclick = function () { openFolder(f.id, a); };
+      li.appendChild(a);
+      var opt = el("option", "  ".repeat(depth) + f.title);
+      opt.value = f.id;
+      select.appendChild(opt);
+      if (node.children && node.children.length) {
+        li.appendChild(folderList(node.children, select, depth + 1));
+      }
+      ul.appendChild(li);
+    });
+    return ul;
+  }
+
+  document.getElementById("search").onsubmit = function (e) {
+    e.preventDefault();
+    var q = e.target.q.value.trim();
+    api("/api/search?q=" + encodeURIComponent(q)).then(showNotes).catch(fail);
+  };
+
+  var capture = document.getElementById("capture");
+  capture.onsubmit = function (e) {
+    e.preventDefault();
+    var status = document.getElementById("status");
+    api("/api/notes", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ title: capture.elements.title.value, body: capture.elements.body.value, folder: capture.elements.folder.value })
+    }).then(function (created) {
+      capture.reset();
+      status.textContent = "Saved";
+      openNote(created.id);
+    }).catch(function (err) {
+      status.textContent = err.message;
+    });
+  };
+
+  var select = capture.querySelector("select");
+  var defaultFolder = el("option", "Default folder");
+  defaultFolder.value = "";
+  select.appendChild(defaultFolder);
+
+  api("/api/config").then(function (config) {
+    capture.hidden = config.read_only;
+    return api("/api/folders");
+  }).then(function (tree) {
+    document.getElementById("folders").replaceChildren(folderList(tree, select, 0));
+  }).catch(fail);
+})();
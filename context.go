@@ -0,0 +1,60 @@
+package goplin
+
+import (
+	"context"
+
+	"github.com/imroc/req/v3"
+)
+
+// WithContext returns a client sending its requests with ctx, so that they
+// can be cancelled or given a deadline, paginated fetches included: the
+// first request failing on the context ends them. The returned client shares
+// everything else with c, such as its caches, settings and write queue.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{clientState: c.clientState, ctx: ctx}
+}
+
+// Context returns the context of the requests of the client,
+// context.Background unless set by WithContext.
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// r starts a request with the context of the client.
+func (c *Client) r() *req.Request {
+	r := c.handle.R().SetContext(c.Context())
+	if c.dumpTo != nil {
+		r = r.EnableDump()
+	}
+
+	return r
+}
+
+// GetAllNotesCtx is GetAllNotes with a context.
+func (c *Client) GetAllNotesCtx(ctx context.Context, fields string, orderBy string, orderDir string) ([]Note, error) {
+	return c.WithContext(ctx).GetAllNotes(fields, orderBy, orderDir)
+}
+
+// GetAllFoldersCtx is GetAllFolders with a context.
+func (c *Client) GetAllFoldersCtx(ctx context.Context, fields string, orderBy string, orderDir string) ([]Folder, error) {
+	return c.WithContext(ctx).GetAllFolders(fields, orderBy, orderDir)
+}
+
+// GetAllTagsCtx is GetAllTagsWithFields with a context.
+func (c *Client) GetAllTagsCtx(ctx context.Context, fields string, orderBy string, orderDir string) ([]Tag, error) {
+	return c.WithContext(ctx).GetAllTagsWithFields(fields, orderBy, orderDir)
+}
+
+// GetAllResourcesCtx is GetAllResources with a context.
+func (c *Client) GetAllResourcesCtx(ctx context.Context, fields string, orderBy string, orderDir string) ([]Resource, error) {
+	return c.WithContext(ctx).GetAllResources(fields, orderBy, orderDir)
+}
+
+// GetEventsCtx is GetEvents with a context.
+func (c *Client) GetEventsCtx(ctx context.Context, cursor string) ([]Event, string, error) {
+	return c.WithContext(ctx).GetEvents(cursor)
+}
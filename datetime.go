@@ -0,0 +1,197 @@
+package goplin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeFields are the fields of items holding timestamps.
+var TimeFields = []string{
+	"created_time", "updated_time", "user_created_time", "user_updated_time",
+	"deleted_time", "todo_due", "todo_completed",
+}
+
+// IsTimeField tells whether field is one of TimeFields.
+func IsTimeField(field string) bool {
+	for _, f := range TimeFields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MillisTime returns the time of a timestamp of Joplin, milliseconds since
+// the Unix epoch in UTC, or the zero time for 0, which Joplin stores for
+// unset times such as the due date of a note that is not a to-do.
+func MillisTime(ms int) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(int64(ms)).UTC()
+}
+
+// Names of date formats, for ParseDateFormat.
+const (
+	DateISO      = "iso"
+	DateRelative = "relative"
+	DateLocale   = "locale"
+)
+
+// dateLayouts are the layouts of dates and times by locale, as in LANG, or
+// by language.
+var dateLayouts = map[string]string{
+	"en_US": "01/02/2006 3:04 PM",
+	"en":    "02/01/2006 15:04",
+	"de":    "02.01.2006 15:04",
+	"fr":    "02/01/2006 15:04",
+	"es":    "02/01/2006 15:04",
+	"it":    "02/01/2006 15:04",
+	"pt":    "02/01/2006 15:04",
+	"nl":    "02-01-2006 15:04",
+	"ru":    "02.01.2006 15:04",
+	"pl":    "02.01.2006 15:04",
+	"sv":    "2006-01-02 15:04",
+	"ja":    "2006/01/02 15:04",
+	"zh":    "2006/01/02 15:04",
+	"ko":    "2006. 01. 02. 15:04",
+}
+
+// DateFormat renders timestamps for display.
+type DateFormat struct {
+	// Layout is a Go time layout; ISO 8601 when empty.
+	Layout string
+	// Relative renders times from now, as in "2 days ago".
+	Relative bool
+	// Location is the time zone of the rendered times, local time when nil.
+	Location *time.Location
+}
+
+// ParseDateFormat returns the format named by s: "iso" (the default),
+// "relative", "locale" for the one of the LANG or LC_TIME environment
+// variable given in lang, a locale such as de_DE, or a Go time layout such
+// as "2006-01-02 15:04".
+func ParseDateFormat(s string, lang string) (DateFormat, error) {
+	switch strings.ToLower(s) {
+	case "", DateISO:
+		return DateFormat{}, nil
+	case DateRelative:
+		return DateFormat{Relative: true}, nil
+	case DateLocale:
+		return DateFormat{Layout: LocaleDateLayout(lang)}, nil
+	}
+
+	if layout, ok := localeLayout(s); ok {
+		return DateFormat{Layout: layout}, nil
+	}
+
+	// A layout has elements, rendered differently from themselves.
+	if t := time.Date(2001, 3, 4, 7, 8, 9, 0, time.UTC); t.Format(s) == s {
+		return DateFormat{}, fmt.Errorf("unknown date format '%s', expected iso, relative, locale, a locale or a Go time layout", s)
+	}
+
+	return DateFormat{Layout: s}, nil
+}
+
+// LocaleDateLayout returns the layout of the locale lang, such as
+// "de_DE.UTF-8", ISO 8601 for unknown locales.
+func LocaleDateLayout(lang string) string {
+	if layout, ok := localeLayout(lang); ok {
+		return layout
+	}
+
+	return ""
+}
+
+func localeLayout(lang string) (string, bool) {
+	lang = strings.SplitN(lang, ".", 2)[0]
+	lang = strings.ReplaceAll(lang, "-", "_")
+
+	if layout, ok := dateLayouts[lang]; ok {
+		return layout, true
+	}
+
+	layout, ok := dateLayouts[strings.ToLower(strings.SplitN(lang, "_", 2)[0])]
+
+	return layout, ok
+}
+
+// Format renders the timestamp ms, empty for 0.
+func (f DateFormat) Format(ms int) string {
+	t := MillisTime(ms)
+	if t.IsZero() {
+		return ""
+	}
+
+	return f.FormatTime(t, time.Now())
+}
+
+// FormatTime renders t, relative to now when f is Relative.
+func (f DateFormat) FormatTime(t time.Time, now time.Time) string {
+	if f.Relative {
+		return RelativeTime(t, now)
+	}
+
+	loc := f.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	layout := f.Layout
+	if len(layout) == 0 {
+		layout = time.RFC3339
+	}
+
+	return t.In(loc).Format(layout)
+}
+
+// Width returns the width of the rendered times, for table columns.
+func (f DateFormat) Width() int {
+	if f.Relative {
+		return len("11 months ago")
+	}
+
+	// A late date of two digit numbers, in a long month.
+	ref := time.Date(2006, 12, 28, 22, 44, 55, 0, time.UTC)
+
+	return len([]rune(f.FormatTime(ref, ref)))
+}
+
+// RelativeTime renders t as the time elapsed until now, as in "2 days
+// ago", or the time left when t is after now, as in "in 3 hours".
+func RelativeTime(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+
+	format := "%s ago"
+	if d < 0 {
+		d = -d
+		format = "in %s"
+	}
+
+	var n int
+	var unit string
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf(format, fmt.Sprintf("%d %s", n, unit))
+}
@@ -0,0 +1,528 @@
+// Package dirsync keeps a directory of Markdown files and the vault in sync,
+// so notes can be edited with external tools.
+//
+// Folders map to directories and notes to .md files whose front matter holds
+// the note ID and the updated_time of the version the file is based on.
+package dirsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/export"
+)
+
+// StateFile is the file in the synced directory remembering the last sync.
+const StateFile = ".goplin-sync.json"
+
+// conflictSuffix marks the copies of notes written when both sides changed.
+const conflictSuffix = ".conflict.md"
+
+const syncNoteFields = "id,parent_id,title,body,updated_time"
+
+// fileState is what the last sync saw of a note's file.
+type fileState struct {
+	Path        string `json:"path"`
+	Hash        string `json:"hash"`
+	UpdatedTime int    `json:"updated_time"`
+}
+
+// Result summarizes what a sync changed.
+type Result struct {
+	// Pulled counts files written from notes changed in Joplin.
+	Pulled int `json:"pulled"`
+	// Pushed counts notes updated from changed files.
+	Pushed int `json:"pushed"`
+	// Created counts notes created from new files.
+	Created int `json:"created"`
+	// Removed counts files removed because their note was deleted.
+	Removed   int      `json:"removed"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+func (r *Result) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+type localFile struct {
+	path  string
+	hash  string
+	front export.FrontMatter
+	body  string
+}
+
+type syncer struct {
+	client  *goplin.Client
+	dir     string
+	result  *Result
+	state   map[string]fileState
+	folders map[string]string // directory -> folder ID
+	dirs    map[string]string // folder ID -> directory
+	taken   map[string]bool   // note paths in use
+}
+
+// Sync runs a two-way sync between the vault and dir. Changes on one side are
+// copied to the other. When a note changed on both sides, the Joplin version
+// is written next to the file as "<name>.conflict.md"; the conflict is
+// resolved once the file carries the updated_time of that copy.
+//
+// Deleting a note in Joplin removes its unchanged file. Deleting a file does
+// not delete the note, it is written again by the next sync.
+func Sync(client *goplin.Client, dir string) (Result, error) {
+	var result Result
+
+	s := &syncer{
+		client:  client,
+		dir:     dir,
+		result:  &result,
+		folders: map[string]string{},
+		dirs:    map[string]string{},
+		taken:   map[string]bool{},
+	}
+
+	err := s.loadState()
+	if err != nil {
+		return result, err
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return result, err
+	}
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		parent := "."
+		if node.Parent != nil {
+			parent = s.dirs[node.Parent.Folder.ID]
+		}
+
+		s.dirs[node.Folder.ID] = s.unique(path.Join(parent, export.FileName(node.Folder.Title)), "", s.folders)
+		s.folders[s.dirs[node.Folder.ID]] = node.Folder.ID
+	})
+
+	notes, err := client.GetLiveNotes(syncNoteFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	remote := make(map[string]goplin.Note, len(notes))
+	for _, note := range notes {
+		remote[note.ID] = note
+	}
+
+	local, created, err := s.scan()
+	if err != nil {
+		return result, err
+	}
+
+	ids := make(map[string]bool)
+	for id := range remote {
+		ids[id] = true
+	}
+
+	for id := range local {
+		ids[id] = true
+		s.taken[local[id].path] = true
+	}
+
+	for id := range s.state {
+		ids[id] = true
+	}
+
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+
+	sort.Strings(sorted)
+
+	for _, id := range sorted {
+		err = s.syncNote(id, remote, local)
+		if err != nil {
+			s.saveState()
+			return result, err
+		}
+	}
+
+	for _, file := range created {
+		err = s.create(file)
+		if err != nil {
+			s.saveState()
+			return result, err
+		}
+	}
+
+	return result, s.saveState()
+}
+
+func (s *syncer) syncNote(id string, remote map[string]goplin.Note, local map[string]localFile) error {
+	note, inRemote := remote[id]
+	file, inLocal := local[id]
+	last, inState := s.state[id]
+
+	switch {
+	case inRemote && !inLocal:
+		if inState && note.UpdatedTime == last.UpdatedTime {
+			s.result.warnf("'%s' was deleted locally, deletions are not synced to Joplin", last.Path)
+		}
+
+		return s.pull(note, "")
+	case !inRemote && inLocal:
+		if !inState {
+			// A file carrying an ID unknown to Joplin, e.g. copied from
+			// another vault: create the note with that ID.
+			return s.create(file)
+		}
+
+		if file.hash != last.Hash || file.path != last.Path {
+			s.conflict("note '%s' was deleted in Joplin but '%s' changed locally", id, file.path)
+			return nil
+		}
+
+		err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(file.path)))
+		if err != nil {
+			return err
+		}
+
+		delete(s.state, id)
+		s.result.Removed++
+
+		return nil
+	case !inRemote && !inLocal:
+		delete(s.state, id)
+		return nil
+	}
+
+	remoteChanged := note.UpdatedTime != file.front.UpdatedTime
+	localChanged := !inState || file.hash != last.Hash || file.path != last.Path
+
+	if !inState && !remoteChanged {
+		localChanged = file.body != note.Body || s.noteTitle(file) != note.Title || s.folders[path.Dir(file.path)] != note.ParentID
+	}
+
+	switch {
+	case remoteChanged && localChanged:
+		if !inState && file.body == note.Body {
+			return s.pull(note, file.path)
+		}
+
+		return s.writeConflict(note, file)
+	case remoteChanged:
+		return s.pull(note, file.path)
+	case localChanged:
+		return s.push(file)
+	}
+
+	s.state[id] = fileState{Path: file.path, Hash: file.hash, UpdatedTime: note.UpdatedTime}
+
+	return nil
+}
+
+// scan reads the Markdown files below the directory, returning the files of
+// known notes by ID and the new files without an ID.
+func (s *syncer) scan() (map[string]localFile, []localFile, error) {
+	local := make(map[string]localFile)
+
+	var created []localFile
+
+	err := filepath.WalkDir(s.dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(d.Name(), ".") && p != s.dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() || filepath.Ext(p) != ".md" || strings.HasSuffix(p, conflictSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+
+		file, err := readFile(p, filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+
+		if len(file.front.ID) == 0 {
+			created = append(created, file)
+			return nil
+		}
+
+		if other, ok := local[file.front.ID]; ok {
+			s.result.warnf("'%s' and '%s' have the same note ID, ignoring the latter", other.path, file.path)
+			return nil
+		}
+
+		local[file.front.ID] = file
+
+		return nil
+	})
+
+	return local, created, err
+}
+
+func readFile(fullPath string, rel string) (localFile, error) {
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return localFile{}, err
+	}
+
+	front, body, err := export.ParseMarkdownNote(data)
+	if err != nil {
+		return localFile{}, fmt.Errorf("%s: %w", rel, err)
+	}
+
+	return localFile{path: rel, hash: hash(data), front: front, body: body}, nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *syncer) noteTitle(file localFile) string {
+	if len(file.front.Title) != 0 {
+		return file.front.Title
+	}
+
+	return strings.TrimSuffix(path.Base(file.path), ".md")
+}
+
+// pull writes a note to its file, replacing the file at oldPath.
+func (s *syncer) pull(note goplin.Note, oldPath string) error {
+	dir, ok := s.dirs[note.ParentID]
+	if !ok {
+		s.result.warnf("note '%s' is not in a known folder, skipping", note.ID)
+		return nil
+	}
+
+	target := oldPath
+	if len(oldPath) == 0 || path.Dir(oldPath) != dir || strings.TrimSuffix(path.Base(oldPath), ".md") != export.FileName(note.Title) {
+		delete(s.taken, oldPath)
+		target = s.unique(path.Join(dir, export.FileName(note.Title)), ".md", nil)
+	}
+
+	data, err := s.write(target, export.FrontMatter{ID: note.ID, Title: note.Title, UpdatedTime: note.UpdatedTime}, note.Body)
+	if err != nil {
+		return err
+	}
+
+	if len(oldPath) != 0 && oldPath != target {
+		err = os.Remove(filepath.Join(s.dir, filepath.FromSlash(oldPath)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	s.state[note.ID] = fileState{Path: target, Hash: hash(data), UpdatedTime: note.UpdatedTime}
+	s.result.Pulled++
+
+	return nil
+}
+
+// push updates a note from its file.
+func (s *syncer) push(file localFile) error {
+	parentID, err := s.folder(path.Dir(file.path))
+	if err != nil {
+		return err
+	}
+
+	if len(parentID) == 0 {
+		s.result.warnf("'%s' is not inside a folder, skipping", file.path)
+		return nil
+	}
+
+	err = s.client.ApplyNoteUpdate(goplin.NewNoteUpdate(file.front.ID).
+		SetTitle(s.noteTitle(file)).
+		SetBody(file.body).
+		SetParent(parentID))
+	if err != nil {
+		return err
+	}
+
+	note, err := s.client.GetNote(file.front.ID, "id", "title", "updated_time")
+	if err != nil {
+		return err
+	}
+
+	err = s.record(file, note)
+	if err != nil {
+		return err
+	}
+
+	s.result.Pushed++
+
+	return nil
+}
+
+// create adds a note for a file, keeping the ID of its front matter if any.
+func (s *syncer) create(file localFile) error {
+	parentID, err := s.folder(path.Dir(file.path))
+	if err != nil {
+		return err
+	}
+
+	if len(parentID) == 0 {
+		s.result.warnf("'%s' is not inside a folder, skipping", file.path)
+		return nil
+	}
+
+	note, err := s.client.CreateNote(goplin.Note{
+		ID:       file.front.ID,
+		ParentID: parentID,
+		Title:    s.noteTitle(file),
+		Body:     file.body,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create note from '%s': %w", file.path, err)
+	}
+
+	note, err = s.client.GetNote(note.ID, "id", "title", "updated_time")
+	if err != nil {
+		return err
+	}
+
+	err = s.record(file, note)
+	if err != nil {
+		return err
+	}
+
+	s.result.Created++
+
+	return nil
+}
+
+// record rewrites the front matter of a pushed file and remembers its state.
+func (s *syncer) record(file localFile, note goplin.Note) error {
+	data, err := s.write(file.path, export.FrontMatter{ID: note.ID, Title: note.Title, UpdatedTime: note.UpdatedTime}, file.body)
+	if err != nil {
+		return err
+	}
+
+	s.state[note.ID] = fileState{Path: file.path, Hash: hash(data), UpdatedTime: note.UpdatedTime}
+
+	return nil
+}
+
+func (s *syncer) writeConflict(note goplin.Note, file localFile) error {
+	copyPath := strings.TrimSuffix(file.path, ".md") + conflictSuffix
+
+	_, err := s.write(copyPath, export.FrontMatter{ID: note.ID, Title: note.Title, UpdatedTime: note.UpdatedTime}, note.Body)
+	if err != nil {
+		return err
+	}
+
+	s.conflict("'%s' and note '%s' both changed, the Joplin version is in '%s'", file.path, note.ID, copyPath)
+
+	return nil
+}
+
+func (s *syncer) conflict(format string, args ...interface{}) {
+	s.result.Conflicts = append(s.result.Conflicts, fmt.Sprintf(format, args...))
+}
+
+func (s *syncer) write(rel string, front export.FrontMatter, body string) ([]byte, error) {
+	data, err := export.FormatMarkdownNote(front, body)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(s.dir, filepath.FromSlash(rel))
+
+	err = os.MkdirAll(filepath.Dir(fullPath), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, os.WriteFile(fullPath, data, 0o644)
+}
+
+// folder returns the ID of the folder for a directory, creating the folders
+// missing in Joplin. The top-level directory has no folder.
+func (s *syncer) folder(dir string) (string, error) {
+	if dir == "." {
+		return "", nil
+	}
+
+	if id, ok := s.folders[dir]; ok {
+		return id, nil
+	}
+
+	parentID, err := s.folder(path.Dir(dir))
+	if err != nil {
+		return "", err
+	}
+
+	folder, err := s.client.CreateFolderItem(goplin.Folder{Title: path.Base(dir), ParentID: parentID})
+	if err != nil {
+		return "", err
+	}
+
+	s.folders[dir] = folder.ID
+	s.dirs[folder.ID] = dir
+
+	return folder.ID, nil
+}
+
+// unique returns name+ext, or name with a counter when that is already used
+// by a note file or, if given, a key of used.
+func (s *syncer) unique(name string, ext string, used map[string]string) string {
+	candidate := name + ext
+
+	for i := 2; ; i++ {
+		_, inUse := used[candidate]
+		if !s.taken[candidate] && !inUse {
+			break
+		}
+
+		candidate = fmt.Sprintf("%s (%d)%s", name, i, ext)
+	}
+
+	if len(ext) != 0 {
+		s.taken[candidate] = true
+	}
+
+	return candidate
+}
+
+func (s *syncer) loadState() error {
+	s.state = make(map[string]fileState)
+
+	data, err := os.ReadFile(filepath.Join(s.dir, StateFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &s.state)
+}
+
+func (s *syncer) saveState() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(s.dir, StateFile), data, 0o644)
+}
@@ -0,0 +1,406 @@
+package dirsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/export"
+)
+
+const (
+	folderID = "f0000000000000000000000000000001"
+	noteID   = "n0000000000000000000000000000001"
+	notePath = "Folder/Note.md"
+)
+
+// fakeJoplin serves the part of the Data API a sync uses, from memory.
+type fakeJoplin struct {
+	mu      sync.Mutex
+	clock   int
+	folders []goplin.Folder
+	notes   map[string]goplin.Note
+}
+
+func (j *fakeJoplin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	list := func(items interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "has_more": false})
+	}
+
+	withDeleted := strings.Contains(r.URL.Query().Get("fields"), "deleted_time")
+	id := strings.TrimPrefix(r.URL.Path, "/notes/")
+
+	switch {
+	case r.URL.Path == "/ping":
+		w.Write([]byte("JoplinClipperServer"))
+	case r.URL.Path == "/folders" && r.Method == http.MethodGet:
+		list(j.folders)
+	case r.URL.Path == "/folders" && r.Method == http.MethodPost:
+		var folder goplin.Folder
+		json.NewDecoder(r.Body).Decode(&folder)
+
+		j.clock++
+		folder.ID = "f" + strconv.Itoa(j.clock)
+		j.folders = append(j.folders, folder)
+
+		json.NewEncoder(w).Encode(folder)
+	case r.URL.Path == "/notes" && r.Method == http.MethodGet:
+		var notes []goplin.Note
+		for _, note := range j.notes {
+			if !withDeleted {
+				note.DeletedTime = 0
+			}
+
+			notes = append(notes, note)
+		}
+
+		list(notes)
+	case r.URL.Path == "/notes" && r.Method == http.MethodPost:
+		var note goplin.Note
+		json.NewDecoder(r.Body).Decode(&note)
+
+		if _, ok := j.notes[note.ID]; ok {
+			http.Error(w, `{"error":"duplicate ID"}`, http.StatusInternalServerError)
+			return
+		}
+
+		j.clock++
+		note.UpdatedTime = j.clock
+		j.notes[note.ID] = note
+
+		json.NewEncoder(w).Encode(note)
+	case id != r.URL.Path && r.Method == http.MethodGet:
+		note, ok := j.notes[id]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+
+		if !withDeleted {
+			note.DeletedTime = 0
+		}
+
+		json.NewEncoder(w).Encode(note)
+	case id != r.URL.Path && r.Method == http.MethodPut:
+		note, ok := j.notes[id]
+		if !ok {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+
+		var fields map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&fields)
+
+		if title, ok := fields["title"].(string); ok {
+			note.Title = title
+		}
+
+		if body, ok := fields["body"].(string); ok {
+			note.Body = body
+		}
+
+		if parentID, ok := fields["parent_id"].(string); ok {
+			note.ParentID = parentID
+		}
+
+		j.clock++
+		note.UpdatedTime = j.clock
+		j.notes[id] = note
+
+		w.Write([]byte("{}"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (j *fakeJoplin) client(t *testing.T) *goplin.Client {
+	server := httptest.NewServer(j)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := goplin.NewWithOptions(goplin.WithAPIToken("token"), goplin.WithHost(u.Hostname()), goplin.WithPort(port), goplin.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// noteFile is the content of the file of the note.
+func noteFile(t *testing.T, updatedTime int, body string) []byte {
+	data, err := export.FormatMarkdownNote(export.FrontMatter{ID: noteID, Title: "Note", UpdatedTime: updatedTime}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestSyncNote(t *testing.T) {
+	type side struct {
+		body        string
+		updatedTime int
+	}
+
+	tests := []struct {
+		name string
+		// remote is the note in Joplin, local its file and last the version
+		// the last sync recorded; nil when absent.
+		remote  *side
+		trashed bool
+		local   *side
+		last    *side
+
+		want       Result
+		wantLocal  string // body of the file, empty when removed
+		wantRemote string // body of the note, empty when absent
+		wantCopy   bool   // whether a conflict copy is written
+	}{
+		{
+			name:       "new in Joplin",
+			remote:     &side{"remote", 10},
+			want:       Result{Pulled: 1},
+			wantLocal:  "remote",
+			wantRemote: "remote",
+		},
+		{
+			name:       "deleted locally",
+			remote:     &side{"remote", 10},
+			last:       &side{"remote", 10},
+			want:       Result{Pulled: 1, Warnings: []string{""}},
+			wantLocal:  "remote",
+			wantRemote: "remote",
+		},
+		{
+			name:       "unknown ID",
+			local:      &side{"local", 10},
+			want:       Result{Created: 1},
+			wantLocal:  "local",
+			wantRemote: "local",
+		},
+		{
+			name:  "deleted in Joplin",
+			local: &side{"local", 10},
+			last:  &side{"local", 10},
+			want:  Result{Removed: 1},
+		},
+		{
+			name:       "trashed in Joplin",
+			remote:     &side{"local", 10},
+			trashed:    true,
+			local:      &side{"local", 10},
+			last:       &side{"local", 10},
+			want:       Result{Removed: 1},
+			wantRemote: "local",
+		},
+		{
+			name:      "deleted in Joplin, changed locally",
+			local:     &side{"changed", 10},
+			last:      &side{"local", 10},
+			want:      Result{Conflicts: []string{""}},
+			wantLocal: "changed",
+		},
+		{
+			name: "gone on both sides",
+			last: &side{"local", 10},
+		},
+		{
+			name:       "unchanged",
+			remote:     &side{"same", 10},
+			local:      &side{"same", 10},
+			last:       &side{"same", 10},
+			wantLocal:  "same",
+			wantRemote: "same",
+		},
+		{
+			name:       "changed in Joplin",
+			remote:     &side{"remote", 20},
+			local:      &side{"old", 10},
+			last:       &side{"old", 10},
+			want:       Result{Pulled: 1},
+			wantLocal:  "remote",
+			wantRemote: "remote",
+		},
+		{
+			name:       "changed locally",
+			remote:     &side{"old", 10},
+			local:      &side{"local", 10},
+			last:       &side{"old", 10},
+			want:       Result{Pushed: 1},
+			wantLocal:  "local",
+			wantRemote: "local",
+		},
+		{
+			name:       "changed on both sides",
+			remote:     &side{"remote", 20},
+			local:      &side{"local", 10},
+			last:       &side{"old", 10},
+			want:       Result{Conflicts: []string{""}},
+			wantLocal:  "local",
+			wantRemote: "remote",
+			wantCopy:   true,
+		},
+		{
+			name:       "same body on both sides without state",
+			remote:     &side{"same", 20},
+			local:      &side{"same", 10},
+			want:       Result{Pulled: 1},
+			wantLocal:  "same",
+			wantRemote: "same",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joplin := &fakeJoplin{
+				clock:   100,
+				folders: []goplin.Folder{{ID: folderID, Title: "Folder"}},
+				notes:   map[string]goplin.Note{},
+			}
+
+			if tt.remote != nil {
+				note := goplin.Note{ID: noteID, ParentID: folderID, Title: "Note", Body: tt.remote.body, UpdatedTime: tt.remote.updatedTime}
+				if tt.trashed {
+					note.DeletedTime = 1
+				}
+
+				joplin.notes[noteID] = note
+			}
+
+			dir := t.TempDir()
+			file := filepath.Join(dir, filepath.FromSlash(notePath))
+
+			if tt.local != nil {
+				err := os.MkdirAll(filepath.Dir(file), 0o755)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = os.WriteFile(file, noteFile(t, tt.local.updatedTime, tt.local.body), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.last != nil {
+				state := map[string]fileState{noteID: {
+					Path:        notePath,
+					Hash:        hash(noteFile(t, tt.last.updatedTime, tt.last.body)),
+					UpdatedTime: tt.last.updatedTime,
+				}}
+
+				data, err := json.Marshal(state)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = os.WriteFile(filepath.Join(dir, StateFile), data, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := Sync(joplin.client(t), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Pulled != tt.want.Pulled || result.Pushed != tt.want.Pushed || result.Created != tt.want.Created || result.Removed != tt.want.Removed ||
+				len(result.Conflicts) != len(tt.want.Conflicts) || len(result.Warnings) != len(tt.want.Warnings) {
+				t.Errorf("got %+v, want %+v", result, tt.want)
+			}
+
+			data, err := os.ReadFile(file)
+			switch {
+			case len(tt.wantLocal) == 0 && !os.IsNotExist(err):
+				t.Errorf("%s was kept, want it removed", notePath)
+			case len(tt.wantLocal) != 0 && err != nil:
+				t.Error(err)
+			case len(tt.wantLocal) != 0:
+				_, body, err := export.ParseMarkdownNote(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if body != tt.wantLocal {
+					t.Errorf("file holds %q, want %q", body, tt.wantLocal)
+				}
+			}
+
+			note, ok := joplin.notes[noteID]
+			if note.Body != tt.wantRemote || ok != (len(tt.wantRemote) != 0) {
+				t.Errorf("note holds %q, want %q", note.Body, tt.wantRemote)
+			}
+
+			_, err = os.Stat(strings.TrimSuffix(file, ".md") + conflictSuffix)
+			if copied := err == nil; copied != tt.wantCopy {
+				t.Errorf("conflict copy written: %t, want %t", copied, tt.wantCopy)
+			}
+		})
+	}
+}
+
+func TestSyncNewFile(t *testing.T) {
+	joplin := &fakeJoplin{notes: map[string]goplin.Note{}}
+
+	dir := t.TempDir()
+
+	err := os.MkdirAll(filepath.Join(dir, "New folder"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "New folder", "Idea.md"), []byte("An idea"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Sync(joplin.client(t), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Created != 1 || len(joplin.folders) != 1 || len(joplin.notes) != 1 {
+		t.Fatalf("got %+v with %d folders and %d notes, want one of each created", result, len(joplin.folders), len(joplin.notes))
+	}
+
+	for _, note := range joplin.notes {
+		if note.Title != "Idea" || note.Body != "An idea" || note.ParentID != joplin.folders[0].ID {
+			t.Errorf("created %+v, want the note Idea in the new folder", note)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "New folder", "Idea.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	front, _, err := export.ParseMarkdownNote(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := joplin.notes[front.ID]; !ok {
+		t.Errorf("the file carries the ID '%s', want the one of the created note", front.ID)
+	}
+}
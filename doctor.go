@@ -0,0 +1,262 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// writeOnlyNoteFields are the fields of Note that Joplin accepts when
+// creating a note but never returns.
+var writeOnlyNoteFields = map[string]bool{
+	"body_html":      true,
+	"base_url":       true,
+	"image_data_url": true,
+	"crop_rect":      true,
+}
+
+// SchemaCheck is the result of probing an endpoint of the Data API.
+type SchemaCheck struct {
+	Endpoint  string `json:"endpoint"`
+	Supported bool   `json:"supported"`
+	Error     string `json:"error,omitempty"`
+	// Missing are the fields goplin knows that Joplin does not have.
+	Missing []string `json:"missing,omitempty"`
+	// Unknown are the fields Joplin returned that goplin does not model.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// OK tells whether the endpoint matches what goplin expects.
+func (s SchemaCheck) OK() bool {
+	return s.Supported && len(s.Error) == 0 && len(s.Missing) == 0 && len(s.Unknown) == 0
+}
+
+// DoctorReport lists the checks of the endpoints goplin uses.
+type DoctorReport struct {
+	Port         int           `json:"port"`
+	Capabilities Capabilities  `json:"capabilities"`
+	Checks       []SchemaCheck `json:"checks"`
+}
+
+// Problems returns the number of checks that failed.
+func (r DoctorReport) Problems() int {
+	problems := 0
+
+	for _, check := range r.Checks {
+		if !check.OK() {
+			problems++
+		}
+	}
+
+	return problems
+}
+
+// Doctor probes the endpoints of the connected Joplin and compares the fields
+// they return with the ones goplin models, to tell a version mismatch from a
+// bug. It only reads.
+func (c *Client) Doctor() DoctorReport {
+	report := DoctorReport{Port: c.port}
+
+	report.Checks = append(report.Checks, c.checkPing())
+
+	// The checks tell what failed, the capabilities are a summary.
+	report.Capabilities, _ = c.Capabilities()
+
+	for _, endpoint := range []struct {
+		name string
+		t    reflect.Type
+	}{
+		{"notes", reflect.TypeOf(Note{})},
+		{"folders", reflect.TypeOf(Folder{})},
+		{"tags", reflect.TypeOf(Tag{})},
+		{"resources", reflect.TypeOf(Resource{})},
+	} {
+		report.Checks = append(report.Checks, c.checkItems(endpoint.name, endpoint.t))
+	}
+
+	report.Checks = append(report.Checks, c.checkEvents(), c.checkSearch())
+
+	return report
+}
+
+// probe sends a GET request to the endpoint, returning the status and the
+// payload whatever the status.
+func (c *Client) probe(endpoint string, query map[string]string) (int, []byte, error) {
+	resp, err := c.r().
+		SetQueryParam("token", c.apiToken).
+		SetQueryParams(query).
+		Get(fmt.Sprintf("http://%s:%d/%s", c.host, c.port, endpoint))
+	if err != nil {
+		return 0, nil, redactError(err)
+	}
+
+	return resp.StatusCode, resp.Bytes(), nil
+}
+
+// schemaKeys returns the JSON names of the fields of t that Joplin returns,
+// sorted.
+func schemaKeys(t reflect.Type) []string {
+	var keys []string
+
+	for key := range jsonKeys(t) {
+		if !writeOnlyNoteFields[key] && key != "type_" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// compareKeys sets the missing and unknown fields of check from the first
+// item of a list payload.
+func compareKeys(check *SchemaCheck, body []byte, known []string) {
+	var list struct {
+		Items []map[string]json.RawMessage `json:"items"`
+	}
+
+	err := json.Unmarshal(body, &list)
+	if err != nil {
+		check.Error = fmt.Sprintf("invalid payload: %v", err)
+		return
+	}
+
+	if len(list.Items) == 0 {
+		return
+	}
+
+	item := list.Items[0]
+	names := make(map[string]bool)
+
+	for _, key := range known {
+		names[strings.ToLower(key)] = true
+
+		if !hasKeyFold(item, key) {
+			check.Missing = append(check.Missing, key)
+		}
+	}
+
+	for key := range item {
+		if !names[strings.ToLower(key)] && key != "type_" {
+			check.Unknown = append(check.Unknown, key)
+		}
+	}
+
+	sort.Strings(check.Unknown)
+}
+
+func hasKeyFold(item map[string]json.RawMessage, key string) bool {
+	for name := range item {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) checkPing() SchemaCheck {
+	check := SchemaCheck{Endpoint: "ping"}
+
+	status, body, err := c.probe("ping", nil)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.Supported = status != 404
+
+	if status >= 400 || !strings.Contains(string(body), "JoplinClipperServer") {
+		check.Error = fmt.Sprintf("unexpected response, status %d: %.80s", status, body)
+	}
+
+	return check
+}
+
+// checkItems requests every known field of an item endpoint. Joplin fails
+// the whole request on a field it does not have, so each field is then tried
+// alone to find the missing ones.
+func (c *Client) checkItems(endpoint string, t reflect.Type) SchemaCheck {
+	check := SchemaCheck{Endpoint: endpoint}
+	known := schemaKeys(t)
+
+	status, body, err := c.probe(endpoint, map[string]string{"fields": strings.Join(known, ","), "limit": "1"})
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	if status == 404 {
+		return check
+	}
+
+	check.Supported = true
+
+	if status < 400 {
+		compareKeys(&check, body, known)
+		return check
+	}
+
+	for _, field := range known {
+		fieldStatus, _, err := c.probe(endpoint, map[string]string{"fields": field, "limit": "1"})
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+
+		if fieldStatus >= 400 {
+			check.Missing = append(check.Missing, field)
+		}
+	}
+
+	if len(check.Missing) == 0 {
+		check.Error = fmt.Sprintf("got error response, status %d", status)
+	}
+
+	return check
+}
+
+// checkEvents compares the first change event, which Joplin returns with all
+// its fields.
+func (c *Client) checkEvents() SchemaCheck {
+	check := SchemaCheck{Endpoint: "events"}
+
+	status, body, err := c.probe("events", map[string]string{"cursor": "0"})
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.Supported = status != 404
+
+	switch {
+	case status == 404:
+	case status >= 400:
+		check.Error = fmt.Sprintf("got error response, status %d", status)
+	default:
+		compareKeys(&check, body, schemaKeys(reflect.TypeOf(Event{})))
+	}
+
+	return check
+}
+
+func (c *Client) checkSearch() SchemaCheck {
+	check := SchemaCheck{Endpoint: "search"}
+
+	status, _, err := c.probe("search", map[string]string{"query": "goplin", "fields": "id,parent_id,title", "limit": "1"})
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	check.Supported = status != 404
+
+	if status >= 400 && status != 404 {
+		check.Error = fmt.Sprintf("got error response, status %d", status)
+	}
+
+	return check
+}
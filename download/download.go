@@ -0,0 +1,324 @@
+// Package download copies the resources of the vault, the files attached to
+// notes, to a directory, each next to a JSON sidecar holding its metadata.
+//
+// Runs are resumable: names are given out in the order of the resource IDs,
+// so a file keeps its path from one run to the next unless resources with
+// the same name come and go, and a file whose sidecar records the same update
+// time as the resource is not downloaded again.
+package download
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/export"
+)
+
+// Layouts of the downloaded files.
+const (
+	// LayoutFlat puts every file in the directory itself.
+	LayoutFlat = "flat"
+	// LayoutByNotebook puts files in the folder path of the first note
+	// linking to them, unlinked ones in UnlinkedDir.
+	LayoutByNotebook = "by-notebook"
+	// LayoutByMime puts files in a directory per MIME type, such as
+	// image/png.
+	LayoutByMime = "by-mime"
+)
+
+// Layouts lists the layouts of Resources.
+var Layouts = []string{LayoutFlat, LayoutByNotebook, LayoutByMime}
+
+// UnlinkedDir holds the resources no note links to with LayoutByNotebook.
+const UnlinkedDir = "_unlinked"
+
+// SidecarSuffix is appended to the name of a file to name its sidecar.
+const SidecarSuffix = ".json"
+
+// DefaultConcurrency is the number of resources downloaded at once.
+const DefaultConcurrency = 4
+
+const resourceFields = "id,title,mime,filename,file_extension,size,created_time,updated_time"
+
+// Options of Resources.
+type Options struct {
+	// Layout is LayoutFlat when empty.
+	Layout string
+	// Concurrency limits the downloads at once, DefaultConcurrency if 0.
+	Concurrency int
+	// Names configure the file names, export.PatternTitle by default.
+	Names export.SluggerOptions
+}
+
+// Sidecar is the metadata written next to each file.
+type Sidecar struct {
+	ID            string   `json:"id"`
+	Title         string   `json:"title"`
+	Mime          string   `json:"mime"`
+	Filename      string   `json:"filename,omitempty"`
+	FileExtension string   `json:"file_extension,omitempty"`
+	Size          int      `json:"size"`
+	CreatedTime   int      `json:"created_time"`
+	UpdatedTime   int      `json:"updated_time"`
+	Notes         []string `json:"notes,omitempty"`
+}
+
+type Failure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// Result counts what a run did.
+type Result struct {
+	Downloaded int       `json:"downloaded"`
+	Skipped    int       `json:"skipped"`
+	Failed     []Failure `json:"failed,omitempty"`
+}
+
+type job struct {
+	resource goplin.Resource
+	// file is the path of the file, relative to the directory.
+	file  string
+	notes []string
+}
+
+// Resources downloads every resource of the vault to dir.
+func Resources(client *goplin.Client, dir string, opts Options) (Result, error) {
+	var result Result
+
+	layout := opts.Layout
+	if len(layout) == 0 {
+		layout = LayoutFlat
+	}
+
+	if layout != LayoutFlat && layout != LayoutByNotebook && layout != LayoutByMime {
+		return result, fmt.Errorf("unknown layout '%s', expected one of %s", layout, strings.Join(Layouts, ", "))
+	}
+
+	resources, err := client.GetAllResources(resourceFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	// Sorted so that names are given out in the same order on every run.
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+
+	notes, folders, err := linkingNotes(client)
+	if err != nil {
+		return result, err
+	}
+
+	names := export.NewSlugger(opts.Names, export.PatternTitle)
+
+	var jobs []job
+
+	for _, resource := range resources {
+		var segments []string
+
+		switch layout {
+		case LayoutByMime:
+			mime := resource.Mime
+			if len(mime) == 0 {
+				mime = "application/octet-stream"
+			}
+
+			for _, part := range strings.Split(mime, "/") {
+				segments = append(segments, names.Segment(part))
+			}
+		case LayoutByNotebook:
+			linked := notes[resource.ID]
+			if len(linked) == 0 {
+				segments = []string{UnlinkedDir}
+				break
+			}
+
+			for _, title := range folders[linked[0]] {
+				segments = append(segments, names.Segment(title))
+			}
+		}
+
+		ext := ""
+		if len(resource.FileExtension) != 0 {
+			ext = "." + resource.FileExtension
+		}
+
+		title := resource.Title
+		if len(title) == 0 {
+			title = resource.Filename
+		}
+
+		title = strings.TrimSuffix(title, ext)
+		if len(title) == 0 {
+			title = resource.ID
+		}
+
+		file := names.Path(path.Join(segments...), title, resource.ID, ext)
+		names.Reserve(file + SidecarSuffix)
+
+		jobs = append(jobs, job{resource: resource, file: file, notes: notes[resource.ID]})
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	queue := make(chan job)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range queue {
+				downloaded, err := fetch(client, dir, j)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed = append(result.Failed, Failure{ID: j.resource.ID, Error: err.Error()})
+				case downloaded:
+					result.Downloaded++
+				default:
+					result.Skipped++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		queue <- j
+	}
+
+	close(queue)
+	wg.Wait()
+
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].ID < result.Failed[j].ID
+	})
+
+	return result, nil
+}
+
+// linkingNotes returns the IDs of the notes linking to each resource, sorted,
+// and the folder path of each note as a list of titles.
+func linkingNotes(client *goplin.Client) (map[string][]string, map[string][]string, error) {
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths := make(map[string][]string)
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		paths[node.Folder.ID] = node.Titles()
+	})
+
+	all, err := client.GetAllNotes("id,parent_id,body", "", "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	notes := make(map[string][]string)
+	folders := make(map[string][]string, len(all))
+
+	for _, note := range all {
+		folders[note.ID] = paths[note.ParentID]
+
+		seen := make(map[string]bool)
+
+		for _, link := range goplin.ExtractLinks(note.Body) {
+			if !seen[link.TargetID] {
+				seen[link.TargetID] = true
+				notes[link.TargetID] = append(notes[link.TargetID], note.ID)
+			}
+		}
+	}
+
+	return notes, folders, nil
+}
+
+// fetch downloads the file of a job unless the sidecar tells it is up to
+// date, and writes the sidecar. The file is written under a temporary name
+// first, so an interrupted run leaves no partial file behind.
+func fetch(client *goplin.Client, dir string, j job) (bool, error) {
+	target := filepath.Join(dir, filepath.FromSlash(j.file))
+	sidecarPath := target + SidecarSuffix
+
+	resource := j.resource
+
+	sidecar := Sidecar{
+		ID:            resource.ID,
+		Title:         resource.Title,
+		Mime:          resource.Mime,
+		Filename:      resource.Filename,
+		FileExtension: resource.FileExtension,
+		Size:          resource.Size,
+		CreatedTime:   resource.CreatedTime,
+		UpdatedTime:   resource.UpdatedTime,
+		Notes:         j.notes,
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return false, err
+	}
+
+	var last Sidecar
+
+	existing, err := os.ReadFile(sidecarPath)
+	if err == nil && json.Unmarshal(existing, &last) == nil && last.ID == resource.ID && last.UpdatedTime == resource.UpdatedTime {
+		info, err := os.Stat(target)
+		// Old resources may not record their size.
+		if err == nil && (resource.Size == 0 || int(info.Size()) == resource.Size) {
+			if string(existing) != string(data) {
+				// The linking notes changed.
+				return false, os.WriteFile(sidecarPath, data, 0o644)
+			}
+
+			return false, nil
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(target), ".download-*")
+	if err != nil {
+		return false, err
+	}
+
+	err = client.GetResourceFile(resource.ID, f)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), target)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return false, err
+	}
+
+	return true, os.WriteFile(sidecarPath, data, 0o644)
+}
@@ -0,0 +1,127 @@
+package goplin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Policies for creating a note whose title already exists in its folder.
+const (
+	// DuplicateAllow creates the note anyway, the Joplin behavior.
+	DuplicateAllow = "allow"
+	// DuplicateFail refuses to create the note with ErrDuplicateTitle.
+	DuplicateFail = "fail"
+	// DuplicateSuffix appends " (2)", " (3)", ... to the title.
+	DuplicateSuffix = "suffix"
+	// DuplicateUpdate updates the existing note instead (upsert).
+	DuplicateUpdate = "update"
+)
+
+var DuplicatePolicies = []string{DuplicateAllow, DuplicateFail, DuplicateSuffix, DuplicateUpdate}
+
+var ErrDuplicateTitle = errors.New("a note with this title already exists in the folder")
+
+type CreateNoteOpts struct {
+	// OnDuplicate is one of the Duplicate* policies; empty means DuplicateAllow.
+	OnDuplicate string
+}
+
+// SetCreateNoteDefaults sets the options CreateNote uses, so a policy chosen
+// once applies to every note created through the client, importers included.
+func (c *Client) SetCreateNoteDefaults(opts CreateNoteOpts) error {
+	err := opts.validate()
+	if err != nil {
+		return err
+	}
+
+	c.noteOpts = opts
+
+	return nil
+}
+
+func (o CreateNoteOpts) validate() error {
+	switch o.OnDuplicate {
+	case "", DuplicateAllow, DuplicateFail, DuplicateSuffix, DuplicateUpdate:
+		return nil
+	}
+
+	return fmt.Errorf("unknown duplicate policy '%s', expected one of %s", o.OnDuplicate, strings.Join(DuplicatePolicies, ", "))
+}
+
+// CreateNoteWithOpts creates a note, handling an existing note with the same
+// title in the target folder as selected by opts. Titles are compared
+// ignoring case and surrounding space. With DuplicateUpdate the existing note
+// gets the writable fields of note and is returned.
+func (c *Client) CreateNoteWithOpts(note Note, opts CreateNoteOpts) (Note, error) {
+	err := opts.validate()
+	if err != nil {
+		return note, err
+	}
+
+	if opts.OnDuplicate == "" || opts.OnDuplicate == DuplicateAllow || len(note.ParentID) == 0 {
+		return c.createNote(note)
+	}
+
+	siblings, err := c.GetNotesInFolder(note.ParentID, "id,title", "", "")
+	if err != nil {
+		return note, err
+	}
+
+	titles := make(map[string]string, len(siblings))
+	for _, sibling := range siblings {
+		titles[foldTitle(sibling.Title)] = sibling.ID
+	}
+
+	existingID, exists := titles[foldTitle(note.Title)]
+	if !exists {
+		return c.createNote(note)
+	}
+
+	switch opts.OnDuplicate {
+	case DuplicateFail:
+		return note, fmt.Errorf("note '%s': %w", note.Title, ErrDuplicateTitle)
+	case DuplicateSuffix:
+		base := note.Title
+
+		for i := 2; exists; i++ {
+			note.Title = fmt.Sprintf("%s (%d)", base, i)
+			_, exists = titles[foldTitle(note.Title)]
+		}
+
+		return c.createNote(note)
+	}
+
+	fields, err := itemBody(note)
+	if err != nil {
+		return note, err
+	}
+
+	update := NewNoteUpdate(existingID)
+
+	for name, value := range fields {
+		if _, ok := writableNoteFields[name]; !ok {
+			continue
+		}
+
+		// JSON numbers decode as float64, the kinds below expect ints.
+		if f, ok := value.(float64); ok && writableNoteFields[name] != fieldFloat {
+			value = int(f)
+		}
+
+		update.Set(name, value)
+	}
+
+	err = c.ApplyNoteUpdate(update)
+	if err != nil {
+		return note, err
+	}
+
+	note.ID = existingID
+
+	return note, nil
+}
+
+func foldTitle(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
@@ -0,0 +1,81 @@
+package goplin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration extends time.ParseDuration with the day ("d") and week ("w")
+// units commonly used on the command line, e.g. "3d" or "2w".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if len(s) == 0 {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var unit time.Duration
+
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return time.ParseDuration(s)
+	}
+
+	n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+
+	return time.Duration(n * float64(unit)), nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses the date formats accepted on the command line: RFC 3339
+// and shorter ISO forms in local time, "now", durations relative to now such
+// as "+3d" or "-2h", and raw Joplin millisecond timestamps.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "now" {
+		return time.Now(), nil
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := ParseDuration(s[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if s[0] == '-' {
+			d = -d
+		}
+
+		return time.Now().Add(d), nil
+	}
+
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date '%s'", s)
+}
@@ -0,0 +1,72 @@
+package goplin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/imroc/req/v3"
+)
+
+var (
+	// ErrNotFound is returned when the item asked for does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is returned when Joplin refuses a request, such as
+	// one with a wrong API token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited is returned when Joplin, or a proxy in front of it,
+	// asks to slow down.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is a response of the Data API other than a success. It matches
+// ErrNotFound, ErrUnauthorized, ErrInvalidToken and ErrRateLimited with
+// errors.Is, according to its status.
+type APIError struct {
+	StatusCode int
+	// Body is the payload of the response, with the token hidden.
+	Body string
+
+	message string
+	dump    string
+}
+
+// newAPIError returns the error of a response that is not a success.
+func newAPIError(resp *req.Response) *APIError {
+	return &APIError{StatusCode: resp.StatusCode, Body: RedactToken(resp.String()), dump: dump(resp)}
+}
+
+// notFoundError returns the error of a 404 response, with a message telling
+// what was not found.
+func notFoundError(resp *req.Response, format string, args ...interface{}) *APIError {
+	err := newAPIError(resp)
+	err.message = fmt.Sprintf(format, args...)
+
+	return err
+}
+
+func (e *APIError) Error() string {
+	if len(e.message) != 0 {
+		return e.message
+	}
+
+	if e.StatusCode >= 400 {
+		return fmt.Sprintf("got error response, raw dump:\n%s", e.dump)
+	}
+
+	return fmt.Sprintf("got unexpected response, raw dump:\n%s", e.dump)
+}
+
+// Is tells whether the status of the response is the one of target.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized, ErrInvalidToken:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
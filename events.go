@@ -0,0 +1,160 @@
+package goplin
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ChangeType is the kind of change an event records, as numbered by Joplin.
+type ChangeType int
+
+// Change event types.
+const (
+	EventCreated ChangeType = 1
+	EventUpdated ChangeType = 2
+	EventDeleted ChangeType = 3
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// EventItemType is the type of the item an event is about, as numbered by
+// Joplin: the position in ItemTypes, from one.
+type EventItemType int
+
+// Event item types. Joplin only records events for notes.
+const (
+	EventItemNote EventItemType = iota + 1
+	EventItemFolder
+	EventItemSetting
+	EventItemResource
+	EventItemTag
+	EventItemNoteTag
+	EventItemSearch
+	EventItemAlarm
+	EventItemMasterKey
+	EventItemItemChange
+	EventItemNoteResource
+	EventItemResourceLocalState
+	EventItemRevision
+	EventItemMigration
+	EventItemSmartFilter
+	EventItemCommand
+)
+
+// String returns the name of the type, one of ItemTypes.
+func (t EventItemType) String() string {
+	if t >= 1 && int(t) <= len(ItemTypes) {
+		return ItemTypes[t-1]
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+type eventsResult struct {
+	Items   []Event `json:"items"`
+	HasMore bool    `json:"has_more"`
+	Cursor  string  `json:"cursor"`
+}
+
+// GetEvents returns the change events recorded after cursor, oldest first,
+// and the cursor to pass next time to get the events that follow. An empty
+// cursor returns no events, only the current cursor. Joplin sends the events
+// a page at a time, all the pages are read.
+func (c *Client) GetEvents(cursor string) ([]Event, string, error) {
+	var events []Event
+
+	for {
+		var result eventsResult
+
+		r := c.r().
+			SetQueryParam("token", c.apiToken).
+			SetResult(&result).
+			SetError(&result)
+
+		if len(cursor) != 0 {
+			r = r.SetQueryParam("cursor", cursor)
+		}
+
+		resp, err := r.Get(fmt.Sprintf("http://%s:%d/events", c.host, c.port))
+		if err != nil {
+			return events, cursor, redactError(err)
+		}
+
+		if resp.IsError() {
+			if resp.StatusCode == 404 {
+				return events, cursor, fmt.Errorf("change events: %w", ErrUnsupported)
+			}
+
+			err = newAPIError(resp)
+
+			return events, cursor, err
+		}
+
+		if resp.IsSuccess() {
+			events = append(events, result.Items...)
+
+			if result.HasMore && result.Cursor != cursor {
+				cursor = result.Cursor
+
+				continue
+			}
+
+			if len(result.Cursor) != 0 {
+				cursor = result.Cursor
+			}
+
+			return events, cursor, nil
+		}
+
+		// Handle response.
+		err = newAPIError(resp)
+
+		return events, cursor, err
+	}
+}
+
+// GetEvent returns the change event with the given ID. Event IDs are cursors
+// too: the cursor returned by GetEvents is the ID of the latest event.
+func (c *Client) GetEvent(id string) (Event, error) {
+	var event Event
+
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetResult(&event).
+		SetError(&event).
+		Get(fmt.Sprintf("http://%s:%d/events/{id}", c.host, c.port))
+	if err != nil {
+		return event, redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find event with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return event, err
+	}
+
+	if resp.IsSuccess() {
+		return event, nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return event, err
+}
@@ -0,0 +1,111 @@
+package goplin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// explainTransport prints every request instead of, for mutations, sending
+// it. Reads are still performed so commands can compute what they would do.
+type explainTransport struct {
+	mu   sync.Mutex
+	next http.RoundTripper
+	w    io.Writer
+	n    int
+}
+
+func (t *explainTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.n++
+	n := t.n
+	t.mu.Unlock()
+
+	query := redactQuery(r.URL.Query())
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var params []string
+	for _, key := range keys {
+		params = append(params, key+"="+query.Get(key))
+	}
+
+	line := fmt.Sprintf("%3d %-6s %s", n, r.Method, r.URL.Path)
+	if len(params) != 0 {
+		line += " ?" + strings.Join(params, "&")
+	}
+
+	if page := query.Get("page"); len(page) != 0 {
+		line += fmt.Sprintf("  (page %s, next page requested while has_more)", page)
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		fmt.Fprintln(t.w, line)
+
+		return t.next.RoundTrip(r)
+	}
+
+	fmt.Fprintf(t.w, "%s  [not sent]\n", line)
+
+	if r.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(r.Body, 512))
+		r.Body.Close()
+
+		if len(body) != 0 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			fmt.Fprintf(t.w, "    body: %s\n", body)
+		}
+	}
+
+	// Pretend the mutation succeeded so the command can carry on.
+	payload := fmt.Sprintf(`{"id":"%s"}`, GenerateID())
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       r,
+	}, nil
+}
+
+// EnableExplain makes the client print every Data API request to w. Read
+// requests are still performed; creations, updates and deletions are only
+// printed and answered with a fake success.
+func (c *Client) EnableExplain(w io.Writer) {
+	httpClient := c.handle.GetClient()
+
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	httpClient.Transport = &explainTransport{next: next, w: w}
+}
+
+// redactQuery hides the API token in a query string.
+func redactQuery(query url.Values) url.Values {
+	redacted := url.Values{}
+
+	for key, values := range query {
+		if key == "token" || key == "auth_token" {
+			redacted[key] = []string{"***"}
+		} else {
+			redacted[key] = values
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/markup"
+)
+
+// AnkiOptions select the flashcards of an Anki export.
+type AnkiOptions struct {
+	// TagID limits the export to the cards of the notes with this tag.
+	TagID string
+	// Deck is the deck the cards are imported into.
+	Deck string
+}
+
+// AnkiResult counts what an Anki export wrote.
+type AnkiResult struct {
+	Notes int `json:"notes"`
+	Cards int `json:"cards"`
+}
+
+// WriteAnki writes the flashcards found in notes, as recognized by
+// goplin.ExtractCards, in the tab separated text format imported by Anki's
+// File > Import. Questions and answers are rendered from Markdown to HTML and
+// the notes' tags are kept. Every card carries a stable GUID, so importing
+// again updates the cards instead of duplicating them.
+func WriteAnki(client *goplin.Client, out io.Writer, opts AnkiOptions) (AnkiResult, error) {
+	var result AnkiResult
+
+	cards, err := client.GetCards(opts.TagID)
+	if err != nil {
+		return result, err
+	}
+
+	tags, err := client.NoteTagTitles()
+	if err != nil {
+		return result, err
+	}
+
+	deck := opts.Deck
+	if len(deck) == 0 {
+		deck = "Goplin"
+	}
+
+	// The headers tell Anki how to read the file, see "Importing text files"
+	// in the Anki manual.
+	_, err = fmt.Fprintf(out, "#separator:tab\n#html:true\n#notetype:Basic\n#deck:%s\n#guid column:1\n#tags column:4\n",
+		strings.NewReplacer("\n", " ", "\r", " ").Replace(deck))
+	if err != nil {
+		return result, err
+	}
+
+	w := csv.NewWriter(out)
+	w.Comma = '\t'
+
+	notes := make(map[string]bool)
+
+	for _, card := range cards {
+		var cardTags []string
+
+		for _, tag := range tags[card.NoteID] {
+			// Anki separates tags with spaces.
+			cardTags = append(cardTags, strings.ReplaceAll(tag, " ", "_"))
+		}
+
+		err = w.Write([]string{
+			"goplin-" + card.NoteID + "-" + card.ID,
+			strings.TrimSpace(markup.MarkdownToHTML(card.Question)),
+			strings.TrimSpace(markup.MarkdownToHTML(card.Answer)),
+			strings.Join(cardTags, " "),
+		})
+		if err != nil {
+			return result, err
+		}
+
+		notes[card.NoteID] = true
+		result.Cards++
+	}
+
+	w.Flush()
+
+	result.Notes = len(notes)
+
+	return result, w.Error()
+}
@@ -0,0 +1,855 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/sink"
+)
+
+// BackupIndexFile is the file of a backup listing its generations, in the
+// clear. The catalog of each generation, listing its items, is kept in
+// catalogs/ and the items in chunks/, named by the SHA-256 of their content
+// so that each is uploaded once. Catalogs and chunks are encrypted when the
+// backup is.
+const BackupIndexFile = "index.json"
+
+// BackupChunkSize is the size of the chunks the files of resources are cut
+// into.
+const BackupChunkSize = 4 << 20
+
+// BackupOptions configure Backup.
+type BackupOptions struct {
+	// Incremental reads only the notes changed since the last run, by the
+	// change event cursor kept in the state file, instead of every note.
+	// Either way only the items changed since the last run are uploaded.
+	Incremental bool
+	// Encryptor, if set, encrypts the catalog and the chunks.
+	Encryptor Encryptor
+	// StatePath is the local file remembering the last run to the
+	// destination, needed by incremental runs and by PruneBackup.
+	StatePath string
+}
+
+// BackupGeneration is a run of Backup, restorable on its own.
+type BackupGeneration struct {
+	Number  int       `json:"number"`
+	Time    time.Time `json:"time"`
+	Items   int       `json:"items"`
+	Catalog string    `json:"catalog"`
+}
+
+// BackupResult summarizes a run of Backup.
+type BackupResult struct {
+	Generation int `json:"generation"`
+	Items      int `json:"items"`
+	// Changed and Removed count the items changed and removed since the
+	// last run.
+	Changed int `json:"changed"`
+	Removed int `json:"removed"`
+	// Chunks and Bytes count what was uploaded.
+	Chunks      int   `json:"chunks"`
+	Bytes       int64 `json:"bytes"`
+	Incremental bool  `json:"incremental"`
+}
+
+// BackupPruneResult summarizes a run of PruneBackup.
+type BackupPruneResult struct {
+	Generations []int `json:"generations"`
+	Chunks      int   `json:"chunks"`
+	// ChunksKept is set when no chunk was removed, the state file not
+	// knowing the chunks of every generation kept.
+	ChunksKept bool `json:"chunks_kept"`
+}
+
+type backupIndex struct {
+	Generations []BackupGeneration `json:"generations"`
+}
+
+// backupEntry is an item of a generation.
+type backupEntry struct {
+	Type        int `json:"type"`
+	UpdatedTime int `json:"updated_time"`
+	// Item is the chunk of the serialized item.
+	Item string `json:"item"`
+	// File, Size and Blob are the name in JEX archives, the size and the
+	// chunks of the file of a resource.
+	File string   `json:"file,omitempty"`
+	Size int64    `json:"size,omitempty"`
+	Blob []string `json:"blob,omitempty"`
+}
+
+type backupCatalog struct {
+	Generation int                    `json:"generation"`
+	Time       time.Time              `json:"time"`
+	Items      map[string]backupEntry `json:"items"`
+}
+
+// backupState is the state file of a destination.
+type backupState struct {
+	Cursor string `json:"cursor"`
+	// Extension is the one of the encryptor of the last run.
+	Extension string        `json:"extension"`
+	Catalog   backupCatalog `json:"catalog"`
+	// Chunks are the chunks used by each generation, by number.
+	Chunks map[int][]string `json:"chunks"`
+}
+
+type backuper struct {
+	client *goplin.Client
+	out    sink.Sink
+	enc    Encryptor
+	ext    string
+	result *BackupResult
+	prev   map[string]backupEntry
+	next   map[string]backupEntry
+	// known are the chunks already in the backup.
+	known map[string]bool
+}
+
+// Backup adds a generation to the backup at dest, a local directory or an S3
+// or WebDAV URL, uploading only the items and resource chunks it does not
+// hold yet. Each generation is restored on its own with RestoreBackup.
+func Backup(client *goplin.Client, dest string, opts BackupOptions) (BackupResult, error) {
+	var result BackupResult
+
+	out, err := sink.Open(dest)
+	if err != nil {
+		return result, err
+	}
+
+	index, err := loadBackupIndex(out)
+	if err != nil {
+		return result, err
+	}
+
+	state, err := loadBackupState(opts.StatePath)
+	if err != nil {
+		return result, err
+	}
+
+	b := &backuper{
+		client: client,
+		out:    out,
+		enc:    opts.Encryptor,
+		result: &result,
+		prev:   state.Catalog.Items,
+		next:   make(map[string]backupEntry),
+		known:  make(map[string]bool),
+	}
+
+	if b.enc != nil {
+		b.ext = b.enc.Extension()
+	}
+
+	// Items are uploaded again when the encryption changed.
+	if b.prev == nil || state.Extension != b.ext {
+		b.prev = make(map[string]backupEntry)
+		state.Cursor = ""
+	}
+
+	for _, chunks := range state.Chunks {
+		for _, chunk := range chunks {
+			b.known[chunk] = true
+		}
+	}
+
+	result.Generation = 1
+	if n := len(index.Generations); n != 0 {
+		result.Generation = index.Generations[n-1].Number + 1
+	}
+
+	result.Incremental = opts.Incremental && len(state.Cursor) != 0
+
+	cursor, err := b.notes(state.Cursor, result.Incremental)
+	if err != nil {
+		return result, err
+	}
+
+	err = b.foldersAndTags()
+	if err != nil {
+		return result, err
+	}
+
+	err = b.resources()
+	if err != nil {
+		return result, err
+	}
+
+	for id := range b.prev {
+		if _, ok := b.next[id]; !ok {
+			result.Removed++
+		}
+	}
+
+	result.Items = len(b.next)
+
+	catalog := backupCatalog{Generation: result.Generation, Time: time.Now().UTC(), Items: b.next}
+
+	data, err := json.Marshal(catalog)
+	if err != nil {
+		return result, err
+	}
+
+	name := fmt.Sprintf("catalogs/%06d.json%s", catalog.Generation, b.ext)
+
+	err = writeBackupFile(out, name, data, b.enc)
+	if err != nil {
+		return result, err
+	}
+
+	index.Generations = append(index.Generations, BackupGeneration{
+		Number:  catalog.Generation,
+		Time:    catalog.Time,
+		Items:   result.Items,
+		Catalog: name,
+	})
+
+	err = saveBackupIndex(out, index)
+	if err != nil {
+		return result, err
+	}
+
+	if state.Chunks == nil {
+		state.Chunks = make(map[int][]string)
+	}
+
+	state.Cursor = cursor
+	state.Extension = b.ext
+	state.Catalog = catalog
+	state.Chunks[catalog.Generation] = catalogChunks(catalog)
+
+	return result, saveBackupState(opts.StatePath, state)
+}
+
+// notes backs up the notes, only those changed since cursor when
+// incremental, and returns the cursor of the next run.
+func (b *backuper) notes(cursor string, incremental bool) (string, error) {
+	if !incremental {
+		// The cursor is taken first so that changes made while the notes
+		// are read are caught by the next run.
+		_, next, err := b.client.GetEvents("")
+		if err != nil {
+			return "", err
+		}
+
+		notes, err := b.client.GetLiveNotes(jexNoteFields, "", "")
+		if err != nil {
+			return "", err
+		}
+
+		for _, note := range notes {
+			err = b.put(note.ID, typeNote, note.UpdatedTime, func() string { return serializeNote(note) })
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return next, nil
+	}
+
+	events, next, err := b.client.GetEvents(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	// The last event of a note tells whether it still exists.
+	last := make(map[string]goplin.ChangeType)
+	for _, event := range events {
+		if event.ItemType == goplin.EventItemNote {
+			last[event.ItemID] = event.Type
+		}
+	}
+
+	for id, entry := range b.prev {
+		if _, changed := last[id]; entry.Type == typeNote && !changed {
+			b.next[id] = entry
+		}
+	}
+
+	var changed []string
+
+	for id, kind := range last {
+		if kind != goplin.EventDeleted {
+			changed = append(changed, id)
+		}
+	}
+
+	sort.Strings(changed)
+
+	for _, id := range changed {
+		note, err := b.client.GetLiveNote(id, jexNoteFields)
+		if errors.Is(err, goplin.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		err = b.put(note.ID, typeNote, note.UpdatedTime, func() string { return serializeNote(note) })
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return next, nil
+}
+
+// foldersAndTags backs up the folders, the tags and which notes carry them,
+// which the change events do not cover.
+func (b *backuper) foldersAndTags() error {
+	folders, err := b.client.GetAllFolders(jexFolderFields, "", "")
+	if err != nil {
+		return err
+	}
+
+	for _, folder := range folders {
+		err = b.put(folder.ID, typeFolder, folder.UpdatedTime, func() string { return serializeFolder(folder) })
+		if err != nil {
+			return err
+		}
+	}
+
+	tags, err := b.client.GetAllTagsWithFields(jexTagFields, "", "")
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		err = b.put(tag.ID, typeTag, tag.UpdatedTime, func() string { return serializeTag(tag) })
+		if err != nil {
+			return err
+		}
+
+		tagged, err := b.client.GetNotesByTagWithFields(tag.ID, "id", "", "")
+		if err != nil {
+			return err
+		}
+
+		for _, note := range tagged {
+			if _, ok := b.next[note.ID]; !ok {
+				continue
+			}
+
+			// The ID is derived from the note and tag, so that the item
+			// stays the same from one run to the next.
+			sum := md5.Sum([]byte(note.ID + tag.ID))
+			id := hex.EncodeToString(sum[:])
+
+			err = b.put(id, typeNoteTag, 0, func() string { return serializeNoteTag(id, note.ID, tag.ID, tag.CreatedTime) })
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resources backs up the resources and their files, in chunks.
+func (b *backuper) resources() error {
+	resources, err := b.client.GetAllResources(jexResourceFields, "", "")
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		if entry, ok := b.prev[resource.ID]; ok && entry.UpdatedTime == resource.UpdatedTime {
+			b.next[resource.ID] = entry
+			continue
+		}
+
+		item, err := b.chunk([]byte(serializeResource(resource)))
+		if err != nil {
+			return err
+		}
+
+		w := &chunkWriter{b: b}
+
+		err = b.client.GetResourceFile(resource.ID, w)
+		if err == nil {
+			err = w.flush()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		file := "resources/" + resource.ID
+		if len(resource.FileExtension) != 0 {
+			file += "." + resource.FileExtension
+		}
+
+		b.next[resource.ID] = backupEntry{
+			Type:        typeResource,
+			UpdatedTime: resource.UpdatedTime,
+			Item:        item,
+			File:        file,
+			Size:        w.size,
+			Blob:        w.chunks,
+		}
+		b.result.Changed++
+	}
+
+	return nil
+}
+
+// put backs up an item, unless the last run did and it has not been updated
+// since.
+func (b *backuper) put(id string, typ int, updatedTime int, serialize func() string) error {
+	if entry, ok := b.prev[id]; ok && entry.Type == typ && entry.UpdatedTime == updatedTime {
+		b.next[id] = entry
+		return nil
+	}
+
+	item, err := b.chunk([]byte(serialize()))
+	if err != nil {
+		return err
+	}
+
+	b.next[id] = backupEntry{Type: typ, UpdatedTime: updatedTime, Item: item}
+	b.result.Changed++
+
+	return nil
+}
+
+// chunk uploads data unless the backup holds it already, and returns its
+// name.
+func (b *backuper) chunk(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	name := path.Join("chunks", hash[:2], hash) + b.ext
+
+	if b.known[name] {
+		return name, nil
+	}
+
+	err := writeBackupFile(b.out, name, data, b.enc)
+	if err != nil {
+		return "", err
+	}
+
+	b.known[name] = true
+	b.result.Chunks++
+	b.result.Bytes += int64(len(data))
+
+	return name, nil
+}
+
+// chunkWriter cuts what is written to it into chunks of BackupChunkSize.
+type chunkWriter struct {
+	b      *backuper
+	buf    bytes.Buffer
+	size   int64
+	chunks []string
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	w.size += int64(len(p))
+
+	for w.buf.Len() >= BackupChunkSize {
+		name, err := w.b.chunk(w.buf.Next(BackupChunkSize))
+		if err != nil {
+			return 0, err
+		}
+
+		w.chunks = append(w.chunks, name)
+	}
+
+	return len(p), nil
+}
+
+func (w *chunkWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	name, err := w.b.chunk(w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	w.chunks = append(w.chunks, name)
+	w.buf.Reset()
+
+	return nil
+}
+
+// catalogChunks returns the chunks used by a catalog, sorted.
+func catalogChunks(catalog backupCatalog) []string {
+	seen := make(map[string]bool)
+
+	var chunks []string
+
+	for _, entry := range catalog.Items {
+		for _, chunk := range append([]string{entry.Item}, entry.Blob...) {
+			if !seen[chunk] {
+				seen[chunk] = true
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+
+	sort.Strings(chunks)
+
+	return chunks
+}
+
+// BackupGenerations returns the generations of the backup at dest, oldest
+// first.
+func BackupGenerations(dest string) ([]BackupGeneration, error) {
+	out, err := sink.Open(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := loadBackupIndex(out)
+
+	return index.Generations, err
+}
+
+// RestoreBackup writes the generation of the backup at dest, the last one
+// when 0, as a JEX archive to w, to be imported in Joplin. Encrypted backups
+// are decrypted with the age identity files, or the gpg keyring.
+func RestoreBackup(dest string, generation int, w io.Writer, identities []string) (JEXResult, error) {
+	var result JEXResult
+
+	out, err := sink.Open(dest)
+	if err != nil {
+		return result, err
+	}
+
+	index, err := loadBackupIndex(out)
+	if err != nil {
+		return result, err
+	}
+
+	var gen *BackupGeneration
+
+	for i := range index.Generations {
+		if g := &index.Generations[i]; g.Number == generation || generation == 0 {
+			gen = g
+		}
+	}
+
+	if gen == nil {
+		if generation == 0 {
+			return result, fmt.Errorf("no backup in '%s'", dest)
+		}
+
+		return result, fmt.Errorf("no generation %d in the backup", generation)
+	}
+
+	data, err := readBackupFile(out, gen.Catalog, identities)
+	if err != nil {
+		return result, err
+	}
+
+	var catalog backupCatalog
+
+	err = json.Unmarshal(data, &catalog)
+	if err != nil {
+		return result, fmt.Errorf("invalid catalog %s: %w", gen.Catalog, err)
+	}
+
+	jw := &jexWriter{tw: tar.NewWriter(w), now: catalog.Time}
+
+	var ids []string
+	for id := range catalog.Items {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		entry := catalog.Items[id]
+
+		item, err := readBackupChunk(out, entry.Item, identities)
+		if err != nil {
+			return result, err
+		}
+
+		if entry.Type == typeResource {
+			err = jw.tw.WriteHeader(&tar.Header{Name: entry.File, Mode: 0o644, Size: entry.Size, ModTime: jw.now})
+			if err != nil {
+				return result, err
+			}
+
+			for _, chunk := range entry.Blob {
+				data, err := readBackupChunk(out, chunk, identities)
+				if err != nil {
+					return result, err
+				}
+
+				_, err = jw.tw.Write(data)
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+
+		err = jw.item(id, string(item))
+		if err != nil {
+			return result, err
+		}
+
+		switch entry.Type {
+		case typeNote:
+			result.Notes++
+		case typeFolder:
+			result.Folders++
+		case typeTag:
+			result.Tags++
+		case typeNoteTag:
+			result.NoteTags++
+		case typeResource:
+			result.Resources++
+		}
+	}
+
+	return result, jw.tw.Close()
+}
+
+// PruneBackup removes the generations of the backup at dest but the last
+// keep, and the chunks only they use. The chunks of each generation are
+// known from the state file of Backup at statePath.
+func PruneBackup(dest string, keep int, statePath string) (BackupPruneResult, error) {
+	var result BackupPruneResult
+
+	if keep < 1 {
+		return result, fmt.Errorf("at least one generation must be kept")
+	}
+
+	out, err := sink.Open(dest)
+	if err != nil {
+		return result, err
+	}
+
+	index, err := loadBackupIndex(out)
+	if err != nil {
+		return result, err
+	}
+
+	state, err := loadBackupState(statePath)
+	if err != nil {
+		return result, err
+	}
+
+	if len(index.Generations) <= keep {
+		return result, nil
+	}
+
+	drop := index.Generations[:len(index.Generations)-keep]
+	index.Generations = index.Generations[len(index.Generations)-keep:]
+
+	inUse := make(map[string]bool)
+
+	for _, gen := range index.Generations {
+		chunks, ok := state.Chunks[gen.Number]
+		if !ok {
+			result.ChunksKept = true
+		}
+
+		for _, chunk := range chunks {
+			inUse[chunk] = true
+		}
+	}
+
+	// The index goes first: a failure below leaves unused files, not
+	// generations missing chunks.
+	err = saveBackupIndex(out, index)
+	if err != nil {
+		return result, err
+	}
+
+	removed := make(map[string]bool)
+
+	for _, gen := range drop {
+		err = out.Remove(gen.Catalog)
+		if err != nil {
+			return result, err
+		}
+
+		result.Generations = append(result.Generations, gen.Number)
+
+		if !result.ChunksKept {
+			for _, chunk := range state.Chunks[gen.Number] {
+				if inUse[chunk] || removed[chunk] {
+					continue
+				}
+
+				err = out.Remove(chunk)
+				if err != nil {
+					return result, err
+				}
+
+				removed[chunk] = true
+				result.Chunks++
+			}
+		}
+
+		delete(state.Chunks, gen.Number)
+	}
+
+	return result, saveBackupState(statePath, state)
+}
+
+func loadBackupIndex(out sink.Sink) (backupIndex, error) {
+	var index backupIndex
+
+	data, err := readBackupFile(out, BackupIndexFile, nil)
+	if errors.Is(err, fs.ErrNotExist) {
+		return index, nil
+	}
+
+	if err != nil {
+		return index, err
+	}
+
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return index, fmt.Errorf("invalid backup index %s: %w", BackupIndexFile, err)
+	}
+
+	return index, nil
+}
+
+func saveBackupIndex(out sink.Sink, index backupIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeBackupFile(out, BackupIndexFile, data, nil)
+}
+
+func loadBackupState(statePath string) (backupState, error) {
+	var state backupState
+
+	if len(statePath) == 0 {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return state, fmt.Errorf("invalid backup state %s, remove it for a full run: %w", statePath, err)
+	}
+
+	return state, nil
+}
+
+func saveBackupState(statePath string, state backupState) error {
+	if len(statePath) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(statePath), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(statePath, data, 0o600)
+}
+
+// writeBackupFile writes data to the file name of out, encrypted by enc
+// unless it is nil.
+func writeBackupFile(out sink.Sink, name string, data []byte, enc Encryptor) error {
+	f, err := out.Create(name)
+	if err != nil {
+		return err
+	}
+
+	var w io.WriteCloser = f
+
+	if enc != nil {
+		w, err = enc.Encrypt(f)
+		if err != nil {
+			sink.Abort(f)
+			return err
+		}
+	}
+
+	_, err = w.Write(data)
+
+	if enc != nil {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		sink.Abort(f)
+		return err
+	}
+
+	return f.Close()
+}
+
+// readBackupFile reads the file name of out, decrypting it when its
+// extension tells it is encrypted.
+func readBackupFile(out sink.Sink, name string, identities []string) ([]byte, error) {
+	f, err := out.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	f.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := path.Ext(name); ext {
+	case AgeEncryptor{}.Extension(), GPGEncryptor{}.Extension():
+		return Decrypt(data, ext, identities)
+	}
+
+	return data, nil
+}
+
+// readBackupChunk reads a chunk and checks it against its name.
+func readBackupChunk(out sink.Sink, name string, identities []string) ([]byte, error) {
+	data, err := readBackupFile(out, name, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if !strings.HasPrefix(path.Base(name), hex.EncodeToString(sum[:])) {
+		return nil, fmt.Errorf("chunk %s is corrupted", name)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,285 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/momo182/goplin/sink"
+)
+
+// Encryptor encrypts an export while it is written, so no plaintext copy
+// reaches the disk.
+type Encryptor interface {
+	// Encrypt returns a writer for the plaintext; the ciphertext is written
+	// to w. Closing the writer finishes the ciphertext but does not close w.
+	Encrypt(w io.Writer) (io.WriteCloser, error)
+	// Extension is the file name extension of the ciphertext, e.g. ".age".
+	Extension() string
+}
+
+// AgeEncryptor encrypts with the age command line tool. Recipients are age or
+// SSH public keys, or files of them.
+type AgeEncryptor struct {
+	Recipients []string
+}
+
+func (e AgeEncryptor) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	var args []string
+
+	for _, recipient := range e.Recipients {
+		if isFile(recipient) {
+			args = append(args, "-R", recipient)
+		} else {
+			args = append(args, "-r", recipient)
+		}
+	}
+
+	return startFilter(w, "age", args...)
+}
+
+func (e AgeEncryptor) Extension() string {
+	return ".age"
+}
+
+// GPGEncryptor encrypts with gpg to keys of the user's keyring, given by
+// e-mail address, key ID or fingerprint.
+type GPGEncryptor struct {
+	Recipients []string
+}
+
+func (e GPGEncryptor) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	args := []string{"--batch", "--yes", "--encrypt", "--output", "-"}
+
+	for _, recipient := range e.Recipients {
+		args = append(args, "--recipient", recipient)
+	}
+
+	return startFilter(w, "gpg", args...)
+}
+
+func (e GPGEncryptor) Extension() string {
+	return ".gpg"
+}
+
+// NewEncryptor picks the tool for recipients: age for age and SSH public keys
+// and for files of them, gpg for anything else. An "age:" or "gpg:" prefix
+// forces the tool. All recipients must use the same tool.
+func NewEncryptor(recipients []string) (Encryptor, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients to encrypt to")
+	}
+
+	var age, gpg []string
+
+	for _, recipient := range recipients {
+		switch {
+		case strings.HasPrefix(recipient, "age:"):
+			age = append(age, strings.TrimPrefix(recipient, "age:"))
+		case strings.HasPrefix(recipient, "gpg:"):
+			gpg = append(gpg, strings.TrimPrefix(recipient, "gpg:"))
+		case strings.HasPrefix(recipient, "age1"), strings.HasPrefix(recipient, "ssh-"), isFile(recipient):
+			age = append(age, recipient)
+		default:
+			gpg = append(gpg, recipient)
+		}
+	}
+
+	if len(age) != 0 && len(gpg) != 0 {
+		return nil, fmt.Errorf("cannot encrypt to age recipients (%s) and gpg recipients (%s) at once",
+			strings.Join(age, ", "), strings.Join(gpg, ", "))
+	}
+
+	if len(age) != 0 {
+		return AgeEncryptor{Recipients: age}, nil
+	}
+
+	return GPGEncryptor{Recipients: gpg}, nil
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// filterWriter feeds an external command on its standard input.
+type filterWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+}
+
+func startFilter(w io.Writer, name string, args ...string) (io.WriteCloser, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required to encrypt exports: %w", name, err)
+	}
+
+	f := &filterWriter{cmd: exec.Command(path, args...)}
+	f.cmd.Stdout = w
+	f.cmd.Stderr = &f.stderr
+
+	f.stdin, err = f.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *filterWriter) Write(p []byte) (int, error) {
+	return f.stdin.Write(p)
+}
+
+func (f *filterWriter) Close() error {
+	err := f.stdin.Close()
+
+	waitErr := f.cmd.Wait()
+	if waitErr != nil {
+		return fmt.Errorf("%s failed: %w: %s", f.cmd.Path, waitErr, strings.TrimSpace(f.stderr.String()))
+	}
+
+	return err
+}
+
+// outputFile is a file written through an optional encryptor.
+type outputFile struct {
+	io.Writer
+	file      io.WriteCloser
+	encrypted io.WriteCloser
+}
+
+func (o *outputFile) Close() error {
+	if o.encrypted != nil {
+		err := o.encrypted.Close()
+		if err != nil {
+			sink.Abort(o.file)
+			return err
+		}
+	}
+
+	return o.file.Close()
+}
+
+// Abort drops the output, removing the file or the upload in progress.
+func (o *outputFile) Abort() error {
+	if o.encrypted != nil {
+		o.encrypted.Close()
+	}
+
+	return sink.Abort(o.file)
+}
+
+// CreateOutput creates the named file for an export, a local path or an S3
+// or WebDAV URL as understood by sink.Create. With a non-nil enc the export
+// is encrypted on the way and only ciphertext is written to the file.
+func CreateOutput(path string, enc Encryptor) (io.WriteCloser, error) {
+	f, err := sink.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptOutput(f, enc)
+}
+
+// encryptOutput writes through enc to f, straight to f when enc is nil.
+func encryptOutput(f io.WriteCloser, enc Encryptor) (io.WriteCloser, error) {
+	if enc == nil {
+		return &outputFile{Writer: f, file: f}, nil
+	}
+
+	encrypted, err := enc.Encrypt(f)
+	if err != nil {
+		sink.Abort(f)
+
+		return nil, err
+	}
+
+	return &outputFile{Writer: encrypted, file: f, encrypted: encrypted}, nil
+}
+
+// encryptedSink encrypts each file written to a sink, the extension of the
+// encryptor added to its name.
+type encryptedSink struct {
+	sink.Sink
+	enc Encryptor
+}
+
+// EncryptSink returns a sink encrypting the files written to out with enc,
+// out itself when enc is nil. The files are named with the extension of the
+// encryption added, so the links between them hold once decrypted.
+func EncryptSink(out sink.Sink, enc Encryptor) sink.Sink {
+	if enc == nil {
+		return out
+	}
+
+	return encryptedSink{Sink: out, enc: enc}
+}
+
+func (s encryptedSink) Create(name string) (io.WriteCloser, error) {
+	f, err := s.Sink.Create(name + s.enc.Extension())
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptOutput(f, s.enc)
+}
+
+func (s encryptedSink) Open(name string) (io.ReadCloser, error) {
+	return s.Sink.Open(name + s.enc.Extension())
+}
+
+func (s encryptedSink) Remove(name string) error {
+	return s.Sink.Remove(name + s.enc.Extension())
+}
+
+// Decrypt returns the plaintext of data encrypted by the encryptor of the
+// extension ext, ".age" or ".gpg". Age reads the private keys from the
+// identity files, gpg from the user's keyring.
+func Decrypt(data []byte, ext string, identities []string) ([]byte, error) {
+	var name string
+	var args []string
+
+	switch ext {
+	case AgeEncryptor{}.Extension():
+		if len(identities) == 0 {
+			return nil, fmt.Errorf("an age identity file is needed to decrypt")
+		}
+
+		name, args = "age", []string{"--decrypt"}
+		for _, identity := range identities {
+			args = append(args, "-i", identity)
+		}
+	case GPGEncryptor{}.Extension():
+		name, args = "gpg", []string{"--batch", "--quiet", "--decrypt"}
+	default:
+		return nil, fmt.Errorf("unknown encryption '%s'", ext)
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required to decrypt: %w", name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
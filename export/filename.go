@@ -0,0 +1,53 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+// FileName turns a title into a file name valid on common file systems.
+func FileName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+
+		return r
+	}, title)
+
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if len(name) == 0 {
+		return "Untitled"
+	}
+
+	return name
+}
+
+// Slug turns a title into a lowercase URL path segment of ASCII letters,
+// digits and dashes, dropping diacritics.
+func Slug(title string) string {
+	var b strings.Builder
+
+	dash := false
+
+	for _, r := range goplin.FoldTagTitle(title) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && b.Len() != 0 {
+				b.WriteByte('-')
+			}
+
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	if b.Len() == 0 {
+		return "untitled"
+	}
+
+	return b.String()
+}
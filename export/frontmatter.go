@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the YAML header written above the body of Markdown notes.
+// The ID and update time let an edited file be matched back to its note.
+type FrontMatter struct {
+	ID          string `yaml:"id,omitempty"`
+	Title       string `yaml:"title,omitempty"`
+	UpdatedTime int    `yaml:"updated_time,omitempty"`
+}
+
+// FormatMarkdownNote renders a note body preceded by its front matter.
+func FormatMarkdownNote(fm FrontMatter, body string) ([]byte, error) {
+	var b bytes.Buffer
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+
+	b.WriteString("---\n")
+	b.Write(header)
+	b.WriteString("---\n\n")
+	b.WriteString(body)
+
+	return b.Bytes(), nil
+}
+
+// ParseMarkdownNote splits a Markdown file into front matter and body. Files
+// without front matter return an empty FrontMatter and the whole content.
+func ParseMarkdownNote(data []byte) (FrontMatter, string, error) {
+	var fm FrontMatter
+
+	text := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+
+	if !strings.HasPrefix(text, "---\n") {
+		return fm, text, nil
+	}
+
+	rest := text[4:]
+	if strings.HasSuffix(rest, "\n---") {
+		rest += "\n"
+	}
+
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		return fm, text, nil
+	}
+
+	err := yaml.Unmarshal([]byte(rest[:end+1]), &fm)
+	if err != nil {
+		return fm, text, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	return fm, strings.TrimPrefix(rest[end+5:], "\n"), nil
+}
+
+// frontMatterField is a key and value of a front matter written in order.
+type frontMatterField struct {
+	Key   string
+	Value interface{}
+}
+
+// formatOrderedFrontMatter renders fields as YAML front matter, keeping their
+// order, followed by body.
+func formatOrderedFrontMatter(fields []frontMatterField, body string) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("---\n")
+
+	for _, field := range fields {
+		out, err := yaml.Marshal(map[string]interface{}{field.Key: field.Value})
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(out)
+	}
+
+	b.WriteString("---\n\n")
+	b.WriteString(body)
+
+	return b.Bytes(), nil
+}
+
+// frontMatterNoteFields are the note fields read to fill FrontMatterFields.
+const frontMatterNoteFields = "id,title,created_time,updated_time,user_created_time,user_updated_time,source_url,author,latitude,longitude,altitude"
+
+// noteFrontMatter returns the front matter fields of a note among
+// FrontMatterFields, in the given order, leaving out the empty ones. Times are
+// written as timestamps.
+func noteFrontMatter(note goplin.Note, tags []string, fields []string) []frontMatterField {
+	var out []frontMatterField
+
+	add := func(key string, value interface{}, empty bool) {
+		if !empty {
+			out = append(out, frontMatterField{Key: key, Value: value})
+		}
+	}
+
+	for _, field := range fields {
+		switch field {
+		case "id":
+			add(field, note.ID, len(note.ID) == 0)
+		case "title":
+			add(field, note.Title, false)
+		case "created_time":
+			created := noteTime(note.UserCreatedTime, note.CreatedTime)
+			add(field, created, created.IsZero())
+		case "updated_time":
+			updated := noteTime(note.UserUpdatedTime, note.UpdatedTime)
+			add(field, updated, updated.IsZero())
+		case "tags":
+			add(field, tags, len(tags) == 0)
+		case "source_url":
+			add(field, note.SourceURL, len(note.SourceURL) == 0)
+		case "author":
+			add(field, note.Author, len(note.Author) == 0)
+		case "location":
+			add(field, map[string]float64{
+				"latitude":  note.Latitude,
+				"longitude": note.Longitude,
+				"altitude":  note.Altitude,
+			}, note.Latitude == 0 && note.Longitude == 0)
+		}
+	}
+
+	return out
+}
+
+// noteTime returns the user set time if any, else the item time, at second
+// precision.
+func noteTime(user int, item int) time.Time {
+	ms := user
+	if ms == 0 {
+		ms = item
+	}
+
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(int64(ms)).Truncate(time.Second)
+}
@@ -0,0 +1,272 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/markup"
+	"github.com/momo182/goplin/sink"
+)
+
+const hugoResourceFields = "id,title,mime,filename,file_extension"
+
+// HugoOptions select the notes of a Hugo export and how they are published.
+type HugoOptions struct {
+	// Folder, by ID or path, holds the pages. Its sub-folders become
+	// sections.
+	Folder string
+	// Notes tagged DraftTag are drafts.
+	DraftTag string
+	// When PublishTag is set, notes without it are drafts.
+	PublishTag string
+	// SkipDrafts leaves the drafts out instead of exporting them with
+	// draft: true.
+	SkipDrafts bool
+	// SkipResources leaves the attachments out of the bundles.
+	SkipResources bool
+	// FrontMatter lists extra FrontMatterFields to write after the Hugo ones.
+	FrontMatter []string
+	// Names configure the bundle directories, named PatternSlug by default.
+	Names SluggerOptions
+	// Encryptor, if set, encrypts each file written, see EncryptSink.
+	Encryptor Encryptor
+}
+
+// HugoResult counts what a Hugo export wrote.
+type HugoResult struct {
+	Pages     int `json:"pages"`
+	Drafts    int `json:"drafts"`
+	Skipped   int `json:"skipped"`
+	Resources int `json:"resources"`
+}
+
+type hugoPage struct {
+	note  goplin.Note
+	tags  []string
+	draft bool
+	// path is the bundle directory, relative to the export directory.
+	path string
+}
+
+// WriteHugo writes the notes of a folder to dir as Hugo page bundles: each
+// note becomes <section>/<slug>/index.md, or index.html for HTML notes, with
+// the resources it links to next to it. Links to resources point into the
+// bundle and links to other exported notes use the relref shortcode.
+func WriteHugo(client *goplin.Client, dir string, opts HugoOptions) (HugoResult, error) {
+	var result HugoResult
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return result, err
+	}
+
+	root, err := goplin.FindFolderNode(tree, opts.Folder)
+	if err != nil {
+		return result, err
+	}
+
+	names := NewSlugger(opts.Names, PatternSlug)
+	sections := map[string]string{root.Folder.ID: "."}
+
+	goplin.WalkFolders(root.Children, func(node *goplin.FolderNode, depth int) {
+		sections[node.Folder.ID] = names.Unique(sections[node.Parent.Folder.ID], names.slug(node.Folder.Title), node.Folder.ID, "")
+	})
+
+	notes, err := client.GetLiveNotes(frontMatterNoteFields+",parent_id,body,markup_language", "", "")
+	if err != nil {
+		return result, err
+	}
+
+	tagsByNote, err := client.NoteTagTitles()
+	if err != nil {
+		return result, err
+	}
+
+	sortForNaming(notes)
+
+	var pages []*hugoPage
+
+	byID := make(map[string]*hugoPage)
+
+	for _, note := range notes {
+		section, ok := sections[note.ParentID]
+		if !ok {
+			continue
+		}
+
+		page := &hugoPage{note: note}
+
+		var draftTagged, publishTagged bool
+
+		for _, tag := range tagsByNote[note.ID] {
+			switch {
+			case len(opts.DraftTag) != 0 && strings.EqualFold(tag, opts.DraftTag):
+				draftTagged = true
+			case len(opts.PublishTag) != 0 && strings.EqualFold(tag, opts.PublishTag):
+				publishTagged = true
+			default:
+				page.tags = append(page.tags, tag)
+			}
+		}
+
+		page.draft = draftTagged || (len(opts.PublishTag) != 0 && !publishTagged)
+
+		if page.draft && opts.SkipDrafts {
+			result.Skipped++
+			continue
+		}
+
+		page.path = names.Path(section, note.Title, note.ID, "")
+		pages = append(pages, page)
+		byID[note.ID] = page
+	}
+
+	resources := make(map[string]goplin.Resource)
+
+	if !opts.SkipResources {
+		all, err := client.GetAllResources(hugoResourceFields, "", "")
+		if err != nil {
+			return result, err
+		}
+
+		for _, resource := range all {
+			resources[resource.ID] = resource
+		}
+	}
+
+	out := EncryptSink(sink.Dir(dir), opts.Encryptor)
+
+	for _, page := range pages {
+		n, err := writeHugoPage(client, out, page, byID, resources, opts)
+		if err != nil {
+			return result, err
+		}
+
+		result.Pages++
+		result.Resources += n
+
+		if page.draft {
+			result.Drafts++
+		}
+	}
+
+	return result, nil
+}
+
+// writeHugoPage writes the bundle of a page and returns the number of
+// resources copied into it.
+func writeHugoPage(client *goplin.Client, out sink.Sink, page *hugoPage, pages map[string]*hugoPage, resources map[string]goplin.Resource, opts HugoOptions) (int, error) {
+	// File names of the resources of the bundle, by resource ID.
+	files := make(map[string]string)
+	used := map[string]bool{"index.md": true, "index.html": true}
+
+	body := goplin.ReplaceLinks(page.note.Body, func(link goplin.Link) (string, bool) {
+		if target, ok := pages[link.TargetID]; ok {
+			ref := target.path
+			if len(link.Anchor) != 0 {
+				ref += "#" + link.Anchor
+			}
+
+			return fmt.Sprintf(`{{< relref "%s" >}}`, ref), true
+		}
+
+		resource, ok := resources[link.TargetID]
+		if !ok {
+			return "", false
+		}
+
+		name, ok := files[resource.ID]
+		if !ok {
+			name = bundleFileName(resource, used)
+			files[resource.ID] = name
+		}
+
+		return name, true
+	})
+
+	for id, name := range files {
+		err := downloadResource(client, out, id, path.Join(page.path, name))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	note := page.note
+
+	fields := []frontMatterField{
+		{Key: "title", Value: note.Title},
+	}
+
+	if created := noteTime(note.UserCreatedTime, note.CreatedTime); !created.IsZero() {
+		fields = append(fields, frontMatterField{Key: "date", Value: created})
+	}
+
+	if updated := noteTime(note.UserUpdatedTime, note.UpdatedTime); !updated.IsZero() {
+		fields = append(fields, frontMatterField{Key: "lastmod", Value: updated})
+	}
+
+	fields = append(fields, frontMatterField{Key: "slug", Value: path.Base(page.path)})
+
+	if len(page.tags) != 0 {
+		fields = append(fields, frontMatterField{Key: "tags", Value: page.tags})
+	}
+
+	if page.draft {
+		fields = append(fields, frontMatterField{Key: "draft", Value: true})
+	}
+
+	for _, field := range noteFrontMatter(note, page.tags, opts.FrontMatter) {
+		// Title and tags are written above under their Hugo names.
+		if field.Key != "title" && field.Key != "tags" {
+			fields = append(fields, field)
+		}
+	}
+
+	data, err := formatOrderedFrontMatter(fields, body)
+	if err != nil {
+		return 0, err
+	}
+
+	index := "index.md"
+	if note.MarkupLanguage == markup.HTML {
+		index = "index.html"
+	}
+
+	return len(files), writeExportFile(out, path.Join(page.path, index), data)
+}
+
+// bundleFileName returns a URL safe file name for a resource, unique among
+// used.
+func bundleFileName(resource goplin.Resource, used map[string]bool) string {
+	base := resource.Filename
+	if len(base) == 0 {
+		base = resource.Title
+	}
+
+	ext := resource.FileExtension
+	if len(ext) == 0 {
+		ext = strings.TrimPrefix(path.Ext(base), ".")
+	}
+
+	base = strings.TrimSuffix(base, path.Ext(base))
+
+	stem := Slug(base)
+	if len(base) == 0 {
+		stem = resource.ID
+	}
+
+	if len(ext) != 0 {
+		ext = "." + strings.ToLower(ext)
+	}
+
+	name := stem + ext
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+
+	used[name] = true
+
+	return name
+}
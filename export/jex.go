@@ -0,0 +1,426 @@
+// Package export writes Joplin items to files and archives.
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/sink"
+)
+
+// Joplin item types as found in the type_ property of serialized items.
+const (
+	typeNote     = 1
+	typeFolder   = 2
+	typeResource = 4
+	typeTag      = 5
+	typeNoteTag  = 6
+)
+
+const (
+	jexNoteFields     = "id,parent_id,title,body,created_time,updated_time,is_conflict,latitude,longitude,altitude,author,source_url,is_todo,todo_due,todo_completed,source,source_application,application_data,order,user_created_time,user_updated_time,encryption_cipher_text,encryption_applied,markup_language,is_shared,share_id,conflict_original_id,master_key_id"
+	jexFolderFields   = "id,parent_id,title,created_time,updated_time,user_created_time,user_updated_time,encryption_cipher_text,encryption_applied,is_shared,share_id,master_key_id,icon"
+	jexTagFields      = "id,parent_id,title,created_time,updated_time,user_created_time,user_updated_time,encryption_cipher_text,encryption_applied,is_shared"
+	jexResourceFields = "id,title,mime,filename,created_time,updated_time,file_extension,encryption_cipher_text,encryption_applied,encryption_blob_encrypted,size,is_shared,share_id,master_key_id"
+)
+
+// serializedItem builds the text format Joplin uses for items in sync targets
+// and JEX archives: title, body, then one "key: value" property per line.
+type serializedItem struct {
+	title    string
+	hasTitle bool
+	body     string
+	props    []string
+}
+
+func (s *serializedItem) prop(key string, value interface{}) {
+	var v string
+
+	switch value := value.(type) {
+	case string:
+		v = strings.ReplaceAll(strings.ReplaceAll(value, "\r", "\\r"), "\n", "\\n")
+	case float64:
+		v = fmt.Sprintf("%.8f", value)
+	default:
+		v = fmt.Sprint(value)
+	}
+
+	s.props = append(s.props, key+": "+v)
+}
+
+func (s *serializedItem) time(key string, ms int) {
+	if ms == 0 {
+		s.prop(key, "")
+		return
+	}
+
+	s.prop(key, time.UnixMilli(int64(ms)).UTC().Format("2006-01-02T15:04:05.000Z"))
+}
+
+func (s *serializedItem) String() string {
+	var parts []string
+
+	if s.hasTitle {
+		parts = append(parts, s.title)
+	}
+
+	if len(s.body) != 0 {
+		parts = append(parts, s.body)
+	}
+
+	if len(s.props) != 0 {
+		parts = append(parts, strings.Join(s.props, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+func serializeNote(n goplin.Note) string {
+	s := serializedItem{title: n.Title, hasTitle: true, body: n.Body}
+
+	s.prop("id", n.ID)
+	s.prop("parent_id", n.ParentID)
+	s.time("created_time", n.CreatedTime)
+	s.time("updated_time", n.UpdatedTime)
+	s.prop("is_conflict", n.IsConflict)
+	s.prop("latitude", n.Latitude)
+	s.prop("longitude", n.Longitude)
+	s.prop("altitude", n.Altitude)
+	s.prop("author", n.Author)
+	s.prop("source_url", n.SourceURL)
+	s.prop("is_todo", n.IsTodo)
+	s.prop("todo_due", n.TodoDue)
+	s.prop("todo_completed", n.TodoCompleted)
+	s.prop("source", n.Source)
+	s.prop("source_application", n.SourceApplication)
+	s.prop("application_data", n.ApplicationData)
+	s.prop("order", int64(n.Order))
+	s.time("user_created_time", n.UserCreatedTime)
+	s.time("user_updated_time", n.UserUpdatedTime)
+	s.prop("encryption_cipher_text", n.EncryptionCipherText)
+	s.prop("encryption_applied", n.EncryptionApplied)
+	s.prop("markup_language", n.MarkupLanguage)
+	s.prop("is_shared", n.IsShared)
+	s.prop("share_id", n.ShareID)
+	s.prop("conflict_original_id", n.ConflictOriginalID)
+	s.prop("master_key_id", n.MasterKeyID)
+	s.prop("type_", typeNote)
+
+	return s.String()
+}
+
+func serializeFolder(f goplin.Folder) string {
+	s := serializedItem{title: f.Title, hasTitle: true}
+
+	s.prop("id", f.ID)
+	s.time("created_time", f.CreatedTime)
+	s.time("updated_time", f.UpdatedTime)
+	s.time("user_created_time", f.UserCreatedTime)
+	s.time("user_updated_time", f.UserUpdatedTime)
+	s.prop("encryption_cipher_text", f.EncryptionCipherText)
+	s.prop("encryption_applied", f.EncryptionApplied)
+	s.prop("parent_id", f.ParentID)
+	s.prop("is_shared", f.IsShared)
+	s.prop("share_id", f.ShareID)
+	s.prop("master_key_id", f.MasterKeyID)
+	s.prop("icon", f.Icon)
+	s.prop("type_", typeFolder)
+
+	return s.String()
+}
+
+func serializeTag(t goplin.Tag) string {
+	s := serializedItem{title: t.Title, hasTitle: true}
+
+	s.prop("id", t.ID)
+	s.time("created_time", t.CreatedTime)
+	s.time("updated_time", t.UpdatedTime)
+	s.time("user_created_time", t.UserCreatedTime)
+	s.time("user_updated_time", t.UserUpdatedTime)
+	s.prop("encryption_cipher_text", t.EncryptionCipherText)
+	s.prop("encryption_applied", t.EncryptionApplied)
+	s.prop("is_shared", t.IsShared)
+	s.prop("parent_id", t.ParentID)
+	s.prop("type_", typeTag)
+
+	return s.String()
+}
+
+func serializeNoteTag(id string, noteID string, tagID string, now int) string {
+	var s serializedItem
+
+	s.prop("id", id)
+	s.prop("note_id", noteID)
+	s.prop("tag_id", tagID)
+	s.time("created_time", now)
+	s.time("updated_time", now)
+	s.time("user_created_time", now)
+	s.time("user_updated_time", now)
+	s.prop("encryption_cipher_text", "")
+	s.prop("encryption_applied", 0)
+	s.prop("is_shared", 0)
+	s.prop("type_", typeNoteTag)
+
+	return s.String()
+}
+
+func serializeResource(r goplin.Resource) string {
+	s := serializedItem{title: r.Title, hasTitle: true}
+
+	s.prop("id", r.ID)
+	s.prop("mime", r.Mime)
+	s.prop("filename", r.Filename)
+	s.time("created_time", r.CreatedTime)
+	s.time("updated_time", r.UpdatedTime)
+	s.time("user_created_time", r.CreatedTime)
+	s.time("user_updated_time", r.UpdatedTime)
+	s.prop("file_extension", r.FileExtension)
+	s.prop("encryption_cipher_text", r.EncryptionCipherText)
+	s.prop("encryption_applied", r.EncryptionApplied)
+	s.prop("encryption_blob_encrypted", r.EncryptionBlobEncrypted)
+	s.prop("size", r.Size)
+	s.prop("is_shared", r.IsShared)
+	s.prop("share_id", r.ShareID)
+	s.prop("master_key_id", r.MasterKeyID)
+	s.prop("type_", typeResource)
+
+	return s.String()
+}
+
+// JEXResult summarizes what an export wrote.
+type JEXResult struct {
+	Notes     int `json:"notes"`
+	Folders   int `json:"folders"`
+	Tags      int `json:"tags"`
+	NoteTags  int `json:"note_tags"`
+	Resources int `json:"resources"`
+}
+
+type jexWriter struct {
+	tw  *tar.Writer
+	now time.Time
+}
+
+func (w *jexWriter) write(name string, data []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.now,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.tw.Write(data)
+
+	return err
+}
+
+func (w *jexWriter) item(id string, serialized string) error {
+	return w.write(id+".md", []byte(serialized))
+}
+
+// JEXOptions restrict and protect a JEX export.
+type JEXOptions struct {
+	// Scope limits the export to a folder, given by ID or path, and its
+	// sub-folders. The tags and resources of the exported notes come along.
+	Scope string
+	// SkipResources leaves the attachments out, keeping the links to them.
+	SkipResources bool
+	// Encryptor, if set, encrypts JEX files written by ExportJEX.
+	Encryptor Encryptor
+}
+
+// WriteJEX writes the whole vault as a JEX (Joplin Export) archive to out,
+// importable by the Joplin desktop application.
+func WriteJEX(client *goplin.Client, out io.Writer) (JEXResult, error) {
+	return WriteJEXWithOptions(client, out, JEXOptions{})
+}
+
+// WriteJEXWithOptions writes a JEX archive of the vault, or of the folder
+// given by opts.Scope, to out.
+func WriteJEXWithOptions(client *goplin.Client, out io.Writer, opts JEXOptions) (JEXResult, error) {
+	var result JEXResult
+
+	w := &jexWriter{tw: tar.NewWriter(out), now: time.Now()}
+
+	folders, err := client.GetAllFolders(jexFolderFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	// inScope is nil when the whole vault is exported.
+	var inScope map[string]bool
+
+	if len(opts.Scope) != 0 {
+		root, err := goplin.FindFolderNode(goplin.BuildFolderTree(folders), opts.Scope)
+		if err != nil {
+			return result, err
+		}
+
+		inScope = make(map[string]bool)
+		folders = nil
+
+		goplin.WalkFolders([]*goplin.FolderNode{root}, func(node *goplin.FolderNode, depth int) {
+			folder := node.Folder
+			if depth == 0 {
+				// The parent is not exported, import the folder at the top.
+				folder.ParentID = ""
+			}
+
+			inScope[folder.ID] = true
+			folders = append(folders, folder)
+		})
+	}
+
+	for _, folder := range folders {
+		err = w.item(folder.ID, serializeFolder(folder))
+		if err != nil {
+			return result, err
+		}
+
+		result.Folders++
+	}
+
+	notes, err := client.GetLiveNotes(jexNoteFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	exported := make(map[string]bool)
+	linked := make(map[string]bool)
+
+	for _, note := range notes {
+		if inScope != nil && !inScope[note.ParentID] {
+			continue
+		}
+
+		err = w.item(note.ID, serializeNote(note))
+		if err != nil {
+			return result, err
+		}
+
+		exported[note.ID] = true
+
+		for _, link := range goplin.ExtractLinks(note.Body) {
+			linked[link.TargetID] = true
+		}
+
+		result.Notes++
+	}
+
+	tags, err := client.GetAllTagsWithFields(jexTagFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	now := int(w.now.UnixMilli())
+
+	for _, tag := range tags {
+		tagged, err := client.GetNotesByTagWithFields(tag.ID, "id", "", "")
+		if err != nil {
+			return result, err
+		}
+
+		var taggedNotes []goplin.Note
+
+		for _, note := range tagged {
+			if exported[note.ID] {
+				taggedNotes = append(taggedNotes, note)
+			}
+		}
+
+		if inScope != nil && len(taggedNotes) == 0 {
+			continue
+		}
+
+		err = w.item(tag.ID, serializeTag(tag))
+		if err != nil {
+			return result, err
+		}
+
+		result.Tags++
+
+		for _, note := range taggedNotes {
+			id := goplin.GenerateID()
+
+			err = w.item(id, serializeNoteTag(id, note.ID, tag.ID, now))
+			if err != nil {
+				return result, err
+			}
+
+			result.NoteTags++
+		}
+	}
+
+	resources, err := client.GetAllResources(jexResourceFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	for _, resource := range resources {
+		if opts.SkipResources || (inScope != nil && !linked[resource.ID]) {
+			continue
+		}
+
+		var blob bytes.Buffer
+
+		err = client.GetResourceFile(resource.ID, &blob)
+		if err != nil {
+			return result, err
+		}
+
+		name := "resources/" + resource.ID
+		if len(resource.FileExtension) != 0 {
+			name += "." + resource.FileExtension
+		}
+
+		err = w.write(name, blob.Bytes())
+		if err != nil {
+			return result, err
+		}
+
+		err = w.item(resource.ID, serializeResource(resource))
+		if err != nil {
+			return result, err
+		}
+
+		result.Resources++
+	}
+
+	return result, w.tw.Close()
+}
+
+// WriteJEXFile writes a JEX archive to the named file.
+func WriteJEXFile(client *goplin.Client, path string) (JEXResult, error) {
+	return WriteEncryptedJEXFile(client, path, nil)
+}
+
+// WriteEncryptedJEXFile writes a JEX archive to the named file, encrypted by
+// enc unless it is nil.
+func WriteEncryptedJEXFile(client *goplin.Client, path string, enc Encryptor) (JEXResult, error) {
+	return ExportJEX(client, path, JEXOptions{Encryptor: enc})
+}
+
+// ExportJEX writes a JEX archive to the named file as set by opts, a local
+// path or an S3 or WebDAV URL. A failed export leaves no partial archive.
+func ExportJEX(client *goplin.Client, path string, opts JEXOptions) (JEXResult, error) {
+	f, err := CreateOutput(path, opts.Encryptor)
+	if err != nil {
+		return JEXResult{}, err
+	}
+
+	result, err := WriteJEXWithOptions(client, f, opts)
+	if err != nil {
+		sink.Abort(f)
+		return result, err
+	}
+
+	return result, f.Close()
+}
@@ -0,0 +1,201 @@
+package export
+
+import (
+	"path"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/sink"
+)
+
+// MarkdownFrontMatter are the front matter fields of a Markdown export by
+// default.
+var MarkdownFrontMatter = []string{"title", "created_time", "updated_time", "tags", "location"}
+
+// MarkdownOptions select the notes of a Markdown export.
+type MarkdownOptions struct {
+	// Scope limits the export to a folder, by ID or path, and its
+	// sub-folders, the folder itself becoming the top of the export.
+	Scope string
+	// SkipResources leaves the attachments out.
+	SkipResources bool
+	// FrontMatter lists the FrontMatterFields to write, MarkdownFrontMatter
+	// when empty.
+	FrontMatter []string
+	// Names configure the note file names, named PatternTitle by default.
+	Names SluggerOptions
+	// Encryptor, if set, encrypts each file written, see EncryptSink.
+	Encryptor Encryptor
+}
+
+// MarkdownResult counts what a Markdown export wrote.
+type MarkdownResult struct {
+	Notes     int `json:"notes"`
+	Resources int `json:"resources"`
+}
+
+// WriteMarkdown writes notes to dir, a local directory or an S3 or WebDAV
+// URL, as .md files in directories named after their folders, each with a
+// front matter. Internal links become relative links to the exported files
+// and the resources linked to are downloaded to _resources.
+func WriteMarkdown(client *goplin.Client, dir string, opts MarkdownOptions) (MarkdownResult, error) {
+	var result MarkdownResult
+
+	out, err := sink.Open(dir)
+	if err != nil {
+		return result, err
+	}
+
+	out = EncryptSink(out, opts.Encryptor)
+
+	fields := opts.FrontMatter
+	if len(fields) == 0 {
+		fields = MarkdownFrontMatter
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return result, err
+	}
+
+	names := NewSlugger(opts.Names, PatternTitle)
+
+	// The directory of each exported folder, relative to dir.
+	folders := make(map[string]string)
+
+	if len(opts.Scope) != 0 {
+		root, err := goplin.FindFolderNode(tree, opts.Scope)
+		if err != nil {
+			return result, err
+		}
+
+		folders[root.Folder.ID] = ""
+		tree = root.Children
+	}
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		var parent string
+		if node.Parent != nil {
+			parent = folders[node.Parent.Folder.ID]
+		}
+
+		folders[node.Folder.ID] = path.Join(parent, names.Segment(node.Folder.Title))
+	})
+
+	all, err := client.GetLiveNotes(frontMatterNoteFields+",parent_id,body", "", "")
+	if err != nil {
+		return result, err
+	}
+
+	tags, err := client.NoteTagTitles()
+	if err != nil {
+		return result, err
+	}
+
+	sortForNaming(all)
+
+	var notes []goplin.Note
+
+	// The exported files of notes and resources, by ID, relative to dir.
+	files := make(map[string]string)
+
+	for _, note := range all {
+		folder, ok := folders[note.ParentID]
+		if !ok {
+			continue
+		}
+
+		files[note.ID] = names.Path(folder, note.Title, note.ID, ".md")
+		notes = append(notes, note)
+	}
+
+	resources := make(map[string]goplin.Resource)
+
+	if !opts.SkipResources {
+		all, err := client.GetAllResources(hugoResourceFields, "", "")
+		if err != nil {
+			return result, err
+		}
+
+		for _, resource := range all {
+			resources[resource.ID] = resource
+		}
+	}
+
+	// The resources linked to, picked a file name the first time seen.
+	linked := make(map[string]string)
+	used := make(map[string]bool)
+
+	for _, note := range notes {
+		file := files[note.ID]
+
+		body := goplin.ReplaceLinks(note.Body, func(link goplin.Link) (string, bool) {
+			if target, ok := files[link.TargetID]; ok {
+				target = markdownLink(relativePath(file, target))
+				if len(link.Anchor) != 0 {
+					target += "#" + link.Anchor
+				}
+
+				return target, true
+			}
+
+			resource, ok := resources[link.TargetID]
+			if !ok {
+				return "", false
+			}
+
+			target, ok := linked[resource.ID]
+			if !ok {
+				target = path.Join("_resources", bundleFileName(resource, used))
+				linked[resource.ID] = target
+			}
+
+			return markdownLink(relativePath(file, target)), true
+		})
+
+		data, err := formatOrderedFrontMatter(noteFrontMatter(note, tags[note.ID], fields), body)
+		if err != nil {
+			return result, err
+		}
+
+		err = writeExportFile(out, file, data)
+		if err != nil {
+			return result, err
+		}
+
+		result.Notes++
+	}
+
+	for id, file := range linked {
+		err = downloadResource(client, out, id, file)
+		if err != nil {
+			return result, err
+		}
+
+		result.Resources++
+	}
+
+	return result, nil
+}
+
+// markdownLink escapes the spaces of a relative path, which end Markdown link
+// destinations.
+func markdownLink(p string) string {
+	return strings.ReplaceAll(p, " ", "%20")
+}
+
+// writeExportFile writes data to the file name of out.
+func writeExportFile(out sink.Sink, name string, data []byte) error {
+	f, err := out.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		sink.Abort(f)
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin/sink"
+)
+
+// Resource handling of a profile.
+const (
+	ResourcesInclude = "include"
+	ResourcesSkip    = "skip"
+)
+
+// FormatSpec tells which profile settings an export format understands.
+type FormatSpec struct {
+	// FrontMatter is set by the formats writing Markdown files with a YAML
+	// header.
+	FrontMatter bool
+	// Encryption is set by the formats which can be encrypted: the archive
+	// of a single file format, or each file of a directory format.
+	Encryption bool
+	// Drafts is set by the formats publishing pages, which may be gated by
+	// tags.
+	Drafts bool
+	// ScopeRequired is set by the formats exporting a single folder.
+	ScopeRequired bool
+	// Remote is set by the formats which can be written to S3 or WebDAV.
+	Remote bool
+}
+
+// Formats are the export formats, by name.
+var Formats = map[string]FormatSpec{
+	"jex":      {Encryption: true, Remote: true},
+	"hugo":     {FrontMatter: true, Encryption: true, Drafts: true, ScopeRequired: true},
+	"markdown": {FrontMatter: true, Encryption: true, Remote: true},
+	"obsidian": {FrontMatter: true, Encryption: true, Remote: true},
+	"dendron":  {FrontMatter: true, Encryption: true, Remote: true},
+}
+
+// FormatNames returns the names of the export formats, sorted.
+func FormatNames() []string {
+	var names []string
+
+	for name := range Formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// FrontMatterFields are the note fields a profile may put in front matter.
+var FrontMatterFields = []string{
+	"id", "title", "created_time", "updated_time", "tags", "source_url", "author", "location",
+}
+
+// Profile is a named, reusable export, read from the export_profiles section
+// of the config file:
+//
+//	export_profiles:
+//	  blog:
+//	    format: hugo
+//	    scope: Blog
+//	    publish_tag: published
+//	    front_matter: [author, source_url]
+//	    out: ~/site/content/posts
+//	  backup:
+//	    format: jex
+//	    out: ~/backups/vault-{date}.jex
+//	    encrypt_to: [age1...]
+type Profile struct {
+	Format string `mapstructure:"format"`
+	// Scope is a folder, by ID or path, exported with its sub-folders.
+	Scope string `mapstructure:"scope"`
+	// FrontMatter lists the fields written in the front matter of Markdown
+	// formats, in order.
+	FrontMatter []string `mapstructure:"front_matter"`
+	// Resources is include (the default) or skip.
+	Resources string `mapstructure:"resources"`
+	// Out is the destination, a local path or an S3 or WebDAV URL such as
+	// s3://bucket/backups/vault-{date}.jex. {date} and {time} are replaced by
+	// the date and time of the export, a leading ~/ by the home directory.
+	Out string `mapstructure:"out"`
+	// EncryptTo are the recipients to encrypt to, see NewEncryptor. The
+	// directory formats encrypt each file they write.
+	EncryptTo []string `mapstructure:"encrypt_to"`
+	// DraftTag marks drafts, PublishTag published pages.
+	DraftTag   string `mapstructure:"draft_tag"`
+	PublishTag string `mapstructure:"publish_tag"`
+	// SkipDrafts leaves drafts out of the export.
+	SkipDrafts bool `mapstructure:"skip_drafts"`
+}
+
+// Validate checks that the profile names a known format and only uses the
+// settings it understands.
+func (p Profile) Validate() error {
+	format := p.Format
+	if len(format) == 0 {
+		format = "jex"
+	}
+
+	spec, ok := Formats[format]
+	if !ok {
+		return fmt.Errorf("unknown export format '%s', expected one of %s", p.Format, strings.Join(FormatNames(), ", "))
+	}
+
+	if len(p.FrontMatter) != 0 && !spec.FrontMatter {
+		return fmt.Errorf("the %s format has no front matter", format)
+	}
+
+	for _, field := range p.FrontMatter {
+		if !contains(FrontMatterFields, field) {
+			return fmt.Errorf("unknown front matter field '%s', expected one of %s", field, strings.Join(FrontMatterFields, ", "))
+		}
+	}
+
+	switch p.Resources {
+	case "", ResourcesInclude, ResourcesSkip:
+	default:
+		return fmt.Errorf("unknown resource handling '%s', expected %s or %s", p.Resources, ResourcesInclude, ResourcesSkip)
+	}
+
+	if len(p.EncryptTo) != 0 && !spec.Encryption {
+		return fmt.Errorf("the %s format cannot be encrypted", format)
+	}
+
+	if (len(p.DraftTag) != 0 || len(p.PublishTag) != 0 || p.SkipDrafts) && !spec.Drafts {
+		return fmt.Errorf("the %s format has no drafts", format)
+	}
+
+	if len(p.Scope) == 0 && spec.ScopeRequired {
+		return fmt.Errorf("the %s format needs a scope", format)
+	}
+
+	if len(p.Out) == 0 {
+		return fmt.Errorf("no destination (out) set")
+	}
+
+	if sink.IsRemote(p.Out) && !spec.Remote {
+		return fmt.Errorf("the %s format can only be written to a local directory", format)
+	}
+
+	return nil
+}
+
+// Output returns the destination of an export run at now.
+func (p Profile) Output(now time.Time) (string, error) {
+	out := strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{time}", now.Format("150405"),
+	).Replace(p.Out)
+
+	if strings.HasPrefix(out, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		out = filepath.Join(home, out[2:])
+	}
+
+	return out, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/momo182/goplin"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Placeholders of a name pattern.
+const (
+	// PatternTitle is the title, made valid as a file name.
+	PatternTitle = "{{title}}"
+	// PatternSlug is the title as a lowercase slug, see Slug.
+	PatternSlug = "{{slug}}"
+	// PatternID is the ID of the item.
+	PatternID = "{{id}}"
+	// PatternShortID is the first ShortIDLength characters of the ID.
+	PatternShortID = "{{shortid}}"
+)
+
+// ShortIDLength is the length of the IDs shortened for file names.
+const ShortIDLength = 8
+
+// DefaultMaxNameLength is the length in bytes of the longest file name most
+// file systems accept, extension included.
+const DefaultMaxNameLength = 255
+
+// windowsReserved are the names Windows refuses for files, whatever their
+// extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// transliterations spell the letters diacritics removal leaves alone in
+// ASCII, in lowercase.
+var transliterations = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+	'ß': "ss", 'æ': "ae", 'ø': "o", 'œ': "oe", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th",
+}
+
+// SluggerOptions configure the file names of exported items.
+type SluggerOptions struct {
+	// Pattern builds the name from the placeholders PatternTitle,
+	// PatternSlug, PatternID and PatternShortID, for example
+	// "{{title}}-{{shortid}}". Empty selects the default of the exporter.
+	Pattern string `mapstructure:"pattern" json:"pattern,omitempty"`
+	// Transliterate spells titles in ASCII letters.
+	Transliterate bool `mapstructure:"transliterate" json:"transliterate,omitempty"`
+	// MaxLength is the length in bytes of the longest name, extension
+	// included, DefaultMaxNameLength when zero.
+	MaxLength int `mapstructure:"max_length" json:"max_length,omitempty"`
+}
+
+// Slugger gives out file names that are valid on common file systems,
+// Windows included, and unique without regard to case. Names only depend on
+// the items named and the names already given out, so exporting the same
+// notes twice gives the same names.
+type Slugger struct {
+	opts SluggerOptions
+	// taken holds the paths given out, in lowercase.
+	taken map[string]bool
+}
+
+// NewSlugger returns a slugger, using defaultPattern when opts has none.
+func NewSlugger(opts SluggerOptions, defaultPattern string) *Slugger {
+	if len(opts.Pattern) == 0 {
+		opts.Pattern = defaultPattern
+	}
+
+	if len(opts.Pattern) == 0 {
+		opts.Pattern = PatternTitle
+	}
+
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = DefaultMaxNameLength
+	}
+
+	return &Slugger{opts: opts, taken: make(map[string]bool)}
+}
+
+// Transliterate spells title in ASCII letters where it can: diacritics are
+// dropped and Cyrillic and a few other letters are spelled out, capitals
+// staying capitals.
+func Transliterate(title string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(t, title)
+	if err != nil {
+		folded = title
+	}
+
+	var b strings.Builder
+
+	for _, r := range folded {
+		lower := unicode.ToLower(r)
+
+		spelled, ok := transliterations[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if lower != r && len(spelled) != 0 {
+			spelled = strings.ToUpper(spelled[:1]) + spelled[1:]
+		}
+
+		b.WriteString(spelled)
+	}
+
+	return b.String()
+}
+
+func (s *Slugger) transliterate(title string) string {
+	if s.opts.Transliterate {
+		return Transliterate(title)
+	}
+
+	return title
+}
+
+// slug is Slug, after transliteration when enabled.
+func (s *Slugger) slug(title string) string {
+	return Slug(s.transliterate(title))
+}
+
+// Name returns the name of an item from the pattern, without extension and
+// without regard to the names already given out.
+func (s *Slugger) Name(title string, id string) string {
+	short := id
+	if len(short) > ShortIDLength {
+		short = short[:ShortIDLength]
+	}
+
+	name := strings.NewReplacer(
+		PatternTitle, FileName(s.transliterate(title)),
+		PatternSlug, s.slug(title),
+		PatternID, id,
+		PatternShortID, short,
+	).Replace(s.opts.Pattern)
+
+	return s.fit(FileName(name), "")
+}
+
+// Segment returns the name of a folder, which has no pattern: its title made
+// valid as a file name.
+func (s *Slugger) Segment(title string) string {
+	return s.fit(FileName(s.transliterate(title)), "")
+}
+
+// fit shortens stem so that it fits the maximum length with suffix, and
+// renames it when Windows reserves it.
+func (s *Slugger) fit(stem string, suffix string) string {
+	max := s.opts.MaxLength - len(suffix)
+
+	if len(stem) > max {
+		// Cut at the last rune starting within the limit.
+		cut := 0
+		for i := range stem {
+			if i > max {
+				break
+			}
+
+			cut = i
+		}
+
+		stem = strings.TrimRight(stem[:cut], " .")
+	}
+
+	if len(stem) == 0 {
+		stem = "Untitled"
+	}
+
+	base := stem
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+
+	if windowsReserved[strings.ToUpper(strings.TrimSpace(base))] {
+		stem = base + "_" + stem[len(base):]
+	}
+
+	return stem
+}
+
+// Path returns the path in dir of an item named from the pattern, with ext,
+// and reserves it. See Unique.
+func (s *Slugger) Path(dir string, title string, id string, ext string) string {
+	return s.Unique(dir, s.Name(title, id), id, ext)
+}
+
+// Unique returns the path of stem with ext in dir and reserves it. When it is
+// taken, the short ID of the item is appended to stem, then a counter.
+func (s *Slugger) Unique(dir string, stem string, id string, ext string) string {
+	short := id
+	if len(short) > ShortIDLength {
+		short = short[:ShortIDLength]
+	}
+
+	candidate := path.Join(dir, s.fit(stem, ext)+ext)
+
+	if s.taken[strings.ToLower(candidate)] && len(short) != 0 && !strings.HasSuffix(stem, short) {
+		suffix := "-" + short + ext
+		candidate = path.Join(dir, s.fit(stem, suffix)+suffix)
+		stem += "-" + short
+	}
+
+	for i := 2; s.taken[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf("-%d%s", i, ext)
+		candidate = path.Join(dir, s.fit(stem, suffix)+suffix)
+	}
+
+	s.Reserve(candidate)
+
+	return candidate
+}
+
+// Reserve marks a path as given out, such as the path of a file kept from a
+// previous export.
+func (s *Slugger) Reserve(p string) {
+	s.taken[strings.ToLower(p)] = true
+}
+
+// Release gives a reserved path back.
+func (s *Slugger) Release(p string) {
+	delete(s.taken, strings.ToLower(p))
+}
+
+// Taken tells whether a path was given out.
+func (s *Slugger) Taken(p string) bool {
+	return s.taken[strings.ToLower(p)]
+}
+
+// sortForNaming sorts notes oldest first, so that when titles collide the
+// oldest note keeps the plain name whatever order the notes were listed in.
+func sortForNaming(notes []goplin.Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].CreatedTime != notes[j].CreatedTime {
+			return notes[i].CreatedTime < notes[j].CreatedTime
+		}
+
+		return notes[i].ID < notes[j].ID
+	})
+}
@@ -0,0 +1,365 @@
+package export
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/sink"
+)
+
+// Wiki styles of WriteWiki.
+const (
+	// WikiObsidian nests notes in directories named after their folders.
+	WikiObsidian = "obsidian"
+	// WikiDendron writes all notes side by side, named by their dotted
+	// hierarchy of folders.
+	WikiDendron = "dendron"
+)
+
+// WikiStyles lists the styles of WriteWiki.
+var WikiStyles = []string{WikiObsidian, WikiDendron}
+
+// wikiMarkdownLinkRe matches Markdown links and images to Joplin items, with
+// an optional title.
+var wikiMarkdownLinkRe = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*:/([0-9a-fA-F]{32})(#[^\s)]*)?(?:\s+"[^"]*")?\s*\)`)
+
+// WikiOptions select the notes of a wiki export and its style.
+type WikiOptions struct {
+	Style string
+	// Scope limits the export to a folder, by ID or path, and its
+	// sub-folders, the folder itself becoming the top of the export.
+	Scope string
+	// SkipResources leaves the attachments out.
+	SkipResources bool
+	// FrontMatter lists the FrontMatterFields to write. Dendron notes always
+	// get the fields Dendron needs.
+	FrontMatter []string
+	// Names configure the note file names, named PatternTitle for Obsidian
+	// and PatternSlug for Dendron by default.
+	Names SluggerOptions
+	// Encryptor, if set, encrypts each file written, see EncryptSink.
+	Encryptor Encryptor
+}
+
+// WikiResult counts what a wiki export wrote.
+type WikiResult struct {
+	Notes     int `json:"notes"`
+	Resources int `json:"resources"`
+}
+
+type wikiNote struct {
+	note goplin.Note
+	// file is the path of the note, relative to the export directory.
+	file string
+	// name is what wikilinks to the note use.
+	name string
+}
+
+type wikiWriter struct {
+	client    *goplin.Client
+	out       sink.Sink
+	opts      WikiOptions
+	notes     map[string]*wikiNote
+	resources map[string]goplin.Resource
+	// files are the exported resources, by ID, relative to dir.
+	files map[string]string
+	used  map[string]bool
+}
+
+// WriteWiki writes notes to dir, a local directory or an S3 or WebDAV URL, as
+// a vault for Obsidian or Dendron. Internal
+// links become [[wikilinks]], the resources linked to are copied to
+// _resources (Obsidian) or assets (Dendron) and tags go in the front matter.
+func WriteWiki(client *goplin.Client, dir string, opts WikiOptions) (WikiResult, error) {
+	var result WikiResult
+
+	if opts.Style != WikiObsidian && opts.Style != WikiDendron {
+		return result, fmt.Errorf("unknown wiki style '%s', expected one of %s", opts.Style, strings.Join(WikiStyles, ", "))
+	}
+
+	out, err := sink.Open(dir)
+	if err != nil {
+		return result, err
+	}
+
+	out = EncryptSink(out, opts.Encryptor)
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return result, err
+	}
+
+	// The path of each exported folder, as a list of titles.
+	folders := make(map[string][]string)
+
+	if len(opts.Scope) != 0 {
+		root, err := goplin.FindFolderNode(tree, opts.Scope)
+		if err != nil {
+			return result, err
+		}
+
+		folders[root.Folder.ID] = nil
+		tree = root.Children
+	}
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		var parent []string
+		if node.Parent != nil {
+			parent = folders[node.Parent.Folder.ID]
+		}
+
+		folders[node.Folder.ID] = append(append([]string(nil), parent...), node.Folder.Title)
+	})
+
+	all, err := client.GetLiveNotes(frontMatterNoteFields+",parent_id,body", "", "")
+	if err != nil {
+		return result, err
+	}
+
+	tags, err := client.NoteTagTitles()
+	if err != nil {
+		return result, err
+	}
+
+	w := &wikiWriter{
+		client:    client,
+		out:       out,
+		opts:      opts,
+		notes:     make(map[string]*wikiNote),
+		resources: make(map[string]goplin.Resource),
+		files:     make(map[string]string),
+		used:      make(map[string]bool),
+	}
+
+	var order []*wikiNote
+
+	sortForNaming(all)
+
+	var names *Slugger
+	if opts.Style == WikiDendron {
+		names = NewSlugger(opts.Names, PatternSlug)
+	} else {
+		names = NewSlugger(opts.Names, PatternTitle)
+	}
+
+	bases := make(map[string]int)
+
+	for _, note := range all {
+		segments, ok := folders[note.ParentID]
+		if !ok {
+			continue
+		}
+
+		n := &wikiNote{note: note}
+
+		if opts.Style == WikiDendron {
+			var parts []string
+			for _, segment := range segments {
+				parts = append(parts, names.slug(segment))
+			}
+
+			hierarchy := strings.Join(append(parts, names.Name(note.Title, note.ID)), ".")
+
+			n.file = names.Unique("", hierarchy, note.ID, ".md")
+			n.name = strings.TrimSuffix(n.file, ".md")
+		} else {
+			var parts []string
+			for _, segment := range segments {
+				parts = append(parts, names.Segment(segment))
+			}
+
+			n.file = names.Path(path.Join(parts...), note.Title, note.ID, ".md")
+			bases[strings.ToLower(path.Base(n.file))]++
+		}
+
+		w.notes[note.ID] = n
+		order = append(order, n)
+	}
+
+	// Obsidian resolves a link by file name alone when no other note has
+	// it, else the path from the top of the vault is needed.
+	if opts.Style == WikiObsidian {
+		for _, n := range order {
+			n.name = strings.TrimSuffix(n.file, ".md")
+			if bases[strings.ToLower(path.Base(n.file))] == 1 {
+				n.name = path.Base(n.name)
+			}
+		}
+	}
+
+	if !opts.SkipResources {
+		resources, err := client.GetAllResources(hugoResourceFields, "", "")
+		if err != nil {
+			return result, err
+		}
+
+		for _, resource := range resources {
+			w.resources[resource.ID] = resource
+		}
+	}
+
+	for _, n := range order {
+		err = w.writeNote(n, tags[n.note.ID])
+		if err != nil {
+			return result, err
+		}
+
+		result.Notes++
+	}
+
+	for id, file := range w.files {
+		err = downloadResource(w.client, w.out, id, file)
+		if err != nil {
+			return result, err
+		}
+
+		result.Resources++
+	}
+
+	return result, nil
+}
+
+// resourceFile returns the path of a linked resource in the export, picking
+// one the first time the resource is seen.
+func (w *wikiWriter) resourceFile(resource goplin.Resource) string {
+	file, ok := w.files[resource.ID]
+	if ok {
+		return file
+	}
+
+	dir := "_resources"
+	if w.opts.Style == WikiDendron {
+		dir = "assets"
+	}
+
+	file = path.Join(dir, bundleFileName(resource, w.used))
+	w.files[resource.ID] = file
+
+	return file
+}
+
+func (w *wikiWriter) writeNote(n *wikiNote, tags []string) error {
+	dendron := w.opts.Style == WikiDendron
+
+	body := wikiMarkdownLinkRe.ReplaceAllStringFunc(n.note.Body, func(match string) string {
+		m := wikiMarkdownLinkRe.FindStringSubmatch(match)
+		embed, text, id, anchor := m[1], m[2], strings.ToLower(m[3]), m[4]
+
+		if target, ok := w.notes[id]; ok {
+			name := target.name + anchor
+
+			switch {
+			case len(text) == 0 || text == target.note.Title:
+				return embed + "[[" + name + "]]"
+			case dendron:
+				// Dendron puts the label first.
+				return embed + "[[" + text + "|" + name + "]]"
+			default:
+				return embed + "[[" + name + "|" + text + "]]"
+			}
+		}
+
+		resource, ok := w.resources[id]
+		if !ok {
+			return match
+		}
+
+		file := w.resourceFile(resource)
+
+		switch {
+		case dendron:
+			return fmt.Sprintf("%s[%s](/%s)", embed, text, file)
+		case len(embed) != 0 || len(text) == 0:
+			return embed + "[[" + path.Base(file) + "]]"
+		default:
+			return "[[" + path.Base(file) + "|" + text + "]]"
+		}
+	})
+
+	// The links left are not Markdown links, e.g. in HTML tags: point them
+	// to the exported files.
+	body = goplin.ReplaceLinks(body, func(link goplin.Link) (string, bool) {
+		if target, ok := w.notes[link.TargetID]; ok {
+			return relativePath(n.file, target.file), true
+		}
+
+		if resource, ok := w.resources[link.TargetID]; ok {
+			return relativePath(n.file, w.resourceFile(resource)), true
+		}
+
+		return "", false
+	})
+
+	// Tags cannot hold spaces in either tool.
+	var tagNames []string
+	for _, tag := range tags {
+		tagNames = append(tagNames, strings.ReplaceAll(tag, " ", "-"))
+	}
+
+	var fields []frontMatterField
+
+	if dendron {
+		note := n.note
+
+		fields = append(fields,
+			frontMatterField{Key: "id", Value: note.ID},
+			frontMatterField{Key: "title", Value: note.Title},
+			frontMatterField{Key: "desc", Value: ""},
+			frontMatterField{Key: "updated", Value: noteTime(note.UserUpdatedTime, note.UpdatedTime).UnixMilli()},
+			frontMatterField{Key: "created", Value: noteTime(note.UserCreatedTime, note.CreatedTime).UnixMilli()},
+		)
+	}
+
+	for _, field := range noteFrontMatter(n.note, tagNames, w.opts.FrontMatter) {
+		if dendron && (field.Key == "id" || field.Key == "title") {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	data := []byte(body)
+
+	if len(fields) != 0 {
+		var err error
+
+		data, err = formatOrderedFrontMatter(fields, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeExportFile(w.out, n.file, data)
+}
+
+// downloadResource writes the file of the resource id to the file name of
+// out.
+func downloadResource(client *goplin.Client, out sink.Sink, id string, name string) error {
+	f, err := out.Create(name)
+	if err != nil {
+		return err
+	}
+
+	err = client.GetResourceFile(id, f)
+	if err != nil {
+		sink.Abort(f)
+		return err
+	}
+
+	return f.Close()
+}
+
+// relativePath returns the slash separated path of to as seen from the
+// directory of the file from, both relative to the same directory.
+func relativePath(from string, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,81 @@
+package goplin
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// noteAlias has the fields of Note without its JSON methods.
+type noteAlias Note
+
+// noteKeys holds the JSON names of the fields modeled by Note.
+var noteKeys = jsonKeys(reflect.TypeOf(Note{}))
+
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) != 0 && name != "-" {
+			keys[name] = true
+		}
+	}
+
+	return keys
+}
+
+// UnmarshalJSON decodes a note, keeping fields not modeled by Note in Extra.
+func (n *Note) UnmarshalJSON(data []byte) error {
+	var all map[string]json.RawMessage
+
+	err := json.Unmarshal(data, (*noteAlias)(n))
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return err
+	}
+
+	n.Extra = nil
+
+	for key, value := range all {
+		if noteKeys[key] {
+			continue
+		}
+
+		if n.Extra == nil {
+			n.Extra = make(map[string]json.RawMessage)
+		}
+
+		n.Extra[key] = value
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes a note including the fields kept in Extra, so unknown
+// fields survive a round-trip. Modeled fields take precedence.
+func (n Note) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(noteAlias(n))
+	if err != nil || len(n.Extra) == 0 {
+		return data, err
+	}
+
+	var all map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range n.Extra {
+		if _, ok := all[key]; !ok {
+			all[key] = value
+		}
+	}
+
+	return json.Marshal(all)
+}
@@ -0,0 +1,264 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type fieldKind int
+
+const (
+	fieldString fieldKind = iota
+	fieldBool
+	fieldInt
+	fieldFloat
+	fieldTime
+)
+
+// writableNoteFields maps the note fields that can be changed through the API
+// to the kind of value they hold.
+var writableNoteFields = map[string]fieldKind{
+	"parent_id":          fieldString,
+	"title":              fieldString,
+	"body":               fieldString,
+	"author":             fieldString,
+	"source_url":         fieldString,
+	"source":             fieldString,
+	"source_application": fieldString,
+	"application_data":   fieldString,
+	"is_todo":            fieldBool,
+	"todo_due":           fieldTime,
+	"todo_completed":     fieldTime,
+	"markup_language":    fieldInt,
+	"latitude":           fieldFloat,
+	"longitude":          fieldFloat,
+	"altitude":           fieldFloat,
+	"order":              fieldFloat,
+	"user_created_time":  fieldTime,
+	"user_updated_time":  fieldTime,
+	"deleted_time":       fieldTime,
+	"user_data":          fieldString,
+}
+
+// CoerceNoteField converts value, typically a string from the command line or
+// a number decoded from JSON, into the type Joplin expects for field and
+// validates its range.
+func CoerceNoteField(field string, value interface{}) (interface{}, error) {
+	kind, ok := writableNoteFields[field]
+	if !ok {
+		return nil, fmt.Errorf("field '%s' is not writable", field)
+	}
+
+	s, isString := value.(string)
+
+	// Integers decoded from JSON arrive as float64.
+	if n, ok := integerValue(value); ok {
+		value = n
+	}
+
+	switch kind {
+	case fieldString:
+		if !isString {
+			return nil, fmt.Errorf("field '%s' must be a string", field)
+		}
+
+		return s, nil
+	case fieldBool:
+		switch value := value.(type) {
+		case bool:
+			if value {
+				return 1, nil
+			}
+
+			return 0, nil
+		case int:
+			if value != 0 && value != 1 {
+				return nil, fmt.Errorf("field '%s' must be 0 or 1", field)
+			}
+
+			return value, nil
+		case string:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s' must be a boolean", field)
+			}
+
+			return CoerceNoteField(field, b)
+		}
+	case fieldInt:
+		n, ok := value.(int)
+		if isString {
+			var err error
+
+			n, err = strconv.Atoi(s)
+			ok = err == nil
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("field '%s' must be an integer", field)
+		}
+
+		if field == "markup_language" && n != 1 && n != 2 {
+			return nil, fmt.Errorf("field '%s' must be 1 (Markdown) or 2 (HTML)", field)
+		}
+
+		return n, nil
+	case fieldFloat:
+		var f float64
+
+		switch value := value.(type) {
+		case float64:
+			f = value
+		case int:
+			f = float64(value)
+		case int64:
+			f = float64(value)
+		case string:
+			var err error
+
+			f, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s' must be a number", field)
+			}
+		default:
+			return nil, fmt.Errorf("field '%s' must be a number", field)
+		}
+
+		if field == "latitude" && (f < -90 || f > 90) {
+			return nil, fmt.Errorf("latitude must be between -90 and 90")
+		}
+
+		if field == "longitude" && (f < -180 || f > 180) {
+			return nil, fmt.Errorf("longitude must be between -180 and 180")
+		}
+
+		return f, nil
+	case fieldTime:
+		switch value := value.(type) {
+		case int:
+			return value, nil
+		case string:
+			if value == "" || value == "0" {
+				return 0, nil
+			}
+
+			t, err := ParseTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %w", field, err)
+			}
+
+			return int(t.UnixMilli()), nil
+		}
+
+		return nil, fmt.Errorf("field '%s' must be a date", field)
+	}
+
+	return nil, fmt.Errorf("field '%s' has an unsupported value", field)
+}
+
+// integerValue returns value as an int when it is an integer, even held in
+// an int64 or a float64.
+func integerValue(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int(value), true
+		}
+	}
+
+	return 0, false
+}
+
+// ParseFieldAssignments parses "field=value" arguments into a field map.
+func ParseFieldAssignments(assignments []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(assignments))
+
+	for _, assignment := range assignments {
+		parts := strings.SplitN(assignment, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid assignment '%s', expected field=value", assignment)
+		}
+
+		fields[strings.TrimSpace(parts[0])] = parts[1]
+	}
+
+	return fields, nil
+}
+
+// SetNoteFields updates the given fields of a note in a single request after
+// coercing and validating every value.
+func (c *Client) SetNoteFields(id string, fields map[string]interface{}) error {
+	return c.setNoteFields(id, fields, nil)
+}
+
+func (c *Client) setNoteFields(id string, fields map[string]interface{}, extra map[string]json.RawMessage) error {
+	bodyParams := make(map[string]interface{}, len(fields))
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := CoerceNoteField(name, fields[name])
+		if err != nil {
+			return err
+		}
+
+		bodyParams[name] = value
+	}
+
+	for name, value := range extra {
+		if _, ok := bodyParams[name]; !ok {
+			bodyParams[name] = value
+		}
+	}
+
+	if len(bodyParams) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	body, _ := bodyParams["body"].(string)
+
+	request, err := c.bodyRequest(id, len(body), bodyParams)
+	if err != nil {
+		return err
+	}
+
+	resp, err := request.
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		Put(fmt.Sprintf("http://%s:%d/notes/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find note with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
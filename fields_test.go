@@ -0,0 +1,64 @@
+package goplin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoerceNoteField(t *testing.T) {
+	tests := []struct {
+		field string
+		value interface{}
+		want  interface{}
+		ok    bool
+	}{
+		{"title", "Title", "Title", true},
+		{"title", 12.0, nil, false},
+		{"title", int64(12), nil, false},
+		{"created_time", "1", nil, false},
+
+		{"is_todo", true, 1, true},
+		{"is_todo", false, 0, true},
+		{"is_todo", "true", 1, true},
+		{"is_todo", "0", 0, true},
+		{"is_todo", 1.0, 1, true},
+		{"is_todo", int64(0), 0, true},
+		{"is_todo", 2, nil, false},
+		{"is_todo", 0.5, nil, false},
+		{"is_todo", "maybe", nil, false},
+
+		{"markup_language", 2, 2, true},
+		{"markup_language", 1.0, 1, true},
+		{"markup_language", int64(2), 2, true},
+		{"markup_language", "1", 1, true},
+		{"markup_language", 1.5, nil, false},
+		{"markup_language", 3, nil, false},
+		{"markup_language", "html", nil, false},
+
+		{"latitude", 48.85, 48.85, true},
+		{"latitude", 48.0, 48.0, true},
+		{"latitude", int64(-33), -33.0, true},
+		{"latitude", "-33.5", -33.5, true},
+		{"latitude", 90.5, nil, false},
+		{"longitude", int64(-181), nil, false},
+		{"longitude", "east", nil, false},
+		{"order", 1700000000000.0, 1700000000000.0, true},
+
+		{"todo_due", 1700000000000.0, 1700000000000, true},
+		{"todo_due", int64(1700000000000), 1700000000000, true},
+		{"todo_due", 1700000000000, 1700000000000, true},
+		{"todo_due", "0", 0, true},
+		{"todo_due", "", 0, true},
+		{"todo_due", 1.5, nil, false},
+		{"todo_due", 1e300, nil, false},
+		{"todo_due", "someday", nil, false},
+		{"todo_due", true, nil, false},
+	}
+
+	for _, test := range tests {
+		got, err := CoerceNoteField(test.field, test.value)
+		if (err == nil) != test.ok || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("CoerceNoteField(%q, %#v) = %#v, %v, want %#v, ok %v", test.field, test.value, got, err, test.want, test.ok)
+		}
+	}
+}
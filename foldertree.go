@@ -0,0 +1,331 @@
+package goplin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FolderNode is a folder together with its sub-folders.
+type FolderNode struct {
+	Folder   Folder        `json:"folder"`
+	Parent   *FolderNode   `json:"-"`
+	Children []*FolderNode `json:"children,omitempty"`
+}
+
+// Path returns the slash separated titles from the root down to the node.
+func (n *FolderNode) Path() string {
+	return strings.Join(n.Titles(), "/")
+}
+
+// Titles returns the titles from the root down to the node.
+func (n *FolderNode) Titles() []string {
+	var titles []string
+
+	for node := n; node != nil; node = node.Parent {
+		titles = append([]string{node.Folder.Title}, titles...)
+	}
+
+	return titles
+}
+
+// BuildFolderTree assembles folders into a forest using their parent IDs.
+// Folders whose parent is unknown become roots. Siblings are sorted by title.
+func BuildFolderTree(folders []Folder) []*FolderNode {
+	var roots []*FolderNode
+
+	nodes := make(map[string]*FolderNode, len(folders))
+
+	for _, folder := range folders {
+		nodes[folder.ID] = &FolderNode{Folder: folder}
+	}
+
+	for _, folder := range folders {
+		node := nodes[folder.ID]
+
+		parent, ok := nodes[folder.ParentID]
+		if !ok || len(folder.ParentID) == 0 {
+			roots = append(roots, node)
+			continue
+		}
+
+		node.Parent = parent
+		parent.Children = append(parent.Children, node)
+	}
+
+	sortFolderNodes(roots)
+
+	return roots
+}
+
+func sortFolderNodes(nodes []*FolderNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Folder.Title) < strings.ToLower(nodes[j].Folder.Title)
+	})
+
+	for _, node := range nodes {
+		sortFolderNodes(node.Children)
+	}
+}
+
+// WalkFolders calls fn for every node depth first, parents before children.
+func WalkFolders(nodes []*FolderNode, fn func(node *FolderNode, depth int)) {
+	var walk func(nodes []*FolderNode, depth int)
+
+	walk = func(nodes []*FolderNode, depth int) {
+		for _, node := range nodes {
+			fn(node, depth)
+			walk(node.Children, depth+1)
+		}
+	}
+
+	walk(nodes, 0)
+}
+
+// FolderNodes returns every node of the tree by folder ID.
+func FolderNodes(tree []*FolderNode) map[string]*FolderNode {
+	nodes := make(map[string]*FolderNode)
+
+	WalkFolders(tree, func(node *FolderNode, depth int) {
+		nodes[node.Folder.ID] = node
+	})
+
+	return nodes
+}
+
+// FolderPaths returns the path of every folder of the tree by folder ID, see
+// FolderNode.Path.
+func FolderPaths(tree []*FolderNode) map[string]string {
+	paths := make(map[string]string)
+
+	WalkFolders(tree, func(node *FolderNode, depth int) {
+		paths[node.Folder.ID] = node.Path()
+	})
+
+	return paths
+}
+
+// GetFolderTree fetches every folder and assembles them into a tree, see
+// BuildFolderTree.
+func (c *Client) GetFolderTree() ([]*FolderNode, error) {
+	folders, err := c.GetAllFolders("id,parent_id,title,icon", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildFolderTree(folders), nil
+}
+
+// CountNotesPerFolder returns the number of notes directly inside each folder.
+func (c *Client) CountNotesPerFolder() (map[string]int, error) {
+	counts := make(map[string]int)
+
+	notes, err := c.GetAllNotes("id,parent_id", "", "")
+	if err != nil {
+		return counts, err
+	}
+
+	for _, note := range notes {
+		counts[note.ParentID]++
+	}
+
+	return counts, nil
+}
+
+// FindEmptyFolders returns the topmost folders whose entire subtree contains no
+// notes. Subtrees holding a folder for which keep returns true are never
+// reported, so deleting the result cannot remove a kept folder.
+func FindEmptyFolders(tree []*FolderNode, notes map[string]int, keep func(node *FolderNode) bool) []*FolderNode {
+	var empty []*FolderNode
+
+	// isEmpty reports whether the whole subtree can go, collecting the topmost
+	// removable nodes of subtrees that cannot.
+	var isEmpty func(node *FolderNode) bool
+
+	isEmpty = func(node *FolderNode) bool {
+		removable := notes[node.Folder.ID] == 0 && (keep == nil || !keep(node))
+
+		var children []*FolderNode
+
+		for _, child := range node.Children {
+			if isEmpty(child) {
+				children = append(children, child)
+			} else {
+				removable = false
+			}
+		}
+
+		if !removable {
+			empty = append(empty, children...)
+		}
+
+		return removable
+	}
+
+	for _, root := range tree {
+		if isEmpty(root) {
+			empty = append(empty, root)
+		}
+	}
+
+	return empty
+}
+
+// FindFolderNode returns the folder with the given ID, slash separated title
+// path (e.g. "Work/Projects") or, when unambiguous, bare title. Titles are
+// compared case-insensitively.
+func FindFolderNode(tree []*FolderNode, idOrPath string) (*FolderNode, error) {
+	var byPath, byTitle []*FolderNode
+	var found *FolderNode
+
+	WalkFolders(tree, func(node *FolderNode, depth int) {
+		switch {
+		case node.Folder.ID == idOrPath:
+			found = node
+		case strings.EqualFold(node.Path(), idOrPath):
+			byPath = append(byPath, node)
+		case strings.EqualFold(node.Folder.Title, idOrPath):
+			byTitle = append(byTitle, node)
+		}
+	})
+
+	if found != nil {
+		return found, nil
+	}
+
+	if len(byPath) == 1 {
+		return byPath[0], nil
+	}
+
+	if len(byPath) == 0 && len(byTitle) == 1 {
+		return byTitle[0], nil
+	}
+
+	if len(byPath)+len(byTitle) == 0 {
+		return nil, fmt.Errorf("could not find folder '%s'", idOrPath)
+	}
+
+	return nil, fmt.Errorf("folder '%s' is ambiguous, use its ID or full path", idOrPath)
+}
+
+// FindFolderByPath returns the folder with the given slash separated title
+// path, such as "Work/Projects/2024", from the top level. Titles are compared
+// case-insensitively; unlike FindFolderNode, IDs and bare titles of nested
+// folders do not match.
+func FindFolderByPath(tree []*FolderNode, path string) (*FolderNode, error) {
+	var found *FolderNode
+
+	nodes := tree
+
+	for _, title := range strings.Split(strings.Trim(path, "/"), "/") {
+		found = nil
+
+		for _, node := range nodes {
+			if !strings.EqualFold(node.Folder.Title, title) {
+				continue
+			}
+
+			if found != nil {
+				return nil, fmt.Errorf("folder '%s' is ambiguous, several folders have this path", path)
+			}
+
+			found = node
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("could not find folder '%s': %w", path, ErrNotFound)
+		}
+
+		nodes = found.Children
+	}
+
+	return found, nil
+}
+
+// GetFolderByPath returns the folder with the given path, see
+// FindFolderByPath.
+func (c *Client) GetFolderByPath(path string) (Folder, error) {
+	tree, err := c.GetFolderTree()
+	if err != nil {
+		return Folder{}, err
+	}
+
+	node, err := FindFolderByPath(tree, path)
+	if err != nil {
+		return Folder{}, err
+	}
+
+	return node.Folder, nil
+}
+
+// GetNotesInScope returns the notes of the folder given by ID or path and of
+// all its sub-folders, or every note when scope is empty.
+func (c *Client) GetNotesInScope(scope string, fields string) ([]Note, error) {
+	if len(scope) == 0 {
+		return c.GetAllNotes(fields, "", "")
+	}
+
+	tree, err := c.GetFolderTree()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := FindFolderNode(tree, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []Note
+	var walkErr error
+
+	WalkFolders([]*FolderNode{root}, func(node *FolderNode, depth int) {
+		if walkErr != nil {
+			return
+		}
+
+		folderNotes, err := c.GetNotesInFolder(node.Folder.ID, fields, "", "")
+		if err != nil {
+			walkErr = err
+			return
+		}
+
+		notes = append(notes, folderNotes...)
+	})
+
+	return notes, walkErr
+}
+
+// FindOrphanNotes returns the notes whose parent folder does not exist, which
+// Joplin hides from every notebook listing.
+func FindOrphanNotes(folders []Folder, notes []Note) []Note {
+	exists := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		exists[folder.ID] = true
+	}
+
+	var orphans []Note
+
+	for _, note := range notes {
+		if !exists[note.ParentID] {
+			orphans = append(orphans, note)
+		}
+	}
+
+	return orphans
+}
+
+// GetOrphanNotes returns the notes of the vault whose parent folder does not
+// exist.
+func (c *Client) GetOrphanNotes() ([]Note, error) {
+	folders, err := c.GetAllFolders("id", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := c.GetAllNotes("id,parent_id,title,updated_time", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return FindOrphanNotes(folders, notes), nil
+}
@@ -3,21 +3,40 @@
 package goplin
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/davecgh/go-spew/spew"
-
 	"github.com/imroc/req/v3"
 )
 
 type Client struct {
-	handle   *req.Client
-	port     int
-	apiToken string
+	*clientState
+	// ctx is the context of the requests, see WithContext.
+	ctx context.Context
+}
+
+// clientState is shared by a client and the copies made by WithContext.
+type clientState struct {
+	handle    *req.Client
+	host      string
+	port      int
+	timeout   time.Duration
+	apiToken  string
+	tags      tagIndexCache
+	meta      noteMetadata
+	langs     languageIndex
+	noteOpts  CreateNoteOpts
+	largeBody LargeBodyOpts
+	caps      capabilitiesCache
+	writes    *writeQueue
+	dumpTo    io.Writer
 }
 
 type Tag struct {
@@ -68,6 +87,10 @@ type Note struct {
 	ImageDataURL         string  `json:"image_data_url,omitempty"`
 	CropRect             string  `json:"crop_rect,omitempty"`
 	Type                 int     `json:"type_,omitempty"`
+	DeletedTime          int     `json:"deleted_time,omitempty"`
+	UserData             string  `json:"user_data,omitempty"`
+	// Extra holds fields returned by Joplin which Note does not model yet.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type Folder struct {
@@ -85,6 +108,8 @@ type Folder struct {
 	ShareID                 string `json:"share_id,omitempty"`
 	MasterKeyID             string `json:"master_key_id,omitempty"`
 	Icon                    string `json:"icon,omitempty"`
+	DeletedTime             int    `json:"deleted_time,omitempty"`
+	UserData                string `json:"user_data,omitempty"`
 }
 
 type Resource struct {
@@ -106,13 +131,13 @@ type Resource struct {
 }
 
 type Event struct {
-	ID               string `json:"id"`
-	ItemType         int    `json:"item_type,omitempty"`
-	ItemID           string `json:"item_id,omitempty"`
-	Type             int    `json:"type,omitempty,omitempty"`
-	CreatedTime      int    `json:"created_time,omitempty"`
-	Source           int    `json:"Source,omitempty"`
-	BeforeChangeItem string `json:"before_change_item,omitempty"`
+	ID               string        `json:"id"`
+	ItemType         EventItemType `json:"item_type,omitempty"`
+	ItemID           string        `json:"item_id,omitempty"`
+	Type             ChangeType    `json:"type,omitempty,omitempty"`
+	CreatedTime      int           `json:"created_time,omitempty"`
+	Source           int           `json:"Source,omitempty"`
+	BeforeChangeItem string        `json:"before_change_item,omitempty"`
 }
 
 type tagsResult struct {
@@ -135,10 +160,6 @@ type Item struct {
 	ParentID string `json:"parent_id"`
 	Title    string `json:"title"`
 }
-type searchResult struct {
-	Items   []Item `json:"items"`
-	HasMore bool   `json:"has_more"`
-}
 
 type CellFormat struct {
 	Name   string
@@ -146,14 +167,28 @@ type CellFormat struct {
 	Format string
 }
 
+// Fields requested when the caller does not ask for specific ones.
+const (
+	DefaultTagFields      = "id,parent_id,title"
+	DefaultNoteFields     = "id,parent_id,title"
+	DefaultFolderFields   = "id,parent_id,title"
+	DefaultSearchFields   = "id,parent_id,title"
+	DefaultResourceFields = "id,title,mime,filename,file_extension,size,updated_time"
+)
+
 const (
 	joplinMinPortNum   = 41184
 	joplinMaxPortNum   = 41194
 	retriesGetApiToken = 20
 )
 
+// ItemTypeName is the former, misspelled, name of ItemTypeNote.
+//
+// Deprecated: use ItemTypeNote.
+const ItemTypeName = "name"
+
 const (
-	ItemTypeName               = "name"
+	ItemTypeNote               = "note"
 	ItemTypeFolder             = "folder"
 	ItemTypeSetting            = "setting"
 	ItemTypeResource           = "resource"
@@ -172,7 +207,7 @@ const (
 )
 
 var ItemTypes = []string{
-	ItemTypeName,
+	ItemTypeNote,
 	ItemTypeFolder,
 	ItemTypeSetting,
 	ItemTypeResource,
@@ -404,6 +439,16 @@ var NoteFormats = map[string]CellFormat{
 		"CropRect",
 		"%-32.32s",
 	},
+	"deleted_time": {
+		"Deleted Time",
+		"DeletedTime",
+		"%16.16d",
+	},
+	"user_data": {
+		"User Data",
+		"UserData",
+		"%-32.32s",
+	},
 }
 
 var ResourceFormats = map[string]CellFormat{
@@ -555,6 +600,16 @@ var FolderFormats = map[string]CellFormat{
 		"Icon",
 		"%-32.32s",
 	},
+	"deleted_time": {
+		"Deleted Time",
+		"DeletedTime",
+		"%16.16d",
+	},
+	"user_data": {
+		"User Data",
+		"UserData",
+		"%-32.32s",
+	},
 }
 
 var SearchFormats = map[string]CellFormat{
@@ -575,30 +630,53 @@ var SearchFormats = map[string]CellFormat{
 	},
 }
 
+// New returns a client of the Joplin instance found on the Web Clipper
+// ports. Without apiToken, a token is requested, which the user has to
+// accept in Joplin.
 func New(apiToken string) (*Client, error) {
+	return NewWithOptions(WithAPIToken(apiToken))
+}
+
+// NewWithPort returns a client of the Joplin instance listening on port, for
+// setups running several instances.
+func NewWithPort(apiToken string, port int) (*Client, error) {
+	return NewWithOptions(WithAPIToken(apiToken), WithPort(port))
+}
+
+func connect(o options) (*Client, error) {
 	var retErr error
 
 	joplinPortFound := false
 
-	// In production, create a client explicitly and reuse it to send all requests
-	// Use C() to create a client and set with chainable client settings.
-	client := req.C().
-		SetUserAgent("goplin").
-		SetTimeout(5 * time.Second)
+	apiToken, minPort, maxPort := o.apiToken, o.minPort, o.maxPort
 
-	newClient := Client{
+	client := newHandle(o)
+
+	transport := client.GetClient().Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	// The deadline of a write starts once it leaves the queue.
+	writes := newWriteQueue(&timeoutTransport{next: transport, timeout: o.timeout}, WriteQueueOpts{})
+	client.GetClient().Transport = writes
+
+	newClient := Client{clientState: &clientState{
 		handle:   client,
+		host:     o.host,
 		port:     0,
 		apiToken: apiToken,
-	}
+		timeout:  o.timeout,
+		writes:   writes,
+	}}
 
-	for i := joplinMinPortNum; i <= joplinMaxPortNum; i++ {
+	for i := minPort; i <= maxPort; i++ {
 		// Use R() to create a request and set with chainable request settings.
 		resp, err := client.R(). // Use R() to create a request and set with chainable request settings.
 						EnableDump(). // Enable dump at request level to help troubleshoot, log content only when an unexpected exception occurs.
-						Get(fmt.Sprintf("http://localhost:%d/ping", i))
+						Get(fmt.Sprintf("http://%s:%d/ping", o.host, i))
 		if err != nil {
-			retErr = err
+			retErr = redactError(err)
 			continue
 		}
 
@@ -631,6 +709,12 @@ func New(apiToken string) (*Client, error) {
 	}
 
 	if !joplinPortFound {
+		if retErr == nil && minPort == maxPort {
+			retErr = fmt.Errorf("could not find Joplin on %s port %d", o.host, minPort)
+		} else if retErr == nil {
+			retErr = fmt.Errorf("could not find Joplin on %s ports %d to %d", o.host, minPort, maxPort)
+		}
+
 		return nil, retErr
 	}
 
@@ -644,16 +728,16 @@ func (c *Client) getAuthToken() (string, error) {
 		AuthToken string `json:"auth_token"`
 	}
 
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetResult(&result).
-		Post(fmt.Sprintf("http://localhost:%d/auth", c.port))
+		Post(fmt.Sprintf("http://%s:%d/auth", c.host, c.port))
 	if err != nil {
-		return token, err
+		return token, redactError(err)
 	}
 
 	if resp.IsError() {
 		// Handle response.
-		err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return token, err
 	}
@@ -663,7 +747,7 @@ func (c *Client) getAuthToken() (string, error) {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return token, err
 }
@@ -680,19 +764,19 @@ func (c *Client) getApiToken(authToken string) (string, error) {
 	receivedApiToken := false
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetQueryParam("auth_token", authToken).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/auth/check", c.port))
+			Get(fmt.Sprintf("http://%s:%d/auth/check", c.host, c.port))
 		if err != nil {
-			retErr = err
+			retErr = redactError(err)
 			break
 		}
 
 		if resp.IsError() {
 			// Handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 			retErr = err
 
 			break
@@ -731,26 +815,28 @@ func (c *Client) getApiToken(authToken string) (string, error) {
 	return "", retErr
 }
 
-func (c *Client) GetTag(id string, fields string) (Tag, error) {
+// GetTag fetches a tag. Fields may be given as separate arguments or as a
+// single comma separated list; DefaultTagFields is used when none are given.
+func (c *Client) GetTag(id string, fields ...string) (Tag, error) {
 	var tag Tag
 
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetPathParam("id", id).
 		SetQueryParam("token", c.apiToken).
-		SetQueryParam("fields", fields).
+		SetQueryParam("fields", fieldList(DefaultTagFields, fields)).
 		SetResult(&tag).
 		SetError(&tag).
-		Get(fmt.Sprintf("http://localhost:%d/tags/{id}", c.port))
+		Get(fmt.Sprintf("http://%s:%d/tags/{id}", c.host, c.port))
 	if err != nil {
-		return tag, err
+		return tag, redactError(err)
 	}
 
 	if resp.IsError() {
 		if resp.StatusCode == 404 {
-			err = fmt.Errorf("could not find tag with IDs '%s", id)
+			err = notFoundError(resp, "could not find tag with ID '%s'", id)
 
 		} else {
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 		}
 
 		return tag, err
@@ -761,7 +847,7 @@ func (c *Client) GetTag(id string, fields string) (Tag, error) {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return tag, err
 }
@@ -775,20 +861,86 @@ func (c *Client) CreateTag(title string) error {
 		"title": title,
 	}
 
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetBody(bodyParams).
 		SetQueryParams(queryParams).
-		Post(fmt.Sprintf("http://localhost:%d/tags", c.port))
+		Post(fmt.Sprintf("http://%s:%d/tags", c.host, c.port))
 	if err != nil {
-		return err
+		return redactError(err)
 	}
 
 	if resp.IsError() {
 		if resp.StatusCode == 404 {
-			err = fmt.Errorf("could not create tag")
+			err = notFoundError(resp, "could not create tag '%s'", title)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
+
+func (c *Client) CreateTagItem(tag Tag) (Tag, error) {
+	var created Tag
+
+	bodyParams, err := itemBody(tag)
+	if err != nil {
+		return created, err
+	}
+
+	resp, err := c.r().
+		SetQueryParam("token", c.apiToken).
+		SetBody(bodyParams).
+		SetResult(&created).
+		Post(fmt.Sprintf("http://%s:%d/tags", c.host, c.port))
+	if err != nil {
+		return created, redactError(err)
+	}
+
+	if resp.IsError() {
+		err = newAPIError(resp)
+
+		return created, err
+	}
+
+	if resp.IsSuccess() {
+		return created, nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return created, err
+}
+
+func (c *Client) UpdateTag(id string, title string) error {
+	bodyParams := map[string]string{
+		"title": title,
+	}
 
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetBody(bodyParams).
+		Put(fmt.Sprintf("http://%s:%d/tags/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find tag with ID '%s'", id)
 		} else {
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 		}
 
 		return err
@@ -799,30 +951,32 @@ func (c *Client) CreateTag(title string) error {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return err
 }
 
-func (c *Client) GetNote(id string, fields string) (Note, error) {
+// GetNote fetches a note. Fields may be given as separate arguments or as a
+// single comma separated list; DefaultNoteFields is used when none are given.
+func (c *Client) GetNote(id string, fields ...string) (Note, error) {
 	var note Note
 
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetPathParam("id", id).
 		SetQueryParam("token", c.apiToken).
-		SetQueryParam("fields", fields).
+		SetQueryParam("fields", fieldList(DefaultNoteFields, fields)).
 		SetResult(&note).
 		SetError(&note).
-		Get(fmt.Sprintf("http://localhost:%d/notes/{id}", c.port))
+		Get(fmt.Sprintf("http://%s:%d/notes/{id}", c.host, c.port))
 	if err != nil {
-		return note, err
+		return note, redactError(err)
 	}
 
 	if resp.IsError() {
 		if resp.StatusCode == 404 {
-			err = fmt.Errorf("could not find note with ID '%s", id)
+			err = notFoundError(resp, "could not find note with ID '%s'", id)
 		} else {
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 		}
 
 		return note, err
@@ -833,48 +987,66 @@ func (c *Client) GetNote(id string, fields string) (Note, error) {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return note, err
 }
 
-func (c *Client) UpdateNote(id string, title string, parent_id string) error {
+// CreateNote creates a note, applying the duplicate title policy set with
+// SetCreateNoteDefaults.
+func (c *Client) CreateNote(note Note) (Note, error) {
+	return c.CreateNoteWithOpts(note, c.noteOpts)
+}
 
-	bodyParams := map[string]string{
-		"parent_id": parent_id,
-		"title":     title,
+func (c *Client) createNote(note Note) (Note, error) {
+	var created Note
+
+	bodyParams, err := itemBody(note)
+	if err != nil {
+		return created, err
 	}
 
-	resp, err := c.handle.R().
-		SetPathParam("id", id).
+	request, err := c.bodyRequest(note.ID, len(note.Body), bodyParams)
+	if err != nil {
+		return created, err
+	}
+
+	resp, err := request.
 		SetQueryParam("token", c.apiToken).
-		SetBody(bodyParams).
-		Put(fmt.Sprintf("http://localhost:%d/notes/{id}", c.port))
+		SetResult(&created).
+		Post(fmt.Sprintf("http://%s:%d/notes", c.host, c.port))
 	if err != nil {
-		return err
+		return created, redactError(err)
 	}
 
 	if resp.IsError() {
-		if resp.StatusCode == 404 {
-			err = fmt.Errorf("could not find note with ID '%s", id)
-		} else {
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
-		}
+		err = newAPIError(resp)
 
-		return err
+		return created, err
 	}
 
 	if resp.IsSuccess() {
-		return nil
+		return created, nil
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
-	return err
+	return created, err
+}
+
+// UpdateNote changes the title and folder of a note.
+//
+// Deprecated: use ApplyNoteUpdate with a NoteUpdate.
+func (c *Client) UpdateNote(id string, title string, parent_id string) error {
+	return c.ApplyNoteUpdate(NewNoteUpdate(id).SetTitle(title).SetParent(parent_id))
 }
 
 func (c *Client) GetNotesByTag(id string, orderBy string, orderDir string) ([]Note, error) {
+	return c.GetNotesByTagWithFields(id, DefaultNoteFields, orderBy, orderDir)
+}
+
+func (c *Client) GetNotesByTagWithFields(id string, fields string, orderBy string, orderDir string) ([]Note, error) {
 	var result notesResult
 	var notes []Note
 
@@ -882,7 +1054,7 @@ func (c *Client) GetNotesByTag(id string, orderBy string, orderDir string) ([]No
 
 	queryParams := map[string]string{
 		"token":  c.apiToken,
-		"fields": "id,parent_id,title",
+		"fields": fields,
 		"page":   strconv.Itoa(page),
 	}
 
@@ -895,21 +1067,21 @@ func (c *Client) GetNotesByTag(id string, orderBy string, orderDir string) ([]No
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("id", id).
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/tags/{id}/notes", c.port))
+			Get(fmt.Sprintf("http://%s:%d/tags/{id}/notes", c.host, c.port))
 		if err != nil {
-			return notes, err
+			return notes, redactError(err)
 		}
 
 		if resp.IsError() {
 			if resp.StatusCode == 404 {
-				err = fmt.Errorf("could not find note with IDs '%s", id)
+				err = notFoundError(resp, "could not find note with ID '%s'", id)
 			} else {
-				err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+				err = newAPIError(resp)
 			}
 
 			return notes, err
@@ -930,7 +1102,7 @@ func (c *Client) GetNotesByTag(id string, orderBy string, orderDir string) ([]No
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return notes, err
 	}
@@ -957,18 +1129,18 @@ func (c *Client) GetAllNotes(fields string, orderBy string, orderDir string) ([]
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/notes", c.port))
+			Get(fmt.Sprintf("http://%s:%d/notes", c.host, c.port))
 		if err != nil {
-			return notes, err
+			return notes, redactError(err)
 		}
 
 		if resp.IsError() {
 			// handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 
 			return notes, err
 		}
@@ -988,7 +1160,7 @@ func (c *Client) GetAllNotes(fields string, orderBy string, orderDir string) ([]
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return notes, err
 	}
@@ -1015,19 +1187,19 @@ func (c *Client) GetNotesInFolder(id string, fields string, orderBy string, orde
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("id", id).
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/folders/{id}/notes", c.port))
+			Get(fmt.Sprintf("http://%s:%d/folders/{id}/notes", c.host, c.port))
 		if err != nil {
-			return notes, err
+			return notes, redactError(err)
 		}
 
 		if resp.IsError() {
 			// handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 
 			return notes, err
 		}
@@ -1047,7 +1219,7 @@ func (c *Client) GetNotesInFolder(id string, fields string, orderBy string, orde
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return notes, err
 	}
@@ -1074,18 +1246,18 @@ func (c *Client) GetAllFolders(fields string, orderBy string, orderDir string) (
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/folders", c.port))
+			Get(fmt.Sprintf("http://%s:%d/folders", c.host, c.port))
 		if err != nil {
-			return folders, err
+			return folders, redactError(err)
 		}
 
 		if resp.IsError() {
 			// Handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 
 			return folders, err
 		}
@@ -1105,31 +1277,33 @@ func (c *Client) GetAllFolders(fields string, orderBy string, orderDir string) (
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return folders, err
 	}
 }
 
-func (c *Client) GetFolder(id string, fields string) (Folder, error) {
+// GetFolder fetches a folder. Fields may be given as separate arguments or as a
+// single comma separated list; DefaultFolderFields is used when none are given.
+func (c *Client) GetFolder(id string, fields ...string) (Folder, error) {
 	var folder Folder
 
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetPathParam("id", id).
 		SetQueryParam("token", c.apiToken).
-		SetQueryParam("fields", fields).
+		SetQueryParam("fields", fieldList(DefaultFolderFields, fields)).
 		SetResult(&folder).
 		SetError(&folder).
-		Get(fmt.Sprintf("http://localhost:%d/folders/{id}", c.port))
+		Get(fmt.Sprintf("http://%s:%d/folders/{id}", c.host, c.port))
 	if err != nil {
-		return folder, err
+		return folder, redactError(err)
 	}
 
 	if resp.IsError() {
 		if resp.StatusCode == 404 {
-			err = fmt.Errorf("could not find folder with ID '%s", id)
+			err = notFoundError(resp, "could not find folder with ID '%s'", id)
 		} else {
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 		}
 
 		return folder, err
@@ -1140,12 +1314,16 @@ func (c *Client) GetFolder(id string, fields string) (Folder, error) {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return folder, err
 }
 
 func (c *Client) GetAllTags(orderBy string, orderDir string) ([]Tag, error) {
+	return c.GetAllTagsWithFields(DefaultTagFields, orderBy, orderDir)
+}
+
+func (c *Client) GetAllTagsWithFields(fields string, orderBy string, orderDir string) ([]Tag, error) {
 	var result tagsResult
 	var tags []Tag
 
@@ -1153,7 +1331,7 @@ func (c *Client) GetAllTags(orderBy string, orderDir string) ([]Tag, error) {
 
 	queryParams := map[string]string{
 		"token":  c.apiToken,
-		"fields": "id,parent_id,title",
+		"fields": fields,
 		"page":   strconv.Itoa(page),
 	}
 
@@ -1166,18 +1344,18 @@ func (c *Client) GetAllTags(orderBy string, orderDir string) ([]Tag, error) {
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/tags/", c.port))
+			Get(fmt.Sprintf("http://%s:%d/tags/", c.host, c.port))
 		if err != nil {
-			return tags, err
+			return tags, redactError(err)
 		}
 
 		if resp.IsError() {
 			// Handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+			err = newAPIError(resp)
 
 			return tags, err
 		}
@@ -1197,24 +1375,24 @@ func (c *Client) GetAllTags(orderBy string, orderDir string) ([]Tag, error) {
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return tags, err
 	}
 }
 
 func (c *Client) DeleteTag(id string) error {
-	resp, err := c.handle.R().
+	resp, err := c.r().
 		SetPathParam("id", id).
 		SetQueryParam("token", c.apiToken).
-		Delete(fmt.Sprintf("http://localhost:%d/tags/{id}", c.port))
+		Delete(fmt.Sprintf("http://%s:%d/tags/{id}", c.host, c.port))
 	if err != nil {
-		return err
+		return redactError(err)
 	}
 
 	if resp.IsError() {
 		// Handle response.
-		err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return err
 	}
@@ -1224,24 +1402,34 @@ func (c *Client) DeleteTag(id string) error {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return err
 }
 
-func (c *Client) DeleteTagFromNote(tagID string, noteID string) error {
-	resp, err := c.handle.R().
-		SetPathParam("tagID", tagID).
-		SetPathParam("noteID", noteID).
-		SetQueryParam("token", c.apiToken).
-		Delete(fmt.Sprintf("http://localhost:%d/tags/{tagID}/notes/{noteID}", c.port))
+// DeleteNote deletes a note. Joplin 3.0 and later move it to the trash
+// unless permanent is set; older versions, without a trash, always delete it
+// permanently.
+func (c *Client) DeleteNote(id string, permanent bool) error {
+	r := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken)
+
+	if permanent {
+		r = r.SetQueryParam("permanent", "1")
+	}
+
+	resp, err := r.Delete(fmt.Sprintf("http://%s:%d/notes/{id}", c.host, c.port))
 	if err != nil {
-		return err
+		return redactError(err)
 	}
 
 	if resp.IsError() {
-		// Handle response.
-		err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find note with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
 
 		return err
 	}
@@ -1251,67 +1439,43 @@ func (c *Client) DeleteTagFromNote(tagID string, noteID string) error {
 	}
 
 	// Handle response.
-	err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	err = newAPIError(resp)
 
 	return err
 }
 
-func (c *Client) Search(query string, queryType string, fields string) ([]Item, error) {
-	var result searchResult
-	var items []Item
-
-	page := 1
-
-	queryParams := map[string]string{
-		"token": c.apiToken,
-		"page":  strconv.Itoa(page),
-		"query": query,
+func (c *Client) DeleteTagFromNote(tagID string, noteID string) error {
+	resp, err := c.r().
+		SetPathParam("tagID", tagID).
+		SetPathParam("noteID", noteID).
+		SetQueryParam("token", c.apiToken).
+		Delete(fmt.Sprintf("http://%s:%d/tags/{tagID}/notes/{noteID}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
 	}
 
-	if len(queryType) != 0 {
-		queryParams["type"] = queryType
-	}
+	if resp.IsError() {
+		// Handle response.
+		err = newAPIError(resp)
 
-	if len(fields) != 0 {
-		queryParams["fields"] = fields
+		return err
 	}
 
-	for {
-		resp, err := c.handle.R().
-			SetQueryParams(queryParams).
-			SetResult(&result).
-			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/search", c.port))
-		if err != nil {
-			return items, err
-		}
-
-		if resp.IsError() {
-			// Handle response.
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
-
-			return items, err
-		}
-
-		if resp.IsSuccess() {
-			items = append(items, result.Items...)
-
-			if result.HasMore {
-				page++
-
-				queryParams["page"] = strconv.Itoa(page)
-
-				continue
-			}
+	if resp.IsSuccess() {
+		return nil
+	}
 
-			return items, nil
-		}
+	// Handle response.
+	err = newAPIError(resp)
 
-		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+	return err
+}
 
-		return items, err
-	}
+// Search returns the items matching query, in Joplin's query syntax, of
+// queryType (note when empty), with their ID, parent ID and title. See
+// SearchNotes and the others for the other fields.
+func (c *Client) Search(query string, queryType string, fields string) ([]Item, error) {
+	return search[Item](c, query, queryType, fields)
 }
 
 func (c *Client) GetNoteTags(id string, orderBy string, orderDir string) ([]Tag, error) {
@@ -1322,7 +1486,7 @@ func (c *Client) GetNoteTags(id string, orderBy string, orderDir string) ([]Tag,
 
 	queryParams := map[string]string{
 		"token":  c.apiToken,
-		"fields": "id,parent_id,title",
+		"fields": DefaultTagFields,
 		"page":   strconv.Itoa(page),
 	}
 
@@ -1335,21 +1499,21 @@ func (c *Client) GetNoteTags(id string, orderBy string, orderDir string) ([]Tag,
 	}
 
 	for {
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("id", id).
 			SetQueryParams(queryParams).
 			SetResult(&result).
 			SetError(&result).
-			Get(fmt.Sprintf("http://localhost:%d/notes/{id}/tags", c.port))
+			Get(fmt.Sprintf("http://%s:%d/notes/{id}/tags", c.host, c.port))
 		if err != nil {
-			return tags, err
+			return tags, redactError(err)
 		}
 
 		if resp.IsError() {
 			if resp.StatusCode == 404 {
-				err = fmt.Errorf("could not find note with IDs '%s", id)
+				err = notFoundError(resp, "could not find note with ID '%s'", id)
 			} else {
-				err = fmt.Errorf("got error response, raw dump:\n%s", resp.Dump())
+				err = newAPIError(resp)
 			}
 
 			return tags, err
@@ -1370,7 +1534,7 @@ func (c *Client) GetNoteTags(id string, orderBy string, orderDir string) ([]Tag,
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return tags, err
 	}
@@ -1389,19 +1553,17 @@ func (c *Client) CreateTagsNotes(note_id string, tagID string) error {
 
 	for {
 		//c.handle.DevMode()
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("tagID", tagID).
 			SetBodyJsonString(fmt.Sprintf("{\"id\": \"%s\"}", note_id)).
 			SetQueryParams(queryParams).
-			Post(fmt.Sprintf("http://localhost:%d/tags/{tagID}/notes", c.port))
+			Post(fmt.Sprintf("http://%s:%d/tags/{tagID}/notes", c.host, c.port))
 		if err != nil {
-			return err
+			return redactError(err)
 		}
 
 		if resp.IsError() {
-			// Handle response.
-			spew.Dump(resp)
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Error())
+			err = newAPIError(resp)
 
 			return err
 		}
@@ -1411,52 +1573,17 @@ func (c *Client) CreateTagsNotes(note_id string, tagID string) error {
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return err
 	}
 }
 
+// UpdateNoteAuthor sets the author of a note.
+//
+// Deprecated: use ApplyNoteUpdate with a NoteUpdate.
 func (c *Client) UpdateNoteAuthor(note Note, value string) error {
-	//var result tagsResult
-
-	queryParams := map[string]string{
-		"token": c.apiToken,
-	}
-
-	bodyParams := map[string]string{
-		"author": value,
-	}
-
-	for {
-		//c.handle.DevMode()
-		resp, err := c.handle.R().
-			SetPathParam("noteid", note.ID).
-			//SetBodyJsonString(fmt.Sprintf("{\"id\": \"%s\"}", note_id)).
-			SetBody(bodyParams).
-			SetQueryParams(queryParams).
-			Put(fmt.Sprintf("http://localhost:%d/notes/{noteid}", c.port))
-		if err != nil {
-			return err
-		}
-
-		if resp.IsError() {
-			// Handle response.
-			spew.Dump(resp)
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Error())
-
-			return err
-		}
-
-		if resp.IsSuccess() {
-			return nil
-		}
-
-		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
-
-		return err
-	}
+	return c.ApplyNoteUpdate(NewNoteUpdate(note.ID).SetAuthor(value))
 }
 
 func (c *Client) GetAuthorField(note Note) (string, error) {
@@ -1469,21 +1596,19 @@ func (c *Client) GetAuthorField(note Note) (string, error) {
 
 	for {
 		//c.handle.DevMode()
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("noteid", note.ID).
 			SetQueryParam("fields", "id,title,author").
 			SetQueryParams(queryParams).
 			SetResult(&this_note).
 			SetError(&this_note).
-			Get(fmt.Sprintf("http://localhost:%d/notes/{noteid}", c.port))
+			Get(fmt.Sprintf("http://%s:%d/notes/{noteid}", c.host, c.port))
 		if err != nil {
-			return result, err
+			return result, redactError(err)
 		}
 
 		if resp.IsError() {
-			// Handle response.
-			spew.Dump(resp)
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Error())
+			err = newAPIError(resp)
 
 			return result, err
 		}
@@ -1494,7 +1619,7 @@ func (c *Client) GetAuthorField(note Note) (string, error) {
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return result, err
 	}
@@ -1514,18 +1639,16 @@ func (c *Client) CreateFolder(folder_name string, parent_id string) error {
 
 	for {
 		//c.handle.DevMode()
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetBody(bodyParams).
 			SetQueryParams(queryParams).
-			Post(fmt.Sprintf("http://localhost:%d/folders", c.port))
+			Post(fmt.Sprintf("http://%s:%d/folders", c.host, c.port))
 		if err != nil {
-			return err
+			return redactError(err)
 		}
 
 		if resp.IsError() {
-			// Handle response.
-			spew.Dump(resp)
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Error())
+			err = newAPIError(resp)
 
 			return err
 		}
@@ -1535,33 +1658,145 @@ func (c *Client) CreateFolder(folder_name string, parent_id string) error {
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
+
+		return err
+	}
+}
+
+func (c *Client) CreateFolderItem(folder Folder) (Folder, error) {
+	var created Folder
+
+	bodyParams, err := itemBody(folder)
+	if err != nil {
+		return created, err
+	}
+
+	resp, err := c.r().
+		SetQueryParam("token", c.apiToken).
+		SetBody(bodyParams).
+		SetResult(&created).
+		Post(fmt.Sprintf("http://%s:%d/folders", c.host, c.port))
+	if err != nil {
+		return created, redactError(err)
+	}
+
+	if resp.IsError() {
+		err = newAPIError(resp)
+
+		return created, err
+	}
+
+	if resp.IsSuccess() {
+		return created, nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return created, err
+}
+
+// UpdateFolder changes the title, parent and icon of a folder. Empty values
+// are left unchanged; use MoveFolder to move a folder to the top level.
+func (c *Client) UpdateFolder(id string, title string, parentID string, icon string) error {
+	bodyParams := map[string]string{}
+
+	if len(title) != 0 {
+		bodyParams["title"] = title
+	}
+
+	if len(parentID) != 0 {
+		bodyParams["parent_id"] = parentID
+	}
+
+	if len(icon) != 0 {
+		bodyParams["icon"] = icon
+	}
+
+	return c.updateFolder(id, bodyParams)
+}
+
+// MoveFolder moves a folder under newParentID, or to the top level when it is
+// empty. Moving a folder under itself or one of its descendants fails.
+func (c *Client) MoveFolder(id string, newParentID string) error {
+	if len(newParentID) != 0 {
+		tree, err := c.GetFolderTree()
+		if err != nil {
+			return err
+		}
+
+		parent, ok := FolderNodes(tree)[newParentID]
+		if !ok {
+			return fmt.Errorf("could not find folder with ID '%s': %w", newParentID, ErrNotFound)
+		}
+
+		for ancestor := parent; ancestor != nil; ancestor = ancestor.Parent {
+			if ancestor.Folder.ID == id {
+				return fmt.Errorf("cannot move folder '%s' under itself or one of its descendants", id)
+			}
+		}
+	}
+
+	return c.updateFolder(id, map[string]string{"parent_id": newParentID})
+}
+
+func (c *Client) updateFolder(id string, bodyParams map[string]string) error {
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetBody(bodyParams).
+		Put(fmt.Sprintf("http://%s:%d/folders/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find folder with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
 
 		return err
 	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
 }
 
-func (c *Client) DeleteFolder(folder_id string) error {
+// DeleteFolder deletes a folder with its notes and sub-folders. Joplin 3.0
+// and later move them to the trash unless permanent is set; older versions,
+// without a trash, always delete them permanently.
+func (c *Client) DeleteFolder(folder_id string, permanent bool) error {
 	//var result tagsResult
 
 	queryParams := map[string]string{
 		"token": c.apiToken,
 	}
 
+	if permanent {
+		queryParams["permanent"] = "1"
+	}
+
 	for {
 		//c.handle.DevMode()
-		resp, err := c.handle.R().
+		resp, err := c.r().
 			SetPathParam("folder_id", folder_id).
 			SetQueryParams(queryParams).
-			Delete(fmt.Sprintf("http://localhost:%d/folders/{folder_id}", c.port))
+			Delete(fmt.Sprintf("http://%s:%d/folders/{folder_id}", c.host, c.port))
 		if err != nil {
-			return err
+			return redactError(err)
 		}
 
 		if resp.IsError() {
-			// Handle response.
-			spew.Dump(resp)
-			err = fmt.Errorf("got error response, raw dump:\n%s", resp.Error())
+			err = newAPIError(resp)
 
 			return err
 		}
@@ -1571,7 +1806,7 @@ func (c *Client) DeleteFolder(folder_id string) error {
 		}
 
 		// Handle response.
-		err = fmt.Errorf("got unexpected response, raw dump:\n%s", resp.Dump())
+		err = newAPIError(resp)
 
 		return err
 	}
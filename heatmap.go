@@ -0,0 +1,199 @@
+package goplin
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// HeatmapDay is the writing activity of a day.
+type HeatmapDay struct {
+	Date    string `json:"date"`
+	Created int    `json:"created"`
+	Updated int    `json:"updated"`
+	Count   int    `json:"count"`
+}
+
+// Heatmap is the day by day writing activity of a year.
+type Heatmap struct {
+	Year          int          `json:"year"`
+	Days          []HeatmapDay `json:"days"`
+	Total         int          `json:"total"`
+	Max           int          `json:"max"`
+	ActiveDays    int          `json:"active_days"`
+	LongestStreak int          `json:"longest_streak"`
+	CurrentStreak int          `json:"current_streak"`
+}
+
+// BuildHeatmap counts the notes created and updated on every day of year, in
+// local time. Saves within a minute of creation are not counted as updates.
+func BuildHeatmap(notes []Note, year int) Heatmap {
+	h := Heatmap{Year: year}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+
+	for day := start; day.Year() == year; day = day.AddDate(0, 0, 1) {
+		h.Days = append(h.Days, HeatmapDay{Date: day.Format("2006-01-02")})
+	}
+
+	dayOf := func(ms int) (int, bool) {
+		t := time.UnixMilli(int64(ms))
+		if ms == 0 || t.Year() != year {
+			return 0, false
+		}
+
+		return t.YearDay() - 1, true
+	}
+
+	for _, note := range notes {
+		if i, ok := dayOf(note.CreatedTime); ok {
+			h.Days[i].Created++
+		}
+
+		if note.UpdatedTime-note.CreatedTime <= int(editGrace.Milliseconds()) {
+			continue
+		}
+
+		if i, ok := dayOf(note.UpdatedTime); ok {
+			h.Days[i].Updated++
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	streak := 0
+
+	for i := range h.Days {
+		day := &h.Days[i]
+		day.Count = day.Created + day.Updated
+
+		h.Total += day.Count
+
+		if day.Count > h.Max {
+			h.Max = day.Count
+		}
+
+		if day.Count == 0 {
+			// Today does not break the streak before anything was written.
+			if day.Date != today {
+				streak = 0
+			}
+		} else {
+			h.ActiveDays++
+			streak++
+		}
+
+		if streak > h.LongestStreak {
+			h.LongestStreak = streak
+		}
+
+		if day.Date == today {
+			h.CurrentStreak = streak
+		}
+	}
+
+	if year < time.Now().Year() {
+		h.CurrentStreak = 0
+	}
+
+	return h
+}
+
+// GetHeatmap returns the writing activity of year, using the cached note
+// metadata.
+func (c *Client) GetHeatmap(year int) (Heatmap, error) {
+	notes, err := c.getNoteMetadata()
+	if err != nil {
+		return Heatmap{}, err
+	}
+
+	return BuildHeatmap(notes, year), nil
+}
+
+// heatmapColors are the GitHub contribution colors, from no activity to the
+// busiest days.
+var heatmapColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+const (
+	heatmapCell   = 11
+	heatmapGap    = 2
+	heatmapLeft   = 28
+	heatmapTop    = 18
+	heatmapLegend = 24
+)
+
+func (h Heatmap) level(count int) int {
+	if count == 0 || h.Max == 0 {
+		return 0
+	}
+
+	return int(math.Ceil(float64(count) / float64(h.Max) * float64(len(heatmapColors)-1)))
+}
+
+// WriteSVG draws the heatmap as a contribution calendar: one column per
+// week starting on Sunday, one row per weekday.
+func (h Heatmap) WriteSVG(w io.Writer) error {
+	if len(h.Days) == 0 {
+		return fmt.Errorf("heatmap of %d has no days", h.Year)
+	}
+
+	first, err := time.ParseInLocation("2006-01-02", h.Days[0].Date, time.Local)
+	if err != nil {
+		return err
+	}
+
+	offset := int(first.Weekday())
+	weeks := (offset + len(h.Days) + 6) / 7
+
+	step := heatmapCell + heatmapGap
+	width := heatmapLeft + weeks*step
+	height := heatmapTop + 7*step + heatmapLegend
+
+	var out []string
+
+	add := func(format string, args ...interface{}) {
+		out = append(out, fmt.Sprintf(format, args...))
+	}
+
+	add(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="9" fill="#767676">`, width, height)
+	add(`<title>%d: %d notes created or updated on %d days</title>`, h.Year, h.Total, h.ActiveDays)
+
+	for i, name := range []string{"Mon", "Wed", "Fri"} {
+		add(`<text x="0" y="%d">%s</text>`, heatmapTop+(2*i+1)*step+heatmapCell-2, name)
+	}
+
+	for i, day := range h.Days {
+		cell := offset + i
+		x := heatmapLeft + cell/7*step
+		y := heatmapTop + cell%7*step
+
+		date, _ := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		if date.Day() == 1 {
+			add(`<text x="%d" y="%d">%s</text>`, x, heatmapTop-6, date.Format("Jan"))
+		}
+
+		add(`<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %d created, %d updated</title></rect>`,
+			x, y, heatmapCell, heatmapCell, heatmapColors[h.level(day.Count)], day.Date, day.Created, day.Updated)
+	}
+
+	legendY := heatmapTop + 7*step + 8
+	legendX := width - len(heatmapColors)*step - 60
+
+	add(`<text x="%d" y="%d">Less</text>`, legendX-26, legendY+heatmapCell-2)
+
+	for i, color := range heatmapColors {
+		add(`<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"/>`, legendX+i*step, legendY, heatmapCell, heatmapCell, color)
+	}
+
+	add(`<text x="%d" y="%d">More</text>`, legendX+len(heatmapColors)*step+4, legendY+heatmapCell-2)
+	add(`</svg>`)
+
+	for _, line := range out {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
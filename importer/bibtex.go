@@ -0,0 +1,650 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/momo182/goplin"
+	"golang.org/x/text/unicode/norm"
+)
+
+type BibTeXOptions struct {
+	// Folder is the title of the top-level folder the references are added
+	// to, it is created when missing.
+	Folder string
+}
+
+// BibEntry is a single reference of a BibTeX file. Field names are lower case
+// and values have their braces and LaTeX escapes removed.
+type BibEntry struct {
+	Type   string
+	Key    string
+	Fields map[string]string
+}
+
+// ParseBibTeX parses the entries of a BibTeX file as written by Zotero,
+// JabRef or BibDesk. @string abbreviations are expanded; @comment and
+// @preamble blocks are skipped.
+func ParseBibTeX(data string) ([]BibEntry, error) {
+	p := &bibParser{s: data, macros: make(map[string]string)}
+
+	var entries []BibEntry
+
+	for {
+		at := strings.IndexByte(p.s[p.pos:], '@')
+		if at < 0 {
+			return entries, nil
+		}
+
+		p.pos += at + 1
+
+		kind := strings.ToLower(p.ident())
+
+		p.space()
+
+		if p.pos >= len(p.s) || (p.s[p.pos] != '{' && p.s[p.pos] != '(') {
+			continue
+		}
+
+		closing := byte('}')
+		if p.s[p.pos] == '(' {
+			closing = ')'
+		}
+
+		p.pos++
+
+		switch kind {
+		case "comment", "preamble":
+			p.pos-- // skip the whole balanced block
+			_, _ = p.braced(p.s[p.pos], closing)
+
+			continue
+		case "string":
+			name, value, err := p.field()
+			if err != nil {
+				return entries, err
+			}
+
+			p.macros[name] = value
+			p.skipTo(closing)
+
+			continue
+		}
+
+		entry := BibEntry{Type: kind, Fields: make(map[string]string)}
+
+		p.space()
+		key := p.until(",", string(closing))
+		entry.Key = strings.TrimSpace(key)
+
+		for {
+			p.space()
+
+			if p.pos >= len(p.s) {
+				return entries, fmt.Errorf("entry '%s' is not terminated", entry.Key)
+			}
+
+			if p.s[p.pos] == closing {
+				p.pos++
+				break
+			}
+
+			if p.s[p.pos] == ',' {
+				p.pos++
+				continue
+			}
+
+			name, value, err := p.field()
+			if err != nil {
+				return entries, fmt.Errorf("entry '%s': %w", entry.Key, err)
+			}
+
+			entry.Fields[name] = value
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+type bibParser struct {
+	s      string
+	pos    int
+	macros map[string]string
+}
+
+func (p *bibParser) space() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *bibParser) ident() string {
+	start := p.pos
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if !(c == '_' || c == '-' || c == ':' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			break
+		}
+
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+func (p *bibParser) until(stops ...string) string {
+	start := p.pos
+
+	for p.pos < len(p.s) {
+		for _, stop := range stops {
+			if strings.HasPrefix(p.s[p.pos:], stop) {
+				return p.s[start:p.pos]
+			}
+		}
+
+		p.pos++
+	}
+
+	return p.s[start:]
+}
+
+func (p *bibParser) skipTo(closing byte) {
+	for p.pos < len(p.s) && p.s[p.pos] != closing {
+		p.pos++
+	}
+
+	if p.pos < len(p.s) {
+		p.pos++
+	}
+}
+
+// braced returns the content between the open delimiter at pos and its
+// matching closing one, honouring nested braces.
+func (p *bibParser) braced(open byte, closing byte) (string, error) {
+	p.pos++
+	start := p.pos
+	depth := 0
+
+	for ; p.pos < len(p.s); p.pos++ {
+		c := p.s[p.pos]
+
+		switch {
+		case c == '\\':
+			p.pos++
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case c == closing && depth == 0:
+			value := p.s[start:p.pos]
+			p.pos++
+
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("unbalanced %c", open)
+}
+
+// field parses "name = value # value ...".
+func (p *bibParser) field() (string, string, error) {
+	p.space()
+	name := strings.ToLower(p.ident())
+
+	if len(name) == 0 {
+		return "", "", fmt.Errorf("expected a field name at offset %d", p.pos)
+	}
+
+	p.space()
+
+	if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+		return "", "", fmt.Errorf("expected '=' after field '%s'", name)
+	}
+
+	p.pos++
+
+	var value strings.Builder
+
+	for {
+		p.space()
+
+		if p.pos >= len(p.s) {
+			return "", "", fmt.Errorf("field '%s' has no value", name)
+		}
+
+		switch c := p.s[p.pos]; {
+		case c == '{':
+			part, err := p.braced('{', '}')
+			if err != nil {
+				return "", "", err
+			}
+
+			value.WriteString(part)
+		case c == '"':
+			part, err := p.braced('"', '"')
+			if err != nil {
+				return "", "", err
+			}
+
+			value.WriteString(part)
+		default:
+			word := p.ident()
+			if len(word) == 0 {
+				return "", "", fmt.Errorf("invalid value for field '%s'", name)
+			}
+
+			if macro, ok := p.macros[strings.ToLower(word)]; ok {
+				word = macro
+			} else if m, ok := bibMonths[strings.ToLower(word)]; ok {
+				word = m
+			}
+
+			value.WriteString(word)
+		}
+
+		p.space()
+
+		if p.pos < len(p.s) && p.s[p.pos] == '#' {
+			p.pos++
+			continue
+		}
+
+		if bibVerbatimFields[name] {
+			return name, strings.TrimSpace(value.String()), nil
+		}
+
+		return name, cleanLaTeX(value.String()), nil
+	}
+}
+
+// bibVerbatimFields hold URLs and paths, which must not be treated as LaTeX.
+var bibVerbatimFields = map[string]bool{
+	"url":  true,
+	"doi":  true,
+	"file": true,
+}
+
+var bibMonths = map[string]string{
+	"jan": "January", "feb": "February", "mar": "March", "apr": "April",
+	"may": "May", "jun": "June", "jul": "July", "aug": "August",
+	"sep": "September", "oct": "October", "nov": "November", "dec": "December",
+}
+
+var latexAccents = map[byte]rune{
+	'"':  '\u0308',
+	'\'': '\u0301',
+	'`':  '\u0300',
+	'^':  '\u0302',
+	'~':  '\u0303',
+	'=':  '\u0304',
+	'.':  '\u0307',
+	'c':  '\u0327',
+	'v':  '\u030c',
+	'u':  '\u0306',
+	'H':  '\u030b',
+}
+
+var latexSymbols = strings.NewReplacer(
+	`\&`, "&", `\%`, "%", `\_`, "_", `\$`, "$", `\#`, "#",
+	`\ss`, "ß", `\o`, "ø", `\O`, "Ø", `\ae`, "æ", `\AE`, "Æ",
+	`\aa`, "å", `\AA`, "Å", `\l`, "ł", `\L`, "Ł",
+	`---`, "—", `--`, "–", "~", " ",
+)
+
+// cleanLaTeX turns the common LaTeX markup of BibTeX values into plain text:
+// accents become combining characters, braces and formatting commands are
+// dropped and whitespace is collapsed.
+func cleanLaTeX(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '\\' && i+1 < len(s) {
+			if mark, ok := latexAccents[s[i+1]]; ok {
+				j := i + 2
+				for j < len(s) && (s[j] == '{' || s[j] == ' ') {
+					j++
+				}
+
+				if j < len(s) && unicode.IsLetter(rune(s[j])) && (s[i+1] < 'a' || s[i+1] > 'z' || j > i+2) {
+					b.WriteByte(s[j])
+					b.WriteRune(mark)
+
+					i = j
+					for i+1 < len(s) && s[i+1] == '}' {
+						i++
+					}
+
+					continue
+				}
+			}
+
+			// Formatting commands such as \emph or \textit keep their argument.
+			j := i + 1
+			for j < len(s) && unicode.IsLetter(rune(s[j])) {
+				j++
+			}
+
+			command := s[i:j]
+			if j > i+1 && latexSymbols.Replace(command) == command {
+				i = j - 1
+				continue
+			}
+		}
+
+		if c == '{' || c == '}' {
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	text := norm.NFC.String(latexSymbols.Replace(b.String()))
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Citation formats the entry as an APA style reference.
+func (e BibEntry) Citation() string {
+	var parts []string
+
+	authors := e.Authors()
+	if len(authors) != 0 {
+		names := make([]string, len(authors))
+		for i, author := range authors {
+			names[i] = shortAuthor(author)
+		}
+
+		list := names[0]
+		if len(names) > 1 {
+			list = strings.Join(names[:len(names)-1], ", ") + ", & " + names[len(names)-1]
+		}
+
+		parts = append(parts, list)
+	}
+
+	year := e.Fields["year"]
+	if len(year) == 0 {
+		year = "n.d."
+	}
+
+	parts = append(parts, "("+year+").")
+
+	if title := e.Fields["title"]; len(title) != 0 {
+		parts = append(parts, strings.TrimSuffix(title, ".")+".")
+	}
+
+	venue := e.Fields["journal"]
+	if len(venue) == 0 {
+		venue = e.Fields["booktitle"]
+	}
+
+	if len(venue) != 0 {
+		venue = "*" + venue + "*"
+
+		if volume := e.Fields["volume"]; len(volume) != 0 {
+			venue += ", " + volume
+
+			if number := e.Fields["number"]; len(number) != 0 {
+				venue += "(" + number + ")"
+			}
+		}
+
+		if pages := e.Fields["pages"]; len(pages) != 0 {
+			venue += ", " + pages
+		}
+
+		parts = append(parts, venue+".")
+	} else if publisher := e.Fields["publisher"]; len(publisher) != 0 {
+		parts = append(parts, publisher+".")
+	}
+
+	if doi := e.Fields["doi"]; len(doi) != 0 {
+		parts = append(parts, "https://doi.org/"+doi)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Authors returns the authors as "Last, First" names.
+func (e BibEntry) Authors() []string {
+	field := e.Fields["author"]
+	if len(field) == 0 {
+		field = e.Fields["editor"]
+	}
+
+	if len(field) == 0 {
+		return nil
+	}
+
+	var authors []string
+
+	for _, name := range strings.Split(field, " and ") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		if !strings.Contains(name, ",") {
+			if i := strings.LastIndex(name, " "); i > 0 {
+				name = name[i+1:] + ", " + name[:i]
+			}
+		}
+
+		authors = append(authors, name)
+	}
+
+	return authors
+}
+
+// shortAuthor turns "Last, First Middle" into "Last, F. M.".
+func shortAuthor(name string) string {
+	parts := strings.SplitN(name, ",", 2)
+	if len(parts) == 1 {
+		return name
+	}
+
+	initials := make([]string, 0, 2)
+	for _, given := range strings.Fields(parts[1]) {
+		r := []rune(given)
+		initials = append(initials, string(r[0])+".")
+	}
+
+	if len(initials) == 0 {
+		return parts[0]
+	}
+
+	return strings.TrimSpace(parts[0]) + ", " + strings.Join(initials, " ")
+}
+
+// Keywords returns the keywords of the entry, separated by commas or
+// semicolons in the file.
+func (e BibEntry) Keywords() []string {
+	var keywords []string
+
+	for _, keyword := range strings.FieldsFunc(e.Fields["keywords"], func(r rune) bool { return r == ',' || r == ';' }) {
+		keyword = strings.TrimSpace(keyword)
+		if len(keyword) != 0 {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	return keywords
+}
+
+// Files returns the attachment paths of the entry. Zotero and JabRef store
+// them as "description:path:mime type" records separated by semicolons.
+func (e BibEntry) Files() []string {
+	var files []string
+
+	for _, record := range strings.Split(e.Fields["file"], ";") {
+		record = strings.TrimSpace(record)
+		if len(record) == 0 {
+			continue
+		}
+
+		// Colons inside paths, such as Windows drive letters, and
+		// backslashes are escaped.
+		var parts []string
+		var current strings.Builder
+
+		for i := 0; i < len(record); i++ {
+			if record[i] == '\\' && i+1 < len(record) && (record[i+1] == ':' || record[i+1] == '\\') {
+				current.WriteByte(record[i+1])
+				i++
+
+				continue
+			}
+
+			if record[i] == ':' {
+				parts = append(parts, current.String())
+				current.Reset()
+
+				continue
+			}
+
+			current.WriteByte(record[i])
+		}
+
+		parts = append(parts, current.String())
+
+		path := parts[0]
+		if len(parts) >= 3 {
+			path = strings.Join(parts[1:len(parts)-1], ":")
+		}
+
+		if len(path) != 0 {
+			files = append(files, path)
+		}
+	}
+
+	return files
+}
+
+// bibNoteBody renders the note of a reference; links holds the Markdown links
+// of the uploaded attachments.
+func bibNoteBody(e BibEntry, links []string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "> %s\n\n", e.Citation())
+
+	rows := [][]string{{"Key", "`" + e.Key + "`"}, {"Type", e.Type}}
+
+	if authors := e.Authors(); len(authors) != 0 {
+		rows = append(rows, []string{"Authors", strings.Join(authors, "; ")})
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		switch name {
+		case "title", "author", "abstract", "keywords", "file":
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		rows = append(rows, []string{name, e.Fields[name]})
+	}
+
+	b.WriteString(MarkdownTable([]string{"Field", "Value"}, rows))
+
+	if abstract := e.Fields["abstract"]; len(abstract) != 0 {
+		fmt.Fprintf(&b, "\n## Abstract\n\n%s\n", abstract)
+	}
+
+	if len(links) != 0 {
+		b.WriteString("\n## Files\n\n")
+
+		for _, link := range links {
+			fmt.Fprintf(&b, "- %s\n", link)
+		}
+	}
+
+	return b.String()
+}
+
+// ImportBibTeX creates one note per reference of a BibTeX file with its
+// citation, abstract and fields, tags it with its keywords and attaches the
+// files it lists. Relative file paths are resolved against the file's
+// directory.
+func ImportBibTeX(client *goplin.Client, bibPath string, opts BibTeXOptions) (Result, error) {
+	var result Result
+
+	if len(opts.Folder) == 0 {
+		opts.Folder = "BibTeX Import"
+	}
+
+	data, err := os.ReadFile(bibPath)
+	if err != nil {
+		return result, err
+	}
+
+	entries, err := ParseBibTeX(string(data))
+	if err != nil {
+		return result, err
+	}
+
+	batch := client.NewBatch()
+
+	rootID, err := openRoot(client, batch, opts.Folder)
+	if err != nil {
+		return result, err
+	}
+
+	tags, err := newTagger(client, batch)
+	if err != nil {
+		return result, err
+	}
+
+	files := newUploader(batch)
+
+	for _, entry := range entries {
+		title := entry.Fields["title"]
+		if len(title) == 0 {
+			title = entry.Key
+		}
+
+		var links []string
+
+		for _, file := range entry.Files() {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(bibPath), file)
+			}
+
+			_, err := os.Stat(file)
+			if err != nil {
+				result.warnf("%s: could not attach '%s': %v", entry.Key, file, err)
+				continue
+			}
+
+			id := files.upload(file, filepath.Base(file), func() (io.ReadCloser, error) {
+				return os.Open(file)
+			})
+
+			links = append(links, fmt.Sprintf("[%s](:/%s)", filepath.Base(file), id))
+		}
+
+		id := batch.CreateNote(goplin.Note{
+			ParentID:  rootID,
+			Title:     title,
+			Body:      bibNoteBody(entry, links),
+			Author:    strings.Join(entry.Authors(), "; "),
+			SourceURL: entry.Fields["url"],
+		})
+
+		tags.tag(id, entry.Keywords()...)
+	}
+
+	return result, runBatch(batch, tags, &result)
+}
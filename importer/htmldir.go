@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"golang.org/x/net/html"
+)
+
+type HTMLDirOptions struct {
+	// Folder is the title of the top-level folder created for the import.
+	Folder string
+	// Images selects how local images are handled: "inline" embeds them as
+	// data URLs, which Joplin turns into resources, "attach" uploads them.
+	Images string
+}
+
+// ImportHTMLDir imports every HTML file below dir as a note, converted by
+// Joplin from body_html. Sub-directories become folders and links between the
+// imported files become Joplin links.
+func ImportHTMLDir(client *goplin.Client, dir string, opts HTMLDirOptions) (Result, error) {
+	var result Result
+
+	if len(opts.Folder) == 0 {
+		opts.Folder = filepath.Base(filepath.Clean(dir))
+	}
+
+	pageIDs := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := strings.ToLower(filepath.Ext(p))
+		if d.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		pageIDs[filepath.ToSlash(rel)] = goplin.GenerateID()
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	batch := client.NewBatch()
+
+	rootID := createRoot(batch, opts.Folder)
+	folders := newFolderMaker(batch, rootID, func(name string) string { return name })
+	uploads := newUploader(batch)
+
+	names := make([]string, 0, len(pageIDs))
+	for name := range pageIDs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = importHTMLFile(batch, dir, name, opts, pageIDs, folders, uploads, &result)
+		if err != nil {
+			return result, fmt.Errorf("could not import '%s': %w", name, err)
+		}
+	}
+
+	return result, runBatch(batch, nil, &result)
+}
+
+func importHTMLFile(batch *goplin.Batch, dir string, name string, opts HTMLDirOptions, pageIDs map[string]string, folders *folderMaker, uploads *uploader, result *Result) error {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	title := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if t := htmlText(findElement(doc, "title")); len(t) != 0 {
+		title = t
+	} else if t := htmlText(findElement(doc, "h1")); len(t) != 0 {
+		title = t
+	}
+
+	// localFile resolves a relative reference against the file being imported.
+	localFile := func(ref string) (string, bool) {
+		if isExternalLink(ref) || strings.HasPrefix(ref, "data:") || len(ref) == 0 {
+			return "", false
+		}
+
+		unescaped, err := url.PathUnescape(strings.SplitN(ref, "#", 2)[0])
+		if err != nil {
+			unescaped = ref
+		}
+
+		return path.Clean(path.Join(path.Dir(name), unescaped)), true
+	}
+
+	walkHTML(doc, func(n *html.Node) {
+		switch n.Data {
+		case "a":
+			for i, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+
+				if target, ok := localFile(attr.Val); ok {
+					if id, ok := pageIDs[target]; ok {
+						n.Attr[i].Val = ":/" + id
+					}
+				}
+			}
+		case "img":
+			for i, attr := range n.Attr {
+				if attr.Key != "src" {
+					continue
+				}
+
+				target, ok := localFile(attr.Val)
+				if !ok {
+					continue
+				}
+
+				src, err := localImage(dir, target, opts.Images, uploads)
+				if err != nil {
+					result.warnf("%s: could not embed image '%s': %v", name, target, err)
+					continue
+				}
+
+				n.Attr[i].Val = src
+			}
+		}
+	})
+
+	var body bytes.Buffer
+
+	content := findElement(doc, "body")
+	if content == nil {
+		content = doc
+	}
+
+	for child := content.FirstChild; child != nil; child = child.NextSibling {
+		err = html.Render(&body, child)
+		if err != nil {
+			return err
+		}
+	}
+
+	batch.CreateNote(goplin.Note{
+		ID:       pageIDs[name],
+		ParentID: folders.ensure(path.Dir(name)),
+		Title:    title,
+		BodyHTML: body.String(),
+	})
+
+	return nil
+}
+
+func localImage(dir string, target string, mode string, uploads *uploader) (string, error) {
+	fullPath := filepath.Join(dir, filepath.FromSlash(target))
+
+	if mode == "attach" {
+		_, err := os.Stat(fullPath)
+		if err != nil {
+			return "", err
+		}
+
+		id := uploads.upload(target, path.Base(target), func() (io.ReadCloser, error) {
+			return os.Open(fullPath)
+		})
+
+		return ":/" + id, nil
+	}
+
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(target))
+	if len(mimeType) == 0 {
+		mimeType = http.DetectContentType(data)
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func walkHTML(n *html.Node, fn func(n *html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walkHTML(child, fn)
+	}
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, tag); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+
+	var collect func(n *html.Node)
+
+	collect = func(n *html.Node) {
+		if n == nil {
+			return
+		}
+
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+
+	collect(n)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,246 @@
+// Package importer converts notes exported by other applications into Joplin
+// folders, notes and resources.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// Result summarizes what an import created.
+type Result struct {
+	Folders   int      `json:"folders"`
+	Notes     int      `json:"notes"`
+	Resources int      `json:"resources"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+func (r *Result) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// mdLinkRe matches Markdown links and images: ![alt](target "title").
+var mdLinkRe = regexp.MustCompile(`(!?)\[([^\]]*)\]\(<?([^)\s>]+)>?(\s+"[^"]*")?\)`)
+
+// rewriteLinks replaces Markdown link targets for which resolve returns a new
+// target, leaving all other links untouched.
+func rewriteLinks(body string, resolve func(target string) (string, bool)) string {
+	return mdLinkRe.ReplaceAllStringFunc(body, func(link string) string {
+		m := mdLinkRe.FindStringSubmatch(link)
+
+		target, ok := resolve(m[3])
+		if !ok {
+			return link
+		}
+
+		return fmt.Sprintf("%s[%s](%s%s)", m[1], m[2], target, m[4])
+	})
+}
+
+// isExternalLink reports whether a link target points outside the export.
+func isExternalLink(target string) bool {
+	return strings.Contains(target, "://") ||
+		strings.HasPrefix(target, "mailto:") ||
+		strings.HasPrefix(target, ":/") ||
+		strings.HasPrefix(target, "#")
+}
+
+// folderMaker queues nested folders on demand, keyed by slash separated path.
+type folderMaker struct {
+	batch *goplin.Batch
+	ids   map[string]string
+	title func(name string) string
+}
+
+func newFolderMaker(batch *goplin.Batch, rootID string, title func(name string) string) *folderMaker {
+	return &folderMaker{
+		batch: batch,
+		ids:   map[string]string{"": rootID, ".": rootID},
+		title: title,
+	}
+}
+
+// ensure returns the ID of the folder of dir, queueing its creation and the
+// one of its parents when new. The operations queued afterwards wait for it.
+func (f *folderMaker) ensure(dir string) string {
+	dir = strings.Trim(path.Clean(dir), "/")
+
+	if id, ok := f.ids[dir]; ok {
+		return id
+	}
+
+	id := f.batch.CreateFolder(goplin.Folder{
+		Title:    f.title(path.Base(dir)),
+		ParentID: f.ensure(path.Dir(dir)),
+	})
+	f.batch.Barrier()
+
+	f.ids[dir] = id
+
+	return id
+}
+
+// createRoot queues the top-level folder an import is placed into.
+func createRoot(batch *goplin.Batch, title string) string {
+	id := batch.CreateFolder(goplin.Folder{Title: title})
+	batch.Barrier()
+
+	return id
+}
+
+// openRoot returns the top-level folder titled title, queueing its creation
+// when there is none, so repeated imports land in the same folder.
+func openRoot(client *goplin.Client, batch *goplin.Batch, title string) (string, error) {
+	folders, err := client.GetAllFolders("id,parent_id,title", "", "")
+	if err != nil {
+		return "", err
+	}
+
+	for _, folder := range folders {
+		if len(folder.ParentID) == 0 && strings.EqualFold(folder.Title, title) {
+			return folder.ID, nil
+		}
+	}
+
+	return createRoot(batch, title), nil
+}
+
+// uploader queues the upload of each source file once and remembers the
+// resource ID.
+type uploader struct {
+	batch *goplin.Batch
+	ids   map[string]string
+}
+
+func newUploader(batch *goplin.Batch) *uploader {
+	return &uploader{
+		batch: batch,
+		ids:   make(map[string]string),
+	}
+}
+
+// upload returns the resource ID of the file key, read from open when the
+// batch runs.
+func (u *uploader) upload(key string, filename string, open func() (io.ReadCloser, error)) string {
+	if id, ok := u.ids[key]; ok {
+		return id
+	}
+
+	id := u.batch.CreateResource(filename, open, goplin.Resource{Title: filename})
+	u.ids[key] = id
+
+	return id
+}
+
+// tagger attaches tags by title, reusing existing tags and queueing missing
+// ones on first use. The tags are attached once the notes are created, see
+// runBatch.
+type tagger struct {
+	batch *goplin.Batch
+	ids   map[string]string
+	links [][2]string
+}
+
+func newTagger(client *goplin.Client, batch *goplin.Batch) (*tagger, error) {
+	tags, err := client.GetAllTags("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tagger{
+		batch: batch,
+		ids:   make(map[string]string, len(tags)),
+	}
+
+	for _, tag := range tags {
+		t.ids[goplin.FoldTagTitle(tag.Title)] = tag.ID
+	}
+
+	return t, nil
+}
+
+func (t *tagger) tag(noteID string, titles ...string) {
+	for _, title := range titles {
+		title = strings.TrimSpace(title)
+		if len(title) == 0 {
+			continue
+		}
+
+		key := goplin.FoldTagTitle(title)
+
+		id, ok := t.ids[key]
+		if !ok {
+			id = t.batch.CreateTag(goplin.Tag{Title: title})
+			t.ids[key] = id
+		}
+
+		t.links = append(t.links, [2]string{noteID, id})
+	}
+}
+
+// runBatch runs the operations queued by an import, attaching the tags of
+// tags, if any, last. What was created is counted in result and the failed
+// operations, and those skipped for needing a folder or note that could not be
+// created, become warnings.
+func runBatch(batch *goplin.Batch, tags *tagger, result *Result) error {
+	if tags != nil && len(tags.links) != 0 {
+		batch.Barrier()
+
+		for _, link := range tags.links {
+			batch.TagNote(link[0], link[1])
+		}
+	}
+
+	report, err := batch.Run()
+	if err != nil {
+		return err
+	}
+
+	failed := make(map[string]bool)
+
+	for _, failure := range append(report.Failed, report.Skipped...) {
+		op := failure.Op
+		failed[op.Type+":"+op.ID] = true
+
+		name := op.Title
+		if len(name) == 0 {
+			name = op.ID
+		}
+
+		result.warnf("could not %s %s '%s': %s", op.Action, op.Type, name, failure.Err)
+	}
+
+	for _, op := range batch.Ops() {
+		if op.Action != goplin.BatchCreate || failed[op.Type+":"+op.ID] {
+			continue
+		}
+
+		switch op.Type {
+		case goplin.ItemTypeFolder:
+			result.Folders++
+		case goplin.ItemTypeNote:
+			result.Notes++
+		case goplin.ItemTypeResource:
+			result.Resources++
+		}
+	}
+
+	return nil
+}
+
+// joplinTime converts an RFC 3339 timestamp into Joplin milliseconds, or 0
+// when it cannot be parsed.
+func joplinTime(s string) int {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0
+	}
+
+	return int(t.UnixMilli())
+}
@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type JSONBackupOptions struct {
+	// Folder is the title of the top-level folder created for the import.
+	Folder string
+	// IncludeTrashed also imports notes that were in the trash.
+	IncludeTrashed bool
+}
+
+type standardNotesBackup struct {
+	Items []struct {
+		UUID        string          `json:"uuid"`
+		ContentType string          `json:"content_type"`
+		Content     json.RawMessage `json:"content"`
+		CreatedAt   string          `json:"created_at"`
+		UpdatedAt   string          `json:"updated_at"`
+		Deleted     bool            `json:"deleted"`
+	} `json:"items"`
+}
+
+type standardNotesContent struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	Trashed    bool   `json:"trashed"`
+	References []struct {
+		UUID        string `json:"uuid"`
+		ContentType string `json:"content_type"`
+	} `json:"references"`
+}
+
+// ImportStandardNotes imports a decrypted Standard Notes backup file. Tags
+// are attached through the tag references of the backup.
+func ImportStandardNotes(client *goplin.Client, backupPath string, opts JSONBackupOptions) (Result, error) {
+	var result Result
+	var backup standardNotesBackup
+
+	if len(opts.Folder) == 0 {
+		opts.Folder = "Standard Notes Import"
+	}
+
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(data, &backup)
+	if err != nil {
+		return result, err
+	}
+
+	notes := make(map[string]standardNotesContent)
+	tagsByNote := make(map[string][]string)
+
+	for _, item := range backup.Items {
+		if item.Deleted {
+			continue
+		}
+
+		var content standardNotesContent
+
+		err = json.Unmarshal(item.Content, &content)
+		if err != nil {
+			return result, fmt.Errorf("item '%s' is encrypted, export a decrypted backup", item.UUID)
+		}
+
+		switch item.ContentType {
+		case "Note":
+			if content.Trashed && !opts.IncludeTrashed {
+				continue
+			}
+
+			notes[item.UUID] = content
+		case "Tag":
+			for _, ref := range content.References {
+				tagsByNote[ref.UUID] = append(tagsByNote[ref.UUID], content.Title)
+			}
+		}
+	}
+
+	batch := client.NewBatch()
+
+	tags, err := newTagger(client, batch)
+	if err != nil {
+		return result, err
+	}
+
+	rootID := createRoot(batch, opts.Folder)
+
+	for _, item := range backup.Items {
+		content, ok := notes[item.UUID]
+		if !ok {
+			continue
+		}
+
+		id := batch.CreateNote(goplin.Note{
+			ParentID:        rootID,
+			Title:           content.Title,
+			Body:            content.Text,
+			UserCreatedTime: joplinTime(item.CreatedAt),
+			UserUpdatedTime: joplinTime(item.UpdatedAt),
+		})
+
+		tags.tag(id, tagsByNote[item.UUID]...)
+	}
+
+	return result, runBatch(batch, tags, &result)
+}
+
+type simplenoteNote struct {
+	ID           string   `json:"id"`
+	Content      string   `json:"content"`
+	CreationDate string   `json:"creationDate"`
+	LastModified string   `json:"lastModified"`
+	Tags         []string `json:"tags"`
+}
+
+type simplenoteExport struct {
+	ActiveNotes  []simplenoteNote `json:"activeNotes"`
+	TrashedNotes []simplenoteNote `json:"trashedNotes"`
+}
+
+// ImportSimplenote imports the notes.json file of a Simplenote export. The
+// first line of each note becomes its title.
+func ImportSimplenote(client *goplin.Client, exportPath string, opts JSONBackupOptions) (Result, error) {
+	var result Result
+	var export simplenoteExport
+
+	if len(opts.Folder) == 0 {
+		opts.Folder = "Simplenote Import"
+	}
+
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		return result, err
+	}
+
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		return result, err
+	}
+
+	notes := export.ActiveNotes
+	if opts.IncludeTrashed {
+		notes = append(notes, export.TrashedNotes...)
+	}
+
+	batch := client.NewBatch()
+
+	tags, err := newTagger(client, batch)
+	if err != nil {
+		return result, err
+	}
+
+	rootID := createRoot(batch, opts.Folder)
+
+	for _, sn := range notes {
+		content := strings.ReplaceAll(sn.Content, "\r\n", "\n")
+		lines := strings.SplitN(content, "\n", 2)
+
+		title := strings.TrimSpace(strings.TrimLeft(lines[0], "# "))
+		body := ""
+
+		if len(lines) == 2 {
+			body = strings.TrimLeft(lines[1], "\n")
+		}
+
+		id := batch.CreateNote(goplin.Note{
+			ParentID:        rootID,
+			Title:           title,
+			Body:            body,
+			UserCreatedTime: joplinTime(sn.CreationDate),
+			UserUpdatedTime: joplinTime(sn.LastModified),
+		})
+
+		tags.tag(id, sn.Tags...)
+	}
+
+	return result, runBatch(batch, tags, &result)
+}
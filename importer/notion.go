@@ -0,0 +1,341 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+type NotionOptions struct {
+	// Folder is the title of the top-level folder created for the import.
+	Folder string
+	// CSV selects how databases are imported: "table" creates one note holding
+	// a Markdown table, "notes" creates one note per row without its own page.
+	CSV string
+}
+
+// notionHashRe matches the ID Notion appends to every exported file name.
+var notionHashRe = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+func notionTitle(name string) string {
+	return strings.TrimSpace(notionHashRe.ReplaceAllString(name, ""))
+}
+
+func notionFileTitle(name string) string {
+	return notionTitle(strings.TrimSuffix(path.Base(name), path.Ext(name)))
+}
+
+// openNotionZip indexes the files of a Notion export by path. Exports split
+// into several parts arrive as a zip of zips, which are opened in memory.
+func openNotionZip(zipPath string) (map[string]*zip.File, io.Closer, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make(map[string]*zip.File)
+	nested := true
+
+	for _, f := range archive.File {
+		if !f.FileInfo().IsDir() && path.Ext(f.Name) != ".zip" {
+			nested = false
+		}
+	}
+
+	readers := []*zip.Reader{&archive.Reader}
+
+	if nested {
+		readers = nil
+
+		for _, f := range archive.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+
+			data, err := readZipFile(f)
+			if err != nil {
+				archive.Close()
+				return nil, nil, err
+			}
+
+			part, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				archive.Close()
+				return nil, nil, err
+			}
+
+			readers = append(readers, part)
+		}
+	}
+
+	for _, r := range readers {
+		for _, f := range r.File {
+			if !f.FileInfo().IsDir() {
+				files[strings.TrimPrefix(f.Name, "/")] = f
+			}
+		}
+	}
+
+	// Drop a single wrapping directory so its content lands in the root folder.
+	prefix := ""
+	for name := range files {
+		dir := strings.SplitN(name, "/", 2)
+		if len(dir) == 1 {
+			prefix = ""
+			break
+		}
+
+		if len(prefix) == 0 {
+			prefix = dir[0] + "/"
+		} else if prefix != dir[0]+"/" {
+			prefix = ""
+			break
+		}
+	}
+
+	if len(prefix) != 0 {
+		trimmed := make(map[string]*zip.File, len(files))
+		for name, f := range files {
+			trimmed[strings.TrimPrefix(name, prefix)] = f
+		}
+
+		files = trimmed
+	}
+
+	return files, archive, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// ImportNotion imports a Notion "Markdown & CSV" export. Pages become notes,
+// page hierarchies become folders, links between pages are rewritten to
+// Joplin links and linked files are uploaded as resources.
+func ImportNotion(client *goplin.Client, zipPath string, opts NotionOptions) (Result, error) {
+	var result Result
+
+	if len(opts.Folder) == 0 {
+		opts.Folder = "Notion Import"
+	}
+
+	files, closer, err := openNotionZip(zipPath)
+	if err != nil {
+		return result, err
+	}
+	defer closer.Close()
+
+	batch := client.NewBatch()
+
+	rootID := createRoot(batch, opts.Folder)
+	folders := newFolderMaker(batch, rootID, notionTitle)
+	uploads := newUploader(batch)
+
+	// Pre-assign IDs to every page so links can be rewritten before creation.
+	pageIDs := make(map[string]string)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+
+		if path.Ext(name) == ".md" {
+			pageIDs[name] = goplin.GenerateID()
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch path.Ext(name) {
+		case ".md":
+			err = importNotionPage(batch, files, name, pageIDs, folders, uploads, &result)
+		case ".csv":
+			if strings.HasSuffix(name, "_all.csv") {
+				if _, ok := files[strings.TrimSuffix(name, "_all.csv")+".csv"]; ok {
+					continue
+				}
+			}
+
+			err = importNotionDatabase(batch, files, name, opts.CSV, pageIDs, folders)
+		}
+
+		if err != nil {
+			return result, fmt.Errorf("could not import '%s': %w", name, err)
+		}
+	}
+
+	// The archive is read by the uploads, so it is closed once they ran.
+	return result, runBatch(batch, nil, &result)
+}
+
+func importNotionPage(batch *goplin.Batch, files map[string]*zip.File, name string, pageIDs map[string]string, folders *folderMaker, uploads *uploader, result *Result) error {
+	data, err := readZipFile(files[name])
+	if err != nil {
+		return err
+	}
+
+	title := notionFileTitle(name)
+	body := strings.TrimPrefix(string(data), "\ufeff")
+
+	// Notion repeats the page title as the first heading.
+	lines := strings.SplitN(strings.TrimLeft(body, "\n"), "\n", 2)
+	if strings.HasPrefix(lines[0], "# ") {
+		title = strings.TrimSpace(strings.TrimPrefix(lines[0], "# "))
+		body = ""
+
+		if len(lines) == 2 {
+			body = strings.TrimLeft(lines[1], "\n")
+		}
+	}
+
+	body = rewriteLinks(body, func(target string) (string, bool) {
+		if isExternalLink(target) {
+			return "", false
+		}
+
+		unescaped, err := url.PathUnescape(target)
+		if err != nil {
+			unescaped = target
+		}
+
+		full := path.Clean(path.Join(path.Dir(name), unescaped))
+
+		if id, ok := pageIDs[full]; ok {
+			return ":/" + id, true
+		}
+
+		f, ok := files[full]
+		if !ok {
+			result.warnf("%s: could not resolve link to '%s'", name, unescaped)
+			return "", false
+		}
+
+		return ":/" + uploads.upload(full, path.Base(full), f.Open), true
+	})
+
+	batch.CreateNote(goplin.Note{
+		ID:       pageIDs[name],
+		ParentID: folders.ensure(path.Dir(name)),
+		Title:    title,
+		Body:     body,
+	})
+
+	return nil
+}
+
+func importNotionDatabase(batch *goplin.Batch, files map[string]*zip.File, name string, mode string, pageIDs map[string]string, folders *folderMaker) error {
+	data, err := readZipFile(files[name])
+	if err != nil {
+		return err
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff"))).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	title := notionFileTitle(strings.TrimSuffix(name, "_all.csv") + ".csv")
+	header, rows := records[0], records[1:]
+
+	if mode != "notes" {
+		batch.CreateNote(goplin.Note{
+			ParentID: folders.ensure(path.Dir(name)),
+			Title:    title,
+			Body:     MarkdownTable(header, rows),
+		})
+
+		return nil
+	}
+
+	// Rows with their own page are imported from the page itself.
+	dir := strings.TrimSuffix(strings.TrimSuffix(name, ".csv"), "_all")
+	pages := make(map[string]bool)
+
+	for page := range pageIDs {
+		if path.Dir(page) == dir {
+			pages[notionFileTitle(page)] = true
+		}
+	}
+
+	for _, row := range rows {
+		if len(row) == 0 || pages[row[0]] {
+			continue
+		}
+
+		var body strings.Builder
+
+		for i, value := range row {
+			if i == 0 || i >= len(header) || len(value) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(&body, "- **%s:** %s\n", header[i], value)
+		}
+
+		batch.CreateNote(goplin.Note{
+			ParentID: folders.ensure(dir),
+			Title:    row[0],
+			Body:     body.String(),
+		})
+	}
+
+	return nil
+}
+
+// MarkdownTable renders a header and rows as a Markdown table.
+func MarkdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+
+	escape := func(cell string) string {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		return strings.ReplaceAll(cell, "\n", "<br>")
+	}
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+
+			b.WriteString(" " + escape(cell) + " |")
+		}
+
+		b.WriteString("\n")
+	}
+
+	writeRow(header)
+
+	b.WriteString("|")
+	for range header {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,880 @@
+//go:build integration
+
+// Package integration exercises the client against a running Joplin instance.
+//
+// Run with a Web Clipper token of a throwaway profile:
+//
+//	JOPLIN_TOKEN=... go test -tags integration ./integration/...
+//
+// The suite covers the Data API wrappers of the client: the CRUD, listing,
+// paging, search, event and user data methods. The features built on top of
+// them, such as reviews, reminders, statistics, tag maps and pipelines, are
+// outside its scope.
+//
+// Notes and folders are created inside a sandbox notebook which is deleted
+// permanently afterwards; tags and resources are deleted by each test.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+var (
+	client  *goplin.Client
+	sandbox goplin.Folder
+)
+
+func TestMain(m *testing.M) {
+	token := os.Getenv("JOPLIN_TOKEN")
+	if len(token) == 0 {
+		fmt.Println("JOPLIN_TOKEN not set, skipping integration tests")
+		os.Exit(0)
+	}
+
+	var err error
+
+	client, err = goplin.New(token)
+	if err != nil {
+		fmt.Printf("could not connect to Joplin: %v\n", err)
+		os.Exit(1)
+	}
+
+	sandbox, err = client.CreateFolderItem(goplin.Folder{
+		Title: fmt.Sprintf("goplin-integration-%d", time.Now().UnixNano()),
+	})
+	if err != nil {
+		fmt.Printf("could not create sandbox folder: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	err = client.DeleteFolder(sandbox.ID, true)
+	if err != nil {
+		fmt.Printf("could not delete sandbox folder '%s': %v\n", sandbox.ID, err)
+	}
+
+	os.Exit(code)
+}
+
+func createNote(t *testing.T, title string) goplin.Note {
+	t.Helper()
+
+	note, err := client.CreateNote(goplin.Note{
+		ParentID: sandbox.ID,
+		Title:    title,
+		Body:     "body of " + title,
+	})
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	return note
+}
+
+func createTag(t *testing.T, title string) goplin.Tag {
+	t.Helper()
+
+	tag, err := client.CreateTagItem(goplin.Tag{Title: fmt.Sprintf("%s-%d", title, time.Now().UnixNano())})
+	if err != nil {
+		t.Fatalf("CreateTagItem: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = client.DeleteTag(tag.ID)
+	})
+
+	return tag
+}
+
+func TestNotes(t *testing.T) {
+	note := createNote(t, "first")
+
+	got, err := client.GetNote(note.ID, "id,parent_id,title,body")
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+
+	if got.Title != "first" || got.ParentID != sandbox.ID || got.Body != "body of first" {
+		t.Errorf("GetNote returned %+v", got)
+	}
+
+	got, err = client.GetNote(note.ID)
+	if err != nil {
+		t.Fatalf("GetNote with default fields: %v", err)
+	}
+
+	if got.ID != note.ID || len(got.Body) != 0 {
+		t.Errorf("GetNote with default fields returned %+v", got)
+	}
+
+	err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(note.ID).SetTitle("renamed").SetAuthor("goplin"))
+	if err != nil {
+		t.Fatalf("ApplyNoteUpdate: %v", err)
+	}
+
+	err = client.SetNoteFields(note.ID, map[string]interface{}{
+		"is_todo":  "true",
+		"todo_due": "2030-01-02",
+		"latitude": "48.85",
+	})
+	if err != nil {
+		t.Fatalf("SetNoteFields: %v", err)
+	}
+
+	got, err = client.GetNote(note.ID, "id", "title", "author", "is_todo", "todo_due", "latitude")
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+
+	if got.Title != "renamed" || got.Author != "goplin" || got.IsTodo != 1 || got.TodoDue == 0 || got.Latitude != 48.85 {
+		t.Errorf("note not updated: %+v", got)
+	}
+
+	err = client.SetNoteFields(note.ID, map[string]interface{}{"id": "nope"})
+	if err == nil {
+		t.Errorf("SetNoteFields accepted a read-only field")
+	}
+
+	author, err := client.GetAuthorField(note)
+	if err != nil || author != "goplin" {
+		t.Errorf("GetAuthorField = %q, %v", author, err)
+	}
+
+	notes, err := client.GetNotesInFolder(sandbox.ID, "id,title", "title", "asc")
+	if err != nil {
+		t.Fatalf("GetNotesInFolder: %v", err)
+	}
+
+	found := false
+	for _, n := range notes {
+		found = found || n.ID == note.ID
+	}
+
+	if !found {
+		t.Errorf("GetNotesInFolder did not return note '%s'", note.ID)
+	}
+
+	_, err = client.GetNote("00000000000000000000000000000000")
+	if err == nil {
+		t.Errorf("GetNote of a missing note did not fail")
+	}
+}
+
+func TestTags(t *testing.T) {
+	note := createNote(t, "tagged")
+	tag := createTag(t, "goplin-tag")
+
+	err := client.CreateTagsNotes(note.ID, tag.ID)
+	if err != nil {
+		t.Fatalf("CreateTagsNotes: %v", err)
+	}
+
+	tags, err := client.GetNoteTags(note.ID, "", "")
+	if err != nil || len(tags) != 1 || tags[0].ID != tag.ID {
+		t.Errorf("GetNoteTags = %+v, %v", tags, err)
+	}
+
+	notes, err := client.GetNotesByTag(tag.ID, "", "")
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Errorf("GetNotesByTag = %+v, %v", notes, err)
+	}
+
+	err = client.UpdateTag(tag.ID, tag.Title+"-renamed")
+	if err != nil {
+		t.Fatalf("UpdateTag: %v", err)
+	}
+
+	got, err := client.GetTag(tag.ID)
+	if err != nil || got.Title != tag.Title+"-renamed" {
+		t.Errorf("GetTag = %+v, %v", got, err)
+	}
+
+	all, err := client.GetAllTags("title", "asc")
+	if err != nil || len(all) == 0 {
+		t.Errorf("GetAllTags = %d tags, %v", len(all), err)
+	}
+
+	err = client.DeleteTagFromNote(tag.ID, note.ID)
+	if err != nil {
+		t.Fatalf("DeleteTagFromNote: %v", err)
+	}
+
+	tags, err = client.GetNoteTags(note.ID, "", "")
+	if err != nil || len(tags) != 0 {
+		t.Errorf("GetNoteTags after delete = %+v, %v", tags, err)
+	}
+}
+
+func TestFolders(t *testing.T) {
+	parent, err := client.CreateFolderItem(goplin.Folder{Title: "parent", ParentID: sandbox.ID})
+	if err != nil {
+		t.Fatalf("CreateFolderItem: %v", err)
+	}
+
+	child, err := client.CreateFolderItem(goplin.Folder{Title: "child", ParentID: parent.ID})
+	if err != nil {
+		t.Fatalf("CreateFolderItem: %v", err)
+	}
+
+	err = client.MoveFolder(parent.ID, child.ID)
+	if err == nil {
+		t.Errorf("MoveFolder allowed moving a folder under its own child")
+	}
+
+	err = client.UpdateFolder(child.ID, "renamed child", "", "")
+	if err != nil {
+		t.Fatalf("UpdateFolder: %v", err)
+	}
+
+	err = client.MoveFolder(child.ID, sandbox.ID)
+	if err != nil {
+		t.Fatalf("MoveFolder: %v", err)
+	}
+
+	got, err := client.GetFolder(child.ID)
+	if err != nil || got.Title != "renamed child" || got.ParentID != sandbox.ID {
+		t.Errorf("GetFolder = %+v, %v", got, err)
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		t.Fatalf("GetFolderTree: %v", err)
+	}
+
+	var sandboxNode *goplin.FolderNode
+
+	goplin.WalkFolders(tree, func(node *goplin.FolderNode, depth int) {
+		if node.Folder.ID == sandbox.ID {
+			sandboxNode = node
+		}
+	})
+
+	if sandboxNode == nil || len(sandboxNode.Children) < 2 {
+		t.Errorf("sandbox folder missing from tree or without children")
+	}
+
+	folders, err := client.GetAllFolders("id,title", "", "")
+	if err != nil || len(folders) < 3 {
+		t.Errorf("GetAllFolders = %d folders, %v", len(folders), err)
+	}
+
+	err = client.CreateFolder("legacy", sandbox.ID)
+	if err != nil {
+		t.Errorf("CreateFolder: %v", err)
+	}
+}
+
+func TestResources(t *testing.T) {
+	content := []byte("hello from goplin")
+
+	resource, err := client.CreateResource("hello.txt", bytes.NewReader(content), goplin.Resource{})
+	if err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = client.DeleteResource(resource.ID)
+	})
+
+	var blob bytes.Buffer
+
+	err = client.GetResourceFile(resource.ID, &blob)
+	if err != nil {
+		t.Fatalf("GetResourceFile: %v", err)
+	}
+
+	if !bytes.Equal(blob.Bytes(), content) {
+		t.Errorf("GetResourceFile returned %q", blob.String())
+	}
+
+	resources, err := client.GetAllResources("id,title", "", "")
+	if err != nil || len(resources) == 0 {
+		t.Errorf("GetAllResources = %d resources, %v", len(resources), err)
+	}
+
+	err = client.UpdateResource(resource.ID, goplin.Resource{Title: "renamed.txt"})
+	if err != nil {
+		t.Fatalf("UpdateResource: %v", err)
+	}
+
+	got, err := client.GetResource(resource.ID, "id", "title")
+	if err != nil || got.Title != "renamed.txt" {
+		t.Errorf("GetResource = %+v, %v", got, err)
+	}
+
+	updated := []byte("updated by goplin")
+
+	err = client.UpdateResourceFile(resource.ID, "hello.txt", bytes.NewReader(updated))
+	if err != nil {
+		t.Fatalf("UpdateResourceFile: %v", err)
+	}
+
+	blob.Reset()
+
+	err = client.GetResourceFile(resource.ID, &blob)
+	if err != nil || !bytes.Equal(blob.Bytes(), updated) {
+		t.Errorf("GetResourceFile after update = %q, %v", blob.String(), err)
+	}
+
+	note, err := client.CreateNote(goplin.Note{
+		ParentID: sandbox.ID,
+		Title:    "linking",
+		Body:     fmt.Sprintf("[hello](:/%s)", resource.ID),
+	})
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+
+	notes, err := client.GetResourceNotes(resource.ID, "id")
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Errorf("GetResourceNotes = %+v, %v", notes, err)
+	}
+
+	err = client.DeleteResource(resource.ID)
+	if err != nil {
+		t.Fatalf("DeleteResource: %v", err)
+	}
+
+	err = client.GetResourceFile(resource.ID, &blob)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetResourceFile of a deleted resource = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	err := client.ValidateToken()
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	invalid, err := goplin.New("invalid-token")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	err = invalid.ValidateToken()
+	if !errors.Is(err, goplin.ErrInvalidToken) {
+		t.Errorf("ValidateToken of an invalid token = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	const missing = "00000000000000000000000000000000"
+
+	_, err := client.GetNote(missing)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetNote = %v, want ErrNotFound", err)
+	}
+
+	_, err = client.GetFolder(missing)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetFolder = %v, want ErrNotFound", err)
+	}
+
+	_, err = client.GetTag(missing)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetTag = %v, want ErrNotFound", err)
+	}
+
+	_, err = client.GetResource(missing)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetResource = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteNote(t *testing.T) {
+	note := createNote(t, "deleted")
+
+	err := client.DeleteNote(note.ID, true)
+	if err != nil {
+		t.Fatalf("DeleteNote: %v", err)
+	}
+
+	_, err = client.GetNote(note.ID)
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetNote of a deleted note = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAppData(t *testing.T) {
+	note := createNote(t, "app data")
+
+	err := client.SetAppData(note.ID, "goplin_test", map[string]interface{}{"answer": 42})
+	if err != nil {
+		t.Fatalf("SetAppData: %v", err)
+	}
+
+	got, err := client.GetNote(note.ID, "id", "application_data")
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+
+	value, err := goplin.GetAppData(got, "goplin_test")
+	if m, ok := value.(map[string]interface{}); err != nil || !ok || m["answer"] != float64(42) {
+		t.Errorf("GetAppData = %v, %v", value, err)
+	}
+}
+
+func TestQueryNotes(t *testing.T) {
+	note := createNote(t, "queried")
+
+	notes, err := client.QueryNotes(goplin.NoteQuery{Folder: sandbox.ID}, "", "", "")
+	if err != nil {
+		t.Fatalf("QueryNotes: %v", err)
+	}
+
+	found := false
+	for _, n := range notes {
+		found = found || (n.ID == note.ID && n.Title == "queried")
+	}
+
+	if !found {
+		t.Errorf("QueryNotes did not return note '%s' with its title", note.ID)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	_, cursor, err := client.GetEvents("")
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+
+	note := createNote(t, "evented")
+
+	events, _, err := client.GetEvents(cursor)
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+
+	found := false
+	for _, event := range events {
+		found = found || (event.ItemType == goplin.EventItemNote && event.ItemID == note.ID && event.Type == goplin.EventCreated)
+	}
+
+	if !found {
+		t.Errorf("GetEvents did not report the creation of note '%s'", note.ID)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	batch := client.NewBatch()
+
+	folderID := batch.CreateFolder(goplin.Folder{Title: "batch", ParentID: sandbox.ID})
+	tagID := batch.CreateTag(goplin.Tag{Title: fmt.Sprintf("goplin-batch-%d", time.Now().UnixNano())})
+	batch.Barrier()
+
+	noteID := batch.CreateNote(goplin.Note{ParentID: folderID, Title: "batched"})
+	batch.Barrier()
+
+	batch.TagNote(noteID, tagID)
+
+	t.Cleanup(func() {
+		_ = client.DeleteTag(tagID)
+	})
+
+	report, err := batch.Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if report.Succeeded != 4 || len(report.Failed) != 0 {
+		t.Errorf("Run = %+v", report)
+	}
+
+	note, err := client.GetNote(noteID, "id", "parent_id", "title")
+	if err != nil || note.ParentID != folderID || note.Title != "batched" {
+		t.Errorf("GetNote = %+v, %v", note, err)
+	}
+
+	tags, err := client.GetNoteTags(noteID, "", "")
+	if err != nil || len(tags) != 1 || tags[0].ID != tagID {
+		t.Errorf("GetNoteTags = %+v, %v", tags, err)
+	}
+}
+
+func TestSearchAndStats(t *testing.T) {
+	marker := fmt.Sprintf("goplinsearch%d", time.Now().UnixNano())
+	createNote(t, marker)
+
+	// The search index is updated asynchronously.
+	var items []goplin.Item
+	var err error
+
+	for i := 0; i < 10; i++ {
+		items, err = client.Search(marker, "", "")
+		if err == nil && len(items) != 0 {
+			break
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	if err != nil || len(items) != 1 || !strings.Contains(items[0].Title, marker) {
+		t.Errorf("Search = %+v, %v", items, err)
+	}
+
+	stats, err := client.GetStats()
+	if err != nil || stats.Notes == 0 || stats.Folders == 0 {
+		t.Errorf("GetStats = %+v, %v", stats, err)
+	}
+
+	_, err = client.TagStats()
+	if err != nil {
+		t.Errorf("TagStats: %v", err)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	note := createNote(t, "raw")
+	tag := createTag(t, "goplin-raw")
+
+	got, raw, err := client.GetNoteRaw(note.ID, "id", "title")
+	if err != nil || got.Title != "raw" || !bytes.Contains(raw, []byte(`"title":"raw"`)) {
+		t.Errorf("GetNoteRaw = %+v, %s, %v", got, raw, err)
+	}
+
+	folder, raw, err := client.GetFolderRaw(sandbox.ID, "id", "title")
+	if err != nil || folder.Title != sandbox.Title || len(raw) == 0 {
+		t.Errorf("GetFolderRaw = %+v, %s, %v", folder, raw, err)
+	}
+
+	gotTag, raw, err := client.GetTagRaw(tag.ID)
+	if err != nil || gotTag.Title != tag.Title || len(raw) == 0 {
+		t.Errorf("GetTagRaw = %+v, %s, %v", gotTag, raw, err)
+	}
+
+	item, err := client.GetItemMap("notes", note.ID, "id", "parent_id")
+	if err != nil || item["parent_id"] != sandbox.ID {
+		t.Errorf("GetItemMap = %v, %v", item, err)
+	}
+}
+
+func TestPagesAndIterators(t *testing.T) {
+	createNote(t, "paged one")
+	createNote(t, "paged two")
+	tag := createTag(t, "goplin-paged")
+
+	page, err := client.NotesPage("id,title", "", "", 1, 1)
+	if err != nil || len(page.Items) != 1 || !page.HasMore || page.Next() != 2 {
+		t.Errorf("NotesPage = %+v, %v", page, err)
+	}
+
+	folders, err := client.FoldersPage("id", "", "", 1, 100)
+	if err != nil || len(folders.Items) == 0 {
+		t.Errorf("FoldersPage = %+v, %v", folders, err)
+	}
+
+	tags, err := client.TagsPage("id", "", "", 1, 100)
+	if err != nil || len(tags.Items) == 0 {
+		t.Errorf("TagsPage = %+v, %v", tags, err)
+	}
+
+	notes := 0
+
+	it := client.NotesIter("id,parent_id", "", "")
+	for it.Next() {
+		if it.Item().ParentID == sandbox.ID {
+			notes++
+		}
+	}
+
+	if it.Err() != nil || notes < 2 {
+		t.Errorf("NotesIter found %d notes in the sandbox, %v", notes, it.Err())
+	}
+
+	found := false
+
+	folderIt := client.FoldersIter("id", "", "")
+	for folderIt.Next() {
+		found = found || folderIt.Item().ID == sandbox.ID
+	}
+
+	if folderIt.Err() != nil || !found {
+		t.Errorf("FoldersIter did not return the sandbox, %v", folderIt.Err())
+	}
+
+	found = false
+
+	tagIt := client.TagsIter("id", "", "")
+	for tagIt.Next() {
+		found = found || tagIt.Item().ID == tag.ID
+	}
+
+	if tagIt.Err() != nil || !found {
+		t.Errorf("TagsIter did not return tag '%s', %v", tag.ID, tagIt.Err())
+	}
+
+	resourceIt := client.ResourcesIter("id", "", "")
+	for resourceIt.Next() {
+	}
+
+	if resourceIt.Err() != nil {
+		t.Errorf("ResourcesIter: %v", resourceIt.Err())
+	}
+}
+
+func TestContextListings(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := client.GetAllNotesCtx(ctx, "id", "", "")
+	if err != nil {
+		t.Errorf("GetAllNotesCtx: %v", err)
+	}
+
+	_, err = client.GetAllFoldersCtx(ctx, "id", "", "")
+	if err != nil {
+		t.Errorf("GetAllFoldersCtx: %v", err)
+	}
+
+	_, err = client.GetAllTagsCtx(ctx, "id", "", "")
+	if err != nil {
+		t.Errorf("GetAllTagsCtx: %v", err)
+	}
+
+	_, err = client.GetAllResourcesCtx(ctx, "id", "", "")
+	if err != nil {
+		t.Errorf("GetAllResourcesCtx: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = client.GetAllNotesCtx(cancelled, "id", "", "")
+	if err == nil {
+		t.Errorf("GetAllNotesCtx with a cancelled context did not fail")
+	}
+}
+
+func TestGetFolderByPath(t *testing.T) {
+	child, err := client.CreateFolderItem(goplin.Folder{Title: "by path", ParentID: sandbox.ID})
+	if err != nil {
+		t.Fatalf("CreateFolderItem: %v", err)
+	}
+
+	got, err := client.GetFolderByPath(sandbox.Title + "/By Path")
+	if err != nil || got.ID != child.ID {
+		t.Errorf("GetFolderByPath = %+v, %v", got, err)
+	}
+
+	_, err = client.GetFolderByPath(sandbox.Title + "/missing")
+	if err == nil {
+		t.Errorf("GetFolderByPath of a missing folder did not fail")
+	}
+}
+
+func TestUpdateAndDuplicates(t *testing.T) {
+	folder, err := client.CreateFolderItem(goplin.Folder{Title: "duplicates", ParentID: sandbox.ID})
+	if err != nil {
+		t.Fatalf("CreateFolderItem: %v", err)
+	}
+
+	note := createNote(t, "moved")
+
+	err = client.UpdateNote(note.ID, "original", folder.ID)
+	if err != nil {
+		t.Fatalf("UpdateNote: %v", err)
+	}
+
+	got, err := client.GetNote(note.ID, "id", "parent_id", "title")
+	if err != nil || got.Title != "original" || got.ParentID != folder.ID {
+		t.Errorf("GetNote after UpdateNote = %+v, %v", got, err)
+	}
+
+	duplicate := goplin.Note{ParentID: folder.ID, Title: "Original"}
+
+	_, err = client.CreateNoteWithOpts(duplicate, goplin.CreateNoteOpts{OnDuplicate: goplin.DuplicateFail})
+	if !errors.Is(err, goplin.ErrDuplicateTitle) {
+		t.Errorf("CreateNoteWithOpts with DuplicateFail = %v, want ErrDuplicateTitle", err)
+	}
+
+	created, err := client.CreateNoteWithOpts(duplicate, goplin.CreateNoteOpts{OnDuplicate: goplin.DuplicateSuffix})
+	if err != nil || created.Title != "Original (2)" {
+		t.Errorf("CreateNoteWithOpts with DuplicateSuffix = %+v, %v", created, err)
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	title := fmt.Sprintf("goplin-legacy-%d", time.Now().UnixNano())
+
+	err := client.CreateTag(title)
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+
+	tags, err := client.GetAllTagsWithFields("id,title", "", "")
+	if err != nil {
+		t.Fatalf("GetAllTagsWithFields: %v", err)
+	}
+
+	var tag goplin.Tag
+	for _, candidate := range tags {
+		if candidate.Title == title {
+			tag = candidate
+		}
+	}
+
+	if len(tag.ID) == 0 {
+		t.Fatalf("GetAllTagsWithFields did not return tag '%s'", title)
+	}
+
+	t.Cleanup(func() {
+		_ = client.DeleteTag(tag.ID)
+	})
+
+	note := createNote(t, "legacy tagged")
+
+	err = client.CreateTagsNotes(note.ID, tag.ID)
+	if err != nil {
+		t.Fatalf("CreateTagsNotes: %v", err)
+	}
+
+	notes, err := client.GetNotesByTagWithFields(tag.ID, "id,title", "", "")
+	if err != nil || len(notes) != 1 || notes[0].Title != "legacy tagged" {
+		t.Errorf("GetNotesByTagWithFields = %+v, %v", notes, err)
+	}
+}
+
+// searchUntil retries a search until it finds something, as the search index
+// is updated asynchronously.
+func searchUntil[T any](search func() ([]T, error)) ([]T, error) {
+	var items []T
+	var err error
+
+	for i := 0; i < 10; i++ {
+		items, err = search()
+		if err == nil && len(items) != 0 {
+			break
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return items, err
+}
+
+func TestSearchByType(t *testing.T) {
+	marker := fmt.Sprintf("goplintyped%d", time.Now().UnixNano())
+	note := createNote(t, marker)
+	tag := createTag(t, marker)
+
+	folder, err := client.CreateFolderItem(goplin.Folder{Title: marker, ParentID: sandbox.ID})
+	if err != nil {
+		t.Fatalf("CreateFolderItem: %v", err)
+	}
+
+	notes, err := searchUntil(func() ([]goplin.Note, error) { return client.SearchNotes(marker, "id,title") })
+	if err != nil || len(notes) != 1 || notes[0].ID != note.ID {
+		t.Errorf("SearchNotes = %+v, %v", notes, err)
+	}
+
+	folders, err := searchUntil(func() ([]goplin.Folder, error) { return client.SearchFolders(marker, "id,title") })
+	if err != nil || len(folders) != 1 || folders[0].ID != folder.ID {
+		t.Errorf("SearchFolders = %+v, %v", folders, err)
+	}
+
+	tags, err := searchUntil(func() ([]goplin.Tag, error) { return client.SearchTags(marker+"*", "id,title") })
+	if err != nil || len(tags) != 1 || tags[0].ID != tag.ID {
+		t.Errorf("SearchTags = %+v, %v", tags, err)
+	}
+
+	_, err = client.SearchResources(marker, "id,title")
+	if err != nil {
+		t.Errorf("SearchResources: %v", err)
+	}
+}
+
+func TestGetEvent(t *testing.T) {
+	_, cursor, err := client.GetEventsCtx(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetEventsCtx: %v", err)
+	}
+
+	createNote(t, "single event")
+
+	events, _, err := client.GetEvents(cursor)
+	if err != nil || len(events) == 0 {
+		t.Fatalf("GetEvents = %+v, %v", events, err)
+	}
+
+	event, err := client.GetEvent(events[0].ID)
+	if err != nil || event.ID != events[0].ID || event.ItemID != events[0].ItemID {
+		t.Errorf("GetEvent = %+v, %v", event, err)
+	}
+}
+
+func TestUserData(t *testing.T) {
+	caps, err := client.Capabilities()
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+
+	if !caps.UserData {
+		t.Skipf("Joplin %s has no user data", caps.Version)
+	}
+
+	note := createNote(t, "user data")
+
+	err = client.SetUserData(note.ID, "goplin.test", "answer", 42)
+	if err != nil {
+		t.Fatalf("SetUserData: %v", err)
+	}
+
+	value, err := client.GetUserData(note.ID, "goplin.test", "answer")
+	if err != nil || value != float64(42) {
+		t.Errorf("GetUserData = %v, %v", value, err)
+	}
+
+	err = client.DeleteUserData(note.ID, "goplin.test", "answer")
+	if err != nil {
+		t.Fatalf("DeleteUserData: %v", err)
+	}
+
+	value, err = client.GetUserData(note.ID, "goplin.test", "answer")
+	if err != nil || value != nil {
+		t.Errorf("GetUserData after delete = %v, %v", value, err)
+	}
+}
+
+func TestLiveNotes(t *testing.T) {
+	caps, err := client.Capabilities()
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+
+	if !caps.Trash {
+		t.Skipf("Joplin %s has no trash", caps.Version)
+	}
+
+	note := createNote(t, "trashed")
+
+	err = client.DeleteNote(note.ID, false)
+	if err != nil {
+		t.Fatalf("DeleteNote: %v", err)
+	}
+
+	_, err = client.GetLiveNote(note.ID, "id")
+	if !errors.Is(err, goplin.ErrNotFound) {
+		t.Errorf("GetLiveNote of a trashed note = %v, want ErrNotFound", err)
+	}
+
+	notes, err := client.GetLiveNotes("id", "", "")
+	if err != nil {
+		t.Fatalf("GetLiveNotes: %v", err)
+	}
+
+	for _, n := range notes {
+		if n.ID == note.ID {
+			t.Errorf("GetLiveNotes returned trashed note '%s'", note.ID)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package goplin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// GenerateID returns a new random item ID in the format used by Joplin. Items
+// created with a pre-generated ID can be linked to before they exist.
+func GenerateID() string {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// itemBody converts an item struct into a request body, dropping empty IDs so
+// Joplin generates them and places the item at the top level.
+func itemBody(item interface{}) (map[string]interface{}, error) {
+	var body map[string]interface{}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return body, err
+	}
+
+	err = json.Unmarshal(data, &body)
+	if err != nil {
+		return body, err
+	}
+
+	for _, key := range []string{"id", "parent_id"} {
+		if value, ok := body[key].(string); ok && len(value) == 0 {
+			delete(body, key)
+		}
+	}
+
+	return body, nil
+}
+
+// fieldList joins optional field arguments into the comma separated list the
+// API expects, falling back to def when no field is given.
+func fieldList(def string, fields []string) string {
+	var columns []string
+
+	for _, f := range fields {
+		for _, column := range strings.Split(f, ",") {
+			column = strings.TrimSpace(column)
+			if len(column) != 0 {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return def
+	}
+
+	return strings.Join(columns, ",")
+}
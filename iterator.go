@@ -0,0 +1,186 @@
+package goplin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Iterator reads items of the Data API a page at a time, requesting the next
+// page only once the items of the previous one are consumed, so that memory
+// stays flat and callers can stop early.
+//
+//	it := client.NotesIter("id,title", "", "")
+//	for it.Next() {
+//		note := it.Item()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	fetch func(page int) ([]T, bool, error)
+	page  int
+	items []T
+	i     int
+	more  bool
+	item  T
+	err   error
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false at the end of the items or on error, see Err.
+func (it *Iterator[T]) Next() bool {
+	for it.i >= len(it.items) {
+		if it.err != nil || it.page != 0 && !it.more {
+			return false
+		}
+
+		it.page++
+		it.i = 0
+
+		it.items, it.more, it.err = it.fetch(it.page)
+		if it.err != nil {
+			return false
+		}
+	}
+
+	it.item = it.items[it.i]
+	it.i++
+
+	return true
+}
+
+// Item returns the current item.
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Page returns the number of the page of the current item, from one.
+func (it *Iterator[T]) Page() int {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+type pageResult[T any] struct {
+	Items   []T  `json:"items"`
+	HasMore bool `json:"has_more"`
+}
+
+// Page is a page of the items of a list endpoint.
+type Page[T any] struct {
+	Items []T
+	// Number is the number of the page, from one.
+	Number  int
+	HasMore bool
+}
+
+// Next returns the number of the page that follows, 0 after the last page.
+func (p Page[T]) Next() int {
+	if !p.HasMore {
+		return 0
+	}
+
+	return p.Number + 1
+}
+
+// getPage requests a page of a list endpoint, of limit items or Joplin's
+// default when 0.
+func getPage[T any](c *Client, endpoint string, fields string, orderBy string, orderDir string, number int, limit int) (Page[T], error) {
+	var result pageResult[T]
+
+	page := Page[T]{Number: number}
+
+	queryParams := map[string]string{
+		"token": c.apiToken,
+		"page":  strconv.Itoa(number),
+	}
+
+	if len(fields) != 0 {
+		queryParams["fields"] = fields
+	}
+
+	if len(orderBy) != 0 {
+		queryParams["order_by"] = orderBy
+	}
+
+	if len(orderDir) != 0 {
+		queryParams["order_dir"] = strings.ToUpper(orderDir)
+	}
+
+	if limit > 0 {
+		queryParams["limit"] = strconv.Itoa(limit)
+	}
+
+	resp, err := c.r().
+		SetQueryParams(queryParams).
+		SetResult(&result).
+		SetError(&result).
+		Get(fmt.Sprintf("http://%s:%d/%s", c.host, c.port, endpoint))
+	if err != nil {
+		return page, redactError(err)
+	}
+
+	if !resp.IsSuccess() {
+		return page, newAPIError(resp)
+	}
+
+	page.Items = result.Items
+	page.HasMore = result.HasMore
+
+	return page, nil
+}
+
+// newIterator returns an iterator over the items of a list endpoint.
+func newIterator[T any](c *Client, endpoint string, fields string, orderBy string, orderDir string) *Iterator[T] {
+	fetch := func(number int) ([]T, bool, error) {
+		page, err := getPage[T](c, endpoint, fields, orderBy, orderDir, number, 0)
+
+		return page.Items, page.HasMore, err
+	}
+
+	return &Iterator[T]{fetch: fetch}
+}
+
+// MaxPageSize is the largest number of items Joplin returns in a page.
+const MaxPageSize = 100
+
+// NotesPage returns a page of all notes, of limit notes up to MaxPageSize or
+// Joplin's default when 0, for callers paging through the notes themselves.
+func (c *Client) NotesPage(fields string, orderBy string, orderDir string, number int, limit int) (Page[Note], error) {
+	return getPage[Note](c, "notes", fields, orderBy, orderDir, number, limit)
+}
+
+// FoldersPage returns a page of all folders, see NotesPage.
+func (c *Client) FoldersPage(fields string, orderBy string, orderDir string, number int, limit int) (Page[Folder], error) {
+	return getPage[Folder](c, "folders", fields, orderBy, orderDir, number, limit)
+}
+
+// TagsPage returns a page of all tags, see NotesPage.
+func (c *Client) TagsPage(fields string, orderBy string, orderDir string, number int, limit int) (Page[Tag], error) {
+	return getPage[Tag](c, "tags", fields, orderBy, orderDir, number, limit)
+}
+
+// NotesIter iterates over all notes, see GetAllNotes.
+func (c *Client) NotesIter(fields string, orderBy string, orderDir string) *Iterator[Note] {
+	return newIterator[Note](c, "notes", fields, orderBy, orderDir)
+}
+
+// FoldersIter iterates over all folders, see GetAllFolders.
+func (c *Client) FoldersIter(fields string, orderBy string, orderDir string) *Iterator[Folder] {
+	return newIterator[Folder](c, "folders", fields, orderBy, orderDir)
+}
+
+// TagsIter iterates over all tags, see GetAllTagsWithFields.
+func (c *Client) TagsIter(fields string, orderBy string, orderDir string) *Iterator[Tag] {
+	return newIterator[Tag](c, "tags", fields, orderBy, orderDir)
+}
+
+// ResourcesIter iterates over all resources, see GetAllResources.
+func (c *Client) ResourcesIter(fields string, orderBy string, orderDir string) *Iterator[Resource] {
+	return newIterator[Resource](c, "resources", fields, orderBy, orderDir)
+}
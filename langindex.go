@@ -0,0 +1,183 @@
+package goplin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// languageBatch is the number of changed notes from which NoteLanguages
+// reads every body in one listing rather than note by note.
+const languageBatch = 50
+
+// languageIndex keeps the detected language of the notes on the client and,
+// when path is set, in a file between runs, so that only the bodies of the
+// notes created or updated since are read again.
+type languageIndex struct {
+	mu      sync.Mutex
+	path    string
+	loaded  bool
+	entries map[string]languageEntry
+}
+
+type languageEntry struct {
+	UpdatedTime int    `json:"updated_time"`
+	Language    string `json:"language"`
+}
+
+// LanguageCount is the number of notes of a language.
+type LanguageCount struct {
+	// Language is an ISO 639-1 code, empty for the notes too short to tell.
+	Language string `json:"language"`
+	Name     string `json:"name"`
+	Notes    int    `json:"notes"`
+}
+
+// SetLanguageCache makes the client keep the detected languages of notes in
+// the file at path, such as one in the user cache directory.
+func (c *Client) SetLanguageCache(path string) {
+	c.langs.mu.Lock()
+	defer c.langs.mu.Unlock()
+
+	c.langs.path = path
+	c.langs.loaded = false
+}
+
+// NoteLanguages returns the languages of all notes by note ID, as told by
+// DetectLanguage, empty for the notes too short to tell. The notes created or
+// updated since the last call are detected again.
+func (c *Client) NoteLanguages() (map[string]string, error) {
+	ix := &c.langs
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.loaded {
+		ix.entries = make(map[string]languageEntry)
+
+		if len(ix.path) != 0 {
+			data, err := os.ReadFile(ix.path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+
+			// A damaged index is built again.
+			if err == nil && json.Unmarshal(data, &ix.entries) != nil {
+				ix.entries = make(map[string]languageEntry)
+			}
+		}
+
+		ix.loaded = true
+	}
+
+	notes, err := c.getNoteMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []Note
+
+	current := make(map[string]bool, len(notes))
+
+	for _, note := range notes {
+		current[note.ID] = true
+
+		if entry, ok := ix.entries[note.ID]; !ok || entry.UpdatedTime != note.UpdatedTime {
+			stale = append(stale, note)
+		}
+	}
+
+	changed := len(stale) != 0
+
+	for id := range ix.entries {
+		if !current[id] {
+			delete(ix.entries, id)
+			changed = true
+		}
+	}
+
+	if len(stale) > languageBatch {
+		stale, err = c.GetAllNotes("id,updated_time,body", "", "")
+	} else {
+		for i, note := range stale {
+			stale[i], err = c.GetNote(note.ID, "id,updated_time,body")
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, note := range stale {
+		ix.entries[note.ID] = languageEntry{UpdatedTime: note.UpdatedTime, Language: DetectLanguage(note.Body)}
+	}
+
+	if changed && len(ix.path) != 0 {
+		err = ix.save()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	languages := make(map[string]string, len(notes))
+	for _, note := range notes {
+		languages[note.ID] = ix.entries[note.ID].Language
+	}
+
+	return languages, nil
+}
+
+func (ix *languageIndex) save() error {
+	data, err := json.Marshal(ix.entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(ix.path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ix.path, data, 0o600)
+}
+
+// LanguageStats returns the number of notes per language, most frequent
+// first.
+func (c *Client) LanguageStats() ([]LanguageCount, error) {
+	languages, err := c.NoteLanguages()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, language := range languages {
+		counts[language]++
+	}
+
+	var stats []LanguageCount
+
+	for language, notes := range counts {
+		name := LanguageName(language)
+		if len(language) == 0 {
+			name = "Unknown"
+		}
+
+		stats = append(stats, LanguageCount{Language: language, Name: name, Notes: notes})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Notes != stats[j].Notes {
+			return stats[i].Notes > stats[j].Notes
+		}
+
+		return stats[i].Language < stats[j].Language
+	})
+
+	return stats, nil
+}
@@ -0,0 +1,190 @@
+package goplin
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// languageNames are the languages DetectLanguage tells apart, by ISO 639-1
+// code.
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"he": "Hebrew",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// stopwords are frequent short words of the languages written in the Latin
+// script, which tell them apart even in short notes.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "it", "for", "with", "was", "on", "are", "this", "be", "by", "not", "you", "have", "from", "at", "but", "or", "which", "they", "we"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ich", "sie", "es", "mit", "den", "von", "zu", "ein", "eine", "auf", "für", "dem", "sich", "auch", "wir", "wird", "bei", "oder", "aber"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pas", "que", "qui", "dans", "pour", "sur", "avec", "ce", "il", "elle", "nous", "vous", "sont", "du", "au", "mais", "ou", "plus"},
+	"es": {"el", "la", "los", "las", "y", "que", "en", "es", "un", "una", "por", "con", "para", "no", "se", "del", "al", "lo", "como", "más", "pero", "su", "sus", "muy", "está"},
+	"it": {"il", "che", "di", "e", "un", "una", "per", "non", "con", "sono", "del", "della", "nel", "gli", "le", "ma", "anche", "come", "più", "questo", "è", "si", "ha", "alla", "dei"},
+	"pt": {"o", "os", "as", "e", "que", "do", "da", "em", "um", "uma", "para", "com", "não", "se", "por", "mais", "como", "mas", "ao", "dos", "das", "é", "na", "no", "são"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "voor", "met", "zijn", "ik", "je", "maar", "ook", "als", "er", "aan", "bij", "wat", "naar", "dit", "hij"},
+	"sv": {"och", "att", "det", "som", "en", "på", "är", "av", "för", "med", "inte", "den", "till", "har", "de", "jag", "om", "ett", "men", "var", "så", "kan", "vi", "ska", "från"},
+	"pl": {"i", "w", "nie", "na", "się", "z", "do", "że", "to", "jest", "jak", "ale", "o", "co", "tak", "po", "przez", "od", "dla", "są", "czy", "już", "tylko", "jego", "może"},
+}
+
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+
+	for lang, words := range stopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+
+	return index
+}()
+
+var (
+	codeFenceRe = regexp.MustCompile("(?s)```.*?```")
+	proseDropRe = regexp.MustCompile("`[^`]*`|\\]\\([^)]*\\)|\\b[a-zA-Z][a-zA-Z0-9+.-]*://\\S+|<[^>]+>")
+)
+
+// minLanguageWords is the number of stopwords a text written in the Latin
+// script needs for its language to be told.
+const minLanguageWords = 3
+
+// maxLanguageText is the length of the start of texts DetectLanguage looks at.
+const maxLanguageText = 10000
+
+// Languages returns the ISO 639-1 codes of the languages DetectLanguage
+// tells apart.
+func Languages() []string {
+	var codes []string
+	for code := range languageNames {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	return codes
+}
+
+// LanguageName returns the English name of the language of an ISO 639-1
+// code, the code itself when unknown.
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+
+	return code
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of a Markdown
+// text, or "" when it is too short to tell. Code, links and HTML tags are
+// left out. Texts in non-Latin scripts are told by their script, the others
+// by their most frequent short words.
+func DetectLanguage(text string) string {
+	if len(text) > maxLanguageText {
+		text = text[:maxLanguageText]
+	}
+
+	text = codeFenceRe.ReplaceAllString(text, " ")
+	text = proseDropRe.ReplaceAllString(text, " ")
+
+	var letters, latin, cyrillic, ukrainian, greek, arabic, hebrew, han, kana, hangul int
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		letters++
+
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				ukrainian++
+			}
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		}
+	}
+
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese mixes kana with Han characters.
+	switch {
+	case latin*2 > letters:
+	case kana != 0 && (kana+han)*2 > letters:
+		return "ja"
+	case han*2 > letters:
+		return "zh"
+	case hangul*2 > letters:
+		return "ko"
+	case cyrillic*2 > letters && ukrainian != 0:
+		return "uk"
+	case cyrillic*2 > letters:
+		return "ru"
+	case greek*2 > letters:
+		return "el"
+	case arabic*2 > letters:
+		return "ar"
+	case hebrew*2 > letters:
+		return "he"
+	default:
+		return ""
+	}
+
+	scores := make(map[string]int)
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		for _, lang := range stopwordIndex[word] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+
+	for _, lang := range Languages() {
+		switch score := scores[lang]; {
+		case score > bestScore:
+			best, bestScore, tie = lang, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+
+	if bestScore < minLanguageWords || tie {
+		return ""
+	}
+
+	return best
+}
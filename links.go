@@ -0,0 +1,169 @@
+package goplin
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// internalLinkRe matches links to Joplin items: ":/" followed by a 32 digit
+// hexadecimal ID and an optional "#anchor", as used by Markdown links, images
+// and the src and href attributes of HTML notes.
+var internalLinkRe = regexp.MustCompile(`:/([0-9a-fA-F]{32})(#[^\s)"'>]*)?`)
+
+// Link is an internal link from one note to a note or resource.
+type Link struct {
+	SourceID string `json:"source_id"`
+	TargetID string `json:"target_id"`
+	Anchor   string `json:"anchor,omitempty"`
+	Line     int    `json:"line"`
+}
+
+// ExtractLinks returns the internal links of a note body with their line
+// number, counted from 1. SourceID is left empty.
+func ExtractLinks(body string) []Link {
+	var links []Link
+
+	for i, line := range strings.Split(body, "\n") {
+		for _, m := range internalLinkRe.FindAllStringSubmatch(line, -1) {
+			links = append(links, Link{
+				TargetID: strings.ToLower(m[1]),
+				Anchor:   strings.TrimPrefix(m[2], "#"),
+				Line:     i + 1,
+			})
+		}
+	}
+
+	return links
+}
+
+// RewriteLinkTarget replaces the internal links to from by links to to,
+// keeping anchors.
+func RewriteLinkTarget(body string, from string, to string) string {
+	return internalLinkRe.ReplaceAllStringFunc(body, func(link string) string {
+		m := internalLinkRe.FindStringSubmatch(link)
+		if !strings.EqualFold(m[1], from) {
+			return link
+		}
+
+		return ":/" + to + m[2]
+	})
+}
+
+// ReplaceLinks replaces the internal links of a body, ":/" and the ID with
+// its anchor, by what replace returns for them. Links for which replace
+// returns false are kept.
+func ReplaceLinks(body string, replace func(link Link) (string, bool)) string {
+	return internalLinkRe.ReplaceAllStringFunc(body, func(match string) string {
+		m := internalLinkRe.FindStringSubmatch(match)
+
+		replaced, ok := replace(Link{TargetID: strings.ToLower(m[1]), Anchor: strings.TrimPrefix(m[2], "#")})
+		if !ok {
+			return match
+		}
+
+		return replaced
+	})
+}
+
+// LinkGraph holds the internal links between the notes of the vault.
+type LinkGraph struct {
+	Notes     map[string]Note
+	Resources map[string]Resource
+	Outgoing  map[string][]Link
+	Incoming  map[string][]Link
+}
+
+// BuildLinkGraph indexes the links found in the bodies of notes.
+func BuildLinkGraph(notes []Note, resources []Resource) *LinkGraph {
+	g := &LinkGraph{
+		Notes:     make(map[string]Note, len(notes)),
+		Resources: make(map[string]Resource, len(resources)),
+		Outgoing:  make(map[string][]Link),
+		Incoming:  make(map[string][]Link),
+	}
+
+	for _, resource := range resources {
+		g.Resources[resource.ID] = resource
+	}
+
+	for _, note := range notes {
+		g.Notes[note.ID] = note
+
+		for _, link := range ExtractLinks(note.Body) {
+			link.SourceID = note.ID
+
+			g.Outgoing[note.ID] = append(g.Outgoing[note.ID], link)
+			g.Incoming[link.TargetID] = append(g.Incoming[link.TargetID], link)
+		}
+	}
+
+	return g
+}
+
+// GetLinkGraph fetches all notes and resources and builds their link graph.
+func (c *Client) GetLinkGraph() (*LinkGraph, error) {
+	notes, err := c.GetAllNotes("id,parent_id,title,body,is_conflict,conflict_original_id", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := c.GetAllResources("id,title,mime", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildLinkGraph(notes, resources), nil
+}
+
+// Exists reports whether id is a note or resource of the graph.
+func (g *LinkGraph) Exists(id string) bool {
+	_, isNote := g.Notes[id]
+	_, isResource := g.Resources[id]
+
+	return isNote || isResource
+}
+
+// Broken returns the links whose target is neither a note nor a resource,
+// ordered by source note and line.
+func (g *LinkGraph) Broken() []Link {
+	var broken []Link
+
+	for _, links := range g.Outgoing {
+		for _, link := range links {
+			if !g.Exists(link.TargetID) {
+				broken = append(broken, link)
+			}
+		}
+	}
+
+	sort.Slice(broken, func(i, j int) bool {
+		if broken[i].SourceID != broken[j].SourceID {
+			return broken[i].SourceID < broken[j].SourceID
+		}
+
+		return broken[i].Line < broken[j].Line
+	})
+
+	return broken
+}
+
+// ConflictCopy returns a conflict note created from the missing note id, the
+// usual replacement for links broken by a sync conflict.
+func (g *LinkGraph) ConflictCopy(id string) (Note, bool) {
+	var found []Note
+
+	for _, note := range g.Notes {
+		if note.IsConflict == 1 && note.ConflictOriginalID == id {
+			found = append(found, note)
+		}
+	}
+
+	if len(found) == 0 {
+		return Note{}, false
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+
+	return found[0], true
+}
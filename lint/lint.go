@@ -0,0 +1,431 @@
+// Package lint checks note bodies for Markdown style problems and spelling
+// mistakes, and fixes the problems that can be corrected safely.
+package lint
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem found on a line of a note body, counted from 1.
+type Issue struct {
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+}
+
+// Document is a note body split into lines, with the lines belonging to
+// fenced code blocks marked so rules can leave them alone.
+type Document struct {
+	Lines []string
+	Code  []bool
+}
+
+// NewDocument splits body into lines.
+func NewDocument(body string) *Document {
+	d := &Document{Lines: strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")}
+	d.Code = make([]bool, len(d.Lines))
+
+	fence := ""
+
+	for i, line := range d.Lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case len(fence) != 0:
+			d.Code[i] = true
+
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			d.Code[i] = true
+			fence = trimmed[:3]
+		}
+	}
+
+	return d
+}
+
+// Body joins the lines back together.
+func (d *Document) Body() string {
+	return strings.Join(d.Lines, "\n")
+}
+
+// Rule checks a document.
+type Rule interface {
+	Name() string
+	Check(doc *Document) []Issue
+}
+
+// Fixer is implemented by rules which can correct what they report.
+type Fixer interface {
+	Fix(doc *Document)
+}
+
+type lineRule struct {
+	name  string
+	check func(line string) (string, bool)
+	fix   func(line string) string
+}
+
+func (r lineRule) Name() string {
+	return r.name
+}
+
+func (r lineRule) Check(doc *Document) []Issue {
+	var issues []Issue
+
+	for i, line := range doc.Lines {
+		if doc.Code[i] {
+			continue
+		}
+
+		if message, ok := r.check(line); ok {
+			issues = append(issues, Issue{Line: i + 1, Rule: r.name, Message: message, Fixable: r.fix != nil})
+		}
+	}
+
+	return issues
+}
+
+func (r lineRule) Fix(doc *Document) {
+	if r.fix == nil {
+		return
+	}
+
+	for i, line := range doc.Lines {
+		if !doc.Code[i] {
+			doc.Lines[i] = r.fix(line)
+		}
+	}
+}
+
+var (
+	headingRe        = regexp.MustCompile(`^(#{1,6})(\S)`)
+	headingLevelRe   = regexp.MustCompile(`^(#{1,6})\s`)
+	emptyLinkRe      = regexp.MustCompile(`\[[^\]]*\]\(\s*\)`)
+	trailingSpacesRe = regexp.MustCompile(`[ \t]+$`)
+)
+
+// trailingWhitespace reports trailing blanks, except the two spaces of a
+// Markdown hard line break.
+var trailingWhitespace = lineRule{
+	name: "trailing-whitespace",
+	check: func(line string) (string, bool) {
+		trailing := trailingSpacesRe.FindString(line)
+		if len(trailing) == 0 || trailing == "  " || len(strings.TrimSpace(line)) == 0 {
+			return "", false
+		}
+
+		return "trailing whitespace", true
+	},
+	fix: func(line string) string {
+		if trailingSpacesRe.FindString(line) == "  " || isBlank(line) {
+			return line
+		}
+
+		return trailingSpacesRe.ReplaceAllString(line, "")
+	},
+}
+
+var headingSpace = lineRule{
+	name: "heading-space",
+	check: func(line string) (string, bool) {
+		m := headingRe.FindStringSubmatch(line)
+		if m == nil || m[2] == "#" {
+			return "", false
+		}
+
+		return fmt.Sprintf("missing space after '%s'", m[1]), true
+	},
+	fix: func(line string) string {
+		if m := headingRe.FindStringSubmatch(line); m != nil && m[2] != "#" {
+			return m[1] + " " + line[len(m[1]):]
+		}
+
+		return line
+	},
+}
+
+var emptyLink = lineRule{
+	name: "empty-link",
+	check: func(line string) (string, bool) {
+		if link := emptyLinkRe.FindString(line); len(link) != 0 {
+			return fmt.Sprintf("link '%s' has no target", link), true
+		}
+
+		return "", false
+	},
+}
+
+type headingIncrement struct{}
+
+func (headingIncrement) Name() string {
+	return "heading-increment"
+}
+
+func (headingIncrement) Check(doc *Document) []Issue {
+	var issues []Issue
+
+	previous := 0
+
+	for i, line := range doc.Lines {
+		if doc.Code[i] {
+			continue
+		}
+
+		m := headingLevelRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		level := len(m[1])
+
+		if previous != 0 && level > previous+1 {
+			issues = append(issues, Issue{
+				Line:    i + 1,
+				Rule:    "heading-increment",
+				Message: fmt.Sprintf("heading level jumps from %d to %d", previous, level),
+			})
+		}
+
+		previous = level
+	}
+
+	return issues
+}
+
+type blankLines struct{}
+
+func (blankLines) Name() string {
+	return "blank-lines"
+}
+
+func (blankLines) Check(doc *Document) []Issue {
+	var issues []Issue
+
+	for i := 1; i < len(doc.Lines); i++ {
+		if !doc.Code[i] && isBlank(doc.Lines[i]) && isBlank(doc.Lines[i-1]) && (i < 2 || !isBlank(doc.Lines[i-2])) {
+			issues = append(issues, Issue{Line: i + 1, Rule: "blank-lines", Message: "multiple consecutive blank lines", Fixable: true})
+		}
+	}
+
+	return issues
+}
+
+func (blankLines) Fix(doc *Document) {
+	var lines []string
+	var code []bool
+
+	for i, line := range doc.Lines {
+		if !doc.Code[i] && isBlank(line) && len(lines) != 0 && isBlank(lines[len(lines)-1]) && !code[len(code)-1] {
+			continue
+		}
+
+		lines = append(lines, line)
+		code = append(code, doc.Code[i])
+	}
+
+	doc.Lines, doc.Code = lines, code
+}
+
+func isBlank(line string) bool {
+	return len(strings.TrimSpace(line)) == 0
+}
+
+type unclosedFence struct{}
+
+func (unclosedFence) Name() string {
+	return "unclosed-fence"
+}
+
+func (unclosedFence) Check(doc *Document) []Issue {
+	start := -1
+
+	for i, line := range doc.Lines {
+		if !doc.Code[i] {
+			start = -1
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+
+		if start < 0 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			start = i
+		} else if start >= 0 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			start = -1
+		}
+	}
+
+	if start < 0 {
+		return nil
+	}
+
+	return []Issue{{Line: start + 1, Rule: "unclosed-fence", Message: "code block is never closed"}}
+}
+
+// builtinRules are the rules run unless disabled in the configuration.
+var builtinRules = []Rule{
+	trailingWhitespace,
+	headingSpace,
+	headingIncrement{},
+	blankLines{},
+	emptyLink,
+	unclosedFence{},
+}
+
+// Rules returns the names of the built-in rules, spelling included.
+func Rules() []string {
+	names := []string{SpellingRule}
+
+	for _, rule := range builtinRules {
+		names = append(names, rule.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Config selects the rules and the spell checker, as read from the lint
+// section of the config file.
+type Config struct {
+	// Disable lists rules that are not run.
+	Disable []string `mapstructure:"disable"`
+	// SpellCommand lists misspelled words of its standard input, one per
+	// line, such as "aspell list" or "hunspell -l". When empty, aspell or
+	// hunspell are used if installed.
+	SpellCommand string `mapstructure:"spell_command"`
+	// IgnoreWords are accepted by the spell checker.
+	IgnoreWords []string `mapstructure:"ignore_words"`
+	// Dictionaries name the dictionary of the spell checker by language of
+	// the notes, an ISO 639-1 code such as de, see LintLanguage.
+	Dictionaries map[string]string `mapstructure:"dictionaries"`
+}
+
+// Linter runs a set of rules.
+type Linter struct {
+	rules        []Rule
+	dictionaries map[string]string
+}
+
+// New returns a linter running the rules enabled in cfg. Spelling is left out
+// when no spell checker is available; Spelling tells whether it runs.
+func New(cfg Config) (*Linter, error) {
+	disabled := make(map[string]bool, len(cfg.Disable))
+
+	known := make(map[string]bool)
+	for _, name := range Rules() {
+		known[name] = true
+	}
+
+	for _, name := range cfg.Disable {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown lint rule '%s', expected one of %s", name, strings.Join(Rules(), ", "))
+		}
+
+		disabled[name] = true
+	}
+
+	l := &Linter{dictionaries: cfg.Dictionaries}
+
+	for _, rule := range builtinRules {
+		if !disabled[rule.Name()] {
+			l.rules = append(l.rules, rule)
+		}
+	}
+
+	if !disabled[SpellingRule] {
+		command := strings.Fields(cfg.SpellCommand)
+
+		if len(command) == 0 {
+			command = findSpellCommand()
+		}
+
+		if len(command) != 0 {
+			l.rules = append(l.rules, newSpelling(command, cfg.IgnoreWords))
+		}
+	}
+
+	return l, nil
+}
+
+func findSpellCommand() []string {
+	if _, err := exec.LookPath("aspell"); err == nil {
+		return []string{"aspell", "list"}
+	}
+
+	if _, err := exec.LookPath("hunspell"); err == nil {
+		return []string{"hunspell", "-l"}
+	}
+
+	return nil
+}
+
+// Spelling reports whether the linter checks spelling.
+func (l *Linter) Spelling() bool {
+	for _, rule := range l.rules {
+		if rule.Name() == SpellingRule {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lint returns the issues of body ordered by line.
+func (l *Linter) Lint(body string) []Issue {
+	doc := NewDocument(body)
+
+	var issues []Issue
+
+	for _, rule := range l.rules {
+		issues = append(issues, rule.Check(doc)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+
+	return issues
+}
+
+// LintLanguage returns the issues of body like Lint, checking its spelling
+// with the dictionary of language when one is configured.
+func (l *Linter) LintLanguage(body string, language string) []Issue {
+	dictionary, ok := l.dictionaries[language]
+	if !ok {
+		return l.Lint(body)
+	}
+
+	rules := make([]Rule, len(l.rules))
+
+	for i, rule := range l.rules {
+		if s, ok := rule.(*spelling); ok {
+			rule = s.withDictionary(dictionary)
+		}
+
+		rules[i] = rule
+	}
+
+	return (&Linter{rules: rules}).Lint(body)
+}
+
+// Fix applies the corrections of the fixable rules and returns the new body
+// and whether it changed.
+func (l *Linter) Fix(body string) (string, bool) {
+	doc := NewDocument(body)
+
+	for _, rule := range l.rules {
+		if fixer, ok := rule.(Fixer); ok {
+			fixer.Fix(doc)
+		}
+	}
+
+	fixed := doc.Body()
+
+	return fixed, fixed != body
+}
@@ -0,0 +1,111 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SpellingRule is the name of the spell checking rule.
+const SpellingRule = "spelling"
+
+var (
+	inlineCodeRe = regexp.MustCompile("`[^`]*`")
+	linkTargetRe = regexp.MustCompile(`\]\([^)]*\)`)
+	urlRe        = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://\S+`)
+	htmlTagRe    = regexp.MustCompile(`<[^>]+>`)
+)
+
+// spelling runs an external spell checker over the prose of a note: code,
+// link targets, URLs and HTML tags are removed first.
+type spelling struct {
+	command []string
+	ignore  map[string]bool
+}
+
+func newSpelling(command []string, ignoreWords []string) *spelling {
+	s := &spelling{command: command, ignore: make(map[string]bool, len(ignoreWords))}
+
+	for _, word := range ignoreWords {
+		s.ignore[strings.ToLower(word)] = true
+	}
+
+	return s
+}
+
+// withDictionary returns the rule checking with dictionary, which aspell
+// and hunspell take with -d.
+func (s *spelling) withDictionary(dictionary string) *spelling {
+	command := append([]string{s.command[0], "-d", dictionary}, s.command[1:]...)
+
+	return &spelling{command: command, ignore: s.ignore}
+}
+
+func (s *spelling) Name() string {
+	return SpellingRule
+}
+
+func prose(line string) string {
+	line = inlineCodeRe.ReplaceAllString(line, " ")
+	line = linkTargetRe.ReplaceAllString(line, "] ")
+	line = urlRe.ReplaceAllString(line, " ")
+
+	return htmlTagRe.ReplaceAllString(line, " ")
+}
+
+func words(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+}
+
+func (s *spelling) Check(doc *Document) []Issue {
+	lines := make([]string, len(doc.Lines))
+
+	for i, line := range doc.Lines {
+		if !doc.Code[i] {
+			lines[i] = prose(line)
+		}
+	}
+
+	var out, stderr bytes.Buffer
+
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return []Issue{{Rule: SpellingRule, Message: fmt.Sprintf("spell checker '%s' failed: %v %s",
+			strings.Join(s.command, " "), err, strings.TrimSpace(stderr.String()))}}
+	}
+
+	misspelled := make(map[string]bool)
+
+	for _, word := range strings.Fields(out.String()) {
+		if !s.ignore[strings.ToLower(word)] {
+			misspelled[word] = true
+		}
+	}
+
+	var issues []Issue
+
+	for i, line := range lines {
+		reported := make(map[string]bool)
+
+		for _, word := range words(line) {
+			word = strings.Trim(word, "'")
+
+			if misspelled[word] && !reported[word] {
+				reported[word] = true
+				issues = append(issues, Issue{Line: i + 1, Rule: SpellingRule, Message: fmt.Sprintf("unknown word '%s'", word)})
+			}
+		}
+	}
+
+	return issues
+}
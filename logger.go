@@ -0,0 +1,208 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Log formats of NewLogger.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	name, ok := levelNames[l]
+	if !ok {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+
+	return name
+}
+
+// ParseLevel returns the level named debug, info, warn or error.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level '%s', expected debug, info, warn or error", name)
+}
+
+// Field is a key and value attached to a log entry, such as a note ID or a
+// duration.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns a field of a log entry.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger writes leveled log entries made of a message and fields, so long
+// running commands can log for humans or for log collectors alike.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	// With returns a logger adding fields to every entry.
+	With(fields ...Field) Logger
+}
+
+type streamLogger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	json   bool
+	level  Level
+	fields []Field
+}
+
+// NewLogger returns a logger writing entries of level or above to out, one
+// per line, as "time LEVEL message key=value..." in the text format or as
+// objects with time, level, msg and the fields in the json format, as read by
+// journald, Loki and the like.
+func NewLogger(out io.Writer, format string, level Level) (Logger, error) {
+	switch format {
+	case LogFormatText, "":
+		return &streamLogger{mu: &sync.Mutex{}, out: out, level: level}, nil
+	case LogFormatJSON:
+		return &streamLogger{mu: &sync.Mutex{}, out: out, json: true, level: level}, nil
+	}
+
+	return nil, fmt.Errorf("unknown log format '%s', expected text or json", format)
+}
+
+// NopLogger returns a logger discarding everything.
+func NopLogger() Logger {
+	return &streamLogger{mu: &sync.Mutex{}, out: io.Discard, level: LevelError + 1}
+}
+
+func (l *streamLogger) Debug(msg string, fields ...Field) { l.log(LevelDebug, msg, fields) }
+func (l *streamLogger) Info(msg string, fields ...Field)  { l.log(LevelInfo, msg, fields) }
+func (l *streamLogger) Warn(msg string, fields ...Field)  { l.log(LevelWarn, msg, fields) }
+func (l *streamLogger) Error(msg string, fields ...Field) { l.log(LevelError, msg, fields) }
+
+func (l *streamLogger) With(fields ...Field) Logger {
+	with := *l
+	with.fields = append(append([]Field(nil), l.fields...), fields...)
+
+	return &with
+}
+
+func (l *streamLogger) log(level Level, msg string, fields []Field) {
+	if level < l.level {
+		return
+	}
+
+	now := time.Now()
+	msg = RedactToken(msg)
+	all := append(append([]Field(nil), l.fields...), fields...)
+
+	var line []byte
+
+	if l.json {
+		line = jsonEntry(now, level, msg, all)
+	} else {
+		line = textEntry(now, level, msg, all)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.out.Write(line)
+}
+
+// fieldValue returns the value of a field as logged: errors by their message
+// and durations in milliseconds, tokens hidden.
+func fieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return RedactToken(v)
+	case error:
+		return RedactToken(v.Error())
+	case time.Duration:
+		return float64(v.Microseconds()) / 1000
+	case fmt.Stringer:
+		return RedactToken(v.String())
+	}
+
+	return value
+}
+
+func jsonEntry(t time.Time, level Level, msg string, fields []Field) []byte {
+	entry := make(map[string]interface{}, len(fields)+3)
+
+	for _, field := range fields {
+		entry[field.Key] = fieldValue(field.Value)
+	}
+
+	entry["time"] = t.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		// A field could not be encoded, keep the entry without the fields.
+		line, _ = json.Marshal(map[string]string{
+			"time":  t.Format(time.RFC3339Nano),
+			"level": level.String(),
+			"msg":   msg,
+			"error": err.Error(),
+		})
+	}
+
+	return append(line, '\n')
+}
+
+func textEntry(t time.Time, level Level, msg string, fields []Field) []byte {
+	var b strings.Builder
+
+	b.WriteString(t.Format("2006/01/02 15:04:05 "))
+	b.WriteString(strings.ToUpper(level.String()))
+	b.WriteString(" ")
+	b.WriteString(msg)
+
+	// Fields are sorted by key, so lines compare well.
+	sorted := append([]Field(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	for _, field := range sorted {
+		value := fmt.Sprint(fieldValue(field.Value))
+		if len(value) == 0 || strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+
+		b.WriteString(" " + field.Key + "=" + value)
+	}
+
+	b.WriteString("\n")
+
+	return []byte(b.String())
+}
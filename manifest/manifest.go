@@ -0,0 +1,252 @@
+// Package manifest records a checksum of every note body and resource file
+// of the vault, to tell after a restore or a sync on another machine whether
+// the vault still holds the same data.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// Version is the version of the manifest format.
+const Version = 1
+
+// Kinds of entries.
+const (
+	KindNote     = "note"
+	KindResource = "resource"
+)
+
+// Problems found by Verify.
+const (
+	// ProblemMissing is an item of the manifest the vault no longer has.
+	ProblemMissing = "missing"
+	// ProblemCorrupted is an item whose content changed while its update
+	// time did not: the data was damaged rather than edited.
+	ProblemCorrupted = "corrupted"
+	// ProblemModified is an item edited since the manifest was written.
+	ProblemModified = "modified"
+	// ProblemAdded is an item of the vault the manifest does not have.
+	ProblemAdded = "added"
+)
+
+const (
+	noteFields     = "id,title,body,updated_time"
+	resourceFields = "id,title,size,updated_time"
+)
+
+// Entry is the checksum of an item.
+type Entry struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	UpdatedTime int    `json:"updated_time"`
+	Size        int    `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// Manifest lists the checksums of the notes and resources, sorted by ID.
+type Manifest struct {
+	Version       int     `json:"version"`
+	GeneratedTime int     `json:"generated_time"`
+	Notes         []Entry `json:"notes"`
+	Resources     []Entry `json:"resources"`
+}
+
+// Difference is an item that does not match the manifest.
+type Difference struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Problem string `json:"problem"`
+}
+
+// Report is the result of Verify.
+type Report struct {
+	Checked     int          `json:"checked"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// Count returns the number of differences with the given problem.
+func (r Report) Count(problem string) int {
+	n := 0
+
+	for _, d := range r.Differences {
+		if d.Problem == problem {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Build hashes every note body and resource file of the vault.
+func Build(client *goplin.Client) (Manifest, error) {
+	m := Manifest{Version: Version, GeneratedTime: int(time.Now().UnixMilli())}
+
+	notes, err := client.GetLiveNotes(noteFields, "", "")
+	if err != nil {
+		return m, err
+	}
+
+	for _, note := range notes {
+		m.Notes = append(m.Notes, Entry{
+			ID:          note.ID,
+			Title:       note.Title,
+			UpdatedTime: note.UpdatedTime,
+			Size:        len(note.Body),
+			SHA256:      hash([]byte(note.Body)),
+		})
+	}
+
+	resources, err := client.GetAllResources(resourceFields, "", "")
+	if err != nil {
+		return m, err
+	}
+
+	for _, resource := range resources {
+		entry, err := resourceEntry(client, resource)
+		if err != nil {
+			return m, err
+		}
+
+		m.Resources = append(m.Resources, entry)
+	}
+
+	sortEntries(m.Notes)
+	sortEntries(m.Resources)
+
+	return m, nil
+}
+
+// Verify hashes the vault again and compares it with m.
+func Verify(client *goplin.Client, m Manifest) (Report, error) {
+	var report Report
+
+	if m.Version != Version {
+		return report, fmt.Errorf("unsupported manifest version %d, expected %d", m.Version, Version)
+	}
+
+	current, err := Build(client)
+	if err != nil {
+		return report, err
+	}
+
+	report.Checked = len(m.Notes) + len(m.Resources)
+	report.Differences = append(compare(KindNote, m.Notes, current.Notes), compare(KindResource, m.Resources, current.Resources)...)
+
+	return report, nil
+}
+
+// Load reads a manifest written by Save.
+func Load(path string) (Manifest, error) {
+	var m Manifest
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return m, fmt.Errorf("invalid manifest '%s': %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest as indented JSON.
+func (m Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// resourceEntry hashes the file of a resource as it is downloaded.
+func resourceEntry(client *goplin.Client, resource goplin.Resource) (Entry, error) {
+	h := sha256.New()
+	counter := &countingWriter{}
+
+	err := client.GetResourceFile(resource.ID, io.MultiWriter(h, counter))
+	if err != nil {
+		return Entry{}, fmt.Errorf("could not read resource '%s': %w", resource.ID, err)
+	}
+
+	return Entry{
+		ID:          resource.ID,
+		Title:       resource.Title,
+		UpdatedTime: resource.UpdatedTime,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+// compare returns the differences between the entries of the manifest and
+// the current ones, both sorted by ID.
+func compare(kind string, old []Entry, current []Entry) []Difference {
+	var differences []Difference
+
+	byID := make(map[string]Entry, len(current))
+	for _, entry := range current {
+		byID[entry.ID] = entry
+	}
+
+	seen := make(map[string]bool, len(old))
+
+	for _, entry := range old {
+		seen[entry.ID] = true
+
+		now, ok := byID[entry.ID]
+
+		problem := ""
+
+		switch {
+		case !ok:
+			problem = ProblemMissing
+		case now.SHA256 == entry.SHA256:
+			continue
+		case now.UpdatedTime == entry.UpdatedTime:
+			problem = ProblemCorrupted
+		default:
+			problem = ProblemModified
+		}
+
+		differences = append(differences, Difference{Kind: kind, ID: entry.ID, Title: entry.Title, Problem: problem})
+	}
+
+	for _, entry := range current {
+		if !seen[entry.ID] {
+			differences = append(differences, Difference{Kind: kind, ID: entry.ID, Title: entry.Title, Problem: ProblemAdded})
+		}
+	}
+
+	return differences
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type countingWriter struct {
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
@@ -0,0 +1,499 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedElements hold no content of the note.
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "title": true, "meta": true, "link": true,
+}
+
+// blockElements start a new Markdown block.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "dd": true, "details": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "html": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "summary": true, "table": true, "ul": true,
+}
+
+var (
+	spacesRe     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+	mdEscaper    = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+	taskSpacesRe = regexp.MustCompile(`^\[([ x])\] +`)
+	lineStartRe  = regexp.MustCompile(`^(#{1,6} |[-+] |\d+[.)] |>)`)
+)
+
+// HTMLToMarkdown converts an HTML note, a document or a fragment, to
+// Markdown. Elements without a Markdown equivalent keep their text.
+func HTMLToMarkdown(src string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return "", err
+	}
+
+	md := strings.Join(blocks(doc), "\n\n")
+	md = blankLinesRe.ReplaceAllString(md, "\n\n")
+
+	return strings.TrimSpace(md) + "\n", nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func isBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && blockElements[n.Data]
+}
+
+// blocks converts the children of n to Markdown blocks. Runs of inline
+// children become paragraphs.
+func blocks(n *html.Node) []string {
+	var out []string
+
+	var para strings.Builder
+
+	flush := func() {
+		text := strings.TrimSpace(para.String())
+		para.Reset()
+
+		// A trailing line break has nothing to break.
+		for strings.HasSuffix(text, "\\") && !strings.HasSuffix(text, "\\\\") {
+			text = strings.TrimSpace(strings.TrimSuffix(text, "\\"))
+		}
+
+		if len(text) == 0 {
+			return
+		}
+
+		// Keep paragraph text from being read as a heading, list or quote.
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			line = strings.TrimLeft(line, " ")
+			if lineStartRe.MatchString(line) {
+				line = escapeLineStart(line)
+			}
+
+			lines[i] = line
+		}
+
+		out = append(out, strings.Join(lines, "\n"))
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && skippedElements[c.Data] {
+			continue
+		}
+
+		if !isBlock(c) && !containsBlock(c) {
+			para.WriteString(inline(c))
+			continue
+		}
+
+		flush()
+
+		if !isBlock(c) {
+			// An inline element wrapping blocks, such as a link around a
+			// div, loses its formatting to keep the blocks.
+			out = append(out, blocks(c)...)
+			continue
+		}
+
+		if block := blockMarkdown(c); len(strings.TrimSpace(block)) != 0 {
+			out = append(out, block)
+		}
+	}
+
+	flush()
+
+	return out
+}
+
+func containsBlock(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isBlock(c) || containsBlock(c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func escapeLineStart(line string) string {
+	switch {
+	case line[0] == '#' || line[0] == '-' || line[0] == '+' || line[0] == '>':
+		return `\` + line
+	}
+
+	// An ordered list marker: escape the period or parenthesis.
+	i := strings.IndexAny(line, ".)")
+
+	return line[:i] + `\` + line[i:]
+}
+
+func blockMarkdown(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		text := strings.ReplaceAll(strings.TrimSpace(inlineChildren(n)), "\n", " ")
+
+		return strings.Repeat("#", level) + " " + text
+
+	case "hr":
+		return "---"
+
+	case "pre":
+		return codeBlock(n)
+
+	case "blockquote":
+		return prefixLines(strings.Join(blocks(n), "\n\n"), "> ", ">")
+
+	case "ul", "ol":
+		return list(n)
+
+	case "table":
+		return table(n)
+	}
+
+	return strings.Join(blocks(n), "\n\n")
+}
+
+func prefixLines(text string, prefix string, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		if len(line) == 0 {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	if n.Type == html.ElementNode && n.Data == "br" {
+		return "\n"
+	}
+
+	var b strings.Builder
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+
+	return b.String()
+}
+
+func codeBlock(n *html.Node) string {
+	language := ""
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "code" {
+			for _, class := range strings.Fields(attr(c, "class")) {
+				if strings.HasPrefix(class, "language-") {
+					language = strings.TrimPrefix(class, "language-")
+				}
+			}
+		}
+	}
+
+	code := strings.TrimSuffix(textContent(n), "\n")
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+func list(n *html.Node) string {
+	ordered := n.Data == "ol"
+
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		number = start
+	}
+
+	var items []string
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		content := strings.Join(blocks(c), "\n")
+		content = taskSpacesRe.ReplaceAllString(content, "[$1] ")
+
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(content, "\n")
+
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) != 0 {
+				lines[i] = indent + lines[i]
+			}
+		}
+
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func table(n *html.Node) string {
+	var rows [][]string
+
+	header := false
+
+	var aligns []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			switch c.Data {
+			case "tr":
+				var row []string
+
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+
+					if len(rows) == 0 {
+						header = header || cell.Data == "th"
+						aligns = append(aligns, cellAlign(cell))
+					}
+
+					text := strings.TrimSpace(inlineChildren(cell))
+					text = strings.ReplaceAll(text, "|", `\|`)
+					text = strings.ReplaceAll(text, "\\\n", "<br>")
+					text = strings.ReplaceAll(text, "\n", " ")
+
+					row = append(row, text)
+				}
+
+				rows = append(rows, row)
+
+			default:
+				walk(c)
+			}
+		}
+	}
+
+	walk(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	if !header {
+		// Markdown tables need a header row.
+		rows = append([][]string{make([]string, columns)}, rows...)
+	}
+
+	var lines []string
+
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+
+		if i != 0 {
+			continue
+		}
+
+		delims := make([]string, columns)
+		for j := range delims {
+			delims[j] = "---"
+
+			if j < len(aligns) {
+				switch aligns[j] {
+				case "left":
+					delims[j] = ":---"
+				case "center":
+					delims[j] = ":---:"
+				case "right":
+					delims[j] = "---:"
+				}
+			}
+		}
+
+		lines = append(lines, "| "+strings.Join(delims, " | ")+" |")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+var textAlignRe = regexp.MustCompile(`text-align:\s*(left|center|right)`)
+
+// cellAlign returns the alignment of a table cell, from its style or its
+// obsolete align attribute.
+func cellAlign(cell *html.Node) string {
+	if m := textAlignRe.FindStringSubmatch(attr(cell, "style")); m != nil {
+		return m[1]
+	}
+
+	return strings.ToLower(attr(cell, "align"))
+}
+
+func inlineChildren(n *html.Node) string {
+	var b strings.Builder
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(inline(c))
+	}
+
+	return b.String()
+}
+
+// wrap surrounds the text of an inline element with a Markdown delimiter,
+// keeping the surrounding spaces outside of it.
+func wrap(text string, delim string) string {
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) == 0 {
+		return text
+	}
+
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+
+	return lead + delim + trimmed + delim + trail
+}
+
+func inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return mdEscaper.Replace(spacesRe.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if skippedElements[n.Data] {
+		return ""
+	}
+
+	if isBlock(n) {
+		// Only reached inside headings, table cells and links, which cannot
+		// hold blocks in Markdown.
+		return " " + inlineChildren(n) + " "
+	}
+
+	switch n.Data {
+	case "br":
+		return "\\\n"
+
+	case "strong", "b":
+		return wrap(inlineChildren(n), "**")
+
+	case "em", "i", "cite":
+		return wrap(inlineChildren(n), "*")
+
+	case "del", "s", "strike":
+		return wrap(inlineChildren(n), "~~")
+
+	case "code", "kbd", "samp", "tt":
+		code := spacesRe.ReplaceAllString(textContent(n), " ")
+		if len(code) == 0 {
+			return ""
+		}
+
+		ticks := "`"
+		for strings.Contains(code, ticks) {
+			ticks += "`"
+		}
+
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+
+		return ticks + code + ticks
+
+	case "a":
+		text := inlineChildren(n)
+
+		href := attr(n, "href")
+		if len(href) == 0 {
+			return text
+		}
+
+		return "[" + strings.TrimSpace(text) + "](" + linkDestination(href) + linkTitle(attr(n, "title")) + ")"
+
+	case "img":
+		src := attr(n, "src")
+		if len(src) == 0 {
+			return ""
+		}
+
+		return "![" + mdEscaper.Replace(attr(n, "alt")) + "](" + linkDestination(src) + linkTitle(attr(n, "title")) + ")"
+
+	case "input":
+		if attr(n, "type") != "checkbox" {
+			return ""
+		}
+
+		for _, a := range n.Attr {
+			if a.Key == "checked" {
+				return "[x] "
+			}
+		}
+
+		return "[ ] "
+	}
+
+	return inlineChildren(n)
+}
+
+func linkDestination(dest string) string {
+	if strings.ContainsAny(dest, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(dest) + ">"
+	}
+
+	return dest
+}
+
+func linkTitle(title string) string {
+	if len(title) == 0 {
+		return ""
+	}
+
+	return ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+}
@@ -0,0 +1,682 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	fenceRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	headingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	quoteRe     = regexp.MustCompile(`^ {0,3}> ?`)
+	itemRe      = regexp.MustCompile(`^( *)([-*+]|(\d{1,9})[.)])(?:( +)(.*))?$`)
+	taskRe      = regexp.MustCompile(`^\[([ xX])\] `)
+	delimRowRe  = regexp.MustCompile(`^ *\|? *:?-+:? *(\| *:?-+:? *)*\|? *$`)
+	htmlBlockRe = regexp.MustCompile(`(?i)^ {0,3}</?(address|article|aside|blockquote|details|div|dl|fieldset|figcaption|figure|footer|form|h[1-6]|header|hr|iframe|li|main|nav|ol|p|pre|section|summary|table|tbody|td|tfoot|th|thead|tr|ul|!--)[\s/>]`)
+	setextRe    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	autolinkRe  = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*)>`)
+	inlineTagRe = regexp.MustCompile(`^</?[a-zA-Z][a-zA-Z0-9-]*(?:\s+[^<>]*)?/?>`)
+	entityRe    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+)
+
+// MarkdownToHTML renders CommonMark with the GitHub extensions Joplin notes
+// commonly use: tables, task lists and strikethrough. HTML embedded in the
+// Markdown is passed through.
+func MarkdownToHTML(src string) string {
+	src = strings.ReplaceAll(strings.ReplaceAll(src, "\r\n", "\n"), "\t", "    ")
+
+	var b strings.Builder
+
+	renderBlocks(&b, strings.Split(src, "\n"), false)
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func isBlank(line string) bool {
+	return len(strings.TrimSpace(line)) == 0
+}
+
+// startsBlock reports whether line interrupts a paragraph.
+func startsBlock(line string) bool {
+	if fenceRe.MatchString(line) || headingRe.MatchString(line) || ruleRe.MatchString(line) ||
+		quoteRe.MatchString(line) || htmlBlockRe.MatchString(line) {
+		return true
+	}
+
+	m := itemRe.FindStringSubmatch(line)
+
+	return m != nil && len(m[1]) < 4 && len(m[5]) > 0
+}
+
+// renderBlocks renders lines as a sequence of blocks. In tight lists the
+// paragraphs are not wrapped in <p>.
+func renderBlocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+
+		case fenceRe.MatchString(line):
+			i = renderFence(b, lines, i)
+
+		case headingRe.MatchString(line):
+			m := headingRe.FindStringSubmatch(line)
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", len(m[1]), renderInline(m[2]), len(m[1]))
+			i++
+
+		case ruleRe.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quoteRe.MatchString(line):
+			var inner []string
+
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				if quoteRe.MatchString(lines[i]) {
+					inner = append(inner, quoteRe.ReplaceAllString(lines[i], ""))
+				} else {
+					// Lazy continuation of a quoted paragraph.
+					inner = append(inner, lines[i])
+				}
+			}
+
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, inner, false)
+			b.WriteString("</blockquote>\n")
+
+		case itemRe.MatchString(line) && len(itemRe.FindStringSubmatch(line)[1]) < 4:
+			i = renderList(b, lines, i)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && delimRowRe.MatchString(lines[i+1]) &&
+			len(splitRow(line)) == len(splitRow(lines[i+1])):
+			i = renderTable(b, lines, i)
+
+		case htmlBlockRe.MatchString(line):
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				b.WriteString(lines[i])
+				b.WriteString("\n")
+			}
+
+		case strings.HasPrefix(line, "    "):
+			var code []string
+
+			for ; i < len(lines) && (strings.HasPrefix(lines[i], "    ") || isBlank(lines[i])); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+
+			fmt.Fprintf(b, "<pre><code>%s\n</code></pre>\n", html.EscapeString(strings.Join(code, "\n")))
+
+		default:
+			i = renderParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+func renderFence(b *strings.Builder, lines []string, i int) int {
+	m := fenceRe.FindStringSubmatch(lines[i])
+	fence := m[1]
+
+	var code []string
+
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && len(strings.Trim(trimmed, fence[:1])) == 0 {
+			i++
+			break
+		}
+
+		code = append(code, lines[i])
+	}
+
+	class := ""
+	if len(m[2]) != 0 {
+		class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(m[2]))
+	}
+
+	text := strings.Join(code, "\n")
+	if len(code) != 0 {
+		text += "\n"
+	}
+
+	fmt.Fprintf(b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(text))
+
+	return i
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var para []string
+
+	// Keep trailing spaces, they make hard line breaks.
+	para = append(para, strings.TrimLeft(lines[i], " "))
+
+	for i++; i < len(lines) && !isBlank(lines[i]); i++ {
+		if m := setextRe.FindStringSubmatch(lines[i]); m != nil {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", level, renderInline(strings.TrimRight(strings.Join(para, "\n"), " ")), level)
+
+			return i + 1
+		}
+
+		if startsBlock(lines[i]) {
+			break
+		}
+
+		para = append(para, strings.TrimLeft(lines[i], " "))
+	}
+
+	// Trailing spaces ending the paragraph make no line break.
+	text := renderInline(strings.TrimRight(strings.Join(para, "\n"), " "))
+
+	if tight {
+		b.WriteString(text)
+		b.WriteString("\n")
+	} else {
+		fmt.Fprintf(b, "<p>%s</p>\n", text)
+	}
+
+	return i
+}
+
+type listItem struct {
+	lines []string
+}
+
+func renderList(b *strings.Builder, lines []string, i int) int {
+	first := itemRe.FindStringSubmatch(lines[i])
+	ordered := len(first[3]) != 0
+	delim := first[2][len(first[2])-1:]
+
+	var items []listItem
+
+	tight := true
+	blank := false
+
+	for i < len(lines) {
+		m := itemRe.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) >= 4 || (len(m[3]) != 0) != ordered || m[2][len(m[2])-1:] != delim {
+			break
+		}
+
+		if blank {
+			tight = false
+		}
+
+		indent := len(m[1]) + len(m[2]) + len(m[4])
+		if len(m[4]) > 4 || len(m[5]) == 0 {
+			// Content starting with indented code, or an empty item.
+			indent = len(m[1]) + len(m[2]) + 1
+		}
+
+		item := listItem{lines: []string{m[5]}}
+
+		var loose bool
+
+		i, blank, loose = collectItem(lines, i+1, indent, &item)
+		if loose {
+			tight = false
+		}
+
+		items = append(items, item)
+	}
+
+	tag := "ul"
+
+	if ordered {
+		tag = "ol"
+
+		start, _ := strconv.Atoi(first[3])
+		if start != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	for _, item := range items {
+		b.WriteString("<li>")
+
+		if m := taskRe.FindStringSubmatch(item.lines[0]); m != nil {
+			checked := ""
+			if m[1] != " " {
+				checked = " checked"
+			}
+
+			fmt.Fprintf(b, `<input type="checkbox" disabled%s> `, checked)
+
+			item.lines[0] = item.lines[0][len(m[0]):]
+		}
+
+		var inner strings.Builder
+
+		renderBlocks(&inner, item.lines, tight)
+
+		b.WriteString(strings.TrimSuffix(inner.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+
+	fmt.Fprintf(b, "</%s>\n", tag)
+
+	return i
+}
+
+// collectItem adds to item the lines following its first one: indented
+// lines, blank lines followed by indented lines and lazy continuation lines.
+// It returns the index of the first line after the item, whether blank lines
+// were skipped to get there and whether the item holds blank lines.
+func collectItem(lines []string, i int, indent int, item *listItem) (int, bool, bool) {
+	loose := false
+
+	for i < len(lines) {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			j := i
+			for j < len(lines) && isBlank(lines[j]) {
+				j++
+			}
+
+			if j == len(lines) || leadingSpaces(lines[j]) < indent {
+				return j, true, loose
+			}
+
+			for ; i < j; i++ {
+				item.lines = append(item.lines, "")
+			}
+
+			loose = true
+
+		case leadingSpaces(line) >= indent:
+			item.lines = append(item.lines, line[indent:])
+			i++
+
+		case !startsBlock(line):
+			item.lines = append(item.lines, strings.TrimLeft(line, " "))
+			i++
+
+		default:
+			return i, false, loose
+		}
+	}
+
+	return i, false, loose
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// splitRow returns the cells of a table row, without the outer pipes.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+
+	var cell strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func renderTable(b *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+
+	var aligns []string
+
+	for _, delim := range splitRow(lines[i+1]) {
+		left, right := strings.HasPrefix(delim, ":"), strings.HasSuffix(delim, ":")
+
+		switch {
+		case left && right:
+			aligns = append(aligns, ` style="text-align: center"`)
+		case right:
+			aligns = append(aligns, ` style="text-align: right"`)
+		case left:
+			aligns = append(aligns, ` style="text-align: left"`)
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	row := func(cells []string, tag string) {
+		b.WriteString("<tr>")
+
+		for j := range aligns {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+
+			fmt.Fprintf(b, "<%s%s>%s</%s>", tag, aligns[j], renderInline(cell), tag)
+		}
+
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n<thead>\n")
+	row(header, "th")
+	b.WriteString("</thead>\n")
+
+	i += 2
+
+	if i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|") {
+		b.WriteString("<tbody>\n")
+
+		for ; i < len(lines) && !isBlank(lines[i]) && strings.Contains(lines[i], "|"); i++ {
+			row(splitRow(lines[i]), "td")
+		}
+
+		b.WriteString("</tbody>\n")
+	}
+
+	b.WriteString("</table>\n")
+
+	return i
+}
+
+// renderInline renders the inline Markdown of a paragraph: code spans, links,
+// images, emphasis, strikethrough, autolinks, inline HTML and line breaks.
+func renderInline(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+
+		case c == ' ' && strings.HasPrefix(strings.TrimLeft(s[i:], " "), "\n"):
+			// Two spaces or more ending a line make a hard line break.
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], " "))
+			if n >= 2 {
+				b.WriteString("<br>")
+			}
+
+			b.WriteString("\n")
+			i += n + 1
+
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			ticks := s[i : i+n]
+
+			end := strings.Index(s[i+n:], ticks)
+			for end >= 0 && i+n+end+n < len(s) && s[i+n+end+n] == '`' {
+				next := strings.Index(s[i+n+end+n:], ticks)
+				if next < 0 {
+					end = -1
+					break
+				}
+
+				end += n + next
+			}
+
+			if end < 0 {
+				b.WriteString(ticks)
+				i += n
+
+				continue
+			}
+
+			code := strings.ReplaceAll(s[i+n:i+n+end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && len(strings.TrimSpace(code)) > 0 {
+				code = code[1 : len(code)-1]
+			}
+
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(code))
+			i += n + end + n
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			text, dest, title, n, ok := parseLink(s[i+1:])
+			if !ok {
+				b.WriteString("!")
+				i++
+
+				continue
+			}
+
+			fmt.Fprintf(&b, `<img src="%s" alt="%s"%s>`, html.EscapeString(dest), html.EscapeString(plainText(text)), titleAttr(title))
+			i += 1 + n
+
+		case c == '[':
+			text, dest, title, n, ok := parseLink(s[i:])
+			if !ok {
+				b.WriteString("[")
+				i++
+
+				continue
+			}
+
+			fmt.Fprintf(&b, `<a href="%s"%s>%s</a>`, html.EscapeString(dest), titleAttr(title), renderInline(text))
+			i += n
+
+		case c == '<' && autolinkRe.MatchString(s[i:]):
+			m := autolinkRe.FindStringSubmatch(s[i:])
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, html.EscapeString(m[1]), html.EscapeString(m[1]))
+			i += len(m[0])
+
+		case c == '<' && inlineTagRe.MatchString(s[i:]):
+			tag := inlineTagRe.FindString(s[i:])
+			b.WriteString(tag)
+			i += len(tag)
+
+		case c == '&' && entityRe.MatchString(s[i:]):
+			entity := entityRe.FindString(s[i:])
+			b.WriteString(entity)
+			i += len(entity)
+
+		case c == '*' || c == '_' || c == '~':
+			n, ok := renderEmphasis(&b, s, i)
+			if !ok {
+				b.WriteString(s[i : i+n])
+			}
+
+			i += n
+
+		default:
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+func titleAttr(title string) string {
+	if len(title) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(` title="%s"`, html.EscapeString(title))
+}
+
+// plainText drops the Markdown syntax of an image description.
+func plainText(s string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "").Replace(s)
+}
+
+// parseLink parses "[text](destination "title")" at the start of s and returns
+// its parts and length.
+func parseLink(s string) (string, string, string, int, bool) {
+	depth := 0
+	closing := -1
+
+	for i := 0; i < len(s) && closing < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+
+	if closing < 0 || closing+1 >= len(s) || s[closing+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	text := s[1:closing]
+
+	depth = 0
+	end := -1
+
+	for i := closing + 1; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+
+	if end < 0 {
+		return "", "", "", 0, false
+	}
+
+	inside := strings.TrimSpace(s[closing+2 : end])
+	dest, title := inside, ""
+
+	if strings.HasPrefix(inside, "<") {
+		if close := strings.Index(inside, ">"); close > 0 {
+			dest, title = inside[1:close], strings.TrimSpace(inside[close+1:])
+		}
+	} else if space := strings.IndexAny(inside, " \n"); space > 0 {
+		dest, title = inside[:space], strings.TrimSpace(inside[space+1:])
+	}
+
+	if len(title) >= 2 && strings.ContainsRune(`"'(`, rune(title[0])) {
+		title = title[1 : len(title)-1]
+	} else if len(title) != 0 {
+		return "", "", "", 0, false
+	}
+
+	return text, dest, title, end + 1, true
+}
+
+// renderEmphasis renders the emphasis, strong emphasis or strikethrough
+// opened by the delimiter run at s[i]. It returns the number of bytes used,
+// or the length of the run and false when the run opens nothing.
+func renderEmphasis(b *strings.Builder, s string, i int) (int, bool) {
+	c := s[i]
+
+	n := 1
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+
+	run := s[i : i+n]
+
+	if c == '~' && n != 2 {
+		return n, false
+	}
+
+	if n > 3 {
+		return n, false
+	}
+
+	// An opening run is followed by a non-space, and underscores may not be
+	// inside a word.
+	if i+n >= len(s) || s[i+n] == ' ' || s[i+n] == '\n' {
+		return n, false
+	}
+
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return n, false
+	}
+
+	for j := i + n; j < len(s); j++ {
+		if s[j] == '\\' {
+			j++
+			continue
+		}
+
+		if s[j] == '`' {
+			// Delimiters inside code spans do not count.
+			if end := strings.IndexByte(s[j+1:], '`'); end >= 0 {
+				j += end + 1
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(s[j:], run) || s[j-1] == ' ' || s[j-1] == '\n' {
+			continue
+		}
+
+		if j+n < len(s) && s[j+n] == c {
+			continue
+		}
+
+		if c == '_' && j+n < len(s) && isWordByte(s[j+n]) {
+			continue
+		}
+
+		inner := renderInline(s[i+n : j])
+
+		switch {
+		case c == '~':
+			fmt.Fprintf(b, "<del>%s</del>", inner)
+		case n == 1:
+			fmt.Fprintf(b, "<em>%s</em>", inner)
+		case n == 2:
+			fmt.Fprintf(b, "<strong>%s</strong>", inner)
+		default:
+			fmt.Fprintf(b, "<em><strong>%s</strong></em>", inner)
+		}
+
+		return j + n - i, true
+	}
+
+	return n, false
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
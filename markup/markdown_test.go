@@ -0,0 +1,71 @@
+package markup
+
+import "testing"
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"soft break", "foo\nbar", "<p>foo\nbar</p>\n"},
+		{"hard break", "foo  \nbar", "<p>foo<br>\nbar</p>\n"},
+		{"hard break, three spaces", "foo   \nbar", "<p>foo<br>\nbar</p>\n"},
+		{"hard break, backslash", "foo\\\nbar", "<p>foo<br>\nbar</p>\n"},
+		{"hard break, indented first line", "   foo  \n  bar", "<p>foo<br>\nbar</p>\n"},
+		{"hard break, second line", "foo\nbar  \nbaz", "<p>foo\nbar<br>\nbaz</p>\n"},
+		{"trailing spaces ending the paragraph", "foo\nbar  ", "<p>foo\nbar</p>\n"},
+		{"single trailing space", "foo \nbar", "<p>foo\nbar</p>\n"},
+		{"setext heading", "Title  \n===", "<h1>Title</h1>\n"},
+		{"tight list", "- a\n- b\n", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"loose list", "- a\n\n- b\n", "<ul>\n<li><p>a</p></li>\n<li><p>b</p></li>\n</ul>\n"},
+		{"ordered list", "1. one\n2. two\n", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"task list", "- [ ] todo\n- [x] done\n", "<ul>\n<li><input type=\"checkbox\" disabled> todo</li>\n<li><input type=\"checkbox\" disabled checked> done</li>\n</ul>\n"},
+		{"fence", "```go\nx := 1\n```\n", "<pre><code class=\"language-go\">x := 1\n</code></pre>\n"},
+		{"tilde fence", "~~~\n<a>\n~~~", "<pre><code>&lt;a&gt;\n</code></pre>\n"},
+		{"fence keeps trailing spaces", "```\nfoo  \n```", "<pre><code>foo  \n</code></pre>\n"},
+		{"table", "| a | b |\n| --- | :-: |\n| 1 | 2 |\n", "<table>\n<thead>\n<tr><th>a</th><th style=\"text-align: center\">b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td style=\"text-align: center\">2</td></tr>\n</tbody>\n</table>\n"},
+		{"inline", "Some *em*, **strong**, `code` and ~~del~~.", "<p>Some <em>em</em>, <strong>strong</strong>, <code>code</code> and <del>del</del>.</p>\n"},
+		{"quote", "> quote\n", "<blockquote>\n<p>quote</p>\n</blockquote>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MarkdownToHTML(test.src)
+			if got != test.want {
+				t.Errorf("MarkdownToHTML(%q) = %q, want %q", test.src, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"paragraphs", "First paragraph.\n\nSecond paragraph.\n"},
+		{"hard break", "foo\\\nbar\n"},
+		{"heading", "# Title\n\nSome *em* and **strong** and `code` and ~~del~~.\n"},
+		{"tight list", "- a\n- b\n"},
+		{"ordered list", "1. one\n2. two\n"},
+		{"task list", "- [ ] todo\n- [x] done\n"},
+		{"fence", "```go\nx := 1\n```\n"},
+		{"table", "| a | b |\n| --- | :---: |\n| 1 | 2 |\n"},
+		{"quote", "> quote\n"},
+		{"links", "[link](http://example.com \"t\") ![img](:/0123456789abcdef0123456789abcdef)\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := HTMLToMarkdown(MarkdownToHTML(test.src))
+			if err != nil {
+				t.Fatalf("HTMLToMarkdown: %v", err)
+			}
+
+			if got != test.src {
+				t.Errorf("round trip of %q = %q", test.src, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package markup converts note bodies between the two markup languages of
+// Joplin: Markdown and HTML.
+package markup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markup languages, as stored in the markup_language field of notes.
+const (
+	Markdown = 1
+	HTML     = 2
+)
+
+// Name returns the name of a markup language, Markdown for unset values.
+func Name(language int) string {
+	if language == HTML {
+		return "html"
+	}
+
+	return "markdown"
+}
+
+// ParseLanguage returns the markup language called name: markdown (or md) or
+// html.
+func ParseLanguage(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "markdown", "md":
+		return Markdown, nil
+	case "html":
+		return HTML, nil
+	}
+
+	return 0, fmt.Errorf("unknown markup language '%s', expected markdown or html", name)
+}
+
+// Convert turns body from one markup language into another. A zero language
+// is taken as Markdown, like Joplin does.
+func Convert(body string, from int, to int) (string, error) {
+	if from == 0 {
+		from = Markdown
+	}
+
+	if to == 0 {
+		to = Markdown
+	}
+
+	switch {
+	case from == to:
+		return body, nil
+	case from == Markdown && to == HTML:
+		return MarkdownToHTML(body), nil
+	case from == HTML && to == Markdown:
+		return HTMLToMarkdown(body)
+	}
+
+	return "", fmt.Errorf("cannot convert markup language %d to %d", from, to)
+}
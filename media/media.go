@@ -0,0 +1,272 @@
+// Package media extracts the duration, codecs and dimensions of audio and
+// video resources. Joplin resources have no application_data, so the
+// extracted metadata is kept in the application_data of the notes linking to
+// them, keyed by resource ID, and reused until the resource changes.
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// AppDataKey is the application_data key of the media metadata of a note's
+// resources.
+const AppDataKey = "goplin_media"
+
+// Info is the metadata of an audio or video file.
+type Info struct {
+	Duration   float64 `json:"duration,omitempty"`
+	Format     string  `json:"format,omitempty"`
+	VideoCodec string  `json:"video_codec,omitempty"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	Bitrate    int     `json:"bitrate,omitempty"`
+	// Probed is when the metadata was extracted, in milliseconds.
+	Probed int `json:"probed"`
+}
+
+// Prober extracts the metadata of a media file.
+type Prober interface {
+	Probe(path string) (Info, error)
+}
+
+// FFProbe runs ffprobe, or Command when set.
+type FFProbe struct {
+	Command string
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+func (p FFProbe) Probe(path string) (Info, error) {
+	command := p.Command
+	if len(command) == 0 {
+		command = "ffprobe"
+	}
+
+	var out, stderr bytes.Buffer
+
+	cmd := exec.Command(command, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return Info{}, fmt.Errorf("%s failed: %v %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var probe ffprobeOutput
+
+	err = json.Unmarshal(out.Bytes(), &probe)
+	if err != nil {
+		return Info{}, fmt.Errorf("could not read the output of %s: %w", command, err)
+	}
+
+	info := Info{Format: probe.Format.FormatName}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Bitrate, _ = strconv.Atoi(probe.Format.BitRate)
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && len(info.VideoCodec) == 0:
+			// Cover art of audio files is a one frame video stream.
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+		case stream.CodecType == "audio" && len(info.AudioCodec) == 0:
+			info.AudioCodec = stream.CodecName
+		}
+	}
+
+	return info, nil
+}
+
+// IsMedia reports whether mime is an audio or video type.
+func IsMedia(mime string) bool {
+	return strings.HasPrefix(mime, "audio/") || strings.HasPrefix(mime, "video/")
+}
+
+// DownloadTemp writes the content of a resource to a temporary file, named
+// with the resource's extension, and returns its path. The caller removes it.
+func DownloadTemp(client *goplin.Client, resource goplin.Resource) (string, error) {
+	pattern := "goplin-media-*"
+	if len(resource.FileExtension) != 0 {
+		pattern += "." + resource.FileExtension
+	}
+
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	err = client.GetResourceFile(resource.ID, f)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		return "", err
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// ProbeResource downloads a resource to a temporary file and probes it.
+func ProbeResource(client *goplin.Client, prober Prober, resource goplin.Resource) (Info, error) {
+	path, err := DownloadTemp(client, resource)
+	if err != nil {
+		return Info{}, err
+	}
+
+	defer os.Remove(path)
+
+	info, err := prober.Probe(path)
+	if err != nil {
+		return info, err
+	}
+
+	info.Probed = int(time.Now().UnixMilli())
+
+	return info, nil
+}
+
+// Entry is a media resource with its metadata, or the error extracting it.
+type Entry struct {
+	Resource goplin.Resource `json:"resource"`
+	Info     *Info           `json:"media,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Cached returns the media metadata stored in the application_data of the
+// notes, by resource ID. The most recent metadata of a resource wins.
+func Cached(client *goplin.Client) (map[string]Info, error) {
+	notes, err := client.GetAllNotes("id,application_data", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	cached := make(map[string]Info)
+
+	for _, note := range notes {
+		value, err := goplin.GetAppData(note, AppDataKey)
+		if err != nil || value == nil {
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+
+		var infos map[string]Info
+
+		if json.Unmarshal(encoded, &infos) != nil {
+			continue
+		}
+
+		for id, info := range infos {
+			if info.Probed > cached[id].Probed {
+				cached[id] = info
+			}
+		}
+	}
+
+	return cached, nil
+}
+
+// store saves the metadata of a resource in the notes linking to it.
+func store(client *goplin.Client, id string, info Info) error {
+	notes, err := client.GetResourceNotes(id, "id")
+	if err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		err = client.SetAppData(note.ID, AppDataKey, map[string]interface{}{id: info})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Collect returns the metadata of the audio and video resources among
+// resources, which need the id, mime, file_extension and updated_time fields.
+// Cached metadata is used unless the resource changed since or refresh is
+// set; newly extracted metadata is stored for next time. Resources failing to
+// probe are returned with their error.
+func Collect(client *goplin.Client, prober Prober, resources []goplin.Resource, refresh bool) ([]Entry, error) {
+	cached, err := Cached(client)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+
+	for _, resource := range resources {
+		if !IsMedia(resource.Mime) {
+			continue
+		}
+
+		entry := Entry{Resource: resource}
+
+		info, ok := cached[resource.ID]
+		if !ok || refresh || info.Probed < resource.UpdatedTime {
+			info, err = ProbeResource(client, prober, resource)
+			if err != nil {
+				entry.Error = err.Error()
+				entries = append(entries, entry)
+
+				continue
+			}
+
+			err = store(client, resource.ID, info)
+			if err != nil {
+				return entries, err
+			}
+		}
+
+		entry.Info = &info
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// FormatDuration renders seconds as h:mm:ss, or m:ss under an hour.
+func FormatDuration(seconds float64) string {
+	total := int(seconds + 0.5)
+	h, m, s := total/3600, total/60%60, total%60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+
+	return fmt.Sprintf("%d:%02d", m, s)
+}
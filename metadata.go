@@ -0,0 +1,41 @@
+package goplin
+
+import (
+	"sync"
+	"time"
+)
+
+const noteMetadataTTL = time.Minute
+
+// noteMetadataFields are the note fields kept in the metadata cache: enough
+// for statistics, never the body.
+const noteMetadataFields = "id,parent_id,title,created_time,updated_time,is_todo,todo_due,todo_completed"
+
+// noteMetadata caches the metadata of all notes on the client for
+// noteMetadataTTL, so several statistics can be computed from one listing.
+type noteMetadata struct {
+	mu    sync.Mutex
+	built time.Time
+	notes []Note
+}
+
+// getNoteMetadata returns the metadata of all notes, from the cache when it
+// is fresh. The returned slice must not be modified.
+func (c *Client) getNoteMetadata() ([]Note, error) {
+	c.meta.mu.Lock()
+	defer c.meta.mu.Unlock()
+
+	if !c.meta.built.IsZero() && time.Since(c.meta.built) < noteMetadataTTL {
+		return c.meta.notes, nil
+	}
+
+	notes, err := c.GetAllNotes(noteMetadataFields, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	c.meta.notes = notes
+	c.meta.built = time.Now()
+
+	return notes, nil
+}
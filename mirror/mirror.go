@@ -0,0 +1,486 @@
+// Package mirror keeps a directory of Markdown files identical to a folder of
+// the vault, one way: notes are written to files, never the reverse. Unlike
+// dirsync, local edits are overwritten, which suits generated trees such as
+// the content directory of a static site generator.
+//
+// A state file in the directory remembers what was written and the change
+// event cursor of the last run, so later runs only fetch the notes that
+// changed since. Notes moved to the trash are mirrored as deleted.
+//
+// The directory may be an S3 or WebDAV URL, see package sink. The files
+// written there are trusted to be left as they were, and are not deleted
+// with Delete, which needs to list them.
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/export"
+	"github.com/momo182/goplin/sink"
+)
+
+// StateFile is the file in the mirrored directory remembering the last run.
+const StateFile = ".goplin-mirror.json"
+
+const noteFields = "id,parent_id,title,body,updated_time"
+
+// fileState is what the last run wrote for a note.
+type fileState struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Hash        string `json:"hash"`
+	UpdatedTime int    `json:"updated_time"`
+}
+
+type state struct {
+	Folder string               `json:"folder"`
+	Cursor string               `json:"cursor"`
+	Dirs   map[string]string    `json:"dirs"`
+	Files  map[string]fileState `json:"files"`
+}
+
+// Options of Dir.
+type Options struct {
+	// Delete removes the files of notes no longer in the folder and the
+	// files goplin did not write. Hidden files and directories are kept.
+	Delete bool
+	// DryRun reports the changes without making them.
+	DryRun bool
+	// Full reads every note of the folder instead of the changes since the
+	// last run.
+	Full bool
+	// Names configure the file names, export.PatternTitle by default.
+	Names export.SluggerOptions
+}
+
+// Result lists the changes of a run, as paths relative to the directory.
+type Result struct {
+	Written   []string `json:"written"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+	// Incremental is set when only the changed notes were read.
+	Incremental bool `json:"incremental"`
+}
+
+type mirrorer struct {
+	client *goplin.Client
+	dir    string
+	out    sink.Sink
+	remote bool
+	opts   Options
+	result *Result
+	old    state
+	next   state
+	names  *export.Slugger
+}
+
+// Dir mirrors the notes of folder, given by ID or path, and its sub-folders
+// to dir. Sub-folders map to directories and notes to .md files with front
+// matter.
+func Dir(client *goplin.Client, folder string, dir string, opts Options) (Result, error) {
+	var result Result
+
+	m := &mirrorer{
+		client: client,
+		dir:    dir,
+		remote: sink.IsRemote(dir),
+		opts:   opts,
+		result: &result,
+		names:  export.NewSlugger(opts.Names, export.PatternTitle),
+	}
+
+	if m.remote && opts.Delete {
+		return result, fmt.Errorf("cannot delete the other files of '%s', only of local directories", dir)
+	}
+
+	var err error
+
+	m.out, err = sink.Open(dir)
+	if err != nil {
+		return result, err
+	}
+
+	err = m.loadState()
+	if err != nil {
+		return result, err
+	}
+
+	tree, err := client.GetFolderTree()
+	if err != nil {
+		return result, err
+	}
+
+	root, err := goplin.FindFolderNode(tree, folder)
+	if err != nil {
+		return result, err
+	}
+
+	m.next = state{Folder: root.Folder.ID, Dirs: folderDirs(root, m.names), Files: map[string]fileState{}}
+
+	// Notes to write, and the IDs of the notes kept from the last run.
+	var notes []goplin.Note
+	var kept []string
+
+	result.Incremental = !opts.Full && m.old.Folder == m.next.Folder && len(m.old.Cursor) != 0 && sameDirs(m.old.Dirs, m.next.Dirs)
+
+	if result.Incremental {
+		var events []goplin.Event
+
+		events, m.next.Cursor, err = client.GetEvents(m.old.Cursor)
+		if err != nil {
+			return result, err
+		}
+
+		// The last event of a note tells whether it still exists.
+		last := make(map[string]goplin.ChangeType)
+		for _, event := range events {
+			if event.ItemType == goplin.EventItemNote {
+				last[event.ItemID] = event.Type
+			}
+		}
+
+		for id := range m.old.Files {
+			if _, changed := last[id]; !changed {
+				kept = append(kept, id)
+			}
+		}
+
+		for id, kind := range last {
+			if kind == goplin.EventDeleted {
+				continue
+			}
+
+			// A note deleted or trashed after its last event is gone all
+			// the same.
+			note, err := client.GetLiveNote(id, noteFields)
+			if errors.Is(err, goplin.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+
+			if _, ok := m.next.Dirs[note.ParentID]; ok {
+				notes = append(notes, note)
+			}
+		}
+	} else {
+		// The cursor is taken first so that changes made while the notes
+		// are read are caught by the next run.
+		_, m.next.Cursor, err = client.GetEvents("")
+		if err != nil {
+			return result, err
+		}
+
+		all, err := client.GetLiveNotes(noteFields, "", "")
+		if err != nil {
+			return result, err
+		}
+
+		for _, note := range all {
+			if _, ok := m.next.Dirs[note.ParentID]; ok {
+				notes = append(notes, note)
+			}
+		}
+	}
+
+	sort.Strings(kept)
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+
+	// Kept notes hold on to their paths before new paths are given out.
+	for _, id := range kept {
+		m.names.Reserve(m.old.Files[id].Path)
+	}
+
+	for _, id := range kept {
+		err = m.keep(id)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for _, note := range notes {
+		err = m.write(note)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = m.removeStale()
+	if err != nil {
+		return result, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	return result, m.saveState()
+}
+
+// folderDirs maps the folders below root to directories, root being the
+// mirrored directory itself.
+func folderDirs(root *goplin.FolderNode, names *export.Slugger) map[string]string {
+	dirs := map[string]string{root.Folder.ID: "."}
+
+	goplin.WalkFolders(root.Children, func(node *goplin.FolderNode, depth int) {
+		dirs[node.Folder.ID] = names.Unique(dirs[node.Parent.Folder.ID], names.Segment(node.Folder.Title), node.Folder.ID, "")
+	})
+
+	return dirs
+}
+
+func sameDirs(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for id, dir := range a {
+		if b[id] != dir {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keep checks that the file of a note unchanged since the last run is still
+// intact, writing it again from the note otherwise.
+func (m *mirrorer) keep(id string) error {
+	last := m.old.Files[id]
+
+	if m.remote {
+		m.next.Files[id] = last
+		m.result.Unchanged++
+
+		return nil
+	}
+
+	data, err := os.ReadFile(m.fullPath(last.Path))
+	if err == nil && hash(data) == last.Hash {
+		m.next.Files[id] = last
+		m.result.Unchanged++
+
+		return nil
+	}
+
+	note, err := m.client.GetLiveNote(id, noteFields)
+	if errors.Is(err, goplin.ErrNotFound) {
+		m.names.Release(last.Path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	m.names.Release(last.Path)
+
+	if _, ok := m.next.Dirs[note.ParentID]; !ok {
+		return nil
+	}
+
+	return m.write(note)
+}
+
+// write writes the file of a note if it differs from what is on disk, moving
+// it when its folder or title changed.
+func (m *mirrorer) write(note goplin.Note) error {
+	data, err := export.FormatMarkdownNote(export.FrontMatter{ID: note.ID, Title: note.Title, UpdatedTime: note.UpdatedTime}, note.Body)
+	if err != nil {
+		return err
+	}
+
+	dir := m.next.Dirs[note.ParentID]
+
+	last, known := m.old.Files[note.ID]
+
+	target := last.Path
+	if !known || path.Dir(last.Path) != dir || last.Title != note.Title || m.names.Taken(last.Path) {
+		target = m.names.Path(dir, note.Title, note.ID, ".md")
+	} else {
+		m.names.Reserve(target)
+	}
+
+	m.next.Files[note.ID] = fileState{Path: target, Title: note.Title, Hash: hash(data), UpdatedTime: note.UpdatedTime}
+
+	if m.unchanged(target, data, known && last.Path == target && last.Hash == hash(data)) {
+		m.result.Unchanged++
+		return nil
+	}
+
+	m.result.Written = append(m.result.Written, target)
+
+	if m.opts.DryRun {
+		return nil
+	}
+
+	return m.writeFile(target, data)
+}
+
+// unchanged tells whether the file rel already holds data. Remote files are
+// not read back: written tells whether the last run wrote data there.
+func (m *mirrorer) unchanged(rel string, data []byte, written bool) bool {
+	if m.remote {
+		return written
+	}
+
+	current, err := os.ReadFile(m.fullPath(rel))
+
+	return err == nil && hash(current) == hash(data)
+}
+
+func (m *mirrorer) writeFile(rel string, data []byte) error {
+	f, err := m.out.Create(rel)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		sink.Abort(f)
+		return err
+	}
+
+	return f.Close()
+}
+
+// removeStale removes the files written by the last run that no note maps to
+// anymore. With Delete, it also removes the notes deleted or moved out of the
+// folder, every other visible file and the directories left empty.
+func (m *mirrorer) removeStale() error {
+	var stale []string
+
+	if m.opts.Delete {
+		err := filepath.WalkDir(m.dir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p == m.dir {
+					return filepath.SkipDir
+				}
+
+				return err
+			}
+
+			if strings.HasPrefix(d.Name(), ".") && p != m.dir {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(m.dir, p)
+			if err != nil {
+				return err
+			}
+
+			if !m.names.Taken(filepath.ToSlash(rel)) {
+				stale = append(stale, filepath.ToSlash(rel))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		// Without Delete, only the files of notes still in the folder are
+		// cleaned up, when their note moved to another path.
+		for id, last := range m.old.Files {
+			if _, ok := m.next.Files[id]; ok && !m.names.Taken(last.Path) {
+				stale = append(stale, last.Path)
+			}
+		}
+	}
+
+	sort.Strings(stale)
+
+	for _, rel := range stale {
+		m.result.Removed = append(m.result.Removed, rel)
+
+		if m.opts.DryRun {
+			continue
+		}
+
+		err := m.out.Remove(rel)
+		if err != nil {
+			return err
+		}
+
+		if m.opts.Delete {
+			m.removeEmptyDirs(path.Dir(rel))
+		}
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents below the mirrored directory
+// while they are empty.
+func (m *mirrorer) removeEmptyDirs(dir string) {
+	for dir != "." {
+		if os.Remove(m.fullPath(dir)) != nil {
+			return
+		}
+
+		dir = path.Dir(dir)
+	}
+}
+
+func (m *mirrorer) fullPath(rel string) string {
+	return filepath.Join(m.dir, filepath.FromSlash(rel))
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *mirrorer) loadState() error {
+	f, err := m.out.Open(StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(f)
+	f.Close()
+
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &m.old)
+	if err != nil {
+		return fmt.Errorf("invalid %s, remove it for a full run: %w", StateFile, err)
+	}
+
+	return nil
+}
+
+func (m *mirrorer) saveState() error {
+	data, err := json.MarshalIndent(m.next, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return m.writeFile(StateFile, data)
+}
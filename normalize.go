@@ -0,0 +1,172 @@
+package goplin
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TagMerge moves the notes of From onto To and deletes From.
+type TagMerge struct {
+	From  Tag      `json:"from"`
+	To    Tag      `json:"to"`
+	Notes []string `json:"notes"`
+}
+
+// TagRename changes the title of a surviving tag.
+type TagRename struct {
+	Tag   Tag    `json:"tag"`
+	Title string `json:"title"`
+}
+
+type TagNormalizePlan struct {
+	Merges  []TagMerge  `json:"merges"`
+	Renames []TagRename `json:"renames"`
+}
+
+// FoldTagTitle returns the key under which tag titles differing only by case,
+// diacritics or surrounding whitespace compare equal.
+func FoldTagTitle(title string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := transform.String(t, title)
+	if err != nil {
+		folded = title
+	}
+
+	return strings.ToLower(strings.TrimSpace(folded))
+}
+
+// PlanTagNormalization groups tags by folded title, after resolving the
+// synonyms map (folded alias -> canonical title), and decides which tag of each
+// group survives. When lowercase is set, surviving titles are lowercased.
+func (c *Client) PlanTagNormalization(lowercase bool, synonyms map[string]string) (TagNormalizePlan, error) {
+	var plan TagNormalizePlan
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return plan, err
+	}
+
+	aliases := make(map[string]string)
+	for alias, target := range synonyms {
+		aliases[FoldTagTitle(alias)] = target
+	}
+
+	groups := make(map[string][]Tag)
+	wanted := make(map[string]string)
+
+	for _, tag := range index.tags {
+		key := FoldTagTitle(tag.Title)
+
+		if target, ok := aliases[key]; ok {
+			key = FoldTagTitle(target)
+			wanted[key] = target
+		}
+
+		groups[key] = append(groups[key], tag)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		tags := groups[key]
+
+		// Prefer the tag already carrying the wanted title, then the most used one.
+		sort.SliceStable(tags, func(i, j int) bool {
+			if title, ok := wanted[key]; ok {
+				if (tags[i].Title == title) != (tags[j].Title == title) {
+					return tags[i].Title == title
+				}
+			}
+
+			return len(index.notesBy[tags[i].ID]) > len(index.notesBy[tags[j].ID])
+		})
+
+		survivor := tags[0]
+
+		for _, tag := range tags[1:] {
+			plan.Merges = append(plan.Merges, TagMerge{
+				From:  tag,
+				To:    survivor,
+				Notes: append([]string(nil), index.notesBy[tag.ID]...),
+			})
+		}
+
+		title := survivor.Title
+		if target, ok := wanted[key]; ok {
+			title = target
+		}
+
+		if lowercase {
+			title = strings.ToLower(title)
+		}
+
+		if title != survivor.Title {
+			plan.Renames = append(plan.Renames, TagRename{Tag: survivor, Title: title})
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyTagNormalization executes a plan: notes are attached to the surviving
+// tags before merged tags are deleted, then survivors are renamed.
+func (c *Client) ApplyTagNormalization(plan TagNormalizePlan) error {
+	defer c.InvalidateCache()
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return err
+	}
+
+	attachedTo := make(map[string]map[string]bool)
+
+	for _, merge := range plan.Merges {
+		attached, ok := attachedTo[merge.To.ID]
+		if !ok {
+			attached = make(map[string]bool)
+			for _, noteID := range index.notesBy[merge.To.ID] {
+				attached[noteID] = true
+			}
+
+			attachedTo[merge.To.ID] = attached
+		}
+
+		for _, noteID := range merge.Notes {
+			if attached[noteID] {
+				continue
+			}
+
+			err = c.CreateTagsNotes(noteID, merge.To.ID)
+			if err != nil {
+				return err
+			}
+
+			attached[noteID] = true
+		}
+
+		err = c.DeleteTag(merge.From.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, rename := range plan.Renames {
+		err = c.UpdateTag(rename.Tag.ID, rename.Title)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
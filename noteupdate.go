@@ -0,0 +1,94 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NoteUpdate collects changes to a note which ApplyNoteUpdate sends as a
+// single PUT request. Setters can be chained:
+//
+//	client.ApplyNoteUpdate(goplin.NewNoteUpdate(id).SetTitle("Done").Clear("todo_due"))
+type NoteUpdate struct {
+	id     string
+	fields map[string]interface{}
+	extra  map[string]json.RawMessage
+}
+
+func NewNoteUpdate(id string) *NoteUpdate {
+	return &NoteUpdate{
+		id:     id,
+		fields: make(map[string]interface{}),
+	}
+}
+
+// Set changes any writable field; the value is validated when applied.
+func (u *NoteUpdate) Set(field string, value interface{}) *NoteUpdate {
+	u.fields[field] = value
+	return u
+}
+
+func (u *NoteUpdate) SetTitle(title string) *NoteUpdate {
+	return u.Set("title", title)
+}
+
+func (u *NoteUpdate) SetBody(body string) *NoteUpdate {
+	return u.Set("body", body)
+}
+
+func (u *NoteUpdate) SetParent(parentID string) *NoteUpdate {
+	return u.Set("parent_id", parentID)
+}
+
+func (u *NoteUpdate) SetAuthor(author string) *NoteUpdate {
+	return u.Set("author", author)
+}
+
+func (u *NoteUpdate) SetTodoDue(due time.Time) *NoteUpdate {
+	return u.Set("todo_due", int(due.UnixMilli()))
+}
+
+// Clear resets a field to its empty value.
+func (u *NoteUpdate) Clear(field string) *NoteUpdate {
+	kind, ok := writableNoteFields[field]
+	if !ok {
+		// Keep the unknown field so applying the update reports it.
+		return u.Set(field, nil)
+	}
+
+	switch kind {
+	case fieldString:
+		return u.Set(field, "")
+	case fieldFloat:
+		return u.Set(field, 0.0)
+	default:
+		return u.Set(field, 0)
+	}
+}
+
+// SetExtra sends fields not modeled by Note back unchanged, typically the
+// Extra map of a fetched note. They are not validated and never override
+// fields set on the update.
+func (u *NoteUpdate) SetExtra(extra map[string]json.RawMessage) *NoteUpdate {
+	u.extra = extra
+	return u
+}
+
+// Fields returns a copy of the pending changes.
+func (u *NoteUpdate) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(u.fields))
+	for name, value := range u.fields {
+		fields[name] = value
+	}
+
+	return fields
+}
+
+func (c *Client) ApplyNoteUpdate(u *NoteUpdate) error {
+	if len(u.id) == 0 {
+		return fmt.Errorf("note update has no note ID")
+	}
+
+	return c.setNoteFields(u.id, u.fields, u.extra)
+}
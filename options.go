@@ -0,0 +1,149 @@
+package goplin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// DefaultHost is the host Joplin is looked for on.
+const DefaultHost = "localhost"
+
+// DefaultUserAgent is the user agent of the requests of a client.
+const DefaultUserAgent = "goplin"
+
+// Option configures a client created by NewWithOptions.
+type Option func(*options)
+
+// RetryPolicy tells how often failed read requests are sent again: those
+// failing to connect or answered with a server error. Creations, updates and
+// deletions are never retried, as Joplin may have applied them.
+type RetryPolicy struct {
+	// Count is the number of retries, none when zero.
+	Count int
+	// MinInterval and MaxInterval bound the exponential backoff between
+	// retries; the interval is fixed to MinInterval when MaxInterval is zero.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+}
+
+type options struct {
+	apiToken   string
+	host       string
+	minPort    int
+	maxPort    int
+	timeout    time.Duration
+	userAgent  string
+	httpClient *http.Client
+	retry      RetryPolicy
+}
+
+// WithAPIToken sets the token of the Data API. Without it, a token is
+// requested, which the user has to accept in Joplin.
+func WithAPIToken(apiToken string) Option {
+	return func(o *options) { o.apiToken = apiToken }
+}
+
+// WithHost sets the host Joplin runs on, DefaultHost by default.
+func WithHost(host string) Option {
+	return func(o *options) { o.host = host }
+}
+
+// WithPort sets the port Joplin listens on, instead of looking for it on the
+// Web Clipper ports.
+func WithPort(port int) Option {
+	return func(o *options) {
+		o.minPort = port
+		o.maxPort = port
+	}
+}
+
+// WithTimeout sets the timeout of each request, 5s by default. Large note
+// bodies get more, see LargeBodyOpts.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithUserAgent sets the user agent of the requests, DefaultUserAgent by
+// default.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) { o.userAgent = userAgent }
+}
+
+// WithHTTPClient sends the requests with the transport of httpClient, such
+// as the one of an httptest.Server, and its timeout unless WithTimeout is
+// given too.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) { o.httpClient = httpClient }
+}
+
+// WithRetryPolicy retries failed read requests, see RetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) { o.retry = policy }
+}
+
+// NewWithOptions returns a client configured by opts, for setups other than
+// a desktop Joplin on localhost and for tests.
+func NewWithOptions(opts ...Option) (*Client, error) {
+	o := options{
+		host:      DefaultHost,
+		minPort:   joplinMinPortNum,
+		maxPort:   joplinMaxPortNum,
+		userAgent: DefaultUserAgent,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.timeout == 0 {
+		o.timeout = requestTimeout
+
+		if o.httpClient != nil && o.httpClient.Timeout != 0 {
+			o.timeout = o.httpClient.Timeout
+		}
+	}
+
+	return connect(o)
+}
+
+// newHandle returns the req client of a client.
+func newHandle(o options) *req.Client {
+	// In production, create a client explicitly and reuse it to send all requests
+	// Use C() to create a client and set with chainable client settings.
+	// The timeout is applied per request by a timeoutTransport, so that
+	// large bodies can be given more.
+	client := req.C().
+		SetUserAgent(o.userAgent).
+		SetTimeout(0)
+
+	if o.httpClient != nil && o.httpClient.Transport != nil {
+		client.GetClient().Transport = o.httpClient.Transport
+	}
+
+	if o.retry.Count > 0 {
+		client.SetCommonRetryCount(o.retry.Count)
+
+		if o.retry.MaxInterval > 0 {
+			client.SetCommonRetryBackoffInterval(o.retry.MinInterval, o.retry.MaxInterval)
+		} else {
+			client.SetCommonRetryFixedInterval(o.retry.MinInterval)
+		}
+
+		client.AddCommonRetryCondition(func(resp *req.Response, err error) bool {
+			if resp == nil || resp.Request == nil {
+				return false
+			}
+
+			method := resp.Request.Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return false
+			}
+
+			return err != nil || resp.Response != nil && resp.StatusCode >= 500
+		})
+	}
+
+	return client
+}
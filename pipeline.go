@@ -0,0 +1,345 @@
+package goplin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pipeline step operations. Selection steps narrow down the notes a pipeline
+// acts on, action steps queue changes to every selected note.
+const (
+	StepSearch    = "search"     // notes matching a search query
+	StepFolder    = "folder"     // notes directly in a folder, by ID or title
+	StepTag       = "tag"        // notes carrying a tag, by ID or title
+	StepOlderThan = "older_than" // notes not updated within a duration
+	StepNewerThan = "newer_than" // notes updated within a duration
+	StepTodo      = "todo"       // to-dos: open, done or overdue
+	StepAddTag    = "add_tag"    // attach a tag, created when missing
+	StepRemoveTag = "remove_tag" // detach a tag
+	StepMove      = "move"       // move to a folder, created when missing
+	StepSet       = "set"        // set note fields
+)
+
+var pipelineSelections = map[string]bool{
+	StepSearch:    true,
+	StepFolder:    true,
+	StepTag:       true,
+	StepOlderThan: true,
+	StepNewerThan: true,
+	StepTodo:      true,
+}
+
+var pipelineActions = map[string]bool{
+	StepAddTag:    true,
+	StepRemoveTag: true,
+	StepMove:      true,
+	StepSet:       true,
+}
+
+// PipelineStep is a single step of a pipeline. Fields is only used by set.
+type PipelineStep struct {
+	Op     string            `json:"op"`
+	Arg    string            `json:"arg,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// PipelineResult tells which notes a pipeline selected and what it did.
+type PipelineResult struct {
+	Selected []Note      `json:"selected"`
+	Ops      []BatchOp   `json:"ops"`
+	Report   BatchReport `json:"report"`
+}
+
+// ParsePipelineSteps converts steps as read from the config file, each a map
+// with a single operation key:
+//
+//	pipelines:
+//	  inbox-zero:
+//	    - search: "notebook:Inbox"
+//	    - older_than: 30d
+//	    - add_tag: archive
+//	    - move: Archive
+func ParsePipelineSteps(raw []map[string]interface{}) ([]PipelineStep, error) {
+	steps := make([]PipelineStep, 0, len(raw))
+	selected := false
+
+	for i, entry := range raw {
+		if len(entry) != 1 {
+			return nil, fmt.Errorf("step %d: expected a single operation, got %d", i+1, len(entry))
+		}
+
+		for op, arg := range entry {
+			step := PipelineStep{Op: op}
+
+			switch {
+			case op == StepSet:
+				fields, ok := arg.(map[string]interface{})
+				if !ok || len(fields) == 0 {
+					return nil, fmt.Errorf("step %d: set expects a map of fields", i+1)
+				}
+
+				step.Fields = make(map[string]string, len(fields))
+				for name, value := range fields {
+					step.Fields[name] = fmt.Sprint(value)
+				}
+			case pipelineSelections[op] || pipelineActions[op]:
+				step.Arg = strings.TrimSpace(fmt.Sprint(arg))
+				if len(step.Arg) == 0 {
+					return nil, fmt.Errorf("step %d: %s needs an argument", i+1, op)
+				}
+			default:
+				return nil, fmt.Errorf("step %d: unknown operation '%s'", i+1, op)
+			}
+
+			if pipelineSelections[op] {
+				selected = true
+			} else if !selected {
+				return nil, fmt.Errorf("step %d: %s comes before any selection step", i+1, op)
+			}
+
+			steps = append(steps, step)
+		}
+	}
+
+	return steps, nil
+}
+
+const pipelineNoteFields = "id,parent_id,title,updated_time,is_todo,todo_due,todo_completed"
+
+// pipelineRun holds the lookups shared by the steps of a run.
+type pipelineRun struct {
+	c       *Client
+	batch   *Batch
+	folders []Folder
+	tags    []Tag
+}
+
+func (r *pipelineRun) folderID(idOrTitle string) (string, bool, error) {
+	if r.folders == nil {
+		folders, err := r.c.GetAllFolders("id,parent_id,title", "", "")
+		if err != nil {
+			return "", false, err
+		}
+
+		r.folders = folders
+	}
+
+	for _, folder := range r.folders {
+		if folder.ID == idOrTitle || strings.EqualFold(folder.Title, idOrTitle) {
+			return folder.ID, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (r *pipelineRun) tagID(idOrTitle string) (string, bool, error) {
+	if r.tags == nil {
+		tags, err := r.c.GetAllTags("", "")
+		if err != nil {
+			return "", false, err
+		}
+
+		r.tags = tags
+	}
+
+	for _, tag := range r.tags {
+		if tag.ID == idOrTitle || FoldTagTitle(tag.Title) == FoldTagTitle(idOrTitle) {
+			return tag.ID, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// RunPipeline selects notes and queues the actions of the pipeline on them
+// in a batch, each action step waiting for the previous one. With dryRun
+// nothing is changed and the result lists the operations that would run.
+func (c *Client) RunPipeline(steps []PipelineStep, dryRun bool) (PipelineResult, error) {
+	var result PipelineResult
+
+	selected, err := c.GetAllNotes(pipelineNoteFields, "", "")
+	if err != nil {
+		return result, err
+	}
+
+	run := &pipelineRun{c: c, batch: c.NewBatch()}
+	run.batch.DryRun = dryRun
+
+	narrow := func(keep func(note Note) bool) {
+		var kept []Note
+
+		for _, note := range selected {
+			if keep(note) {
+				kept = append(kept, note)
+			}
+		}
+
+		selected = kept
+	}
+
+	inSet := func(ids map[string]bool) func(note Note) bool {
+		return func(note Note) bool {
+			return ids[note.ID]
+		}
+	}
+
+	for _, step := range steps {
+		switch step.Op {
+		case StepSearch:
+			items, err := c.Search(step.Arg, "note", "id")
+			if err != nil {
+				return result, err
+			}
+
+			ids := make(map[string]bool, len(items))
+			for _, item := range items {
+				ids[item.ID] = true
+			}
+
+			narrow(inSet(ids))
+		case StepFolder:
+			id, ok, err := run.folderID(step.Arg)
+			if err != nil {
+				return result, err
+			}
+
+			if !ok {
+				return result, fmt.Errorf("could not find folder '%s'", step.Arg)
+			}
+
+			narrow(func(note Note) bool {
+				return note.ParentID == id
+			})
+		case StepTag:
+			id, ok, err := run.tagID(step.Arg)
+			if err != nil {
+				return result, err
+			}
+
+			if !ok {
+				return result, fmt.Errorf("could not find tag '%s'", step.Arg)
+			}
+
+			tagged, err := c.GetNotesByTagWithFields(id, "id", "", "")
+			if err != nil {
+				return result, err
+			}
+
+			ids := make(map[string]bool, len(tagged))
+			for _, note := range tagged {
+				ids[note.ID] = true
+			}
+
+			narrow(inSet(ids))
+		case StepOlderThan, StepNewerThan:
+			d, err := ParseDuration(step.Arg)
+			if err != nil {
+				return result, err
+			}
+
+			limit := int(time.Now().Add(-d).UnixMilli())
+			older := step.Op == StepOlderThan
+
+			narrow(func(note Note) bool {
+				return (note.UpdatedTime < limit) == older
+			})
+		case StepTodo:
+			filter := TodoFilter{}
+
+			switch step.Arg {
+			case "open":
+				filter.Open = true
+			case "done":
+				filter.Done = true
+			case "overdue":
+				filter.Overdue = true
+			default:
+				return result, fmt.Errorf("todo expects open, done or overdue, got '%s'", step.Arg)
+			}
+
+			narrow(filter.Match)
+		default:
+			err = run.act(step, selected)
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Title < selected[j].Title
+	})
+
+	result.Selected = selected
+	result.Ops = run.batch.Ops()
+	result.Report, err = run.batch.Run()
+
+	return result, err
+}
+
+// act queues an action step for the selected notes.
+func (r *pipelineRun) act(step PipelineStep, notes []Note) error {
+	b := r.batch
+
+	switch step.Op {
+	case StepAddTag:
+		id, ok, err := r.tagID(step.Arg)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			id = b.CreateTag(Tag{Title: step.Arg})
+			r.tags = append(r.tags, Tag{ID: id, Title: step.Arg})
+			b.Barrier()
+		}
+
+		for _, note := range notes {
+			b.TagNote(note.ID, id)
+		}
+	case StepRemoveTag:
+		id, ok, err := r.tagID(step.Arg)
+		if err != nil || !ok {
+			return err
+		}
+
+		for _, note := range notes {
+			b.UntagNote(note.ID, id)
+		}
+	case StepMove:
+		id, ok, err := r.folderID(step.Arg)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			id = b.CreateFolder(Folder{Title: step.Arg})
+			r.folders = append(r.folders, Folder{ID: id, Title: step.Arg})
+			b.Barrier()
+		}
+
+		for _, note := range notes {
+			if note.ParentID != id {
+				b.UpdateNote(NewNoteUpdate(note.ID).SetParent(id))
+			}
+		}
+	case StepSet:
+		for _, note := range notes {
+			u := NewNoteUpdate(note.ID)
+			for name, value := range step.Fields {
+				u.Set(name, value)
+			}
+
+			b.UpdateNote(u)
+		}
+	default:
+		return fmt.Errorf("unknown pipeline operation '%s'", step.Op)
+	}
+
+	b.Barrier()
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+// Inline image protocols of terminals.
+const (
+	ProtocolNone  = ""
+	ProtocolKitty = "kitty"
+	ProtocolITerm = "iterm"
+	ProtocolSixel = "sixel"
+)
+
+// Protocols lists the supported inline image protocols.
+var Protocols = []string{ProtocolKitty, ProtocolITerm, ProtocolSixel}
+
+// DetectProtocol guesses the inline image protocol of the terminal from the
+// environment, ProtocolNone when it supports none known.
+func DetectProtocol() string {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+
+	switch {
+	case len(os.Getenv("KITTY_WINDOW_ID")) != 0 || term == "xterm-kitty" || program == "ghostty":
+		return ProtocolKitty
+	case program == "iTerm.app" || program == "WezTerm" || len(os.Getenv("ITERM_SESSION_ID")) != 0:
+		return ProtocolITerm
+	case strings.Contains(term, "sixel") || term == "foot" || term == "mlterm" || program == "mlterm":
+		return ProtocolSixel
+	}
+
+	return ProtocolNone
+}
+
+// Write draws img in the terminal with the given protocol.
+func Write(w io.Writer, img image.Image, protocol string) error {
+	switch protocol {
+	case ProtocolKitty:
+		return WriteKitty(w, img)
+	case ProtocolITerm:
+		return WriteITerm(w, img)
+	case ProtocolSixel:
+		return WriteSixel(w, img)
+	}
+
+	return fmt.Errorf("unknown image protocol '%s', expected one of %s", protocol, strings.Join(Protocols, ", "))
+}
+
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// WriteKitty draws img with the kitty graphics protocol, sending the PNG in
+// chunks of 4096 bytes.
+func WriteKitty(w io.Writer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+
+	const chunk = 4096
+
+	for i := 0; i < len(data); i += chunk {
+		end := i + chunk
+		more := 1
+
+		if end >= len(data) {
+			end, more = len(data), 0
+		}
+
+		control := fmt.Sprintf("m=%d", more)
+		if i == 0 {
+			control = "a=T,f=100," + control
+		}
+
+		_, err = fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", control, data[i:end])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintln(w)
+
+	return err
+}
+
+// WriteITerm draws img with the inline images protocol of iTerm2, also
+// understood by WezTerm.
+func WriteITerm(w io.Writer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+
+	size := base64.StdEncoding.DecodedLen(len(data))
+
+	_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a\n", size, data)
+
+	return err
+}
+
+// WriteSixel draws img as DEC sixel graphics, reduced to the 216 web safe
+// colors with Floyd-Steinberg dithering. Mostly transparent pixels are left
+// undrawn.
+func WriteSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, b.Min)
+
+	bw := bufio.NewWriter(w)
+
+	// P2=1 keeps pixels with no sixel set transparent.
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+
+	for i, c := range palette.WebSafe {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	opaque := func(x, y int) bool {
+		_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return a >= 0x8000
+	}
+
+	for top := 0; top < height; top += 6 {
+		// The sixels of each color used in the band, one per column.
+		bands := make(map[uint8][]byte)
+
+		var order []uint8
+
+		for x := 0; x < width; x++ {
+			for dy := 0; dy < 6 && top+dy < height; dy++ {
+				if !opaque(x, top+dy) {
+					continue
+				}
+
+				index := paletted.ColorIndexAt(x, top+dy)
+
+				row, ok := bands[index]
+				if !ok {
+					row = make([]byte, width)
+					bands[index] = row
+					order = append(order, index)
+				}
+
+				row[x] |= 1 << dy
+			}
+		}
+
+		for i, index := range order {
+			if i > 0 {
+				bw.WriteByte('$')
+			}
+
+			fmt.Fprintf(bw, "#%d", index)
+			writeSixelRow(bw, bands[index])
+		}
+
+		bw.WriteByte('-')
+	}
+
+	bw.WriteString("\x1b\\\n")
+
+	return bw.Flush()
+}
+
+// writeSixelRow writes the sixels of a band, run length encoded.
+func writeSixelRow(w *bufio.Writer, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+
+		c := row[x] + '?'
+
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, c)
+		} else {
+			for i := 0; i < n; i++ {
+				w.WriteByte(c)
+			}
+		}
+
+		x += n
+	}
+}
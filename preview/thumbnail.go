@@ -0,0 +1,187 @@
+// Package preview makes thumbnails of image resources and draws them in
+// terminals supporting inline images.
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Registers the GIF decoder, PNG and JPEG come with their encoders.
+	_ "image/gif"
+
+	"github.com/momo182/goplin"
+)
+
+// Default thumbnail bounds.
+const (
+	DefaultMaxWidth  = 640
+	DefaultMaxHeight = 480
+)
+
+// Thumbnailer scales images down to fit in MaxWidth by MaxHeight pixels. When
+// CacheDir is set, the thumbnails of resources are kept there as PNG files and
+// reused until the resource changes.
+type Thumbnailer struct {
+	MaxWidth  int
+	MaxHeight int
+	CacheDir  string
+}
+
+// NewThumbnailer returns a thumbnailer with the default bounds and no cache.
+func NewThumbnailer() *Thumbnailer {
+	return &Thumbnailer{MaxWidth: DefaultMaxWidth, MaxHeight: DefaultMaxHeight}
+}
+
+// Thumbnail decodes a PNG, JPEG or GIF image and scales it down.
+func (t *Thumbnailer) Thumbnail(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode image: %w", err)
+	}
+
+	return Scale(img, t.MaxWidth, t.MaxHeight), nil
+}
+
+func (t *Thumbnailer) cachePath(id string) string {
+	return filepath.Join(t.CacheDir, fmt.Sprintf("%s-%dx%d.png", id, t.MaxWidth, t.MaxHeight))
+}
+
+// Resource returns the thumbnail of an image resource.
+func (t *Thumbnailer) Resource(client *goplin.Client, id string) (image.Image, error) {
+	resource, err := client.GetResource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(resource.Mime, "image/") {
+		return nil, fmt.Errorf("resource '%s' is not an image but %s", id, resource.Mime)
+	}
+
+	if len(t.CacheDir) != 0 {
+		info, err := os.Stat(t.cachePath(id))
+		if err == nil && !info.ModTime().Before(time.UnixMilli(int64(resource.UpdatedTime))) {
+			f, err := os.Open(t.cachePath(id))
+			if err == nil {
+				defer f.Close()
+
+				img, err := png.Decode(f)
+				if err == nil {
+					return img, nil
+				}
+			}
+		}
+	}
+
+	var data bytes.Buffer
+
+	err = client.GetResourceFile(id, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := t.Thumbnail(&data)
+	if err != nil {
+		return nil, fmt.Errorf("resource '%s': %w", id, err)
+	}
+
+	if len(t.CacheDir) != 0 {
+		// A cache that cannot be written only costs time.
+		if os.MkdirAll(t.CacheDir, 0o700) == nil {
+			_ = WriteFile(t.cachePath(id), img)
+		}
+	}
+
+	return img, nil
+}
+
+// Scale shrinks img to fit in maxWidth by maxHeight pixels keeping its aspect
+// ratio, averaging the pixels covered by each new pixel. Smaller images and
+// zero bounds leave img unchanged.
+func Scale(img image.Image, maxWidth int, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if maxWidth <= 0 || maxHeight <= 0 || w == 0 || h == 0 || (w <= maxWidth && h <= maxHeight) {
+		return img
+	}
+
+	dw, dh := maxWidth, h*maxWidth/w
+	if dh > maxHeight {
+		dw, dh = w*maxHeight/h, maxHeight
+	}
+
+	if dw == 0 {
+		dw = 1
+	}
+
+	if dh == 0 {
+		dh = 1
+	}
+
+	out := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+(y+1)*h/dh
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+(x+1)*w/dw
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, bl, a, n uint64
+
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			out.Set(x, y, color.NRGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n),
+			})
+		}
+	}
+
+	return out
+}
+
+// WriteFile saves img as PNG, or as JPEG when path ends in .jpg or .jpeg.
+func WriteFile(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 85})
+	default:
+		err = png.Encode(f, img)
+	}
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,223 @@
+package goplin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NoteQuery combines filters on notes, all of which a note must pass.
+type NoteQuery struct {
+	// Folder is the ID, path or title of a folder; notes of its sub-folders
+	// match too.
+	Folder string
+	// Tags are the IDs or titles of tags, notes must carry all of them.
+	Tags []string
+	// Todo filters to-dos.
+	Todo TodoFilter
+	// UpdatedSince keeps the notes updated at or after it, when not zero.
+	UpdatedSince time.Time
+	// Language keeps the notes in this language, an ISO 639-1 code as told
+	// by DetectLanguage, see NoteLanguages.
+	Language string
+}
+
+// IsZero tells whether the query matches every note.
+func (q NoteQuery) IsZero() bool {
+	return len(q.Folder) == 0 && len(q.Tags) == 0 && q.Todo.IsZero() && q.UpdatedSince.IsZero() && len(q.Language) == 0
+}
+
+// QueryNotes returns the notes matching q with the given fields, picking the
+// cheapest way to get them: the notes of the first tag when there are tags,
+// intersected with the IDs of the notes of the others; the notes of the
+// folder when it has no sub-folders; else every note, from the metadata
+// cache when it holds the fields. The remaining filters are applied to the
+// notes fetched, whose order is the one asked for. Empty fields stand for
+// DefaultNoteFields.
+func (c *Client) QueryNotes(q NoteQuery, fields string, orderBy string, orderDir string) ([]Note, error) {
+	if len(fields) == 0 {
+		fields = DefaultNoteFields
+	}
+
+	fetchFields := fields
+	if len(q.Folder) != 0 {
+		fetchFields = withField(fetchFields, "parent_id")
+	}
+
+	if !q.UpdatedSince.IsZero() {
+		fetchFields = withField(fetchFields, "updated_time")
+	}
+
+	if !q.Todo.IsZero() {
+		for _, field := range TodoFields {
+			fetchFields = withField(fetchFields, field)
+		}
+	}
+
+	// The folders of the subtree, nil when any folder matches.
+	var folders map[string]bool
+	var root string
+
+	if len(q.Folder) != 0 {
+		tree, err := c.GetFolderTree()
+		if err != nil {
+			return nil, err
+		}
+
+		node, err := FindFolderNode(tree, q.Folder)
+		if err != nil {
+			return nil, err
+		}
+
+		root = node.Folder.ID
+		folders = make(map[string]bool)
+
+		WalkFolders([]*FolderNode{node}, func(node *FolderNode, depth int) {
+			folders[node.Folder.ID] = true
+		})
+	}
+
+	// The languages of the notes, nil when any language matches.
+	var languages map[string]string
+
+	if len(q.Language) != 0 {
+		var err error
+
+		languages, err = c.NoteLanguages()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The notes carrying every tag but the first, nil without tags.
+	var tagged map[string]bool
+
+	var notes []Note
+	var err error
+
+	switch {
+	case len(q.Tags) != 0:
+		ids, err := c.resolveTags(q.Tags, false)
+		if err != nil {
+			return nil, err
+		}
+
+		notes, err = c.GetNotesByTagWithFields(ids[0], fetchFields, orderBy, orderDir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids[1:] {
+			others, err := c.GetNotesByTagWithFields(id, "id", "", "")
+			if err != nil {
+				return nil, err
+			}
+
+			next := make(map[string]bool, len(others))
+			for _, note := range others {
+				if tagged == nil || tagged[note.ID] {
+					next[note.ID] = true
+				}
+			}
+
+			tagged = next
+		}
+	case len(folders) == 1:
+		notes, err = c.GetNotesInFolder(root, fetchFields, orderBy, orderDir)
+	case len(orderBy) == 0 && hasFields(noteMetadataFields, fetchFields):
+		notes, err = c.getNoteMetadata()
+	default:
+		notes, err = c.GetAllNotes(fetchFields, orderBy, orderDir)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []Note
+
+	for _, note := range notes {
+		switch {
+		case tagged != nil && !tagged[note.ID]:
+		case folders != nil && !folders[note.ParentID]:
+		case !q.UpdatedSince.IsZero() && note.UpdatedTime < int(q.UpdatedSince.UnixMilli()):
+		case languages != nil && !strings.EqualFold(languages[note.ID], q.Language):
+		case !q.Todo.Match(note):
+		default:
+			matched = append(matched, note)
+		}
+	}
+
+	return matched, nil
+}
+
+// resolveTags returns the IDs of tags given by ID or title. Missing tags are
+// created with create, else they are an error.
+func (c *Client) resolveTags(tags []string, create bool) ([]string, error) {
+	all, err := c.GetAllTagsWithFields("id,title", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, name := range tags {
+		id := ""
+
+		for _, tag := range all {
+			if tag.ID == name || FoldTagTitle(tag.Title) == FoldTagTitle(name) {
+				id = tag.ID
+				break
+			}
+		}
+
+		if len(id) == 0 && create {
+			tag, err := c.CreateTagItem(Tag{Title: name})
+			if err != nil {
+				return nil, err
+			}
+
+			all = append(all, tag)
+			id = tag.ID
+		}
+
+		if len(id) == 0 {
+			return nil, fmt.Errorf("could not find tag '%s': %w", name, ErrNotFound)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// EnsureTags returns the IDs of tags given by ID or title, creating the
+// missing ones.
+func (c *Client) EnsureTags(tags []string) ([]string, error) {
+	return c.resolveTags(tags, true)
+}
+
+// withField adds field to a list of fields unless it is there.
+func withField(fields string, field string) string {
+	if hasFields(fields, field) {
+		return fields
+	}
+
+	return fields + "," + field
+}
+
+// hasFields tells whether every field of want is in fields.
+func hasFields(fields string, want string) bool {
+	have := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		have[strings.TrimSpace(field)] = true
+	}
+
+	for _, field := range strings.Split(want, ",") {
+		if !have[strings.TrimSpace(field)] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,96 @@
+package goplin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// getRaw fetches a single item from the given endpoint ("notes", "folders",
+// ...) and returns the payload without decoding it.
+func (c *Client) getRaw(endpoint string, id string, fields string) (json.RawMessage, error) {
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetQueryParam("fields", fields).
+		Get(fmt.Sprintf("http://%s:%d/%s/{id}", c.host, c.port, endpoint))
+	if err != nil {
+		return nil, redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find %s with ID '%s'", strings.TrimSuffix(endpoint, "s"), id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return nil, err
+	}
+
+	if resp.IsSuccess() {
+		return json.RawMessage(resp.Bytes()), nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return nil, err
+}
+
+// GetNoteRaw works like GetNote but also returns the JSON payload as sent by
+// Joplin, giving access to fields the Note struct does not model.
+func (c *Client) GetNoteRaw(id string, fields ...string) (Note, json.RawMessage, error) {
+	var note Note
+
+	raw, err := c.getRaw("notes", id, fieldList(DefaultNoteFields, fields))
+	if err != nil {
+		return note, raw, err
+	}
+
+	return note, raw, json.Unmarshal(raw, &note)
+}
+
+// GetFolderRaw works like GetFolder but also returns the JSON payload.
+func (c *Client) GetFolderRaw(id string, fields ...string) (Folder, json.RawMessage, error) {
+	var folder Folder
+
+	raw, err := c.getRaw("folders", id, fieldList(DefaultFolderFields, fields))
+	if err != nil {
+		return folder, raw, err
+	}
+
+	return folder, raw, json.Unmarshal(raw, &folder)
+}
+
+// GetTagRaw works like GetTag but also returns the JSON payload.
+func (c *Client) GetTagRaw(id string, fields ...string) (Tag, json.RawMessage, error) {
+	var tag Tag
+
+	raw, err := c.getRaw("tags", id, fieldList(DefaultTagFields, fields))
+	if err != nil {
+		return tag, raw, err
+	}
+
+	return tag, raw, json.Unmarshal(raw, &tag)
+}
+
+// GetItemMap fetches a single item from endpoint ("notes", "folders", "tags"
+// or "resources") and decodes it into a generic map. Numbers are kept as
+// json.Number so millisecond timestamps and IDs survive unchanged.
+func (c *Client) GetItemMap(endpoint string, id string, fields ...string) (map[string]interface{}, error) {
+	var item map[string]interface{}
+
+	raw, err := c.getRaw(endpoint, id, fieldList("id,parent_id,title", fields))
+	if err != nil {
+		return item, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	err = decoder.Decode(&item)
+
+	return item, err
+}
@@ -0,0 +1,113 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadingAppDataKey is the application_data key holding reading progress.
+const ReadingAppDataKey = "goplin_reading"
+
+// ReadingProgress tells how far a note has been read.
+type ReadingProgress struct {
+	Percent     int `json:"percent"`
+	UpdatedTime int `json:"updated_time"`
+}
+
+func (p ReadingProgress) Done() bool {
+	return p.Percent >= 100
+}
+
+// ReadingEntry is a note together with its reading progress.
+type ReadingEntry struct {
+	Note     Note            `json:"note"`
+	Progress ReadingProgress `json:"progress"`
+}
+
+// ParsePercent parses a progress such as "45%", "45" or "done".
+func ParsePercent(s string) (int, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "done" {
+		return 100, nil
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid progress '%s', expected a percentage between 0 and 100", s)
+	}
+
+	return percent, nil
+}
+
+func readingProgress(note Note) (ReadingProgress, bool, error) {
+	var progress ReadingProgress
+
+	value, err := GetAppData(note, ReadingAppDataKey)
+	if err != nil || value == nil {
+		return progress, false, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return progress, false, err
+	}
+
+	err = json.Unmarshal(data, &progress)
+
+	return progress, err == nil, err
+}
+
+// SetReadingProgress records how far a note has been read, in percent.
+func (c *Client) SetReadingProgress(noteID string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("reading progress must be between 0 and 100, got %d", percent)
+	}
+
+	return c.SetAppData(noteID, ReadingAppDataKey, ReadingProgress{
+		Percent:     percent,
+		UpdatedTime: int(time.Now().UnixMilli()),
+	})
+}
+
+// GetReadingProgress returns the reading progress of a note and whether one
+// was recorded.
+func (c *Client) GetReadingProgress(noteID string) (ReadingProgress, bool, error) {
+	note, err := c.GetNote(noteID, "id", "application_data")
+	if err != nil {
+		return ReadingProgress{}, false, err
+	}
+
+	return readingProgress(note)
+}
+
+// ListReadingProgress returns the notes with a recorded reading progress,
+// most recently read first. Finished notes are only included with done.
+// Notes whose application_data is not JSON are skipped.
+func (c *Client) ListReadingProgress(done bool) ([]ReadingEntry, error) {
+	var entries []ReadingEntry
+
+	notes, err := c.GetAllNotes("id,parent_id,title,source_url,application_data", "", "")
+	if err != nil {
+		return entries, err
+	}
+
+	for _, note := range notes {
+		progress, ok, err := readingProgress(note)
+		if err != nil || !ok || (progress.Done() && !done) {
+			continue
+		}
+
+		entries = append(entries, ReadingEntry{Note: note, Progress: progress})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Progress.UpdatedTime > entries[j].Progress.UpdatedTime
+	})
+
+	return entries, nil
+}
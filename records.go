@@ -0,0 +1,261 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RecordAppDataKey is the application_data key holding the fields of
+// structured notes.
+const RecordAppDataKey = "goplin_record"
+
+// RecordField is a field of a type of structured notes.
+type RecordField struct {
+	Name  string
+	Label string
+	// List fields hold one item per line, rendered as a list.
+	List bool
+}
+
+// RecordType is a kind of structured note, such as a contact, whose fields
+// are kept in the application_data of its notes and rendered in their body.
+type RecordType struct {
+	Name string
+	// TitleField is the required field the notes are titled after.
+	TitleField string
+	Fields     []RecordField
+}
+
+// Types of structured notes.
+var (
+	ContactType = RecordType{Name: "contact", TitleField: "name", Fields: []RecordField{
+		{Name: "name", Label: "Name"},
+		{Name: "email", Label: "Email"},
+		{Name: "phone", Label: "Phone"},
+		{Name: "company", Label: "Company"},
+		{Name: "address", Label: "Address"},
+		{Name: "birthday", Label: "Birthday"},
+		{Name: "website", Label: "Website"},
+	}}
+	BookType = RecordType{Name: "book", TitleField: "title", Fields: []RecordField{
+		{Name: "title", Label: "Title"},
+		{Name: "author", Label: "Author"},
+		{Name: "isbn", Label: "ISBN"},
+		{Name: "year", Label: "Year"},
+		{Name: "publisher", Label: "Publisher"},
+		{Name: "status", Label: "Status"},
+		{Name: "rating", Label: "Rating"},
+	}}
+	RecipeType = RecordType{Name: "recipe", TitleField: "name", Fields: []RecordField{
+		{Name: "name", Label: "Name"},
+		{Name: "servings", Label: "Servings"},
+		{Name: "prep_time", Label: "Preparation time"},
+		{Name: "cook_time", Label: "Cooking time"},
+		{Name: "source", Label: "Source"},
+		{Name: "ingredients", Label: "Ingredients", List: true},
+		{Name: "steps", Label: "Steps", List: true},
+	}}
+)
+
+// RecordTypes are the types of structured notes by name.
+var RecordTypes = map[string]RecordType{
+	ContactType.Name: ContactType,
+	BookType.Name:    BookType,
+	RecipeType.Name:  RecipeType,
+}
+
+// RecordTypeNames returns the names of RecordTypes.
+func RecordTypeNames() []string {
+	var names []string
+	for name := range RecordTypes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Record is the structured data of a note.
+type Record struct {
+	Type   string            `json:"type"`
+	Fields map[string]string `json:"fields"`
+}
+
+// RecordNote is a structured note together with its record.
+type RecordNote struct {
+	Note   Note   `json:"note"`
+	Record Record `json:"record"`
+}
+
+// Field returns the field of the type named name.
+func (t RecordType) Field(name string) (RecordField, bool) {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return RecordField{}, false
+}
+
+// NewRecord checks fields against the type and returns the record holding
+// the non-empty ones.
+func (t RecordType) NewRecord(fields map[string]string) (Record, error) {
+	record := Record{Type: t.Name, Fields: make(map[string]string)}
+
+	for name, value := range fields {
+		if _, ok := t.Field(name); !ok {
+			return record, fmt.Errorf("unknown %s field '%s'", t.Name, name)
+		}
+
+		if value = strings.TrimSpace(value); len(value) != 0 {
+			record.Fields[name] = value
+		}
+	}
+
+	if len(record.Fields[t.TitleField]) == 0 {
+		return record, fmt.Errorf("a %s needs a %s", t.Name, t.TitleField)
+	}
+
+	return record, nil
+}
+
+// Render returns the body of the note of record: a heading, the fields as a
+// list of labels and values, then a section per list field.
+func (t RecordType) Render(record Record) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", record.Fields[t.TitleField])
+
+	for _, field := range t.Fields {
+		if value := record.Fields[field.Name]; len(value) != 0 && !field.List && field.Name != t.TitleField {
+			fmt.Fprintf(&b, "- **%s:** %s\n", field.Label, value)
+		}
+	}
+
+	for _, field := range t.Fields {
+		value := record.Fields[field.Name]
+		if len(value) == 0 || !field.List {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", field.Label)
+
+		for _, item := range strings.Split(value, "\n") {
+			if item = strings.TrimSpace(item); len(item) != 0 {
+				fmt.Fprintf(&b, "- %s\n", item)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Note returns the note of record, to be created, then given the record with
+// SetRecord.
+func (t RecordType) Note(record Record) Note {
+	return Note{Title: record.Fields[t.TitleField], Body: t.Render(record)}
+}
+
+// SetRecord stores the record of a structured note in its application_data.
+func (c *Client) SetRecord(noteID string, record Record) error {
+	return c.SetAppData(noteID, RecordAppDataKey, record)
+}
+
+// GetRecord returns the record of a structured note and whether it has one.
+// The note must have been fetched with the application_data field.
+func GetRecord(note Note) (Record, bool, error) {
+	var record Record
+
+	value, err := GetAppData(note, RecordAppDataKey)
+	if err != nil || value == nil {
+		return record, false, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return record, false, err
+	}
+
+	err = json.Unmarshal(data, &record)
+
+	return record, err == nil, err
+}
+
+// RecordFilter selects structured notes by a field: field=value matches the
+// whole value, field~value a part of it, both ignoring case.
+type RecordFilter struct {
+	Field    string
+	Value    string
+	Contains bool
+}
+
+// ParseRecordFilter parses a filter such as email=ada@example.com or
+// name~ada.
+func ParseRecordFilter(s string) (RecordFilter, error) {
+	i := strings.IndexAny(s, "=~")
+	if i <= 0 {
+		return RecordFilter{}, fmt.Errorf("invalid filter '%s', expected field=value or field~value", s)
+	}
+
+	return RecordFilter{Field: s[:i], Value: s[i+1:], Contains: s[i] == '~'}, nil
+}
+
+// Match tells whether the record passes the filter.
+func (f RecordFilter) Match(record Record) bool {
+	value := strings.ToLower(record.Fields[f.Field])
+	want := strings.ToLower(f.Value)
+
+	if f.Contains {
+		return strings.Contains(value, want)
+	}
+
+	return value == want
+}
+
+// FindRecords returns the structured notes of type typ, every type when
+// empty, passing all the filters, ordered by title. Notes whose
+// application_data is not JSON are skipped.
+func (c *Client) FindRecords(typ string, filters []RecordFilter) ([]RecordNote, error) {
+	if t, ok := RecordTypes[typ]; ok {
+		for _, filter := range filters {
+			if _, ok := t.Field(filter.Field); !ok {
+				return nil, fmt.Errorf("unknown %s field '%s'", typ, filter.Field)
+			}
+		}
+	} else if len(typ) != 0 {
+		return nil, fmt.Errorf("unknown type '%s', expected one of %s", typ, strings.Join(RecordTypeNames(), ", "))
+	}
+
+	notes, err := c.GetAllNotes("id,parent_id,title,application_data", "title", "ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	var found []RecordNote
+
+	for _, note := range notes {
+		record, ok, err := GetRecord(note)
+		if err != nil || !ok || len(typ) != 0 && record.Type != typ {
+			continue
+		}
+
+		matched := true
+
+		for _, filter := range filters {
+			if !filter.Match(record) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			found = append(found, RecordNote{Note: note, Record: record})
+		}
+	}
+
+	return found, nil
+}
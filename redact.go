@@ -0,0 +1,57 @@
+package goplin
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+
+	"github.com/imroc/req/v3"
+)
+
+// tokenRes match the API and authorization tokens in query strings and JSON
+// payloads.
+var tokenRes = []*regexp.Regexp{
+	regexp.MustCompile(`(\b(?:auth_)?token=)[^&\s"']+`),
+	regexp.MustCompile(`("(?:auth_)?token"\s*:\s*")[^"]*`),
+}
+
+// RedactToken hides the values of the token and auth_token parameters in s,
+// such as a URL, a request dump or an error message.
+func RedactToken(s string) string {
+	for _, re := range tokenRes {
+		s = re.ReplaceAllString(s, "${1}***")
+	}
+
+	return s
+}
+
+// redactError hides the token in the URL of the errors of failed requests,
+// keeping the error they wrap.
+func redactError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	redacted := *urlErr
+	redacted.URL = RedactToken(urlErr.URL)
+
+	return &redacted
+}
+
+// dump returns the dump of a response, for error messages, without the
+// token.
+func dump(resp *req.Response) string {
+	return RedactToken(resp.Dump())
+}
+
+// EnableDump makes the client write the dump of every request and response
+// to w, without the token, to be called before sending requests.
+func (c *Client) EnableDump(w io.Writer) {
+	c.dumpTo = w
+
+	c.handle.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		_, _ = io.WriteString(w, dump(resp)+"\n")
+		return nil
+	})
+}
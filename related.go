@@ -0,0 +1,218 @@
+package goplin
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// RelatedOptions tune RelatedNotes. Zero weights fall back to 1, a negative
+// weight turns the signal off.
+type RelatedOptions struct {
+	Limit       int
+	TagWeight   float64
+	LinkWeight  float64
+	TitleWeight float64
+}
+
+// RelatedNote is a note ranked by its closeness to another one.
+type RelatedNote struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Score      float64  `json:"score"`
+	SharedTags []string `json:"shared_tags,omitempty"`
+	// Distance is the number of links between the notes, in either
+	// direction, or 0 when they are not within two links of each other.
+	Distance int     `json:"distance,omitempty"`
+	TitleSim float64 `json:"title_similarity,omitempty"`
+}
+
+func relatedWeight(w float64) float64 {
+	switch {
+	case w == 0:
+		return 1
+	case w < 0:
+		return 0
+	}
+
+	return w
+}
+
+// titleWords returns the lowercased words of a title, leaving out words of
+// less than three letters.
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 {
+			words[word] = true
+		}
+	}
+
+	return words
+}
+
+// TitleSimilarity is the Jaccard index of the words of two titles.
+func TitleSimilarity(a string, b string) float64 {
+	wa, wb := titleWords(a), titleWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range wa {
+		if wb[word] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// linkDistances returns the notes within two links of id, ignoring the
+// direction of the links.
+func (g *LinkGraph) linkDistances(id string) map[string]int {
+	neighbours := func(id string) []string {
+		var out []string
+
+		for _, link := range g.Outgoing[id] {
+			out = append(out, link.TargetID)
+		}
+
+		for _, link := range g.Incoming[id] {
+			out = append(out, link.SourceID)
+		}
+
+		return out
+	}
+
+	dist := map[string]int{id: 0}
+	frontier := []string{id}
+
+	for depth := 1; depth <= 2; depth++ {
+		var next []string
+
+		for _, from := range frontier {
+			for _, to := range neighbours(from) {
+				if _, seen := dist[to]; seen {
+					continue
+				}
+
+				if _, isNote := g.Notes[to]; !isNote {
+					continue
+				}
+
+				dist[to] = depth
+				next = append(next, to)
+			}
+		}
+
+		frontier = next
+	}
+
+	delete(dist, id)
+
+	return dist
+}
+
+// RankRelated scores the notes of graph against the note id. Shared tags
+// count more the fewer notes carry them, a direct link scores 1 and a link
+// through another note 0.5, and titles add their word overlap. tagsByNote
+// maps note IDs to tag IDs and tagTitles tag IDs to titles.
+func RankRelated(id string, graph *LinkGraph, tagsByNote map[string][]string, tagTitles map[string]string, opts RelatedOptions) []RelatedNote {
+	source := graph.Notes[id]
+
+	tagNotes := make(map[string]int)
+	for _, tags := range tagsByNote {
+		for _, tag := range tags {
+			tagNotes[tag]++
+		}
+	}
+
+	ownTags := make(map[string]bool)
+	for _, tag := range tagsByNote[id] {
+		ownTags[tag] = true
+	}
+
+	distances := graph.linkDistances(id)
+
+	tagWeight := relatedWeight(opts.TagWeight)
+	linkWeight := relatedWeight(opts.LinkWeight)
+	titleWeight := relatedWeight(opts.TitleWeight)
+
+	var related []RelatedNote
+
+	for _, note := range graph.Notes {
+		if note.ID == id || note.IsConflict == 1 {
+			continue
+		}
+
+		r := RelatedNote{ID: note.ID, Title: note.Title}
+
+		tagScore := 0.0
+		for _, tag := range tagsByNote[note.ID] {
+			if ownTags[tag] {
+				r.SharedTags = append(r.SharedTags, tagTitles[tag])
+				tagScore += 1 / math.Log2(1+float64(tagNotes[tag]))
+			}
+		}
+
+		sort.Strings(r.SharedTags)
+
+		linkScore := 0.0
+		if d, ok := distances[note.ID]; ok {
+			r.Distance = d
+			linkScore = 1 / float64(d)
+		}
+
+		r.TitleSim = TitleSimilarity(source.Title, note.Title)
+
+		r.Score = tagWeight*tagScore + linkWeight*linkScore + titleWeight*r.TitleSim
+		if r.Score > 0 {
+			related = append(related, r)
+		}
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+
+		return related[i].Title < related[j].Title
+	})
+
+	if opts.Limit > 0 && len(related) > opts.Limit {
+		related = related[:opts.Limit]
+	}
+
+	return related
+}
+
+// RelatedNotes ranks the other notes of the vault by shared tags, link
+// proximity and title similarity to the note id, most related first.
+func (c *Client) RelatedNotes(id string, opts RelatedOptions) ([]RelatedNote, error) {
+	graph, err := c.GetLinkGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := graph.Notes[id]; !ok {
+		return nil, fmt.Errorf("could not find note with ID '%s': %w", id, ErrNotFound)
+	}
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	tagTitles := make(map[string]string, len(index.tags))
+	for _, tag := range index.tags {
+		tagTitles[tag.ID] = tag.Title
+	}
+
+	return RankRelated(id, graph, index.tagsByID, tagTitles, opts), nil
+}
@@ -0,0 +1,101 @@
+package goplin
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ReminderAppDataKey is the application_data key holding the reminder state
+// of a to-do: when it was last notified and until when it is snoozed.
+const ReminderAppDataKey = "goplin_reminder"
+
+type ReminderState struct {
+	// NotifiedDue is the todo_due the reminder was raised for.
+	NotifiedDue int `json:"notified_due,omitempty"`
+	// SnoozedUntil postpones the reminder, in milliseconds.
+	SnoozedUntil int `json:"snoozed_until,omitempty"`
+}
+
+// Reminder is an open to-do whose due time has come. Missed reminders came
+// before the time given to DueReminders, e.g. while no daemon was running.
+type Reminder struct {
+	Note   Note          `json:"note"`
+	Due    time.Time     `json:"due"`
+	State  ReminderState `json:"state"`
+	Missed bool          `json:"missed,omitempty"`
+}
+
+func reminderState(note Note) ReminderState {
+	var state ReminderState
+
+	value, err := GetAppData(note, ReminderAppDataKey)
+	if err != nil || value == nil {
+		return state
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	return state
+}
+
+// DueReminders returns the open to-dos due at or before now which have not
+// been notified for their current due time and are not snoozed. Joplin
+// raises its own alarms from todo_due, so these are the same reminders.
+//
+// The reminders due, or snoozed until, before since are Missed, for the
+// caller to record as notified without raising them. None are with a zero
+// since.
+func (c *Client) DueReminders(now time.Time, since time.Time) ([]Reminder, error) {
+	var reminders []Reminder
+
+	notes, err := c.GetAllNotes("id,parent_id,title,is_todo,todo_due,todo_completed,application_data", "todo_due", "asc")
+	if err != nil {
+		return reminders, err
+	}
+
+	for _, note := range notes {
+		if note.IsTodo == 0 || note.TodoCompleted != 0 || note.TodoDue == 0 {
+			continue
+		}
+
+		due := time.UnixMilli(int64(note.TodoDue))
+		if due.After(now) {
+			continue
+		}
+
+		state := reminderState(note)
+		came := due
+
+		if state.SnoozedUntil != 0 {
+			came = time.UnixMilli(int64(state.SnoozedUntil))
+			if came.After(now) {
+				continue
+			}
+		} else if state.NotifiedDue == note.TodoDue {
+			continue
+		}
+
+		reminders = append(reminders, Reminder{Note: note, Due: due, State: state, Missed: came.Before(since)})
+	}
+
+	return reminders, nil
+}
+
+// MarkReminderNotified records that the reminder of a to-do was raised for
+// its current due time, clearing an elapsed snooze.
+func (c *Client) MarkReminderNotified(r Reminder) error {
+	return c.SetAppData(r.Note.ID, ReminderAppDataKey, map[string]interface{}{
+		"notified_due":  r.Note.TodoDue,
+		"snoozed_until": nil,
+	})
+}
+
+// SnoozeReminder postpones the reminder of a to-do until the given time.
+func (c *Client) SnoozeReminder(noteID string, until time.Time) error {
+	return c.SetAppData(noteID, ReminderAppDataKey, map[string]interface{}{
+		"snoozed_until": int(until.UnixMilli()),
+	})
+}
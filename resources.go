@@ -0,0 +1,352 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type resourcesResult struct {
+	Items   []Resource `json:"items"`
+	HasMore bool       `json:"has_more"`
+}
+
+// CreateResource uploads data as a new resource. Fields set on props, such as
+// ID, Title or Mime, are sent along as the resource properties.
+func (c *Client) CreateResource(filename string, data io.Reader, props Resource) (Resource, error) {
+	var created Resource
+
+	if len(props.Title) == 0 {
+		props.Title = filename
+	}
+
+	bodyParams, err := itemBody(props)
+	if err != nil {
+		return created, err
+	}
+
+	propsJSON, err := json.Marshal(bodyParams)
+	if err != nil {
+		return created, err
+	}
+
+	resp, err := c.r().
+		SetQueryParam("token", c.apiToken).
+		SetFileReader("data", filename, data).
+		SetFormData(map[string]string{"props": string(propsJSON)}).
+		SetResult(&created).
+		Post(fmt.Sprintf("http://%s:%d/resources", c.host, c.port))
+	if err != nil {
+		return created, redactError(err)
+	}
+
+	if resp.IsError() {
+		err = newAPIError(resp)
+
+		return created, err
+	}
+
+	if resp.IsSuccess() {
+		return created, nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return created, err
+}
+
+func (c *Client) GetAllResources(fields string, orderBy string, orderDir string) ([]Resource, error) {
+	var result resourcesResult
+	var resources []Resource
+
+	page := 1
+
+	queryParams := map[string]string{
+		"token":  c.apiToken,
+		"fields": fields,
+		"page":   strconv.Itoa(page),
+	}
+
+	if len(orderBy) != 0 {
+		queryParams["order_by"] = orderBy
+	}
+
+	if len(orderDir) != 0 {
+		queryParams["order_dir"] = strings.ToUpper(orderDir)
+	}
+
+	for {
+		resp, err := c.r().
+			SetQueryParams(queryParams).
+			SetResult(&result).
+			SetError(&result).
+			Get(fmt.Sprintf("http://%s:%d/resources", c.host, c.port))
+		if err != nil {
+			return resources, redactError(err)
+		}
+
+		if resp.IsError() {
+			// Handle response.
+			err = newAPIError(resp)
+
+			return resources, err
+		}
+
+		if resp.IsSuccess() {
+			resources = append(resources, result.Items...)
+
+			if result.HasMore {
+				page++
+
+				queryParams["page"] = strconv.Itoa(page)
+
+				continue
+			}
+
+			return resources, nil
+		}
+
+		// Handle response.
+		err = newAPIError(resp)
+
+		return resources, err
+	}
+}
+
+// GetResource fetches the properties of a resource. Fields may be given as
+// separate arguments or as a single comma separated list;
+// DefaultResourceFields is used when none are given.
+func (c *Client) GetResource(id string, fields ...string) (Resource, error) {
+	var resource Resource
+
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetQueryParam("fields", fieldList(DefaultResourceFields, fields)).
+		SetResult(&resource).
+		SetError(&resource).
+		Get(fmt.Sprintf("http://%s:%d/resources/{id}", c.host, c.port))
+	if err != nil {
+		return resource, redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find resource with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return resource, err
+	}
+
+	if resp.IsSuccess() {
+		return resource, nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return resource, err
+}
+
+// GetResourceNotes returns the notes linking to a resource.
+func (c *Client) GetResourceNotes(id string, fields string) ([]Note, error) {
+	var result notesResult
+	var notes []Note
+
+	page := 1
+
+	queryParams := map[string]string{
+		"token":  c.apiToken,
+		"fields": fields,
+		"page":   strconv.Itoa(page),
+	}
+
+	for {
+		resp, err := c.r().
+			SetPathParam("id", id).
+			SetQueryParams(queryParams).
+			SetResult(&result).
+			SetError(&result).
+			Get(fmt.Sprintf("http://%s:%d/resources/{id}/notes", c.host, c.port))
+		if err != nil {
+			return notes, redactError(err)
+		}
+
+		if resp.IsError() {
+			if resp.StatusCode == 404 {
+				err = notFoundError(resp, "could not find resource with ID '%s'", id)
+			} else {
+				err = newAPIError(resp)
+			}
+
+			return notes, err
+		}
+
+		if resp.IsSuccess() {
+			notes = append(notes, result.Items...)
+
+			if result.HasMore {
+				page++
+
+				queryParams["page"] = strconv.Itoa(page)
+
+				continue
+			}
+
+			return notes, nil
+		}
+
+		// Handle response.
+		err = newAPIError(resp)
+
+		return notes, err
+	}
+}
+
+// UpdateResource changes the properties of a resource set on props, such as
+// Title or Filename. Use UpdateResourceFile to change its content.
+func (c *Client) UpdateResource(id string, props Resource) error {
+	bodyParams, err := itemBody(props)
+	if err != nil {
+		return err
+	}
+
+	delete(bodyParams, "id")
+
+	// Title has no omitempty, an unset title is left alone.
+	if title, ok := bodyParams["title"].(string); ok && len(title) == 0 {
+		delete(bodyParams, "title")
+	}
+
+	if len(bodyParams) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetBody(bodyParams).
+		Put(fmt.Sprintf("http://%s:%d/resources/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find resource with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
+
+// UpdateResourceFile replaces the content of a resource with data, keeping
+// its ID so that the notes linking to it show the new file.
+func (c *Client) UpdateResourceFile(id string, filename string, data io.Reader) error {
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetFileReader("data", filename, data).
+		SetFormData(map[string]string{"props": "{}"}).
+		Put(fmt.Sprintf("http://%s:%d/resources/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find resource with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
+
+// GetResourceFile writes the content of the resource to w.
+func (c *Client) GetResourceFile(id string, w io.Writer) error {
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		SetOutput(w).
+		Get(fmt.Sprintf("http://%s:%d/resources/{id}/file", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find resource with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
+
+// DeleteResource deletes a resource and its file. Notes linking to it keep
+// their now broken links.
+func (c *Client) DeleteResource(id string) error {
+	resp, err := c.r().
+		SetPathParam("id", id).
+		SetQueryParam("token", c.apiToken).
+		Delete(fmt.Sprintf("http://%s:%d/resources/{id}", c.host, c.port))
+	if err != nil {
+		return redactError(err)
+	}
+
+	if resp.IsError() {
+		if resp.StatusCode == 404 {
+			err = notFoundError(resp, "could not find resource with ID '%s'", id)
+		} else {
+			err = newAPIError(resp)
+		}
+
+		return err
+	}
+
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	// Handle response.
+	err = newAPIError(resp)
+
+	return err
+}
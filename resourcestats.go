@@ -0,0 +1,82 @@
+package goplin
+
+import "sort"
+
+// MimeCount aggregates the resources of a MIME type. Duration is the total
+// length in seconds of audio and video resources, when known.
+type MimeCount struct {
+	Mime      string  `json:"mime"`
+	Resources int     `json:"resources"`
+	Size      int     `json:"size"`
+	Duration  float64 `json:"duration,omitempty"`
+}
+
+// ResourceStatsReport summarizes the attachments of the vault.
+type ResourceStatsReport struct {
+	Resources int         `json:"resources"`
+	Size      int         `json:"size"`
+	Duration  float64     `json:"duration,omitempty"`
+	ByMime    []MimeCount `json:"by_mime"`
+	Largest   []Resource  `json:"largest"`
+}
+
+// BuildResourceStats counts resources and their size per MIME type, largest
+// type first, and keeps the largest resources. durations gives the length of
+// media resources by ID and may be nil.
+func BuildResourceStats(resources []Resource, durations map[string]float64, largest int) ResourceStatsReport {
+	report := ResourceStatsReport{Resources: len(resources)}
+
+	byMime := make(map[string]*MimeCount)
+
+	for _, resource := range resources {
+		mime := resource.Mime
+		if len(mime) == 0 {
+			mime = "unknown"
+		}
+
+		count, ok := byMime[mime]
+		if !ok {
+			count = &MimeCount{Mime: mime}
+			byMime[mime] = count
+		}
+
+		count.Resources++
+		count.Size += resource.Size
+		count.Duration += durations[resource.ID]
+
+		report.Size += resource.Size
+		report.Duration += durations[resource.ID]
+	}
+
+	for _, count := range byMime {
+		report.ByMime = append(report.ByMime, *count)
+	}
+
+	sort.Slice(report.ByMime, func(i, j int) bool {
+		if report.ByMime[i].Size != report.ByMime[j].Size {
+			return report.ByMime[i].Size > report.ByMime[j].Size
+		}
+
+		return report.ByMime[i].Mime < report.ByMime[j].Mime
+	})
+
+	report.Largest = append([]Resource(nil), resources...)
+	sort.SliceStable(report.Largest, func(i, j int) bool { return report.Largest[i].Size > report.Largest[j].Size })
+
+	if len(report.Largest) > largest {
+		report.Largest = report.Largest[:largest]
+	}
+
+	return report
+}
+
+// ResourceStats returns the resource statistics of the vault, without media
+// durations.
+func (c *Client) ResourceStats(largest int) (ResourceStatsReport, error) {
+	resources, err := c.GetAllResources("id,title,mime,size", "", "")
+	if err != nil {
+		return ResourceStatsReport{}, err
+	}
+
+	return BuildResourceStats(resources, nil, largest), nil
+}
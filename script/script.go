@@ -0,0 +1,372 @@
+// Package script exposes a subset of the client to automation scripts.
+//
+// Starlark scripts (.star) run in-process, see RunStarlark. The interpreter
+// has no access to the file system, the network or the environment: the
+// methods listed by Methods are all a script can reach, the API token is not
+// among them, and read-only mode rejects every method that changes the vault.
+//
+// Other programs can be run as child processes with Run, calling the same
+// methods through a small JSON API served on the loopback interface for their
+// lifetime. This keeps the API token from them but is no sandbox: they run
+// with the rights of the user.
+//
+// A program finds the API in GOPLIN_SCRIPT_URL and calls it by POSTing
+// {"method": "notes.get", "params": {"id": "..."}}; the answer is
+// {"result": ...} or {"error": "..."}.
+package script
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/momo182/goplin"
+)
+
+// EnvURL is the environment variable holding the address of the API, including
+// the secret path that authorizes the script.
+const EnvURL = "GOPLIN_SCRIPT_URL"
+
+// Interpreters maps script extensions to the command used to run them when the
+// script is not executable itself.
+var Interpreters = map[string][]string{
+	".sh":  {"sh"},
+	".py":  {"python3"},
+	".js":  {"node"},
+	".rb":  {"ruby"},
+	".lua": {"lua"},
+}
+
+type method struct {
+	write bool
+	call  func(client *goplin.Client, params json.RawMessage) (interface{}, error)
+}
+
+var methods = map[string]method{
+	"notes.list": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			FolderID string `json:"folder_id"`
+			TagID    string `json:"tag_id"`
+			Fields   string `json:"fields"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p.Fields) == 0 {
+			p.Fields = goplin.DefaultNoteFields
+		}
+
+		switch {
+		case len(p.FolderID) != 0:
+			return client.GetNotesInFolder(p.FolderID, p.Fields, "", "")
+		case len(p.TagID) != 0:
+			return client.GetNotesByTagWithFields(p.TagID, p.Fields, "", "")
+		}
+
+		return client.GetAllNotes(p.Fields, "", "")
+	}},
+	"notes.get": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID     string `json:"id"`
+			Fields string `json:"fields"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p.Fields) == 0 {
+			p.Fields = "id,parent_id,title,body,is_todo,todo_due,todo_completed,created_time,updated_time"
+		}
+
+		return client.GetNote(p.ID, p.Fields)
+	}},
+	"notes.tags": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID string `json:"id"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.GetNoteTags(p.ID, "", "")
+	}},
+	"notes.create": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ParentID string `json:"parent_id"`
+			Title    string `json:"title"`
+			Body     string `json:"body"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.CreateNote(goplin.Note{ParentID: p.ParentID, Title: p.Title, Body: p.Body})
+	}},
+	"notes.update": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID     string                 `json:"id"`
+			Fields map[string]interface{} `json:"fields"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, client.SetNoteFields(p.ID, p.Fields)
+	}},
+	"notes.tag": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID    string `json:"id"`
+			TagID string `json:"tag_id"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, client.CreateTagsNotes(p.ID, p.TagID)
+	}},
+	"notes.untag": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID    string `json:"id"`
+			TagID string `json:"tag_id"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, client.DeleteTagFromNote(p.TagID, p.ID)
+	}},
+	"folders.list": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		return client.GetAllFolders(goplin.DefaultFolderFields, "", "")
+	}},
+	"folders.create": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ParentID string `json:"parent_id"`
+			Title    string `json:"title"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.CreateFolderItem(goplin.Folder{ParentID: p.ParentID, Title: p.Title})
+	}},
+	"tags.list": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		return client.GetAllTags("", "")
+	}},
+	"tags.create": {write: true, call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Title string `json:"title"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.CreateTagItem(goplin.Tag{Title: p.Title})
+	}},
+	"search": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Query string `json:"query"`
+			Type  string `json:"type"`
+		}
+
+		err := decodeParams(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return client.Search(p.Query, p.Type, "")
+	}},
+	"stats": {call: func(client *goplin.Client, params json.RawMessage) (interface{}, error) {
+		return client.GetStats()
+	}},
+}
+
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+
+	err := json.Unmarshal(params, v)
+	if err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	return nil
+}
+
+// Methods returns the names of the methods scripts can call, sorted.
+func Methods() []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// API dispatches script calls to a client.
+type API struct {
+	client   *goplin.Client
+	readOnly bool
+}
+
+// NewAPI returns an API calling client. In read-only mode every method that
+// changes the vault fails.
+func NewAPI(client *goplin.Client, readOnly bool) *API {
+	return &API{client: client, readOnly: readOnly}
+}
+
+// Call runs a single method with its JSON encoded params.
+func (a *API) Call(name string, params json.RawMessage) (interface{}, error) {
+	m, ok := methods[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown method '%s'", name)
+	}
+
+	if m.write && a.readOnly {
+		return nil, fmt.Errorf("method '%s' changes the vault and the script runs read-only", name)
+	}
+
+	return m.call(a.client, params)
+}
+
+type request struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func (a *API) handler(secret string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/"+secret, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		var req request
+
+		var resp response
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else {
+			resp.Result, err = a.Call(req.Method, req.Params)
+			if err != nil {
+				resp.Error = err.Error()
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if len(resp.Error) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	return mux
+}
+
+// Command returns the command running the script at path: the script itself
+// when it is executable, else the interpreter registered for its extension.
+func Command(path string, args []string) (*exec.Cmd, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode()&0111 != 0 {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return exec.Command(abs, args...), nil
+	}
+
+	interpreter, ok := Interpreters[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not executable and no interpreter is known for '%s' files", path, filepath.Ext(path))
+	}
+
+	argv := append([]string{}, interpreter[1:]...)
+	argv = append(argv, path)
+	argv = append(argv, args...)
+
+	return exec.Command(interpreter[0], argv...), nil
+}
+
+// Run serves the API while cmd runs and returns the error of the command.
+// cmd inherits the environment of goplin plus EnvURL.
+func (a *API) Run(cmd *exec.Cmd) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 16)
+
+	_, err = rand.Read(buf)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	secret := hex.EncodeToString(buf)
+	server := &http.Server{Handler: a.handler(secret)}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	defer func() {
+		_ = server.Shutdown(context.Background())
+	}()
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=http://%s/%s", EnvURL, listener.Addr(), secret))
+
+	return cmd.Run()
+}
@@ -0,0 +1,111 @@
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/starlarkstruct"
+)
+
+// StarlarkExt is the extension of the scripts run in-process by RunStarlark.
+const StarlarkExt = ".star"
+
+// RunStarlark runs the Starlark script at path. Its print statements are
+// written to stdout.
+//
+// Each method is a builtin taking keyword arguments, the params of the
+// method: methods named like notes.get are members of the module notes,
+// others are global. Results are returned as Starlark values. The script
+// also gets the json module and args, the list of its arguments.
+func (a *API) RunStarlark(path string, args []string, stdout io.Writer) error {
+	thread := &starlark.Thread{
+		Name: path,
+		Print: func(_ *starlark.Thread, msg string) {
+			fmt.Fprintln(stdout, msg)
+		},
+	}
+
+	scriptArgs := make([]starlark.Value, 0, len(args))
+	for _, arg := range args {
+		scriptArgs = append(scriptArgs, starlark.String(arg))
+	}
+
+	predeclared := a.builtins()
+	predeclared["json"] = starlarkjson.Module
+	predeclared["args"] = starlark.NewList(scriptArgs)
+
+	_, err := starlark.ExecFile(thread, path, nil, predeclared)
+
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+
+	return err
+}
+
+// builtins returns the methods as Starlark builtins, grouped in modules by
+// the prefix of their names.
+func (a *API) builtins() starlark.StringDict {
+	builtins := make(starlark.StringDict)
+	modules := make(map[string]*starlarkstruct.Module)
+
+	for _, name := range Methods() {
+		prefix, member, ok := strings.Cut(name, ".")
+		if !ok {
+			builtins[name] = a.builtin(name)
+			continue
+		}
+
+		module, ok := modules[prefix]
+		if !ok {
+			module = &starlarkstruct.Module{Name: prefix, Members: make(starlark.StringDict)}
+			modules[prefix] = module
+			builtins[prefix] = module
+		}
+
+		module.Members[member] = a.builtin(name)
+	}
+
+	return builtins
+}
+
+// builtin returns the builtin calling the method name, its keyword arguments
+// passed as params through JSON.
+func (a *API) builtin(name string) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if len(args) != 0 {
+			return nil, errors.New("takes keyword arguments only")
+		}
+
+		params := starlark.NewDict(len(kwargs))
+		for _, kwarg := range kwargs {
+			err := params.SetKey(kwarg[0], kwarg[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		encoded, err := starlark.Call(thread, starlarkjson.Module.Members["encode"], starlark.Tuple{params}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := a.Call(name, json.RawMessage(encoded.(starlark.String)))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+
+		return starlark.Call(thread, starlarkjson.Module.Members["decode"], starlark.Tuple{starlark.String(data)}, nil)
+	})
+}
@@ -0,0 +1,78 @@
+package goplin
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Item types of Search.
+const (
+	SearchNote     = "note"
+	SearchFolder   = "folder"
+	SearchTag      = "tag"
+	SearchResource = "resource"
+)
+
+// search returns every page of the items matching query.
+func search[T any](c *Client, query string, queryType string, fields string) ([]T, error) {
+	var items []T
+
+	queryParams := map[string]string{
+		"token": c.apiToken,
+		"query": query,
+	}
+
+	if len(queryType) != 0 {
+		queryParams["type"] = queryType
+	}
+
+	if len(fields) != 0 {
+		queryParams["fields"] = fields
+	}
+
+	for page := 1; ; page++ {
+		var result pageResult[T]
+
+		queryParams["page"] = strconv.Itoa(page)
+
+		resp, err := c.r().
+			SetQueryParams(queryParams).
+			SetResult(&result).
+			SetError(&result).
+			Get(fmt.Sprintf("http://%s:%d/search", c.host, c.port))
+		if err != nil {
+			return items, redactError(err)
+		}
+
+		if !resp.IsSuccess() {
+			return items, newAPIError(resp)
+		}
+
+		items = append(items, result.Items...)
+
+		if !result.HasMore {
+			return items, nil
+		}
+	}
+}
+
+// SearchNotes returns the notes matching query, in Joplin's query syntax
+// (e.g. "tag:work created:20240101"), with the given fields.
+func (c *Client) SearchNotes(query string, fields string) ([]Note, error) {
+	return search[Note](c, query, SearchNote, fields)
+}
+
+// SearchFolders returns the folders whose title matches query.
+func (c *Client) SearchFolders(query string, fields string) ([]Folder, error) {
+	return search[Folder](c, query, SearchFolder, fields)
+}
+
+// SearchTags returns the tags whose title matches query.
+func (c *Client) SearchTags(query string, fields string) ([]Tag, error) {
+	return search[Tag](c, query, SearchTag, fields)
+}
+
+// SearchResources returns the resources whose title matches query.
+func (c *Client) SearchResources(query string, fields string) ([]Resource, error) {
+	return search[Resource](c, query, SearchResource, fields)
+}
@@ -0,0 +1,98 @@
+package goplin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selectors of GetNotesBy.
+const (
+	// SelectID selects the note with the given ID.
+	SelectID = "id"
+	// SelectTag selects the notes of the tag with the given ID.
+	SelectTag = "tag"
+	// SelectFolder selects the notes of the folder with the given ID, path
+	// or title, not those of its sub-folders.
+	SelectFolder = "folder"
+	// SelectAuthor selects the notes of the given author, ignoring case.
+	SelectAuthor = "author"
+	// SelectSource selects the notes created by the given application, such
+	// as net.cozic.joplin-desktop, ignoring case.
+	SelectSource = "source"
+	// SelectSourceURL selects the notes whose source URL starts with the
+	// given prefix, such as the clips of a site.
+	SelectSourceURL = "source-url"
+)
+
+// Selectors lists the selectors of GetNotesBy.
+var Selectors = []string{SelectID, SelectTag, SelectFolder, SelectAuthor, SelectSource, SelectSourceURL}
+
+// IsSelector tells whether by is one of Selectors.
+func IsSelector(by string) bool {
+	for _, selector := range Selectors {
+		if strings.EqualFold(by, selector) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetNotesBy returns the notes matching value for the selector by, one of
+// Selectors, SelectID when empty. IDs, tags and folders have endpoints; the
+// other selectors are matched on all the notes, which are fetched with the
+// field they compare on top of fields.
+func (c *Client) GetNotesBy(by string, value string, fields string, orderBy string, orderDir string) ([]Note, error) {
+	var field string
+	var match func(Note) bool
+
+	switch strings.ToLower(by) {
+	case "", SelectID:
+		note, err := c.GetNote(value, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Note{note}, nil
+	case SelectTag:
+		return c.GetNotesByTagWithFields(value, fields, orderBy, orderDir)
+	case SelectFolder:
+		tree, err := c.GetFolderTree()
+		if err != nil {
+			return nil, err
+		}
+
+		node, err := FindFolderNode(tree, value)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.GetNotesInFolder(node.Folder.ID, fields, orderBy, orderDir)
+	case SelectAuthor:
+		field = "author"
+		match = func(note Note) bool { return strings.EqualFold(note.Author, value) }
+	case SelectSource:
+		field = "source_application"
+		match = func(note Note) bool { return strings.EqualFold(note.SourceApplication, value) }
+	case SelectSourceURL:
+		field = "source_url"
+		match = func(note Note) bool { return len(note.SourceURL) != 0 && strings.HasPrefix(note.SourceURL, value) }
+	default:
+		return nil, fmt.Errorf("unknown selector '%s', expected one of %s", by, strings.Join(Selectors, ", "))
+	}
+
+	notes, err := c.GetAllNotes(withField(fields, field), orderBy, orderDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Note
+
+	for _, note := range notes {
+		if match(note) {
+			selected = append(selected, note)
+		}
+	}
+
+	return selected, nil
+}
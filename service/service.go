@@ -0,0 +1,453 @@
+// Package service installs goplin commands as user services, with systemd on
+// Linux and launchd on macOS, so daemons start with the session and restart
+// when they fail.
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Modes of Spec.
+const (
+	// ModeWatch runs the notify daemon, watching the vault.
+	ModeWatch = "watch"
+	// ModeCron runs a command periodically.
+	ModeCron = "cron"
+	// ModeServe runs the HTTP API.
+	ModeServe = "serve"
+)
+
+// Modes lists the modes of Spec.
+var Modes = []string{ModeWatch, ModeCron, ModeServe}
+
+// NamePrefix starts the names of the services installed by goplin.
+const NamePrefix = "goplin-"
+
+const launchdLabelPrefix = "com.github.momo182."
+
+// Spec describes a service running goplin.
+type Spec struct {
+	// Name of the service, NamePrefix followed by the mode when empty.
+	Name string
+	Mode string
+	// Executable is the goplin binary.
+	Executable string
+	// Args are the arguments of goplin. Watch and serve modes run the notify
+	// daemon and the HTTP API when empty; cron mode requires them.
+	Args []string
+	// Interval between runs in cron mode.
+	Interval time.Duration
+}
+
+// File is a file making up a service.
+type File struct {
+	Path    string
+	Content string
+}
+
+// DefaultArgs returns the goplin arguments run in mode when none are given.
+func DefaultArgs(mode string) []string {
+	switch mode {
+	case ModeWatch:
+		return []string{"notify", "daemon"}
+	case ModeServe:
+		return []string{"serve", "api"}
+	}
+
+	return nil
+}
+
+func (s Spec) normalize() (Spec, error) {
+	if !contains(Modes, s.Mode) {
+		return s, fmt.Errorf("unknown mode '%s', expected one of %s", s.Mode, strings.Join(Modes, ", "))
+	}
+
+	if len(s.Name) == 0 {
+		s.Name = NamePrefix + s.Mode
+	}
+
+	if !strings.HasPrefix(s.Name, NamePrefix) {
+		s.Name = NamePrefix + s.Name
+	}
+
+	if strings.ContainsAny(s.Name, " /\\\t") {
+		return s, fmt.Errorf("invalid service name '%s'", s.Name)
+	}
+
+	if len(s.Args) == 0 {
+		s.Args = DefaultArgs(s.Mode)
+	}
+
+	if len(s.Args) == 0 {
+		return s, fmt.Errorf("cron mode needs the goplin command to run, e.g. 'run nightly'")
+	}
+
+	if s.Mode == ModeCron && s.Interval < time.Minute {
+		return s, fmt.Errorf("interval of cron mode must be at least a minute")
+	}
+
+	return s, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FullName returns the name services are installed under, NamePrefix added.
+func FullName(name string) string {
+	if strings.HasPrefix(name, NamePrefix) {
+		return name
+	}
+
+	return NamePrefix + name
+}
+
+func unsupported() error {
+	return fmt.Errorf("services are supported on Linux (systemd) and macOS (launchd), not %s", runtime.GOOS)
+}
+
+func systemdDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+func launchdDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, "Library", "LaunchAgents"), nil
+}
+
+// Files returns the files of a service on this system, without writing them.
+func Files(spec Spec) ([]File, error) {
+	spec, err := spec.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := systemdDir()
+		if err != nil {
+			return nil, err
+		}
+
+		files := []File{{Path: filepath.Join(dir, spec.Name+".service"), Content: systemdService(spec)}}
+		if spec.Mode == ModeCron {
+			files = append(files, File{Path: filepath.Join(dir, spec.Name+".timer"), Content: systemdTimer(spec)})
+		}
+
+		return files, nil
+	case "darwin":
+		dir, err := launchdDir()
+		if err != nil {
+			return nil, err
+		}
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		logFile := filepath.Join(home, "Library", "Logs", "goplin", spec.Name+".log")
+
+		return []File{{Path: filepath.Join(dir, launchdLabelPrefix+spec.Name+".plist"), Content: launchdPlist(spec, logFile)}}, nil
+	}
+
+	return nil, unsupported()
+}
+
+// systemdQuote quotes a word of a systemd command line.
+func systemdQuote(word string) string {
+	word = strings.ReplaceAll(word, "%", "%%")
+	word = strings.ReplaceAll(word, "$", "$$")
+
+	if len(word) != 0 && !strings.ContainsAny(word, " \t\"'\\;") {
+		return word
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+}
+
+func systemdService(spec Spec) string {
+	var command []string
+	for _, word := range append([]string{spec.Executable}, spec.Args...) {
+		command = append(command, systemdQuote(word))
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "[Unit]\nDescription=goplin %s (%s)\nAfter=network-online.target\n\n", spec.Mode, spec.Name)
+	b.WriteString("[Service]\n")
+
+	if spec.Mode == ModeCron {
+		b.WriteString("Type=oneshot\n")
+	} else {
+		b.WriteString("Type=simple\nRestart=on-failure\nRestartSec=10\n")
+	}
+
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(command, " "))
+
+	if spec.Mode != ModeCron {
+		b.WriteString("\n[Install]\nWantedBy=default.target\n")
+	}
+
+	return b.String()
+}
+
+func systemdTimer(spec Spec) string {
+	// The first run comes shortly after login, as with launchd's RunAtLoad.
+	return fmt.Sprintf("[Unit]\nDescription=Run %s every %s\n\n[Timer]\nOnActiveSec=1min\nOnUnitActiveSec=%ds\n\n[Install]\nWantedBy=timers.target\n",
+		spec.Name, spec.Interval, int(spec.Interval.Seconds()))
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+func launchdPlist(spec Spec, logFile string) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", xmlEscape(launchdLabelPrefix+spec.Name))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+
+	for _, word := range append([]string{spec.Executable}, spec.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(word))
+	}
+
+	b.WriteString("\t</array>\n\t<key>RunAtLoad</key>\n\t<true/>\n")
+
+	if spec.Mode == ModeCron {
+		fmt.Fprintf(&b, "\t<key>StartInterval</key>\n\t<integer>%d</integer>\n", int(spec.Interval.Seconds()))
+	} else {
+		b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	}
+
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", xmlEscape(logFile))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", xmlEscape(logFile))
+	b.WriteString("</dict>\n</plist>\n")
+
+	return b.String()
+}
+
+func run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// Install writes the files of a service and, unless noStart is set, enables
+// and starts it.
+func Install(spec Spec, noStart bool) ([]File, error) {
+	files, err := Files(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		err = os.MkdirAll(filepath.Dir(file.Path), 0o755)
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.WriteFile(file.Path, []byte(file.Content), 0o644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if runtime.GOOS == "darwin" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.MkdirAll(filepath.Join(home, "Library", "Logs", "goplin"), 0o755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if noStart {
+		return files, nil
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		err = run("systemctl", "--user", "daemon-reload")
+		if err != nil {
+			return files, err
+		}
+
+		// Cron services are started by their timer.
+		unit := filepath.Base(files[len(files)-1].Path)
+
+		return files, run("systemctl", "--user", "enable", "--now", unit)
+	case "darwin":
+		return files, run("launchctl", "load", "-w", files[0].Path)
+	}
+
+	return files, nil
+}
+
+// installedFiles returns the files of the services installed under name, all
+// services of goplin when name is empty.
+func installedFiles(name string) ([]string, error) {
+	var pattern string
+
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := systemdDir()
+		if err != nil {
+			return nil, err
+		}
+
+		pattern = filepath.Join(dir, NamePrefix+"*")
+		if len(name) != 0 {
+			pattern = filepath.Join(dir, FullName(name)+".*")
+		}
+	case "darwin":
+		dir, err := launchdDir()
+		if err != nil {
+			return nil, err
+		}
+
+		pattern = filepath.Join(dir, launchdLabelPrefix+NamePrefix+"*.plist")
+		if len(name) != 0 {
+			pattern = filepath.Join(dir, launchdLabelPrefix+FullName(name)+".plist")
+		}
+	default:
+		return nil, unsupported()
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Installed returns the names of the services installed by goplin.
+func Installed() ([]string, error) {
+	files, err := installedFiles("")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		name := strings.TrimPrefix(filepath.Base(file), launchdLabelPrefix)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// Status returns what the service manager reports about a service.
+func Status(name string) (string, error) {
+	files, err := installedFiles(name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) == 0 {
+		return "", fmt.Errorf("no service named '%s' is installed", FullName(name))
+	}
+
+	var out []byte
+
+	switch runtime.GOOS {
+	case "linux":
+		args := []string{"--user", "status", "--no-pager"}
+		for _, file := range files {
+			args = append(args, filepath.Base(file))
+		}
+
+		// systemctl status exits with an error for stopped units, its
+		// output still tells why.
+		out, err = exec.Command("systemctl", args...).CombinedOutput()
+	case "darwin":
+		out, err = exec.Command("launchctl", "list", launchdLabelPrefix+FullName(name)).CombinedOutput()
+	}
+
+	if len(out) != 0 {
+		return string(out), nil
+	}
+
+	return "", err
+}
+
+// Uninstall stops and disables a service and removes its files, returning
+// their paths.
+func Uninstall(name string) ([]string, error) {
+	files, err := installedFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no service named '%s' is installed", FullName(name))
+	}
+
+	for _, file := range files {
+		// A service that is not running cannot be stopped, ignore it.
+		switch runtime.GOOS {
+		case "linux":
+			_ = run("systemctl", "--user", "disable", "--now", filepath.Base(file))
+		case "darwin":
+			_ = run("launchctl", "unload", "-w", file)
+		}
+
+		err = os.Remove(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if runtime.GOOS == "linux" {
+		err = run("systemctl", "--user", "daemon-reload")
+		if err != nil {
+			return files, err
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,426 @@
+package sink
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 is a sink writing to a bucket of S3 or of a compatible service.
+type S3 struct {
+	client   *http.Client
+	endpoint *url.URL
+	// pathStyle puts the bucket in the path rather than the host name, as
+	// compatible services need.
+	pathStyle bool
+	bucket    string
+	prefix    string
+	region    string
+
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// NewS3 returns the sink of an s3://bucket/prefix URL. The credentials and
+// region are read from the usual variables: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION, us-east-1 by
+// default. AWS_ENDPOINT_URL points to a compatible service such as MinIO.
+func NewS3(u *url.URL) (*S3, error) {
+	s := &S3{
+		client:       &http.Client{},
+		bucket:       u.Host,
+		prefix:       strings.Trim(u.Path, "/"),
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if len(s.bucket) == 0 {
+		return nil, fmt.Errorf("no bucket in '%s', expected s3://bucket/path", u)
+	}
+
+	if len(s.accessKey) == 0 || len(s.secretKey) == 0 {
+		return nil, errors.New("set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to write to S3")
+	}
+
+	if len(s.region) == 0 {
+		s.region = "us-east-1"
+	}
+
+	endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+
+	switch {
+	case len(endpoint) != 0:
+		e, err := url.Parse(endpoint)
+		if err != nil || len(e.Host) == 0 {
+			return nil, fmt.Errorf("invalid S3 endpoint '%s'", endpoint)
+		}
+
+		s.endpoint = &url.URL{Scheme: e.Scheme, Host: e.Host}
+		s.pathStyle = true
+	case strings.Contains(s.bucket, "."):
+		// Dotted bucket names do not match the certificate of the
+		// virtual host.
+		s.endpoint = &url.URL{Scheme: "https", Host: "s3." + s.region + ".amazonaws.com"}
+		s.pathStyle = true
+	default:
+		s.endpoint = &url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com"}
+	}
+
+	return s, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); len(value) != 0 {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (s *S3) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+func (s *S3) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, path.Clean("/"+name)), "/")
+}
+
+// do sends a request about the object key, signed anew for each attempt.
+func (s *S3) do(method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	uri := "/" + s3Escape(key, false)
+	if s.pathStyle {
+		uri = "/" + s3Escape(s.bucket, true) + uri
+	}
+
+	rawQuery := s3Query(query)
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	return send(s.client, func() (*http.Request, error) {
+		u := s.endpoint.String() + uri
+		if len(rawQuery) != 0 {
+			u += "?" + rawQuery
+		}
+
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		s.sign(req, uri, rawQuery, payloadHash, time.Now())
+
+		return req, nil
+	})
+}
+
+// sign signs req with AWS Signature Version 4.
+func (s *S3) sign(req *http.Request, uri string, rawQuery string, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if len(s.sessionToken) != 0 {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(values[0])
+		}
+	}
+
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+
+	fmt.Fprintf(&canonical, "%s\n%s\n%s\n", req.Method, uri, rawQuery)
+
+	for _, name := range names {
+		fmt.Fprintf(&canonical, "%s:%s\n", name, headers[name])
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	fmt.Fprintf(&canonical, "\n%s\n%s", signedHeaders, payloadHash)
+
+	sum := sha256.Sum256([]byte(canonical.String()))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{amzDate[:8], s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+// s3Escape percent-encodes s as S3 signatures expect: every byte but the
+// unreserved characters, and slashes unless escapeSlash.
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// s3Query returns the canonical query string of query, sorted by key.
+func s3Query(query url.Values) string {
+	var pairs []string
+
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+func (s *S3) Create(name string) (io.WriteCloser, error) {
+	return &s3Writer{s: s, key: s.key(name)}, nil
+}
+
+func (s *S3) Open(name string) (io.ReadCloser, error) {
+	key := s.key(name)
+
+	resp, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !success(resp) {
+		return nil, statusError(resp, key)
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3) Remove(name string) error {
+	key := s.key(name)
+
+	resp, err := s.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if !success(resp) && resp.StatusCode != http.StatusNotFound {
+		return statusError(resp, key)
+	}
+
+	resp.Body.Close()
+
+	return nil
+}
+
+// s3Writer uploads an object, in one request when it is smaller than
+// PartSize, else as a multipart upload of parts of PartSize.
+type s3Writer struct {
+	s        *S3
+	key      string
+	buf      bytes.Buffer
+	uploadID string
+	parts    []s3Part
+	err      error
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.buf.Write(p)
+
+	for w.buf.Len() >= PartSize && w.err == nil {
+		w.err = w.uploadPart(w.buf.Next(PartSize))
+	}
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	return len(p), nil
+}
+
+func (w *s3Writer) uploadPart(data []byte) error {
+	if len(w.uploadID) == 0 {
+		resp, err := w.s.do(http.MethodPost, w.key, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+
+		if !success(resp) {
+			return statusError(resp, w.key)
+		}
+
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		if err != nil {
+			return fmt.Errorf("invalid answer to the upload of %s: %w", w.key, err)
+		}
+
+		w.uploadID = result.UploadID
+	}
+
+	number := len(w.parts) + 1
+
+	resp, err := w.s.do(http.MethodPut, w.key, url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {w.uploadID},
+	}, data)
+	if err != nil {
+		return err
+	}
+
+	if !success(resp) {
+		return statusError(resp, w.key)
+	}
+
+	resp.Body.Close()
+
+	w.parts = append(w.parts, s3Part{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+	return nil
+}
+
+func (w *s3Writer) Close() error {
+	if w.err != nil {
+		w.Abort()
+		return w.err
+	}
+
+	if len(w.uploadID) == 0 {
+		resp, err := w.s.do(http.MethodPut, w.key, nil, w.buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if !success(resp) {
+			return statusError(resp, w.key)
+		}
+
+		return resp.Body.Close()
+	}
+
+	if w.buf.Len() != 0 {
+		err := w.uploadPart(w.buf.Bytes())
+		if err != nil {
+			w.Abort()
+			return err
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: w.parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.s.do(http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, body)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+
+	if !success(resp) {
+		err = statusError(resp, w.key)
+		w.Abort()
+
+		return err
+	}
+
+	// S3 may report a failure once the upload is complete, with a
+	// successful status.
+	answer, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err == nil && bytes.Contains(answer, []byte("<Error>")) {
+		err = fmt.Errorf("could not complete the upload of %s: %s", w.key, answer)
+	}
+
+	if err != nil {
+		w.Abort()
+	}
+
+	return err
+}
+
+// Abort drops the multipart upload in progress, if any, so that the bucket
+// is not charged for its parts.
+func (w *s3Writer) Abort() error {
+	w.buf.Reset()
+
+	if len(w.uploadID) == 0 {
+		return nil
+	}
+
+	resp, err := w.s.do(http.MethodDelete, w.key, url.Values{"uploadId": {w.uploadID}}, nil)
+	w.uploadID = ""
+
+	if err != nil {
+		return err
+	}
+
+	if !success(resp) {
+		return statusError(resp, w.key)
+	}
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,224 @@
+// Package sink writes the files of exports and mirrors to a local directory,
+// an S3 bucket or a WebDAV server, named by destinations such as
+// ~/backups, s3://bucket/backups or webdavs://cloud.example.com/dav/backups.
+//
+// Remote files are uploaded while they are written, in parts for S3, so that
+// large exports need no local copy. Failed requests are sent again.
+package sink
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Schemes of remote destinations.
+const (
+	SchemeS3      = "s3"
+	SchemeWebDAV  = "webdav"
+	SchemeWebDAVS = "webdavs"
+)
+
+// PartSize is the size of the parts of S3 uploads, and of the WebDAV uploads
+// which are buffered, hence retried, rather than streamed.
+const PartSize = 16 << 20
+
+// Sink stores files named by slash separated paths.
+type Sink interface {
+	// Create returns a writer of the file name, created with its parent
+	// directories. The file is stored once the writer is closed.
+	Create(name string) (io.WriteCloser, error)
+	// Open returns a reader of the file name, an error wrapping
+	// fs.ErrNotExist when there is none.
+	Open(name string) (io.ReadCloser, error)
+	// Remove removes the file name. Missing files are not an error.
+	Remove(name string) error
+	// String returns the destination of the sink.
+	String() string
+}
+
+// Aborter is implemented by the writers of Create which can drop what was
+// written, such as an upload in progress, instead of storing it.
+type Aborter interface {
+	Abort() error
+}
+
+// IsRemote tells whether dest is an S3 or WebDAV URL rather than a local
+// path.
+func IsRemote(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case SchemeS3, SchemeWebDAV, SchemeWebDAVS:
+		return true
+	}
+
+	return false
+}
+
+// Open returns the sink of dest: a local directory, an S3 URL such as
+// s3://bucket/prefix or a WebDAV URL, webdav:// for HTTP or webdavs:// for
+// HTTPS. See NewS3 and NewWebDAV for the credentials.
+func Open(dest string) (Sink, error) {
+	if !IsRemote(dest) {
+		return Dir(dest), nil
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == SchemeS3 {
+		return NewS3(u)
+	}
+
+	return NewWebDAV(u)
+}
+
+// Create returns a writer of the single file dest, a local path or a remote
+// URL as for Open.
+func Create(dest string) (io.WriteCloser, error) {
+	if !IsRemote(dest) {
+		f, err := os.Create(dest)
+		if err != nil {
+			return nil, err
+		}
+
+		return &localFile{File: f}, nil
+	}
+
+	i := strings.LastIndex(dest, "/")
+
+	s, err := Open(dest[:i])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Create(dest[i+1:])
+}
+
+// Abort drops the file being written by w if it can, else closes it.
+func Abort(w io.WriteCloser) error {
+	if a, ok := w.(Aborter); ok {
+		return a.Abort()
+	}
+
+	return w.Close()
+}
+
+// Dir is a sink writing to a local directory.
+type Dir string
+
+func (d Dir) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(path.Clean("/"+name)))
+}
+
+func (d Dir) Create(name string) (io.WriteCloser, error) {
+	p := d.path(name)
+
+	err := os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localFile{File: f}, nil
+}
+
+func (d Dir) Open(name string) (io.ReadCloser, error) {
+	return os.Open(d.path(name))
+}
+
+func (d Dir) Remove(name string) error {
+	err := os.Remove(d.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (d Dir) String() string {
+	return string(d)
+}
+
+// localFile is a local file removed when aborted.
+type localFile struct {
+	*os.File
+}
+
+func (f *localFile) Abort() error {
+	f.File.Close()
+
+	return os.Remove(f.Name())
+}
+
+// maxAttempts is the number of times a request is sent before giving up.
+const maxAttempts = 4
+
+// send sends the request made by newRequest, again when it fails to connect
+// or is answered with a server error, waiting longer each time. The body of
+// the last response is left to the caller.
+func send(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	wait := 500 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+
+		retry := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		if !retry || attempt == maxAttempts {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// statusError returns the error of an unexpected response, closing its body.
+func statusError(resp *http.Response, name string) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	msg := strings.TrimSpace(string(data))
+	if len(msg) == 0 {
+		return fmt.Errorf("%s %s: %s", resp.Request.Method, name, resp.Status)
+	}
+
+	return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, name, resp.Status, msg)
+}
+
+// success tells whether the status of a response is 2xx.
+func success(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
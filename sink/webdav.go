@@ -0,0 +1,270 @@
+package sink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Environment variables holding the WebDAV credentials when the URL has none.
+const (
+	EnvWebDAVUser     = "GOPLIN_WEBDAV_USER"
+	EnvWebDAVPassword = "GOPLIN_WEBDAV_PASSWORD"
+)
+
+// errAborted ends the upload of an aborted WebDAV writer.
+var errAborted = errors.New("upload aborted")
+
+// WebDAV is a sink writing to a directory of a WebDAV server.
+type WebDAV struct {
+	client   *http.Client
+	base     *url.URL
+	user     string
+	password string
+
+	mu sync.Mutex
+	// dirs are the collections known to exist.
+	dirs map[string]bool
+}
+
+// NewWebDAV returns the sink of a webdav:// (HTTP) or webdavs:// (HTTPS) URL.
+// The credentials are taken from the URL, else from GOPLIN_WEBDAV_USER and
+// GOPLIN_WEBDAV_PASSWORD, which keep them out of the shell history.
+func NewWebDAV(u *url.URL) (*WebDAV, error) {
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("no host in '%s', expected %s://host/path", u.Redacted(), u.Scheme)
+	}
+
+	s := &WebDAV{
+		client:   &http.Client{},
+		user:     os.Getenv(EnvWebDAVUser),
+		password: os.Getenv(EnvWebDAVPassword),
+		dirs:     map[string]bool{"/": true},
+	}
+
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+
+	scheme := "http"
+	if u.Scheme == SchemeWebDAVS {
+		scheme = "https"
+	}
+
+	s.base = &url.URL{Scheme: scheme, Host: u.Host, Path: "/" + strings.Trim(u.Path, "/")}
+
+	return s, nil
+}
+
+func (s *WebDAV) String() string {
+	return s.base.String()
+}
+
+// url returns the URL of the file or collection p, a path on the server.
+func (s *WebDAV) url(p string) string {
+	u := *s.base
+	u.Path = p
+
+	return u.String()
+}
+
+func (s *WebDAV) path(name string) string {
+	return path.Join(s.base.Path, path.Clean("/"+name))
+}
+
+func (s *WebDAV) newRequest(method string, p string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, s.url(p), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.user) != 0 {
+		req.SetBasicAuth(s.user, s.password)
+	}
+
+	return req, nil
+}
+
+func (s *WebDAV) do(method string, p string, body []byte) (*http.Response, error) {
+	return send(s.client, func() (*http.Request, error) {
+		return s.newRequest(method, p, bytes.NewReader(body))
+	})
+}
+
+// mkdirAll creates the collection dir and its parents, WebDAV servers
+// refusing files in missing collections.
+func (s *WebDAV) mkdirAll(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var missing []string
+
+	for d := dir; !s.dirs[d]; d = path.Dir(d) {
+		missing = append(missing, d)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		d := missing[i]
+
+		resp, err := s.do("MKCOL", d+"/", nil)
+		if err != nil {
+			return err
+		}
+
+		// 405 Method Not Allowed answers collections which exist.
+		if !success(resp) && resp.StatusCode != http.StatusMethodNotAllowed {
+			return statusError(resp, d)
+		}
+
+		resp.Body.Close()
+		s.dirs[d] = true
+	}
+
+	return nil
+}
+
+func (s *WebDAV) Create(name string) (io.WriteCloser, error) {
+	p := s.path(name)
+
+	err := s.mkdirAll(path.Dir(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return &webdavWriter{s: s, path: p}, nil
+}
+
+func (s *WebDAV) Open(name string) (io.ReadCloser, error) {
+	p := s.path(name)
+
+	resp, err := send(s.client, func() (*http.Request, error) {
+		return s.newRequest(http.MethodGet, p, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !success(resp) {
+		return nil, statusError(resp, p)
+	}
+
+	return resp.Body, nil
+}
+
+func (s *WebDAV) Remove(name string) error {
+	p := s.path(name)
+
+	resp, err := s.do(http.MethodDelete, p, nil)
+	if err != nil {
+		return err
+	}
+
+	if !success(resp) && resp.StatusCode != http.StatusNotFound {
+		return statusError(resp, p)
+	}
+
+	return resp.Body.Close()
+}
+
+// webdavWriter uploads a file with a PUT request. Files smaller than
+// PartSize are buffered and sent again on failure, larger ones are streamed
+// as they are written, WebDAV having no standard multipart upload.
+type webdavWriter struct {
+	s    *WebDAV
+	path string
+	buf  bytes.Buffer
+
+	// pw feeds the streamed upload, whose result is sent to done.
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *webdavWriter) Write(p []byte) (int, error) {
+	if w.pw != nil {
+		return w.pw.Write(p)
+	}
+
+	w.buf.Write(p)
+
+	if w.buf.Len() < PartSize {
+		return len(p), nil
+	}
+
+	pr, pw := io.Pipe()
+
+	req, err := w.s.newRequest(http.MethodPut, w.path, pr)
+	if err != nil {
+		return 0, err
+	}
+
+	w.pw = pw
+	w.done = make(chan error, 1)
+
+	go func() {
+		resp, err := w.s.client.Do(req)
+		if err == nil && !success(resp) {
+			err = statusError(resp, w.path)
+		} else if err == nil {
+			err = resp.Body.Close()
+		}
+
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+
+	_, err = pw.Write(w.buf.Bytes())
+	w.buf.Reset()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *webdavWriter) Close() error {
+	if w.pw != nil {
+		w.pw.Close()
+		return <-w.done
+	}
+
+	resp, err := w.s.do(http.MethodPut, w.path, w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if !success(resp) {
+		return statusError(resp, w.path)
+	}
+
+	return resp.Body.Close()
+}
+
+// Abort ends a streamed upload with an error, which servers drop, and
+// removes whatever they stored of it.
+func (w *webdavWriter) Abort() error {
+	w.buf.Reset()
+
+	if w.pw == nil {
+		return nil
+	}
+
+	w.pw.CloseWithError(errAborted)
+	<-w.done
+	w.pw = nil
+
+	resp, err := w.s.do(http.MethodDelete, w.path, nil)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,301 @@
+// Package slim makes bloated notes, typically clipped web pages, smaller:
+// HTML notes become Markdown, links lose their tracking parameters and large
+// images are scaled down and compressed again.
+package slim
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"regexp"
+	"strings"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/markup"
+	"github.com/momo182/goplin/preview"
+)
+
+// NoteFields are the fields of the notes passed to Note.
+const NoteFields = "id,title,body,markup_language"
+
+const resourceFields = "id,title,mime,filename,file_extension,size"
+
+// Defaults of the image options.
+const (
+	DefaultMinImageSize  = 512 << 10
+	DefaultMaxImageWidth = 1600
+	DefaultJPEGQuality   = 80
+)
+
+// urlRe matches the URLs of a body, in Markdown or HTML.
+var urlRe = regexp.MustCompile(`https?://[^\s<>()"'\[\]]+`)
+
+// trackingParams are the query parameters used to track clicks, on top of
+// the utm_ ones.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "gclsrc": true, "dclid": true, "msclkid": true,
+	"yclid": true, "twclid": true, "ttclid": true, "igshid": true, "li_fat_id": true,
+	"mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true, "mkt_tok": true,
+	"vero_id": true, "vero_conv": true, "oly_anon_id": true, "oly_enc_id": true,
+	"rb_clickid": true, "s_cid": true, "wickedid": true, "_ga": true, "_gl": true,
+	"ref_src": true, "spm": true,
+}
+
+// Options select what Note does.
+type Options struct {
+	// Markdown converts HTML notes to Markdown.
+	Markdown bool
+	// Links strips the tracking parameters of links.
+	Links bool
+	// Images scales down and compresses again the JPEG and PNG images the
+	// note links to, of MinImageSize bytes or more, keeping the new file
+	// only when it is smaller. Resources are shared, notes linking to the
+	// same image see the new one too.
+	Images bool
+	// MinImageSize is DefaultMinImageSize when zero.
+	MinImageSize int
+	// MaxImageWidth is DefaultMaxImageWidth when zero.
+	MaxImageWidth int
+	// Quality of JPEG images, DefaultJPEGQuality when zero.
+	Quality int
+	// DryRun reports what would change without changing anything.
+	DryRun bool
+}
+
+// Report gives the sizes of a note before and after slimming, in bytes.
+type Report struct {
+	NoteID       string `json:"note_id"`
+	Title        string `json:"title"`
+	BodyBefore   int    `json:"body_before"`
+	BodyAfter    int    `json:"body_after"`
+	Converted    bool   `json:"converted"`
+	LinksCleaned int    `json:"links_cleaned"`
+	// ImagesBefore and ImagesAfter are the sizes of the images looked at.
+	ImagesBefore int `json:"images_before"`
+	ImagesAfter  int `json:"images_after"`
+	Recompressed int `json:"recompressed"`
+}
+
+// Changed tells whether slimming changed the note or its images.
+func (r Report) Changed() bool {
+	return r.Converted || r.LinksCleaned != 0 || r.Recompressed != 0
+}
+
+// Note slims a note, fetched with NoteFields.
+func Note(client *goplin.Client, note goplin.Note, opts Options) (Report, error) {
+	report := Report{NoteID: note.ID, Title: note.Title, BodyBefore: len(note.Body)}
+
+	body := note.Body
+
+	if opts.Markdown && note.MarkupLanguage == markup.HTML {
+		converted, err := markup.HTMLToMarkdown(body)
+		if err != nil {
+			return report, fmt.Errorf("could not convert note '%s': %w", note.ID, err)
+		}
+
+		body = converted
+		report.Converted = true
+	}
+
+	if opts.Links {
+		body, report.LinksCleaned = StripTracking(body)
+	}
+
+	report.BodyAfter = len(body)
+
+	if opts.Images {
+		err := recompressImages(client, body, opts, &report)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if opts.DryRun || !report.Converted && report.LinksCleaned == 0 {
+		return report, nil
+	}
+
+	update := goplin.NewNoteUpdate(note.ID).SetBody(body)
+	if report.Converted {
+		update.Set("markup_language", markup.Markdown)
+	}
+
+	return report, client.ApplyNoteUpdate(update)
+}
+
+// StripTracking removes the tracking parameters from the URLs of body and
+// returns the number of URLs changed.
+func StripTracking(body string) (string, int) {
+	cleaned := 0
+
+	body = urlRe.ReplaceAllStringFunc(body, func(u string) string {
+		// Punctuation ending a sentence is not part of the URL.
+		trimmed := strings.TrimRight(u, ".,;:!?")
+
+		stripped := stripURL(trimmed)
+		if stripped == trimmed {
+			return u
+		}
+
+		cleaned++
+
+		return stripped + u[len(trimmed):]
+	})
+
+	return body, cleaned
+}
+
+func isTracking(key string) bool {
+	key = strings.ToLower(key)
+
+	return strings.HasPrefix(key, "utm_") || trackingParams[key]
+}
+
+// stripURL removes the tracking parameters of a URL, leaving the others as
+// written, HTML escaped ampersands included.
+func stripURL(u string) string {
+	q := strings.IndexByte(u, '?')
+	if q < 0 {
+		return u
+	}
+
+	base, query, fragment := u[:q], u[q+1:], ""
+	if i := strings.IndexByte(query, '#'); i >= 0 {
+		query, fragment = query[:i], query[i:]
+	}
+
+	sep := "&"
+	if strings.Contains(query, "&amp;") {
+		sep = "&amp;"
+	}
+
+	params := strings.Split(query, sep)
+
+	var kept []string
+
+	for _, param := range params {
+		key := param
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key = key[:i]
+		}
+
+		if !isTracking(key) {
+			kept = append(kept, param)
+		}
+	}
+
+	if len(kept) == len(params) {
+		return u
+	}
+
+	if len(kept) == 0 {
+		return base + fragment
+	}
+
+	return base + "?" + strings.Join(kept, sep) + fragment
+}
+
+// recompressImages recompresses the large images body links to.
+func recompressImages(client *goplin.Client, body string, opts Options, report *Report) error {
+	minSize := opts.MinImageSize
+	if minSize <= 0 {
+		minSize = DefaultMinImageSize
+	}
+
+	seen := make(map[string]bool)
+
+	for _, link := range goplin.ExtractLinks(body) {
+		if seen[link.TargetID] {
+			continue
+		}
+
+		seen[link.TargetID] = true
+
+		resource, err := client.GetResource(link.TargetID, resourceFields)
+		if errors.Is(err, goplin.ErrNotFound) {
+			// A link to a note.
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if resource.Mime != "image/jpeg" && resource.Mime != "image/png" || resource.Size < minSize {
+			continue
+		}
+
+		var original bytes.Buffer
+
+		err = client.GetResourceFile(resource.ID, &original)
+		if err != nil {
+			return err
+		}
+
+		report.ImagesBefore += original.Len()
+
+		data, err := recompress(original.Bytes(), resource.Mime, opts)
+		if err != nil || len(data) >= original.Len() {
+			// Images that cannot be decoded, or do not get smaller, stay.
+			report.ImagesAfter += original.Len()
+			continue
+		}
+
+		report.ImagesAfter += len(data)
+		report.Recompressed++
+
+		if opts.DryRun {
+			continue
+		}
+
+		filename := resource.Filename
+		if len(filename) == 0 {
+			filename = resource.ID + "." + resource.FileExtension
+		}
+
+		err = client.UpdateResourceFile(resource.ID, filename, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recompress scales an image down to the maximum width and encodes it again
+// in the same format.
+func recompress(data []byte, mime string, opts Options) ([]byte, error) {
+	maxWidth := opts.MaxImageWidth
+	if maxWidth <= 0 {
+		maxWidth = DefaultMaxImageWidth
+	}
+
+	quality := opts.Quality
+	if quality <= 0 {
+		quality = DefaultJPEGQuality
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img = preview.Scale(img, maxWidth, img.Bounds().Dy())
+
+	var out bytes.Buffer
+
+	if mime == "image/png" {
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&out, img)
+	} else {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: quality})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,327 @@
+package goplin
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SRSAppDataKey is the application_data key holding the review state of the
+// flashcards of a note, keyed by card ID.
+const SRSAppDataKey = "goplin_srs"
+
+// CardGrade is the answer to a review, as the four buttons of Anki.
+type CardGrade int
+
+const (
+	GradeAgain CardGrade = iota
+	GradeHard
+	GradeGood
+	GradeEasy
+)
+
+const (
+	defaultEase = 2.5
+	minimumEase = 1.3
+	relearnStep = 10 * time.Minute
+)
+
+// CardState is the scheduling state of a card.
+type CardState struct {
+	Due      int     `json:"due"`
+	Interval float64 `json:"interval"`
+	Ease     float64 `json:"ease"`
+	Reps     int     `json:"reps"`
+	Lapses   int     `json:"lapses"`
+}
+
+// Card is a question and answer pair extracted from a note.
+type Card struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	NoteTitle string    `json:"note_title"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	State     CardState `json:"state"`
+}
+
+// New reports whether the card was never reviewed.
+func (c Card) New() bool {
+	return c.State.Reps == 0 && c.State.Lapses == 0 && c.State.Due == 0
+}
+
+// Review returns the state after answering with grade at now, following the
+// SM-2 algorithm: intervals in days grow by the ease factor, which failed and
+// hard answers lower.
+func (s CardState) Review(grade CardGrade, now time.Time) CardState {
+	if s.Ease == 0 {
+		s.Ease = defaultEase
+	}
+
+	switch grade {
+	case GradeAgain:
+		s.Lapses++
+		s.Reps = 0
+		s.Interval = 0
+		s.Ease = math.Max(minimumEase, s.Ease-0.2)
+		s.Due = int(now.Add(relearnStep).UnixMilli())
+
+		return s
+	case GradeHard:
+		s.Interval = math.Max(1, s.Interval*1.2)
+		s.Ease = math.Max(minimumEase, s.Ease-0.15)
+	case GradeGood, GradeEasy:
+		switch s.Reps {
+		case 0:
+			s.Interval = 1
+		case 1:
+			s.Interval = 6
+		default:
+			s.Interval = math.Round(s.Interval * s.Ease)
+		}
+
+		if grade == GradeEasy {
+			s.Interval = math.Round(s.Interval * 1.3)
+			s.Ease += 0.15
+		}
+	}
+
+	s.Reps++
+	s.Due = int(now.Add(time.Duration(s.Interval * float64(24*time.Hour))).UnixMilli())
+
+	return s
+}
+
+// clozeRe matches Anki cloze deletions: {{c1::answer}} or {{c1::answer::hint}}.
+var clozeRe = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// ExtractCards returns the flashcards of a note body. Two conventions are
+// recognized:
+//
+//	Q: What is the capital of France?
+//	A: Paris
+//
+// where questions and answers run until the next Q:, A: or empty line, and
+// Anki style cloze deletions, where every cloze number of a paragraph is a
+// card: "The capital of {{c1::France}} is {{c2::Paris}}."
+func ExtractCards(body string) []Card {
+	var cards []Card
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	var question, answer []string
+	var target *[]string
+
+	flush := func() {
+		if len(question) != 0 && len(answer) != 0 {
+			cards = append(cards, newCard(strings.Join(question, "\n"), strings.Join(answer, "\n")))
+		}
+
+		question, answer, target = nil, nil, nil
+	}
+
+	var paragraphs []string
+	var paragraph []string
+
+	endParagraph := func() {
+		if len(paragraph) != 0 {
+			paragraphs = append(paragraphs, strings.Join(paragraph, "\n"))
+		}
+
+		paragraph = nil
+	}
+
+	inFence := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		fence := strings.HasPrefix(trimmed, "```")
+
+		if fence {
+			inFence = !inFence
+		}
+
+		if fence || inFence || len(trimmed) == 0 {
+			endParagraph()
+		} else {
+			paragraph = append(paragraph, line)
+		}
+
+		switch {
+		case !inFence && strings.HasPrefix(trimmed, "Q:"):
+			flush()
+
+			question = append(question, strings.TrimSpace(trimmed[2:]))
+			target = &question
+		case !inFence && strings.HasPrefix(trimmed, "A:") && len(question) != 0:
+			answer = append(answer, strings.TrimSpace(trimmed[2:]))
+			target = &answer
+		case len(trimmed) == 0 && !inFence:
+			flush()
+		case target != nil:
+			*target = append(*target, line)
+		}
+	}
+
+	flush()
+	endParagraph()
+
+	for _, paragraph := range paragraphs {
+		numbers := make(map[string]bool)
+
+		for _, m := range clozeRe.FindAllStringSubmatch(paragraph, -1) {
+			numbers[m[1]] = true
+		}
+
+		ordered := make([]string, 0, len(numbers))
+		for n := range numbers {
+			ordered = append(ordered, n)
+		}
+
+		sort.Strings(ordered)
+
+		for _, n := range ordered {
+			var answers []string
+
+			question := clozeRe.ReplaceAllStringFunc(paragraph, func(cloze string) string {
+				m := clozeRe.FindStringSubmatch(cloze)
+				if m[1] != n {
+					return m[2]
+				}
+
+				answers = append(answers, m[2])
+
+				if len(m[3]) != 0 {
+					return "[" + m[3] + "]"
+				}
+
+				return "[...]"
+			})
+
+			cards = append(cards, newCard(strings.TrimSpace(question), strings.Join(answers, ", ")))
+		}
+	}
+
+	return cards
+}
+
+// newCard identifies a card by its question so that editing the answer keeps
+// its review history.
+func newCard(question string, answer string) Card {
+	sum := sha1.Sum([]byte(question))
+
+	return Card{
+		ID:       hex.EncodeToString(sum[:8]),
+		Question: question,
+		Answer:   strings.TrimSpace(answer),
+	}
+}
+
+func cardStates(note Note) (map[string]CardState, error) {
+	states := make(map[string]CardState)
+
+	value, err := GetAppData(note, SRSAppDataKey)
+	if err != nil || value == nil {
+		return states, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return states, err
+	}
+
+	err = json.Unmarshal(data, &states)
+
+	return states, err
+}
+
+// GetCards returns the flashcards of all notes, or of the notes tagged tagID,
+// with their review state. Notes whose application_data is not JSON are
+// skipped.
+func (c *Client) GetCards(tagID string) ([]Card, error) {
+	var cards []Card
+	var notes []Note
+	var err error
+
+	fields := "id,title,body,application_data"
+
+	if len(tagID) != 0 {
+		notes, err = c.GetNotesByTagWithFields(tagID, fields, "", "")
+	} else {
+		notes, err = c.GetAllNotes(fields, "", "")
+	}
+
+	if err != nil {
+		return cards, err
+	}
+
+	for _, note := range notes {
+		extracted := ExtractCards(note.Body)
+		if len(extracted) == 0 {
+			continue
+		}
+
+		// Reviews could not be recorded for notes whose application_data
+		// is not JSON, skip them.
+		states, err := cardStates(note)
+		if err != nil {
+			continue
+		}
+
+		for _, card := range extracted {
+			card.NoteID = note.ID
+			card.NoteTitle = note.Title
+			card.State = states[card.ID]
+
+			cards = append(cards, card)
+		}
+	}
+
+	return cards, nil
+}
+
+// DueCards returns the cards due at now, overdue ones first, followed by at
+// most newLimit cards never reviewed. A negative newLimit includes all new
+// cards.
+func (c *Client) DueCards(tagID string, now time.Time, newLimit int) ([]Card, error) {
+	cards, err := c.GetCards(tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	var due, fresh []Card
+
+	for _, card := range cards {
+		switch {
+		case card.New():
+			if newLimit < 0 || len(fresh) < newLimit {
+				fresh = append(fresh, card)
+			}
+		case card.State.Due <= int(now.UnixMilli()):
+			due = append(due, card)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].State.Due < due[j].State.Due })
+
+	return append(due, fresh...), nil
+}
+
+// ReviewCard records the answer to a card and returns its new state.
+func (c *Client) ReviewCard(card Card, grade CardGrade, now time.Time) (CardState, error) {
+	if grade < GradeAgain || grade > GradeEasy {
+		return card.State, fmt.Errorf("invalid grade %d", grade)
+	}
+
+	state := card.State.Review(grade, now)
+
+	err := c.SetAppData(card.NoteID, SRSAppDataKey, map[string]CardState{card.ID: state})
+
+	return state, err
+}
@@ -0,0 +1,92 @@
+package goplin
+
+import (
+	"fmt"
+	"time"
+)
+
+// VaultStats is a cheap aggregate of the vault contents, suitable for status
+// bars and dashboards.
+type VaultStats struct {
+	Notes          int `json:"notes"`
+	Todos          int `json:"todos"`
+	TodosCompleted int `json:"todos_completed"`
+	TodosDueToday  int `json:"todos_due_today"`
+	Folders        int `json:"folders"`
+	Tags           int `json:"tags"`
+	LastEventTime  int `json:"last_event_time,omitempty"`
+	GeneratedTime  int `json:"generated_time"`
+}
+
+func (c *Client) GetStats() (VaultStats, error) {
+	var stats VaultStats
+
+	notes, err := c.GetLiveNotes("id,is_todo,todo_due,todo_completed", "", "")
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	endOfDay := startOfDay.AddDate(0, 0, 1)
+
+	stats.Notes = len(notes)
+
+	for _, note := range notes {
+		if note.IsTodo == 0 {
+			continue
+		}
+
+		stats.Todos++
+
+		if note.TodoCompleted != 0 {
+			stats.TodosCompleted++
+			continue
+		}
+
+		if note.TodoDue == 0 {
+			continue
+		}
+
+		due := time.UnixMilli(int64(note.TodoDue))
+		if !due.Before(startOfDay) && due.Before(endOfDay) {
+			stats.TodosDueToday++
+		}
+	}
+
+	folders, err := c.GetAllFolders("id", "", "")
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Folders = len(folders)
+
+	tags, err := c.GetAllTags("", "")
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Tags = len(tags)
+
+	event, err := c.getLatestEvent()
+	if err == nil {
+		stats.LastEventTime = event.CreatedTime
+	}
+
+	stats.GeneratedTime = int(now.UnixMilli())
+
+	return stats, nil
+}
+
+func (c *Client) getLatestEvent() (Event, error) {
+	_, cursor, err := c.GetEvents("")
+	if err != nil {
+		return Event{}, err
+	}
+
+	if len(cursor) == 0 {
+		return Event{}, fmt.Errorf("no events recorded yet")
+	}
+
+	return c.GetEvent(cursor)
+}
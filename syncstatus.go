@@ -0,0 +1,84 @@
+package goplin
+
+import (
+	"time"
+)
+
+// EventSourceSync is the source of the change events made by Joplin's
+// synchronization, the changes coming from other devices.
+const EventSourceSync = 2
+
+// SyncStatus approximates whether the vault is synchronized, from the change
+// events and conflicts Joplin keeps; the Data API does not expose the state
+// of the synchronization itself.
+type SyncStatus struct {
+	// Cursor is the current events cursor, a checkpoint for later calls.
+	Cursor string `json:"cursor"`
+	// Checkpoint is the cursor changes are counted from, empty when they are
+	// counted from the last remote change.
+	Checkpoint string `json:"checkpoint,omitempty"`
+	// Pending are the notes changed on this device since the checkpoint,
+	// likely not synchronized yet.
+	Pending []string `json:"pending"`
+	// Conflicts is the number of conflict copies waiting to be resolved.
+	Conflicts int `json:"conflicts"`
+	// LastRemoteChange is when the last change coming from synchronization
+	// was recorded, zero when Joplin keeps none.
+	LastRemoteChange time.Time `json:"last_remote_change"`
+}
+
+// GetSyncStatus returns the sync status of the vault. Local changes are
+// counted after the event cursor checkpoint, or after the last change coming
+// from synchronization when checkpoint is empty: a device changing notes
+// after its last sync has changes to send.
+func (c *Client) GetSyncStatus(checkpoint string) (SyncStatus, error) {
+	status := SyncStatus{Checkpoint: checkpoint, Pending: []string{}}
+
+	events, cursor, err := c.GetEvents("0")
+	if err != nil {
+		return status, err
+	}
+
+	status.Cursor = cursor
+
+	// Events after the last remote change.
+	after := events
+
+	for i, event := range events {
+		if event.Source == EventSourceSync {
+			status.LastRemoteChange = time.UnixMilli(int64(event.CreatedTime))
+			after = events[i+1:]
+		}
+	}
+
+	if len(checkpoint) != 0 {
+		after, _, err = c.GetEvents(checkpoint)
+		if err != nil {
+			return status, err
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	for _, event := range after {
+		if event.Source == EventSourceSync || seen[event.ItemID] {
+			continue
+		}
+
+		seen[event.ItemID] = true
+		status.Pending = append(status.Pending, event.ItemID)
+	}
+
+	notes, err := c.GetAllNotes("id,is_conflict", "", "")
+	if err != nil {
+		return status, err
+	}
+
+	for _, note := range notes {
+		if note.IsConflict == 1 {
+			status.Conflicts++
+		}
+	}
+
+	return status, nil
+}
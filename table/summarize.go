@@ -0,0 +1,315 @@
+package table
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time buckets of SummarizeOptions.GroupBy.
+const (
+	GroupDay   = "day"
+	GroupWeek  = "week"
+	GroupMonth = "month"
+	GroupYear  = "year"
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"2006/01/02",
+	"02.01.2006",
+}
+
+// SummarizeOptions of Summarize.
+type SummarizeOptions struct {
+	// Column holds the numbers to summarize.
+	Column string
+	// GroupBy is day, week, month or year to group by the date column, else
+	// a column whose values form the groups. Empty makes a single group,
+	// "all".
+	GroupBy string
+	// DateColumn holds the dates of time groups, by default the first
+	// column named date.
+	DateColumn string
+}
+
+// Summary aggregates the numbers of a group. Skipped counts the rows whose
+// value is not a number; rows whose date could not be read are skipped in
+// the group "unknown".
+type Summary struct {
+	Group   string  `json:"group"`
+	Count   int     `json:"count"`
+	Sum     float64 `json:"sum"`
+	Average float64 `json:"average"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Skipped int     `json:"skipped,omitempty"`
+}
+
+// ParseNumber reads a number written with an optional currency symbol or
+// unit, thousands separators and a decimal point or comma, such as
+// "$1,234.50", "12,5 €" or "(30)" for -30. Signs, currency symbols and units
+// may only surround a single run of digits, so ranges, dates, fractions and
+// exponents such as "10-20", "2024-01-05", "3/4" or "1e3" are not numbers.
+// Thousands separators must group the digits by three.
+func ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+
+	negative, parenthesized := false, false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, parenthesized = true, true
+		s = s[1 : len(s)-1]
+	}
+
+	runes := []rune(s)
+
+	// The body runs from the first digit, or a separator right before it,
+	// to the last digit.
+	first, last := -1, -1
+	for i, r := range runes {
+		if r >= '0' && r <= '9' {
+			if first < 0 {
+				first = i
+			}
+
+			last = i
+		}
+	}
+
+	if first < 0 {
+		return 0, false
+	}
+
+	if first > 0 && (runes[first-1] == '.' || runes[first-1] == ',') {
+		first--
+	}
+
+	signs := 0
+	for _, r := range append(runes[:first:first], runes[last+1:]...) {
+		switch r {
+		case '-', '−':
+			negative = !negative
+			signs++
+		case '+':
+			signs++
+		case '(', ')':
+			return 0, false
+		}
+	}
+
+	if signs > 1 || (signs == 1 && parenthesized) {
+		return 0, false
+	}
+
+	var b strings.Builder
+
+	for _, r := range runes[first : last+1] {
+		switch r {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.', ',':
+			b.WriteRune(r)
+		case ' ', '\'', '’', '\u00a0', '\u202f':
+			// Spaces and apostrophes group thousands.
+		default:
+			return 0, false
+		}
+	}
+
+	digits := b.String()
+
+	lastDot, lastComma := strings.LastIndex(digits, "."), strings.LastIndex(digits, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// The last separator is the decimal one, the other groups
+		// thousands.
+		decimal, thousands := ".", ","
+		if lastComma > lastDot {
+			decimal, thousands = ",", "."
+		}
+
+		point := strings.LastIndex(digits, decimal)
+		if !groupsThousands(digits[:point], thousands) {
+			return 0, false
+		}
+
+		digits = strings.ReplaceAll(digits[:point], thousands, "") + "." + digits[point+1:]
+	case lastComma >= 0:
+		// "1,234" and "1,234,567" group thousands, "12,5" has a decimal
+		// comma.
+		if strings.Count(digits, ",") == 1 && len(digits)-lastComma-1 != 3 {
+			digits = strings.Replace(digits, ",", ".", 1)
+		} else if groupsThousands(digits, ",") {
+			digits = strings.ReplaceAll(digits, ",", "")
+		} else {
+			return 0, false
+		}
+	case strings.Count(digits, ".") > 1:
+		if !groupsThousands(digits, ".") {
+			return 0, false
+		}
+
+		digits = strings.ReplaceAll(digits, ".", "")
+	}
+
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	if negative {
+		value = -value
+	}
+
+	return value, true
+}
+
+// groupsThousands reports whether sep splits digits into groups of three
+// after a first group of one to three digits.
+func groupsThousands(digits string, sep string) bool {
+	groups := strings.Split(digits, sep)
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return false
+	}
+
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseDate reads a date in ISO, slash or dotted day first form.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range dateLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func bucket(t time.Time, groupBy string) string {
+	switch groupBy {
+	case GroupDay:
+		return t.Format("2006-01-02")
+	case GroupWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GroupMonth:
+		return t.Format("2006-01")
+	}
+
+	return t.Format("2006")
+}
+
+// Summarize computes the count, sum, average, minimum and maximum of a column
+// of a table per group, groups sorted by name.
+func Summarize(t Table, opts SummarizeOptions) ([]Summary, error) {
+	column := t.Column(opts.Column)
+	if column < 0 {
+		return nil, fmt.Errorf("no column '%s' in the table, columns are %s", opts.Column, strings.Join(t.Header, ", "))
+	}
+
+	groupColumn := -1
+
+	switch {
+	case len(opts.GroupBy) == 0:
+	case isTimeGroup(opts.GroupBy):
+		name := opts.DateColumn
+		if len(name) == 0 {
+			name = "date"
+		}
+
+		groupColumn = t.Column(name)
+		if groupColumn < 0 {
+			return nil, fmt.Errorf("no date column '%s' to group by %s, set one", name, opts.GroupBy)
+		}
+	default:
+		groupColumn = t.Column(opts.GroupBy)
+		if groupColumn < 0 {
+			return nil, fmt.Errorf("no column '%s' to group by in the table, columns are %s", opts.GroupBy, strings.Join(t.Header, ", "))
+		}
+	}
+
+	groups := make(map[string]*Summary)
+
+	group := func(name string) *Summary {
+		s, ok := groups[name]
+		if !ok {
+			s = &Summary{Group: name, Min: math.Inf(1), Max: math.Inf(-1)}
+			groups[name] = s
+		}
+
+		return s
+	}
+
+	for _, row := range t.Rows {
+		row = fit(row, len(t.Header))
+
+		name := "all"
+
+		if groupColumn >= 0 {
+			name = strings.TrimSpace(row[groupColumn])
+
+			if isTimeGroup(opts.GroupBy) {
+				date, ok := ParseDate(name)
+				if !ok {
+					group("unknown").Skipped++
+					continue
+				}
+
+				name = bucket(date, opts.GroupBy)
+			}
+		}
+
+		s := group(name)
+
+		value, ok := ParseNumber(row[column])
+		if !ok {
+			s.Skipped++
+			continue
+		}
+
+		s.Count++
+		s.Sum += value
+		s.Min = math.Min(s.Min, value)
+		s.Max = math.Max(s.Max, value)
+	}
+
+	var summaries []Summary
+
+	for _, s := range groups {
+		if s.Count == 0 {
+			s.Min, s.Max = 0, 0
+		} else {
+			s.Average = s.Sum / float64(s.Count)
+		}
+
+		summaries = append(summaries, *s)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Group < summaries[j].Group })
+
+	return summaries, nil
+}
+
+func isTimeGroup(groupBy string) bool {
+	switch groupBy {
+	case GroupDay, GroupWeek, GroupMonth, GroupYear:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package table
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+		ok   bool
+	}{
+		{"42", 42, true},
+		{" 3.5 ", 3.5, true},
+		{"-7", -7, true},
+		{"+7", 7, true},
+		{"−2.5", -2.5, true},
+		{".5", 0.5, true},
+		{"$1,234.50", 1234.5, true},
+		{"-$1,234.50", -1234.5, true},
+		{"$-12", -12, true},
+		{"12,5 €", 12.5, true},
+		{"1.234,56 €", 1234.56, true},
+		{"1,234", 1234, true},
+		{"1,234,567", 1234567, true},
+		{"1.234.567", 1234567, true},
+		{"1 234", 1234, true},
+		{"1'234.5", 1234.5, true},
+		{"(30)", -30, true},
+		{"(1,000.25)", -1000.25, true},
+		{"12 kg", 12, true},
+		{"50%", 50, true},
+		{"USD 99", 99, true},
+		{"-12 kg", -12, true},
+		{"12-", -12, true},
+		{"- 5", -5, true},
+		{"€-3,5", -3.5, true},
+		{"-€3,5", -3.5, true},
+		{"1,5 kg", 1.5, true},
+		{"−1 234,5 €", -1234.5, true},
+		{"+5%", 5, true},
+		{"£ 7.25", 7.25, true},
+		{"7.25 £", 7.25, true},
+		{"3 km/h", 3, true},
+		{"12.", 12, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"$", 0, false},
+		{"10-20", 0, false},
+		{"2024-01-05", 0, false},
+		{"3/4", 0, false},
+		{"1e3", 0, false},
+		{"12abc34", 0, false},
+		{"--5", 0, false},
+		{"+-5", 0, false},
+		{"(5", 0, false},
+		{"12:30", 0, false},
+		{"(-5)", 0, false},
+		{"(−5)", 0, false},
+		{"-(5)", 0, false},
+		{"(5)-", 0, false},
+		{"-", 0, false},
+		{"+-", 0, false},
+		{"kg", 0, false},
+		{"100 m2", 0, false},
+		{"1.5.2", 0, false},
+		{"1,23,456", 0, false},
+		{"12345,678", 0, false},
+		{"1.23,5", 0, false},
+		{"1,2345.6", 0, false},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseNumber(test.s)
+		if ok != test.ok || got != test.want {
+			t.Errorf("ParseNumber(%q) = %v, %v, want %v, %v", test.s, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Package table reads and writes the Markdown tables of notes, so notes can
+// serve as small databases edited from scripts.
+package table
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/momo182/goplin"
+)
+
+// Column alignments, as set by the delimiter row.
+const (
+	AlignNone   = ""
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+var (
+	delimCellRe = regexp.MustCompile(`^:?-+:?$`)
+	headingRe   = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+(.*?)[ \t#]*$`)
+)
+
+// Table is a Markdown table of a note body.
+type Table struct {
+	Header []string   `json:"header"`
+	Align  []string   `json:"align"`
+	Rows   [][]string `json:"rows"`
+	// Heading is the last heading above the table, if any.
+	Heading string `json:"heading,omitempty"`
+	// Start and End are the lines of the table in the body, counted from 0,
+	// End excluded.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Column returns the index of the column named name, compared without case,
+// or -1.
+func (t Table) Column(name string) int {
+	for i, title := range t.Header {
+		if strings.EqualFold(strings.TrimSpace(title), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// splitRow returns the cells of a table row, without the outer pipes.
+// Escaped pipes are unescaped.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+
+	var cell strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// delimiterRow returns the alignments of a delimiter row with n cells, false
+// when line is not one.
+func delimiterRow(line string, n int) ([]string, bool) {
+	if !strings.Contains(line, "-") {
+		return nil, false
+	}
+
+	cells := splitRow(line)
+	if len(cells) != n {
+		return nil, false
+	}
+
+	var aligns []string
+
+	for _, cell := range cells {
+		if !delimCellRe.MatchString(cell) {
+			return nil, false
+		}
+
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+
+		switch {
+		case left && right:
+			aligns = append(aligns, AlignCenter)
+		case right:
+			aligns = append(aligns, AlignRight)
+		case left:
+			aligns = append(aligns, AlignLeft)
+		default:
+			aligns = append(aligns, AlignNone)
+		}
+	}
+
+	return aligns, true
+}
+
+// Parse returns the tables of a Markdown body in order. Tables in fenced code
+// blocks are ignored. Rows are padded or cut to the width of the header.
+func Parse(body string) []Table {
+	var tables []Table
+
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	heading := ""
+	inFence := false
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+
+		if inFence {
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			heading = m[1]
+			continue
+		}
+
+		if !strings.Contains(line, "|") || i+1 >= len(lines) {
+			continue
+		}
+
+		header := splitRow(line)
+
+		aligns, ok := delimiterRow(lines[i+1], len(header))
+		if !ok {
+			continue
+		}
+
+		t := Table{Header: header, Align: aligns, Heading: heading, Start: i}
+
+		for i += 2; i < len(lines) && len(strings.TrimSpace(lines[i])) != 0 && strings.Contains(lines[i], "|"); i++ {
+			t.Rows = append(t.Rows, fit(splitRow(lines[i]), len(header)))
+		}
+
+		t.End = i
+		tables = append(tables, t)
+
+		// The line ending the table may start a new block.
+		i--
+	}
+
+	return tables
+}
+
+func fit(cells []string, n int) []string {
+	for len(cells) < n {
+		cells = append(cells, "")
+	}
+
+	return cells[:n]
+}
+
+func escapeCell(cell string) string {
+	cell = strings.ReplaceAll(cell, "|", "\\|")
+	cell = strings.ReplaceAll(cell, "\r\n", "<br>")
+
+	return strings.ReplaceAll(cell, "\n", "<br>")
+}
+
+// Markdown renders the table with its columns padded to the same width.
+func (t Table) Markdown() string {
+	n := len(t.Header)
+
+	widths := make([]int, n)
+
+	measure := func(cells []string) {
+		for i, cell := range fit(append([]string(nil), cells...), n) {
+			if w := utf8.RuneCountInString(escapeCell(cell)); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	measure(t.Header)
+
+	for _, row := range t.Rows {
+		measure(row)
+	}
+
+	var b strings.Builder
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+
+		for i, cell := range fit(append([]string(nil), cells...), n) {
+			cell = escapeCell(cell)
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+
+			if i < len(t.Align) && t.Align[i] == AlignRight {
+				b.WriteString(" " + pad + cell + " |")
+			} else {
+				b.WriteString(" " + cell + pad + " |")
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	writeRow(t.Header)
+
+	b.WriteString("|")
+
+	for i := 0; i < n; i++ {
+		w := widths[i]
+		if w < 3 {
+			w = 3
+		}
+
+		align := AlignNone
+		if i < len(t.Align) {
+			align = t.Align[i]
+		}
+
+		switch align {
+		case AlignLeft:
+			b.WriteString(" :" + strings.Repeat("-", w-1) + " |")
+		case AlignRight:
+			b.WriteString(" " + strings.Repeat("-", w-1) + ": |")
+		case AlignCenter:
+			b.WriteString(" :" + strings.Repeat("-", w-2) + ": |")
+		default:
+			b.WriteString(" " + strings.Repeat("-", w) + " |")
+		}
+	}
+
+	b.WriteString("\n")
+
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+
+	return b.String()
+}
+
+// Replace returns body with the lines of old replaced by the rendering of t.
+func Replace(body string, old Table, t Table) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	rendered := strings.Split(strings.TrimSuffix(t.Markdown(), "\n"), "\n")
+
+	out := append([]string(nil), lines[:old.Start]...)
+	out = append(out, rendered...)
+	out = append(out, lines[old.End:]...)
+
+	return strings.Join(out, "\n")
+}
+
+// WriteCSV writes the header and rows of the table as CSV, separated by comma.
+func (t Table) WriteCSV(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	err := cw.Write(t.Header)
+	if err != nil {
+		return err
+	}
+
+	err = cw.WriteAll(t.Rows)
+	if err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// ReadCSV reads a header and rows from CSV separated by comma. Rows may have
+// fewer or more fields than the header, they are fitted to it.
+func ReadCSV(r io.Reader, comma rune) ([]string, [][]string, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("no header row")
+	}
+
+	header := records[0]
+
+	var rows [][]string
+
+	for _, record := range records[1:] {
+		rows = append(rows, fit(record, len(header)))
+	}
+
+	return header, rows, nil
+}
+
+// ParseTables returns the tables of a note.
+func ParseTables(client *goplin.Client, noteID string) ([]Table, error) {
+	note, err := client.GetNote(noteID, "id,body")
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(note.Body), nil
+}
+
+// Get returns the table of a note at index, counted from 0.
+func Get(client *goplin.Client, noteID string, index int) (Table, error) {
+	tables, err := ParseTables(client, noteID)
+	if err != nil {
+		return Table{}, err
+	}
+
+	if index < 0 || index >= len(tables) {
+		return Table{}, fmt.Errorf("note '%s' has %d tables, there is no table %d", noteID, len(tables), index)
+	}
+
+	return tables[index], nil
+}
+
+// Update replaces the header and rows of the table of a note at index. The
+// alignment of columns keeping their name is kept.
+func Update(client *goplin.Client, noteID string, index int, header []string, rows [][]string) error {
+	note, err := client.GetNote(noteID, "id,body")
+	if err != nil {
+		return err
+	}
+
+	tables := Parse(note.Body)
+
+	if index < 0 || index >= len(tables) {
+		return fmt.Errorf("note '%s' has %d tables, there is no table %d", noteID, len(tables), index)
+	}
+
+	old := tables[index]
+
+	updated := Table{Header: header}
+
+	for _, title := range header {
+		align := AlignNone
+		if i := old.Column(title); i >= 0 {
+			align = old.Align[i]
+		}
+
+		updated.Align = append(updated.Align, align)
+	}
+
+	for _, row := range rows {
+		updated.Rows = append(updated.Rows, fit(append([]string(nil), row...), len(header)))
+	}
+
+	return client.ApplyNoteUpdate(goplin.NewNoteUpdate(noteID).SetBody(Replace(note.Body, old, updated)))
+}
@@ -0,0 +1,142 @@
+package table
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/momo182/goplin"
+)
+
+// TrackOptions of Track.
+type TrackOptions struct {
+	// Heading picks the table under this heading and titles the table when
+	// it is created.
+	Heading string
+	// Now fills the date and time columns left empty.
+	Now time.Time
+}
+
+// TrackResult tells where a row was added.
+type TrackResult struct {
+	Index   int      `json:"index"`
+	Row     []string `json:"row"`
+	Created bool     `json:"created"`
+}
+
+// matches reports whether a table has all columns, or at least its heading
+// when heading is set.
+func (t Table) matches(columns []string, heading string) bool {
+	if len(heading) != 0 {
+		return strings.EqualFold(strings.TrimSpace(t.Heading), strings.TrimSpace(heading))
+	}
+
+	for _, column := range columns {
+		if t.Column(column) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Track appends a row of values, one per column, to the first table of a note
+// with these columns, or under opts.Heading. Columns missing from the table
+// are added to it. Without such a table, one is created at the end of the
+// note. Empty values of columns named date or time are filled from opts.Now.
+func Track(client *goplin.Client, noteID string, columns []string, values []string, opts TrackOptions) (TrackResult, error) {
+	var result TrackResult
+
+	if len(columns) == 0 {
+		return result, fmt.Errorf("no columns given")
+	}
+
+	if len(values) > len(columns) {
+		return result, fmt.Errorf("%d values given for %d columns", len(values), len(columns))
+	}
+
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	note, err := client.GetNote(noteID, "id,body")
+	if err != nil {
+		return result, err
+	}
+
+	tables := Parse(note.Body)
+
+	result.Index = -1
+
+	for i, t := range tables {
+		if t.matches(columns, opts.Heading) {
+			result.Index = i
+			break
+		}
+	}
+
+	var old, t Table
+
+	if result.Index >= 0 {
+		old = tables[result.Index]
+		t = old
+	} else {
+		result.Index = len(tables)
+		result.Created = true
+
+		t = Table{Header: append([]string(nil), columns...), Align: make([]string, len(columns))}
+	}
+
+	for _, column := range columns {
+		if t.Column(column) < 0 {
+			t.Header = append(t.Header, column)
+			t.Align = append(t.Align, AlignNone)
+		}
+	}
+
+	row := make([]string, len(t.Header))
+
+	for i, column := range columns {
+		value := ""
+		if i < len(values) {
+			value = strings.TrimSpace(values[i])
+		}
+
+		if len(value) == 0 {
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "date":
+				value = opts.Now.Format("2006-01-02")
+			case "time":
+				value = opts.Now.Format("15:04")
+			}
+		}
+
+		row[t.Column(column)] = value
+	}
+
+	for i := range t.Rows {
+		t.Rows[i] = fit(t.Rows[i], len(t.Header))
+	}
+
+	t.Rows = append(t.Rows, row)
+	result.Row = row
+
+	var body string
+
+	if result.Created {
+		body = strings.TrimRight(note.Body, "\n")
+		if len(body) != 0 {
+			body += "\n\n"
+		}
+
+		if len(opts.Heading) != 0 {
+			body += "## " + opts.Heading + "\n\n"
+		}
+
+		body += t.Markdown()
+	} else {
+		body = Replace(note.Body, old, t)
+	}
+
+	return result, client.ApplyNoteUpdate(goplin.NewNoteUpdate(noteID).SetBody(body))
+}
@@ -0,0 +1,255 @@
+package goplin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TagMapVersion is the version of the tag map format written by GetTagMap.
+const TagMapVersion = 1
+
+// TagMapEntry holds the tags of a note, which is found again by the path of
+// its folder and its title, as IDs change when notes are imported again.
+type TagMapEntry struct {
+	Folder string   `json:"folder"`
+	Title  string   `json:"title"`
+	Tags   []string `json:"tags"`
+}
+
+// TagMap is a portable record of which notes carry which tags.
+type TagMap struct {
+	Version int           `json:"version"`
+	Notes   []TagMapEntry `json:"notes"`
+}
+
+// TagMapAttach lists the tags to attach to a note, or detach from it.
+type TagMapAttach struct {
+	NoteID string   `json:"note_id"`
+	Title  string   `json:"title"`
+	Tags   []string `json:"tags"`
+	// TagIDs are the IDs of the tags to detach.
+	TagIDs []string `json:"tag_ids,omitempty"`
+}
+
+// TagMapPlan is what applying a tag map changes.
+type TagMapPlan struct {
+	// CreateTags are the titles of the tags to create.
+	CreateTags []string       `json:"create_tags,omitempty"`
+	Attach     []TagMapAttach `json:"attach,omitempty"`
+	// Detach lists the tags not in the map, removed when replacing.
+	Detach []TagMapAttach `json:"detach,omitempty"`
+	// Unmatched entries have no note with their title.
+	Unmatched []TagMapEntry `json:"unmatched,omitempty"`
+	// Ambiguous entries match several notes, none is tagged.
+	Ambiguous []TagMapEntry `json:"ambiguous,omitempty"`
+}
+
+// noteFolderPaths returns the folder path of every note, by ID.
+func (c *Client) noteFolderPaths(notes []Note) (map[string]string, error) {
+	tree, err := c.GetFolderTree()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := FolderPaths(tree)
+
+	byNote := make(map[string]string, len(notes))
+	for _, note := range notes {
+		byNote[note.ID] = paths[note.ParentID]
+	}
+
+	return byNote, nil
+}
+
+// GetTagMap returns the tags of every tagged note, sorted by folder and title.
+func (c *Client) GetTagMap() (TagMap, error) {
+	m := TagMap{Version: TagMapVersion, Notes: []TagMapEntry{}}
+
+	tags, err := c.NoteTagTitles()
+	if err != nil {
+		return m, err
+	}
+
+	notes, err := c.GetAllNotes("id,parent_id,title", "", "")
+	if err != nil {
+		return m, err
+	}
+
+	folders, err := c.noteFolderPaths(notes)
+	if err != nil {
+		return m, err
+	}
+
+	for _, note := range notes {
+		if len(tags[note.ID]) == 0 {
+			continue
+		}
+
+		m.Notes = append(m.Notes, TagMapEntry{Folder: folders[note.ID], Title: note.Title, Tags: tags[note.ID]})
+	}
+
+	sort.SliceStable(m.Notes, func(i, j int) bool {
+		if m.Notes[i].Folder != m.Notes[j].Folder {
+			return m.Notes[i].Folder < m.Notes[j].Folder
+		}
+
+		return m.Notes[i].Title < m.Notes[j].Title
+	})
+
+	return m, nil
+}
+
+// PlanTagMap matches the entries of a tag map to notes, by folder path and
+// title, else by title alone when a single note has it, and plans attaching
+// the missing tags. Tags are matched by folded title and created when
+// missing. When replace is set, the tags of matched notes that are not in
+// the map are detached.
+func (c *Client) PlanTagMap(m TagMap, replace bool) (TagMapPlan, error) {
+	var plan TagMapPlan
+
+	if m.Version > TagMapVersion {
+		return plan, fmt.Errorf("tag map version %d is newer than the supported version %d", m.Version, TagMapVersion)
+	}
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return plan, err
+	}
+
+	tagsByTitle := make(map[string]Tag)
+	titles := make(map[string]string)
+
+	for _, tag := range index.tags {
+		tagsByTitle[FoldTagTitle(tag.Title)] = tag
+		titles[tag.ID] = tag.Title
+	}
+
+	notes, err := c.GetAllNotes("id,parent_id,title", "", "")
+	if err != nil {
+		return plan, err
+	}
+
+	folders, err := c.noteFolderPaths(notes)
+	if err != nil {
+		return plan, err
+	}
+
+	byPath := make(map[string][]Note)
+	byTitle := make(map[string][]Note)
+
+	for _, note := range notes {
+		key := strings.ToLower(folders[note.ID]) + "\x00" + note.Title
+
+		byPath[key] = append(byPath[key], note)
+		byTitle[note.Title] = append(byTitle[note.Title], note)
+	}
+
+	created := make(map[string]bool)
+
+	for _, entry := range m.Notes {
+		candidates := byPath[strings.ToLower(entry.Folder)+"\x00"+entry.Title]
+		if len(candidates) == 0 {
+			candidates = byTitle[entry.Title]
+		}
+
+		switch len(candidates) {
+		case 0:
+			plan.Unmatched = append(plan.Unmatched, entry)
+			continue
+		case 1:
+		default:
+			plan.Ambiguous = append(plan.Ambiguous, entry)
+			continue
+		}
+
+		note := candidates[0]
+
+		current := make(map[string]bool)
+		for _, tagID := range index.tagsByID[note.ID] {
+			current[FoldTagTitle(titles[tagID])] = true
+		}
+
+		wanted := make(map[string]bool)
+		attach := TagMapAttach{NoteID: note.ID, Title: note.Title}
+
+		for _, title := range entry.Tags {
+			key := FoldTagTitle(title)
+			if len(key) == 0 || wanted[key] {
+				continue
+			}
+
+			wanted[key] = true
+
+			if current[key] {
+				continue
+			}
+
+			if _, ok := tagsByTitle[key]; !ok && !created[key] {
+				created[key] = true
+				plan.CreateTags = append(plan.CreateTags, strings.TrimSpace(title))
+			}
+
+			attach.Tags = append(attach.Tags, strings.TrimSpace(title))
+		}
+
+		if len(attach.Tags) != 0 {
+			plan.Attach = append(plan.Attach, attach)
+		}
+
+		if !replace {
+			continue
+		}
+
+		detach := TagMapAttach{NoteID: note.ID, Title: note.Title}
+
+		for _, tagID := range index.tagsByID[note.ID] {
+			if !wanted[FoldTagTitle(titles[tagID])] {
+				detach.Tags = append(detach.Tags, titles[tagID])
+				detach.TagIDs = append(detach.TagIDs, tagID)
+			}
+		}
+
+		if len(detach.Tags) != 0 {
+			plan.Detach = append(plan.Detach, detach)
+		}
+	}
+
+	return plan, nil
+}
+
+// TagMapBatch queues the changes of a plan on a new batch, for the caller to
+// review and run: tags are created first, then attached and detached.
+func (c *Client) TagMapBatch(plan TagMapPlan) (*Batch, error) {
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string)
+	for _, tag := range index.tags {
+		ids[FoldTagTitle(tag.Title)] = tag.ID
+	}
+
+	b := c.NewBatch()
+
+	for _, title := range plan.CreateTags {
+		ids[FoldTagTitle(title)] = b.CreateTag(Tag{Title: title})
+	}
+
+	b.Barrier()
+
+	for _, attach := range plan.Attach {
+		for _, title := range attach.Tags {
+			b.TagNote(attach.NoteID, ids[FoldTagTitle(title)])
+		}
+	}
+
+	for _, detach := range plan.Detach {
+		for _, tagID := range detach.TagIDs {
+			b.UntagNote(detach.NoteID, tagID)
+		}
+	}
+
+	return b, nil
+}
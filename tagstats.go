@@ -0,0 +1,221 @@
+package goplin
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const tagIndexTTL = time.Minute
+
+// tagIndex maps tags to the notes carrying them. It is never changed once
+// built, so it can be read while the cache builds the next one.
+type tagIndex struct {
+	tags     []Tag
+	notesBy  map[string][]string
+	tagsByID map[string][]string
+}
+
+// tagIndexCache holds the tag index of a client for tagIndexTTL, as building
+// it costs one request per tag.
+type tagIndexCache struct {
+	mu    sync.Mutex
+	built time.Time
+	index *tagIndex
+}
+
+type TagCount struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Notes int    `json:"notes"`
+}
+
+type TagPair struct {
+	A     TagCount `json:"a"`
+	B     TagCount `json:"b"`
+	Notes int      `json:"notes"`
+}
+
+type TagStatsReport struct {
+	Counts       []TagCount `json:"counts"`
+	Unused       []Tag      `json:"unused"`
+	CoOccurrence []TagPair  `json:"co_occurrence"`
+}
+
+func (c *Client) buildTagIndex() (*tagIndex, error) {
+	c.tags.mu.Lock()
+	defer c.tags.mu.Unlock()
+
+	if c.tags.index != nil && !c.tags.built.IsZero() && time.Since(c.tags.built) < tagIndexTTL {
+		return c.tags.index, nil
+	}
+
+	tags, err := c.GetAllTagsWithFields("id,parent_id,title,updated_time,user_updated_time", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	notesBy := make(map[string][]string)
+	tagsByID := make(map[string][]string)
+
+	for _, tag := range tags {
+		notes, err := c.GetNotesByTagWithFields(tag.ID, "id", "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, note := range notes {
+			notesBy[tag.ID] = append(notesBy[tag.ID], note.ID)
+			tagsByID[note.ID] = append(tagsByID[note.ID], tag.ID)
+		}
+	}
+
+	c.tags.index = &tagIndex{tags: tags, notesBy: notesBy, tagsByID: tagsByID}
+	c.tags.built = time.Now()
+
+	return c.tags.index, nil
+}
+
+// InvalidateCache drops cached metadata so the next call refetches it.
+func (c *Client) InvalidateCache() {
+	c.tags.mu.Lock()
+	c.tags.built = time.Time{}
+	c.tags.mu.Unlock()
+
+	c.meta.mu.Lock()
+	c.meta.built = time.Time{}
+	c.meta.mu.Unlock()
+}
+
+// NoteTagTitles returns the titles of the tags of every tagged note, sorted,
+// by note ID.
+func (c *Client) NoteTagTitles() (map[string][]string, error) {
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[string]string, len(index.tags))
+	for _, tag := range index.tags {
+		titles[tag.ID] = tag.Title
+	}
+
+	byNote := make(map[string][]string, len(index.tagsByID))
+
+	for noteID, tagIDs := range index.tagsByID {
+		for _, tagID := range tagIDs {
+			byNote[noteID] = append(byNote[noteID], titles[tagID])
+		}
+
+		sort.Strings(byNote[noteID])
+	}
+
+	return byNote, nil
+}
+
+// FindUnusedTags returns the tags attached to no note. When olderThan is set,
+// only tags not modified within that duration are returned.
+func (c *Client) FindUnusedTags(olderThan time.Duration) ([]Tag, error) {
+	var unused []Tag
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return unused, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+
+	for _, tag := range index.tags {
+		if len(index.notesBy[tag.ID]) != 0 {
+			continue
+		}
+
+		if olderThan != 0 {
+			updated := tag.UpdatedTime
+			if tag.UserUpdatedTime > updated {
+				updated = tag.UserUpdatedTime
+			}
+
+			if time.UnixMilli(int64(updated)).After(cutoff) {
+				continue
+			}
+		}
+
+		unused = append(unused, tag)
+	}
+
+	return unused, nil
+}
+
+func (c *Client) TagStats() (TagStatsReport, error) {
+	var report TagStatsReport
+
+	index, err := c.buildTagIndex()
+	if err != nil {
+		return report, err
+	}
+
+	counts := make(map[string]TagCount)
+
+	for _, tag := range index.tags {
+		count := TagCount{
+			ID:    tag.ID,
+			Title: tag.Title,
+			Notes: len(index.notesBy[tag.ID]),
+		}
+
+		counts[tag.ID] = count
+
+		if count.Notes == 0 {
+			report.Unused = append(report.Unused, tag)
+		} else {
+			report.Counts = append(report.Counts, count)
+		}
+	}
+
+	sort.Slice(report.Counts, func(i, j int) bool {
+		if report.Counts[i].Notes != report.Counts[j].Notes {
+			return report.Counts[i].Notes > report.Counts[j].Notes
+		}
+
+		return report.Counts[i].Title < report.Counts[j].Title
+	})
+
+	pairs := make(map[[2]string]int)
+
+	for _, tagIDs := range index.tagsByID {
+		for i := 0; i < len(tagIDs); i++ {
+			for j := i + 1; j < len(tagIDs); j++ {
+				a, b := tagIDs[i], tagIDs[j]
+				if b < a {
+					a, b = b, a
+				}
+
+				pairs[[2]string{a, b}]++
+			}
+		}
+	}
+
+	for key, n := range pairs {
+		report.CoOccurrence = append(report.CoOccurrence, TagPair{
+			A:     counts[key[0]],
+			B:     counts[key[1]],
+			Notes: n,
+		})
+	}
+
+	sort.Slice(report.CoOccurrence, func(i, j int) bool {
+		pi, pj := report.CoOccurrence[i], report.CoOccurrence[j]
+		if pi.Notes != pj.Notes {
+			return pi.Notes > pj.Notes
+		}
+
+		if pi.A.Title != pj.A.Title {
+			return pi.A.Title < pj.A.Title
+		}
+
+		return pi.B.Title < pj.B.Title
+	})
+
+	return report, nil
+}
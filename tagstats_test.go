@@ -0,0 +1,53 @@
+package goplin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTagStatsConcurrentRebuild(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items interface{}
+
+		switch {
+		case r.URL.Path == "/tags/":
+			items = []Tag{{ID: "a", Title: "a"}, {ID: "b", Title: "b"}, {ID: "unused", Title: "unused"}}
+		case strings.HasSuffix(r.URL.Path, "/notes") && !strings.Contains(r.URL.Path, "/unused/"):
+			items = []Note{{ID: "1"}, {ID: "2"}}
+		default:
+			items = []Note{}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "has_more": false})
+	}))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				report, err := client.TagStats()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if len(report.Counts) != 2 || len(report.Unused) != 1 || len(report.CoOccurrence) != 1 || report.CoOccurrence[0].Notes != 2 {
+					t.Errorf("got %+v, want a and b on two notes and one unused tag", report)
+					return
+				}
+
+				client.InvalidateCache()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
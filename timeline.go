@@ -0,0 +1,124 @@
+package goplin
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Kinds of timeline entries.
+const (
+	TimelineCreated   = "created"
+	TimelineUpdated   = "updated"
+	TimelineCompleted = "completed"
+	TimelineDeleted   = "deleted"
+)
+
+// editGrace is how long after its creation a note may be saved without the
+// save showing up as a separate update.
+const editGrace = time.Minute
+
+// TimelineEntry is something that happened to a note.
+type TimelineEntry struct {
+	Time   int    `json:"time"`
+	Kind   string `json:"kind"`
+	NoteID string `json:"note_id"`
+	Title  string `json:"title"`
+}
+
+// TimelineDay groups the entries of a day, in local time.
+type TimelineDay struct {
+	Date    string          `json:"date"`
+	Counts  map[string]int  `json:"counts"`
+	Entries []TimelineEntry `json:"entries"`
+}
+
+// listEvents returns the change events Joplin still keeps, oldest first.
+func (c *Client) listEvents() ([]Event, error) {
+	events, _, err := c.GetEvents("0")
+
+	return events, err
+}
+
+// BuildTimeline groups note activity after since into days, most recent day
+// first and entries of a day in chronological order. Deletions come from
+// change events, which Joplin only keeps for a limited time.
+func BuildTimeline(notes []Note, events []Event, since time.Time) []TimelineDay {
+	var entries []TimelineEntry
+
+	from := int(since.UnixMilli())
+
+	add := func(ms int, kind string, id string, title string) {
+		if ms >= from {
+			entries = append(entries, TimelineEntry{Time: ms, Kind: kind, NoteID: id, Title: title})
+		}
+	}
+
+	for _, note := range notes {
+		add(note.CreatedTime, TimelineCreated, note.ID, note.Title)
+
+		if note.UpdatedTime-note.CreatedTime > int(editGrace.Milliseconds()) {
+			add(note.UpdatedTime, TimelineUpdated, note.ID, note.Title)
+		}
+
+		if note.IsTodo == 1 && note.TodoCompleted != 0 {
+			add(note.TodoCompleted, TimelineCompleted, note.ID, note.Title)
+		}
+	}
+
+	for _, event := range events {
+		if event.Type != EventDeleted || event.ItemType != EventItemNote {
+			continue
+		}
+
+		title := ""
+
+		var before struct {
+			Title string `json:"title"`
+		}
+
+		if json.Unmarshal([]byte(event.BeforeChangeItem), &before) == nil {
+			title = before.Title
+		}
+
+		add(event.CreatedTime, TimelineDeleted, event.ItemID, title)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time < entries[j].Time })
+
+	var days []TimelineDay
+
+	index := make(map[string]int)
+
+	for _, entry := range entries {
+		date := time.UnixMilli(int64(entry.Time)).Format("2006-01-02")
+
+		i, ok := index[date]
+		if !ok {
+			i = len(days)
+			index[date] = i
+			days = append(days, TimelineDay{Date: date, Counts: make(map[string]int)})
+		}
+
+		days[i].Entries = append(days[i].Entries, entry)
+		days[i].Counts[entry.Kind]++
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Date > days[j].Date })
+
+	return days
+}
+
+// GetTimeline returns the note activity since the given time, day by day.
+// The timeline is still built when the events cannot be read, without
+// deletions.
+func (c *Client) GetTimeline(since time.Time) ([]TimelineDay, error) {
+	notes, err := c.getNoteMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	events, _ := c.listEvents()
+
+	return BuildTimeline(notes, events, since), nil
+}
@@ -0,0 +1,175 @@
+package goplin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Timings measure where the time of the requests of a client goes, see
+// EnableTimings. Nothing leaves the process.
+type Timings struct {
+	mu       sync.Mutex
+	calls    []time.Duration
+	decoding time.Duration
+}
+
+// TimingSummary sums up Timings. A call lasts from sending the request to
+// reading the last byte of the response, decoding from then to the payload
+// being decoded.
+type TimingSummary struct {
+	Calls    int           `json:"calls"`
+	Total    time.Duration `json:"total"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	Decoding time.Duration `json:"decoding"`
+}
+
+// Summary sums up the calls measured so far.
+func (t *Timings) Summary() TimingSummary {
+	t.mu.Lock()
+	calls := append([]time.Duration(nil), t.calls...)
+	summary := TimingSummary{Calls: len(calls), Decoding: t.decoding}
+	t.mu.Unlock()
+
+	sort.Slice(calls, func(i, j int) bool { return calls[i] < calls[j] })
+
+	for _, d := range calls {
+		summary.Total += d
+	}
+
+	summary.P50 = percentile(calls, 50)
+	summary.P95 = percentile(calls, 95)
+
+	return summary
+}
+
+func (t *Timings) addCall(d time.Duration) {
+	t.mu.Lock()
+	t.calls = append(t.calls, d)
+	t.mu.Unlock()
+}
+
+func (t *Timings) addDecoding(d time.Duration) {
+	t.mu.Lock()
+	t.decoding += d
+	t.mu.Unlock()
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// timingKey is the context key of the callTiming of a request.
+type timingKey struct{}
+
+// callTiming tells when the response of a request was read.
+type callTiming struct {
+	mu   sync.Mutex
+	read time.Time
+}
+
+// timingTransport measures the calls sent to next.
+type timingTransport struct {
+	next    http.RoundTripper
+	timings *Timings
+}
+
+func (t *timingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		t.timings.addCall(time.Since(start))
+		return resp, err
+	}
+
+	call, _ := r.Context().Value(timingKey{}).(*callTiming)
+	resp.Body = &timedBody{ReadCloser: resp.Body, start: start, timings: t.timings, call: call}
+
+	return resp, nil
+}
+
+// timedBody records the call when the body is read to the end or closed.
+type timedBody struct {
+	io.ReadCloser
+	start   time.Time
+	timings *Timings
+	call    *callTiming
+	once    sync.Once
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.done()
+	}
+
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.done()
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) done() {
+	b.once.Do(func() {
+		now := time.Now()
+		b.timings.addCall(now.Sub(b.start))
+
+		if b.call != nil {
+			b.call.mu.Lock()
+			b.call.read = now
+			b.call.mu.Unlock()
+		}
+	})
+}
+
+// EnableTimings starts measuring the requests of the client and returns the
+// measures, to be called before sending requests. Time spent waiting in the
+// write queue does not count.
+func (c *Client) EnableTimings() *Timings {
+	timings := &Timings{}
+
+	c.writes.next = &timingTransport{next: c.writes.next, timings: timings}
+
+	c.handle.OnBeforeRequest(func(_ *req.Client, r *req.Request) error {
+		r.SetContext(context.WithValue(r.Context(), timingKey{}, &callTiming{}))
+		return nil
+	})
+
+	c.handle.OnAfterResponse(func(_ *req.Client, resp *req.Response) error {
+		call, _ := resp.Request.Context().Value(timingKey{}).(*callTiming)
+		if call == nil {
+			return nil
+		}
+
+		call.mu.Lock()
+		read := call.read
+		call.mu.Unlock()
+
+		if !read.IsZero() {
+			timings.addDecoding(time.Since(read))
+		}
+
+		return nil
+	})
+
+	return timings
+}
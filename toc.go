@@ -0,0 +1,189 @@
+package goplin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Markers delimiting the generated part of a table of contents note. Text
+// outside them is left alone when the note is regenerated.
+const (
+	TOCBegin = "<!-- goplin-toc:begin -->"
+	TOCEnd   = "<!-- goplin-toc:end -->"
+)
+
+// TOCOrders lists the accepted TOCOptions.OrderBy values.
+var TOCOrders = []string{"title", "updated_time", "created_time"}
+
+// TOCOptions configure a table of contents note.
+type TOCOptions struct {
+	// Title of the note created when the folder has none yet.
+	Title string
+	// OrderBy sorts the notes of each folder: title, updated_time or
+	// created_time.
+	OrderBy string
+	// Desc reverses the order.
+	Desc bool
+}
+
+// ReplaceMarkerBlock replaces the text between begin and end in body by
+// content, or appends a new block when body has none.
+func ReplaceMarkerBlock(body string, begin string, end string, content string) string {
+	block := begin + "\n" + strings.TrimRight(content, "\n") + "\n" + end
+
+	start := strings.Index(body, begin)
+	if start >= 0 {
+		if stop := strings.Index(body[start:], end); stop >= 0 {
+			return body[:start] + block + body[start+stop+len(end):]
+		}
+	}
+
+	if len(strings.TrimSpace(body)) == 0 {
+		return block + "\n"
+	}
+
+	return strings.TrimRight(body, "\n") + "\n\n" + block + "\n"
+}
+
+func sortTOCNotes(notes []Note, opts TOCOptions) {
+	less := func(a, b Note) bool {
+		switch opts.OrderBy {
+		case "updated_time":
+			return a.UpdatedTime < b.UpdatedTime
+		case "created_time":
+			return a.CreatedTime < b.CreatedTime
+		}
+
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		if opts.Desc {
+			return less(notes[j], notes[i])
+		}
+
+		return less(notes[i], notes[j])
+	})
+}
+
+// RenderTOC lists the notes of root and its sub-folders as Markdown links,
+// with a heading per sub-folder. The note skipID, the index itself, is left
+// out.
+func RenderTOC(root *FolderNode, notes map[string][]Note, skipID string, opts TOCOptions) string {
+	var b strings.Builder
+
+	escape := strings.NewReplacer("[", "\\[", "]", "\\]")
+
+	WalkFolders([]*FolderNode{root}, func(node *FolderNode, depth int) {
+		if depth > 0 {
+			level := depth + 1
+			if level > 6 {
+				level = 6
+			}
+
+			fmt.Fprintf(&b, "\n%s %s\n\n", strings.Repeat("#", level), node.Folder.Title)
+		}
+
+		folderNotes := append([]Note(nil), notes[node.Folder.ID]...)
+		sortTOCNotes(folderNotes, opts)
+
+		for _, note := range folderNotes {
+			if note.ID == skipID {
+				continue
+			}
+
+			fmt.Fprintf(&b, "- [%s](:/%s)\n", escape.Replace(note.Title), note.ID)
+		}
+	})
+
+	return b.String()
+}
+
+// UpdateTOCNote writes the table of contents of a folder, given by ID or
+// path, into the note of that folder holding the TOC markers, creating the
+// note when there is none. The note is only saved when its content changes.
+// It returns the note and whether it was created or changed.
+func (c *Client) UpdateTOCNote(folder string, opts TOCOptions) (Note, bool, error) {
+	if len(opts.Title) == 0 {
+		opts.Title = "Index"
+	}
+
+	valid := false
+	for _, order := range TOCOrders {
+		valid = valid || len(opts.OrderBy) == 0 || opts.OrderBy == order
+	}
+
+	if !valid {
+		return Note{}, false, fmt.Errorf("invalid order '%s', expected one of %s", opts.OrderBy, strings.Join(TOCOrders, ", "))
+	}
+
+	tree, err := c.GetFolderTree()
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	root, err := FindFolderNode(tree, folder)
+	if err != nil {
+		return Note{}, false, err
+	}
+
+	notes := make(map[string][]Note)
+
+	var index Note
+
+	fields := "id,parent_id,title,created_time,updated_time"
+
+	var walkErr error
+
+	WalkFolders([]*FolderNode{root}, func(node *FolderNode, depth int) {
+		if walkErr != nil {
+			return
+		}
+
+		notes[node.Folder.ID], walkErr = c.GetNotesInFolder(node.Folder.ID, fields, "", "")
+	})
+
+	if walkErr != nil {
+		return Note{}, false, walkErr
+	}
+
+	// Titles alone cannot tell which note is the index, look for the markers.
+	for _, note := range notes[root.Folder.ID] {
+		full, err := c.GetNote(note.ID, "id", "parent_id", "title", "body")
+		if err != nil {
+			return Note{}, false, err
+		}
+
+		if strings.Contains(full.Body, TOCBegin) {
+			index = full
+			break
+		}
+	}
+
+	content := RenderTOC(root, notes, index.ID, opts)
+
+	if len(index.ID) == 0 {
+		note, err := c.CreateNote(Note{
+			ParentID: root.Folder.ID,
+			Title:    opts.Title,
+			Body:     ReplaceMarkerBlock("", TOCBegin, TOCEnd, content),
+		})
+
+		return note, err == nil, err
+	}
+
+	body := ReplaceMarkerBlock(index.Body, TOCBegin, TOCEnd, content)
+	if body == index.Body {
+		return index, false, nil
+	}
+
+	err = c.ApplyNoteUpdate(NewNoteUpdate(index.ID).SetBody(body))
+	if err != nil {
+		return index, false, err
+	}
+
+	index.Body = body
+
+	return index, true, nil
+}
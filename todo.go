@@ -0,0 +1,83 @@
+package goplin
+
+import (
+	"time"
+)
+
+// TodoFields lists the note fields TodoFilter needs to evaluate a note.
+var TodoFields = []string{"is_todo", "todo_due", "todo_completed"}
+
+// TodoFilter selects to-do notes by completion state and due date. All set
+// criteria must match; a zero filter matches every note.
+type TodoFilter struct {
+	Open      bool
+	Done      bool
+	Overdue   bool
+	DueWithin time.Duration
+	Now       time.Time
+}
+
+func (f TodoFilter) IsZero() bool {
+	return !f.Open && !f.Done && !f.Overdue && f.DueWithin == 0
+}
+
+func (f TodoFilter) Match(note Note) bool {
+	if f.IsZero() {
+		return true
+	}
+
+	if note.IsTodo == 0 {
+		return false
+	}
+
+	completed := note.TodoCompleted != 0
+
+	if f.Open && completed {
+		return false
+	}
+
+	if f.Done && !completed {
+		return false
+	}
+
+	now := f.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	due := time.UnixMilli(int64(note.TodoDue))
+
+	if f.Overdue {
+		if completed || note.TodoDue == 0 || !due.Before(now) {
+			return false
+		}
+	}
+
+	if f.DueWithin != 0 {
+		if completed || note.TodoDue == 0 || due.After(now.Add(f.DueWithin)) {
+			return false
+		}
+
+		if !f.Overdue && due.Before(now) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f TodoFilter) Apply(notes []Note) []Note {
+	if f.IsZero() {
+		return notes
+	}
+
+	var filtered []Note
+
+	for _, note := range notes {
+		if f.Match(note) {
+			filtered = append(filtered, note)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,258 @@
+// Package transcribe turns audio attachments into text and writes the
+// transcripts into the notes they are attached to.
+package transcribe
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/media"
+)
+
+// Transcriber turns an audio file into text.
+type Transcriber interface {
+	Transcribe(path string) (string, error)
+}
+
+// Config selects and configures the transcription backend, as read from the
+// transcribe section of the config file.
+type Config struct {
+	// Backend is whisper (a local binary, the default) or http.
+	Backend string `mapstructure:"backend"`
+	// Command is the whisper binary, "whisper" by default.
+	Command  string `mapstructure:"command"`
+	Model    string `mapstructure:"model"`
+	Language string `mapstructure:"language"`
+	// URL is the endpoint of an OpenAI compatible transcription API.
+	URL    string `mapstructure:"url"`
+	APIKey string `mapstructure:"api_key"`
+}
+
+// New returns the transcriber configured by cfg.
+func New(cfg Config) (Transcriber, error) {
+	switch cfg.Backend {
+	case "", "whisper":
+		return &Whisper{Command: cfg.Command, Model: cfg.Model, Language: cfg.Language}, nil
+	case "http":
+		if len(cfg.URL) == 0 {
+			return nil, fmt.Errorf("the http transcription backend needs a url")
+		}
+
+		return &HTTP{URL: cfg.URL, APIKey: cfg.APIKey, Model: cfg.Model, Language: cfg.Language}, nil
+	}
+
+	return nil, fmt.Errorf("unknown transcription backend '%s', expected whisper or http", cfg.Backend)
+}
+
+// Whisper runs the openai-whisper command line tool.
+type Whisper struct {
+	Command  string
+	Model    string
+	Language string
+}
+
+func (w *Whisper) Transcribe(path string) (string, error) {
+	command := w.Command
+	if len(command) == 0 {
+		command = "whisper"
+	}
+
+	dir, err := os.MkdirTemp("", "goplin-whisper-*")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(dir)
+
+	args := []string{path, "--output_format", "txt", "--output_dir", dir}
+
+	if len(w.Model) != 0 {
+		args = append(args, "--model", w.Model)
+	}
+
+	if len(w.Language) != 0 {
+		args = append(args, "--language", w.Language)
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	text, err := os.ReadFile(filepath.Join(dir, base+".txt"))
+	if err != nil {
+		return "", fmt.Errorf("%s wrote no transcript: %w", command, err)
+	}
+
+	return strings.TrimSpace(string(text)), nil
+}
+
+// HTTP posts the audio to an OpenAI compatible /audio/transcriptions
+// endpoint.
+type HTTP struct {
+	URL      string
+	APIKey   string
+	Model    string
+	Language string
+}
+
+var httpClient = req.C().
+	SetTimeout(10 * time.Minute).
+	SetUserAgent("goplin")
+
+func (h *HTTP) Transcribe(path string) (string, error) {
+	model := h.Model
+	if len(model) == 0 {
+		model = "whisper-1"
+	}
+
+	form := map[string]string{
+		"model":           model,
+		"response_format": "text",
+	}
+
+	if len(h.Language) != 0 {
+		form["language"] = h.Language
+	}
+
+	r := httpClient.R().
+		SetFile("file", path).
+		SetFormData(form)
+
+	if len(h.APIKey) != 0 {
+		r = r.SetHeader("Authorization", "Bearer "+h.APIKey)
+	}
+
+	resp, err := r.Post(h.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.IsSuccess() {
+		return strings.TrimSpace(resp.String()), nil
+	}
+
+	return "", fmt.Errorf("transcription API returned status %d: %s", resp.StatusCode, strings.TrimSpace(resp.String()))
+}
+
+// Markers delimit the transcript of a resource in a note body.
+func Markers(resourceID string) (string, string) {
+	return fmt.Sprintf("<!-- goplin-transcript:%s:begin -->", resourceID),
+		fmt.Sprintf("<!-- goplin-transcript:%s:end -->", resourceID)
+}
+
+// IsAudio reports whether mime is an audio type.
+func IsAudio(mime string) bool {
+	return strings.HasPrefix(mime, "audio/")
+}
+
+// Options of Process.
+type Options struct {
+	// Force transcribes resources already transcribed in their notes.
+	Force bool
+	// DryRun only reports the resources that would be transcribed.
+	DryRun bool
+}
+
+// Result is the outcome of transcribing a resource.
+type Result struct {
+	Resource goplin.Resource `json:"resource"`
+	Notes    []string        `json:"notes"`
+	Skipped  bool            `json:"skipped,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Process transcribes the audio resources among resources, which need the id,
+// title, mime and file_extension fields, and writes each transcript into the
+// notes linking to the resource between its Markers. Resources whose notes
+// all hold a transcript already are skipped unless opts.Force is set.
+func Process(client *goplin.Client, t Transcriber, resources []goplin.Resource, opts Options) ([]Result, error) {
+	var results []Result
+
+	for _, resource := range resources {
+		if !IsAudio(resource.Mime) {
+			continue
+		}
+
+		result := Result{Resource: resource}
+
+		notes, err := client.GetResourceNotes(resource.ID, "id,title,body")
+		if err != nil {
+			return results, err
+		}
+
+		begin, end := Markers(resource.ID)
+
+		var pending []goplin.Note
+
+		for _, note := range notes {
+			if opts.Force || !strings.Contains(note.Body, begin) {
+				pending = append(pending, note)
+			}
+		}
+
+		if len(pending) == 0 {
+			result.Skipped = true
+			results = append(results, result)
+
+			continue
+		}
+
+		for _, note := range pending {
+			result.Notes = append(result.Notes, note.ID)
+		}
+
+		if opts.DryRun {
+			results = append(results, result)
+			continue
+		}
+
+		text, err := transcribeResource(client, t, resource)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+
+			continue
+		}
+
+		section := fmt.Sprintf("**Transcript of %s**\n\n%s", resource.Title, text)
+
+		for _, note := range pending {
+			body := goplin.ReplaceMarkerBlock(note.Body, begin, end, section)
+
+			err = client.ApplyNoteUpdate(goplin.NewNoteUpdate(note.ID).SetBody(body))
+			if err != nil {
+				return results, err
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func transcribeResource(client *goplin.Client, t Transcriber, resource goplin.Resource) (string, error) {
+	path, err := media.DownloadTemp(client, resource)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(path)
+
+	return t.Transcribe(path)
+}
@@ -0,0 +1,79 @@
+package goplin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InTrash reports whether the note was moved to the trash, as told by its
+// deleted_time. Notes read without that field are never in the trash.
+func (n Note) InTrash() bool {
+	return n.DeletedTime != 0
+}
+
+// trashFields adds deleted_time to fields, DefaultNoteFields when empty, when
+// the connected Joplin has a trash, so that the notes in it can be told apart.
+func (c *Client) trashFields(fields string) (string, error) {
+	if len(strings.TrimSpace(fields)) == 0 {
+		fields = DefaultNoteFields
+	}
+
+	caps, err := c.Capabilities()
+	if err != nil {
+		return fields, err
+	}
+
+	if !caps.Trash {
+		return fields, nil
+	}
+
+	for _, field := range strings.Split(fields, ",") {
+		if strings.TrimSpace(field) == "deleted_time" {
+			return fields, nil
+		}
+	}
+
+	return fields + ",deleted_time", nil
+}
+
+// GetLiveNotes is GetAllNotes leaving out the notes in the trash, which
+// DeleteNote moves notes to unless told to delete them permanently.
+func (c *Client) GetLiveNotes(fields string, orderBy string, orderDir string) ([]Note, error) {
+	fields, err := c.trashFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := c.GetAllNotes(fields, orderBy, orderDir)
+	if err != nil {
+		return nil, err
+	}
+
+	live := notes[:0]
+	for _, note := range notes {
+		if !note.InTrash() {
+			live = append(live, note)
+		}
+	}
+
+	return live, nil
+}
+
+// GetLiveNote is GetNote failing with ErrNotFound for a note in the trash.
+func (c *Client) GetLiveNote(id string, fields string) (Note, error) {
+	fields, err := c.trashFields(fields)
+	if err != nil {
+		return Note{}, err
+	}
+
+	note, err := c.GetNote(id, fields)
+	if err != nil {
+		return note, err
+	}
+
+	if note.InTrash() {
+		return note, fmt.Errorf("note '%s' is in the trash: %w", id, ErrNotFound)
+	}
+
+	return note, nil
+}
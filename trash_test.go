@@ -0,0 +1,86 @@
+package goplin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// trashServer serves notes, one of them in the trash when trash is set, and
+// fails requests for deleted_time otherwise, like Joplin before 3.0.
+func trashServer(t *testing.T, trash bool) *Client {
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/notes") {
+			http.NotFound(w, r)
+			return
+		}
+
+		withDeleted := strings.Contains(r.URL.Query().Get("fields"), "deleted_time")
+		if withDeleted && !trash {
+			http.Error(w, `{"error":"no such column: deleted_time"}`, http.StatusInternalServerError)
+			return
+		}
+
+		notes := []Note{{ID: "live", Title: "live"}, {ID: "trashed", Title: "trashed"}}
+		if withDeleted {
+			notes[1].DeletedTime = 1700000000000
+		}
+
+		if id := strings.TrimPrefix(r.URL.Path, "/notes/"); id != r.URL.Path {
+			for _, note := range notes {
+				if note.ID == id {
+					json.NewEncoder(w).Encode(note)
+					return
+				}
+			}
+
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": notes, "has_more": false})
+	}))
+}
+
+func TestGetLiveNotes(t *testing.T) {
+	tests := []struct {
+		name  string
+		trash bool
+		want  []string
+	}{
+		{"trash", true, []string{"live"}},
+		{"no trash", false, []string{"live", "trashed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := trashServer(t, tt.trash)
+
+			notes, err := client.GetLiveNotes("id,title", "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, note := range notes {
+				got = append(got, note.ID)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			_, err = client.GetLiveNote("trashed", "")
+			if tt.trash && !errors.Is(err, ErrNotFound) {
+				t.Errorf("got %v for a note in the trash, want ErrNotFound", err)
+			}
+
+			if !tt.trash && err != nil {
+				t.Errorf("got %v, want the note", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package goplin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UserDataValue is a single entry of the user_data field. T is the time the
+// value was set and D marks deleted entries, so synced copies can be merged.
+type UserDataValue struct {
+	V interface{} `json:"v"`
+	T int         `json:"t"`
+	D int         `json:"d,omitempty"`
+}
+
+// UserData is the decoded user_data field of a note or folder: values keyed by
+// namespace, usually a plugin or application ID, then by key.
+type UserData map[string]map[string]UserDataValue
+
+// ParseUserData decodes a user_data field; an empty field is an empty map.
+func ParseUserData(s string) (UserData, error) {
+	data := make(UserData)
+
+	if len(s) == 0 {
+		return data, nil
+	}
+
+	err := json.Unmarshal([]byte(s), &data)
+	if err != nil {
+		return data, fmt.Errorf("invalid user_data: %w", err)
+	}
+
+	return data, nil
+}
+
+// Get returns the value stored under namespace and key, or nil when there is
+// none or it was deleted.
+func (d UserData) Get(namespace string, key string) interface{} {
+	entry, ok := d[namespace][key]
+	if !ok || entry.D != 0 {
+		return nil
+	}
+
+	return entry.V
+}
+
+// Set stores value under namespace and key, stamped with the current time.
+func (d UserData) Set(namespace string, key string, value interface{}) {
+	if d[namespace] == nil {
+		d[namespace] = make(map[string]UserDataValue)
+	}
+
+	d[namespace][key] = UserDataValue{V: value, T: int(time.Now().UnixMilli())}
+}
+
+// Delete marks the value under namespace and key as deleted.
+func (d UserData) Delete(namespace string, key string) {
+	if _, ok := d[namespace][key]; ok {
+		d[namespace][key] = UserDataValue{T: int(time.Now().UnixMilli()), D: 1}
+	}
+}
+
+func (d UserData) String() string {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+func (c *Client) getNoteUserData(noteID string) (UserData, error) {
+	err := c.require("user data", func(caps Capabilities) bool { return caps.UserData })
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := c.GetNote(noteID, "id", "user_data")
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseUserData(note.UserData)
+}
+
+// GetUserData returns the value a note holds under namespace and key, or nil
+// if it is not set, like userDataGet in the plugin API.
+func (c *Client) GetUserData(noteID string, namespace string, key string) (interface{}, error) {
+	data, err := c.getNoteUserData(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Get(namespace, key), nil
+}
+
+// SetUserData stores value on a note under namespace and key, keeping the
+// values of other namespaces and keys.
+func (c *Client) SetUserData(noteID string, namespace string, key string, value interface{}) error {
+	data, err := c.getNoteUserData(noteID)
+	if err != nil {
+		return err
+	}
+
+	data.Set(namespace, key, value)
+
+	return c.SetNoteFields(noteID, map[string]interface{}{"user_data": data.String()})
+}
+
+// DeleteUserData removes the value a note holds under namespace and key.
+func (c *Client) DeleteUserData(noteID string, namespace string, key string) error {
+	data, err := c.getNoteUserData(noteID)
+	if err != nil {
+		return err
+	}
+
+	data.Delete(namespace, key)
+
+	return c.SetNoteFields(noteID, map[string]interface{}{"user_data": data.String()})
+}
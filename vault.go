@@ -0,0 +1,254 @@
+package goplin
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// vaultNoteFields are loaded for every note handled by a Vault.
+const vaultNoteFields = "id,parent_id,title,body,created_time,updated_time,author,source_url,is_todo,todo_due,todo_completed,application_data,markup_language,user_data"
+
+// Vault is a session over a client for scripts touching many items. Folders,
+// tags and notes are loaded on first use and cached; changes to notes are
+// collected and sent by Save or Flush, one request per note however many
+// fields changed:
+//
+//	vault := goplin.NewVault(client)
+//	vault.Note(id).SetTitle("Done").Set("is_todo", 0)
+//	err := vault.Flush()
+//
+// The cache is never refreshed on its own, call Reset after changing the
+// vault by other means.
+type Vault struct {
+	client *Client
+
+	mu      sync.Mutex
+	folders []Folder
+	tags    []Tag
+	notes   map[string]*VaultNote
+}
+
+func NewVault(client *Client) *Vault {
+	return &Vault{
+		client: client,
+		notes:  make(map[string]*VaultNote),
+	}
+}
+
+// VaultNote is a lazily loaded note with pending changes.
+type VaultNote struct {
+	vault  *Vault
+	id     string
+	note   *Note
+	update *NoteUpdate
+}
+
+// Note returns the handle of a note without loading it. Handles are shared,
+// so changes made through any of them are saved together.
+func (v *Vault) Note(id string) *VaultNote {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	n, ok := v.notes[id]
+	if !ok {
+		n = &VaultNote{vault: v, id: id, update: NewNoteUpdate(id)}
+		v.notes[id] = n
+	}
+
+	return n
+}
+
+// Folders returns all folders, loading them on first use.
+func (v *Vault) Folders() ([]Folder, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.folders == nil {
+		folders, err := v.client.GetAllFolders("id,parent_id,title,icon,created_time,updated_time", "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		v.folders = folders
+	}
+
+	return v.folders, nil
+}
+
+// Tags returns all tags, loading them on first use.
+func (v *Vault) Tags() ([]Tag, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.tags == nil {
+		tags, err := v.client.GetAllTagsWithFields("id,parent_id,title,created_time,updated_time", "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		v.tags = tags
+	}
+
+	return v.tags, nil
+}
+
+// NotesInFolder loads the notes of a folder into the cache in one request per
+// page and returns their handles.
+func (v *Vault) NotesInFolder(folderID string) ([]*VaultNote, error) {
+	notes, err := v.client.GetNotesInFolder(folderID, vaultNoteFields, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	handles := make([]*VaultNote, 0, len(notes))
+
+	for i := range notes {
+		n := v.Note(notes[i].ID)
+
+		v.mu.Lock()
+		n.note = &notes[i]
+		v.mu.Unlock()
+
+		handles = append(handles, n)
+	}
+
+	return handles, nil
+}
+
+// Dirty returns the notes with unsaved changes, sorted by ID.
+func (v *Vault) Dirty() []*VaultNote {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var dirty []*VaultNote
+
+	for _, n := range v.notes {
+		if len(n.update.fields) != 0 {
+			dirty = append(dirty, n)
+		}
+	}
+
+	sort.Slice(dirty, func(i, j int) bool {
+		return dirty[i].id < dirty[j].id
+	})
+
+	return dirty
+}
+
+// Flush saves every note with unsaved changes, stopping at the first error.
+func (v *Vault) Flush() error {
+	for _, n := range v.Dirty() {
+		err := n.Save()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reset drops cached items and discards unsaved changes.
+func (v *Vault) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.folders = nil
+	v.tags = nil
+	v.notes = make(map[string]*VaultNote)
+}
+
+func (n *VaultNote) ID() string {
+	return n.id
+}
+
+// Get returns the note with its unsaved changes applied, loading it first if
+// needed.
+func (n *VaultNote) Get() (Note, error) {
+	n.vault.mu.Lock()
+	defer n.vault.mu.Unlock()
+
+	if n.note == nil {
+		note, err := n.vault.client.GetNote(n.id, vaultNoteFields)
+		if err != nil {
+			return note, err
+		}
+
+		n.note = &note
+	}
+
+	if len(n.update.fields) == 0 {
+		return *n.note, nil
+	}
+
+	// Overlay the coerced changes through the JSON form of the note.
+	var fields map[string]interface{}
+
+	data, err := json.Marshal(n.note)
+	if err != nil {
+		return *n.note, err
+	}
+
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return *n.note, err
+	}
+
+	for name, value := range n.update.fields {
+		fields[name], err = CoerceNoteField(name, value)
+		if err != nil {
+			return *n.note, err
+		}
+	}
+
+	var note Note
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return note, err
+	}
+
+	return note, json.Unmarshal(data, &note)
+}
+
+// Set changes a field; the value is validated when read back or saved.
+func (n *VaultNote) Set(field string, value interface{}) *VaultNote {
+	n.vault.mu.Lock()
+	n.update.Set(field, value)
+	n.vault.mu.Unlock()
+
+	return n
+}
+
+func (n *VaultNote) SetTitle(title string) *VaultNote {
+	return n.Set("title", title)
+}
+
+func (n *VaultNote) SetBody(body string) *VaultNote {
+	return n.Set("body", body)
+}
+
+func (n *VaultNote) SetParent(parentID string) *VaultNote {
+	return n.Set("parent_id", parentID)
+}
+
+// Save sends the unsaved changes of the note in a single request. The note is
+// loaded again on next use to pick up the times set by Joplin.
+func (n *VaultNote) Save() error {
+	n.vault.mu.Lock()
+	defer n.vault.mu.Unlock()
+
+	if len(n.update.fields) == 0 {
+		return nil
+	}
+
+	err := n.vault.client.ApplyNoteUpdate(n.update)
+	if err != nil {
+		return err
+	}
+
+	n.update = NewNoteUpdate(n.id)
+	n.note = nil
+
+	return nil
+}
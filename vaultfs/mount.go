@@ -0,0 +1,326 @@
+//go:build linux || darwin
+
+package vaultfs
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	gofs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+// Mount serves f through FUSE on the directory dir until it is unmounted.
+// The kernel caches nothing, every access goes to f, so the mount shows the
+// vault as of the last Update.
+//
+// Note files are written in place and saved when closed. Editors saving to a
+// new file renamed over the note are not supported.
+func Mount(f *FS, dir string) (Server, error) {
+	_, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var noCache time.Duration
+
+	return gofs.Mount(dir, &fuseNode{fsys: f, path: "."}, &gofs.Options{
+		MountOptions: fuse.MountOptions{
+			FsName: "goplin",
+			Name:   "goplin",
+		},
+		EntryTimeout:    &noCache,
+		AttrTimeout:     &noCache,
+		NegativeTimeout: &noCache,
+	})
+}
+
+// fuseNode is the file or directory at path in the vault.
+type fuseNode struct {
+	gofs.Inode
+
+	fsys *FS
+	path string
+}
+
+var (
+	_ gofs.NodeLookuper  = (*fuseNode)(nil)
+	_ gofs.NodeReaddirer = (*fuseNode)(nil)
+	_ gofs.NodeGetattrer = (*fuseNode)(nil)
+	_ gofs.NodeSetattrer = (*fuseNode)(nil)
+	_ gofs.NodeOpener    = (*fuseNode)(nil)
+)
+
+// errno returns the error number reported for err.
+func errno(err error) syscall.Errno {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT
+	case errors.Is(err, fs.ErrPermission):
+		return syscall.EACCES
+	case errors.Is(err, fs.ErrInvalid):
+		return syscall.EINVAL
+	}
+
+	return syscall.EIO
+}
+
+// ino returns the inode number of n, from the ID of its note or resource so
+// that it stays the same when the item is renamed.
+func ino(p string, n *node) uint64 {
+	key := p
+	switch {
+	case len(n.noteID) != 0:
+		key = n.noteID
+	case len(n.resourceID) != 0:
+		key = n.resourceID
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	return h.Sum64()
+}
+
+func fuseMode(n *node) uint32 {
+	mode := (&fileInfo{n: n}).Mode()
+	if n.dir {
+		return fuse.S_IFDIR | uint32(mode.Perm())
+	}
+
+	return fuse.S_IFREG | uint32(mode.Perm())
+}
+
+func setAttr(out *fuse.Attr, p string, n *node, size int64) {
+	out.Ino = ino(p, n)
+	out.Mode = fuseMode(n)
+	out.Size = uint64(size)
+	out.SetTimes(nil, &n.modTime, &n.modTime)
+}
+
+func (n *fuseNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*gofs.Inode, syscall.Errno) {
+	p := path.Join(n.path, name)
+
+	child, _, err := n.fsys.lookup("lookup", p)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	setAttr(&out.Attr, p, child, child.size)
+
+	return n.NewInode(ctx, &fuseNode{fsys: n.fsys, path: p}, gofs.StableAttr{
+		Mode: fuseMode(child) & syscall.S_IFMT,
+		Ino:  ino(p, child),
+	}), 0
+}
+
+func (n *fuseNode) Readdir(ctx context.Context) (gofs.DirStream, syscall.Errno) {
+	entries, err := n.fsys.ReadDir(n.path)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	list := make([]fuse.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errno(err)
+		}
+
+		child := info.(*fileInfo).n
+		p := path.Join(n.path, entry.Name())
+
+		list = append(list, fuse.DirEntry{
+			Name: entry.Name(),
+			Mode: fuseMode(child),
+			Ino:  ino(p, child),
+		})
+	}
+
+	return gofs.NewListDirStream(list), 0
+}
+
+func (n *fuseNode) Getattr(ctx context.Context, fh gofs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	node, _, err := n.fsys.lookup("stat", n.path)
+	if err != nil {
+		return errno(err)
+	}
+
+	size := node.size
+	if file, ok := fh.(*fuseFile); ok {
+		size = file.size()
+	}
+
+	setAttr(&out.Attr, n.path, node, size)
+
+	return 0
+}
+
+// Setattr only changes the size of notes, other changes are ignored.
+func (n *fuseNode) Setattr(ctx context.Context, fh gofs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	size, ok := in.GetSize()
+	if !ok {
+		return n.Getattr(ctx, fh, out)
+	}
+
+	if file, open := fh.(*fuseFile); open {
+		file.truncate(int64(size))
+
+		return n.Getattr(ctx, fh, out)
+	}
+
+	file, code := n.open(syscall.O_WRONLY)
+	if code != 0 {
+		return code
+	}
+
+	file.truncate(int64(size))
+
+	code = file.Flush(ctx)
+	if code != 0 {
+		return code
+	}
+
+	return n.Getattr(ctx, nil, out)
+}
+
+func (n *fuseNode) Open(ctx context.Context, flags uint32) (gofs.FileHandle, uint32, syscall.Errno) {
+	file, code := n.open(flags)
+	if code != 0 {
+		return nil, 0, code
+	}
+
+	// Sizes in the listing are not those of the notes as read, so reads go
+	// to the file rather than stopping at the size the kernel knows.
+	return file, fuse.FOPEN_DIRECT_IO, 0
+}
+
+// open reads the content of the file, unless flags truncate it.
+func (n *fuseNode) open(flags uint32) (*fuseFile, syscall.Errno) {
+	node, _, err := n.fsys.lookup("open", n.path)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	write := flags&syscall.O_ACCMODE != syscall.O_RDONLY
+	if write && len(node.noteID) == 0 {
+		return nil, syscall.EACCES
+	}
+
+	file := &fuseFile{fsys: n.fsys, path: n.path}
+
+	if flags&syscall.O_TRUNC != 0 && write {
+		file.dirty = true
+		return file, 0
+	}
+
+	f, err := n.fsys.Open(n.path)
+	if err != nil {
+		return nil, errno(err)
+	}
+	defer f.Close()
+
+	file.data, err = io.ReadAll(f)
+	if err != nil {
+		return nil, errno(err)
+	}
+
+	return file, 0
+}
+
+// fuseFile is an open file, its content held in memory.
+type fuseFile struct {
+	fsys *FS
+	path string
+
+	mu    sync.Mutex
+	data  []byte
+	dirty bool
+}
+
+var (
+	_ gofs.FileReader  = (*fuseFile)(nil)
+	_ gofs.FileWriter  = (*fuseFile)(nil)
+	_ gofs.FileFlusher = (*fuseFile)(nil)
+	_ gofs.FileFsyncer = (*fuseFile)(nil)
+)
+
+func (f *fuseFile) size() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return int64(len(f.data))
+}
+
+func (f *fuseFile) truncate(size int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if size > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+	}
+
+	f.data = f.data[:size]
+	f.dirty = true
+}
+
+func (f *fuseFile) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if off >= int64(len(f.data)) {
+		return fuse.ReadResultData(nil), 0
+	}
+
+	end := off + int64(len(dest))
+	if end > int64(len(f.data)) {
+		end = int64(len(f.data))
+	}
+
+	return fuse.ReadResultData(f.data[off:end]), 0
+}
+
+func (f *fuseFile) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := off + int64(len(data))
+	if end > int64(len(f.data)) {
+		f.data = append(f.data, make([]byte, end-int64(len(f.data)))...)
+	}
+
+	copy(f.data[off:], data)
+	f.dirty = true
+
+	return uint32(len(data)), 0
+}
+
+// Flush saves the note when the file changed.
+func (f *fuseFile) Flush(ctx context.Context) syscall.Errno {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.dirty {
+		return 0
+	}
+
+	err := f.fsys.WriteNote(f.path, f.data)
+	if err != nil {
+		return errno(err)
+	}
+
+	f.dirty = false
+
+	return 0
+}
+
+func (f *fuseFile) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	return f.Flush(ctx)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package vaultfs
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Mount serves f through FUSE on the directory dir. FUSE is supported on
+// Linux and macOS only.
+func Mount(f *FS, dir string) (Server, error) {
+	return nil, fmt.Errorf("mounting is supported on Linux and macOS, not %s", runtime.GOOS)
+}
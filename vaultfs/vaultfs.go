@@ -0,0 +1,432 @@
+// Package vaultfs presents the vault as a file system: folders are
+// directories, notes are Markdown files with front matter and resources are
+// files below the top-level "_resources" directory.
+//
+// FS implements io/fs.FS for any tool reading io/fs file systems, and Mount
+// serves it through FUSE. Writing a note file maps to a note update.
+package vaultfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/momo182/goplin"
+	"github.com/momo182/goplin/export"
+)
+
+// ResourcesDir is the top-level directory holding the resource files.
+const ResourcesDir = "_resources"
+
+// Server is a mounted file system.
+type Server interface {
+	// Unmount unmounts the file system.
+	Unmount() error
+	// Wait returns once the file system is unmounted.
+	Wait()
+}
+
+type node struct {
+	name       string
+	dir        bool
+	noteID     string
+	resourceID string
+	size       int64
+	modTime    time.Time
+	children   []string
+}
+
+// FS is a file system view of the vault. It is safe for concurrent use.
+type FS struct {
+	client *goplin.Client
+
+	mu    sync.Mutex
+	nodes map[string]*node
+	// cursor is the change event the listing is up to date with.
+	cursor string
+}
+
+// New returns a file system for the vault of client. Its listing is loaded on
+// first access and kept until Update finds changes.
+func New(client *goplin.Client) *FS {
+	return &FS{client: client}
+}
+
+// Invalidate makes the next access load the listing again.
+func (f *FS) Invalidate() {
+	f.mu.Lock()
+	f.nodes = nil
+	f.mu.Unlock()
+}
+
+// Update reads the change events recorded since the listing was loaded and
+// invalidates it when there are any, reporting whether it did. Joplin records
+// events for notes only: folders and resources added, renamed or deleted show
+// with the next change of a note.
+func (f *FS) Update() (bool, error) {
+	f.mu.Lock()
+	cursor := f.cursor
+	f.mu.Unlock()
+
+	if len(cursor) == 0 {
+		return false, nil
+	}
+
+	events, next, err := f.client.GetEvents(cursor)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// A load in between already holds these changes.
+	if f.cursor != cursor {
+		return false, nil
+	}
+
+	f.cursor = next
+
+	if len(events) == 0 {
+		return false, nil
+	}
+
+	f.nodes = nil
+
+	return true, nil
+}
+
+func msTime(ms int) time.Time {
+	return time.UnixMilli(int64(ms))
+}
+
+// load returns the current listing, loading it when it was invalidated.
+func (f *FS) load() (map[string]*node, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.nodes != nil {
+		return f.nodes, nil
+	}
+
+	// Taken first, so that the changes made while loading are found by the
+	// next Update.
+	_, cursor, err := f.client.GetEvents("")
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := f.client.GetFolderTree()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[string]*node{".": {name: ".", dir: true}}
+	names := export.NewSlugger(export.SluggerOptions{}, export.PatternTitle)
+
+	add := func(p string, n *node) string {
+		parent := path.Dir(p)
+		n.name = path.Base(p)
+
+		nodes[p] = n
+		nodes[parent].children = append(nodes[parent].children, n.name)
+
+		return p
+	}
+
+	// Added first so a folder of the same name cannot take its place.
+	add(names.Unique(".", ResourcesDir, "", ""), &node{dir: true, modTime: time.Now()})
+
+	dirs := make(map[string]string)
+
+	goplin.WalkFolders(tree, func(folder *goplin.FolderNode, depth int) {
+		parent := "."
+		if folder.Parent != nil {
+			parent = dirs[folder.Parent.Folder.ID]
+		}
+
+		dirs[folder.Folder.ID] = add(names.Unique(parent, names.Segment(folder.Folder.Title), folder.Folder.ID, ""), &node{
+			dir:     true,
+			modTime: msTime(folder.Folder.UpdatedTime),
+		})
+	})
+
+	notes, err := f.client.GetLiveNotes("id,parent_id,title,created_time,updated_time", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first, so that the names stay the same from one load to the
+	// next.
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].CreatedTime != notes[j].CreatedTime {
+			return notes[i].CreatedTime < notes[j].CreatedTime
+		}
+
+		return notes[i].ID < notes[j].ID
+	})
+
+	for _, note := range notes {
+		parent, ok := dirs[note.ParentID]
+		if !ok {
+			continue
+		}
+
+		add(names.Path(parent, note.Title, note.ID, ".md"), &node{
+			noteID:  note.ID,
+			modTime: msTime(note.UpdatedTime),
+		})
+	}
+
+	resources, err := f.client.GetAllResources("id,title,filename,file_extension,size,updated_time", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range resources {
+		name := resource.ID
+		if len(resource.FileExtension) != 0 {
+			name += "." + resource.FileExtension
+		}
+
+		add(names.Unique(ResourcesDir, name, "", ""), &node{
+			resourceID: resource.ID,
+			size:       int64(resource.Size),
+			modTime:    msTime(resource.UpdatedTime),
+		})
+	}
+
+	for _, n := range nodes {
+		sort.Strings(n.children)
+	}
+
+	f.nodes = nodes
+	f.cursor = cursor
+
+	return nodes, nil
+}
+
+// lookup returns the node of name and the listing holding it.
+func (f *FS) lookup(op string, name string) (*node, map[string]*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	nodes, err := f.load()
+	if err != nil {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	n, ok := nodes[name]
+	if !ok {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return n, nodes, nil
+}
+
+// noteContent renders a note the way its file is read.
+func (f *FS) noteContent(id string) ([]byte, error) {
+	note, err := f.client.GetNote(id, "id", "title", "body", "updated_time")
+	if err != nil {
+		return nil, err
+	}
+
+	return export.FormatMarkdownNote(export.FrontMatter{
+		ID:          note.ID,
+		Title:       note.Title,
+		UpdatedTime: note.UpdatedTime,
+	}, note.Body)
+}
+
+// Open opens the named file or directory. The content of notes and resources
+// is fetched when they are opened.
+func (f *FS) Open(name string) (fs.File, error) {
+	n, _, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &fileInfo{n: n}
+
+	if n.dir {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return &openDir{info: info, entries: entries}, nil
+	}
+
+	var data []byte
+
+	if len(n.noteID) != 0 {
+		data, err = f.noteContent(n.noteID)
+	} else {
+		var blob bytes.Buffer
+
+		err = f.client.GetResourceFile(n.resourceID, &blob)
+		data = blob.Bytes()
+	}
+
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	info = &fileInfo{n: n, size: int64(len(data)), sized: true}
+
+	return &openFile{info: info, Reader: bytes.NewReader(data)}, nil
+}
+
+// ReadDir lists a directory. Note sizes are only known once a note is opened
+// and are reported as zero until then.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, nodes, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !n.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(n.children))
+	for _, child := range n.children {
+		entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{n: nodes[path.Join(name, child)]}))
+	}
+
+	return entries, nil
+}
+
+// WriteNote updates the note of the named file from data, a Markdown file as
+// read from the file system. Front matter is optional; its title, when set,
+// renames the note.
+func (f *FS) WriteNote(name string, data []byte) error {
+	n, _, err := f.lookup("write", name)
+	if err != nil {
+		return err
+	}
+
+	if len(n.noteID) == 0 {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrPermission}
+	}
+
+	front, body, err := export.ParseMarkdownNote(data)
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+
+	update := goplin.NewNoteUpdate(n.noteID).SetBody(body)
+	if len(front.Title) != 0 {
+		update.SetTitle(front.Title)
+	}
+
+	err = f.client.ApplyNoteUpdate(update)
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+
+	if len(front.Title) != 0 {
+		f.Invalidate()
+	}
+
+	return nil
+}
+
+type fileInfo struct {
+	n     *node
+	size  int64
+	sized bool
+}
+
+func (i *fileInfo) Name() string {
+	return i.n.name
+}
+
+func (i *fileInfo) Size() int64 {
+	if i.sized {
+		return i.size
+	}
+
+	return i.n.size
+}
+
+func (i *fileInfo) Mode() fs.FileMode {
+	switch {
+	case i.n.dir:
+		return fs.ModeDir | 0o555
+	case len(i.n.noteID) != 0:
+		return 0o644
+	default:
+		return 0o444
+	}
+}
+
+func (i *fileInfo) ModTime() time.Time {
+	return i.n.modTime
+}
+
+func (i *fileInfo) IsDir() bool {
+	return i.n.dir
+}
+
+func (i *fileInfo) Sys() interface{} {
+	return nil
+}
+
+type openFile struct {
+	*bytes.Reader
+	info *fileInfo
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *openFile) Close() error {
+	return nil
+}
+
+type openDir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *openDir) Close() error {
+	return nil
+}
+
+func (d *openDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+
+	if count <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(rest) {
+		count = len(rest)
+	}
+
+	d.offset += count
+
+	return rest[:count], nil
+}
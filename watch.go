@@ -0,0 +1,269 @@
+package goplin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WatchRule selects the notes whose creations and updates a Watcher reports.
+// A note matches when it meets every criterion set.
+type WatchRule struct {
+	Name string `mapstructure:"name" json:"name"`
+	// Tag is the title of a tag of the note, compared without case.
+	Tag string `mapstructure:"tag" json:"tag,omitempty"`
+	// Folder is the ID or path of the folder holding the note, directly or
+	// in a sub-folder.
+	Folder string `mapstructure:"folder" json:"folder,omitempty"`
+	// Title is a regular expression matching the title of the note.
+	Title string `mapstructure:"title" json:"title,omitempty"`
+	// Events are the changes reported, TimelineCreated or TimelineUpdated,
+	// both when empty.
+	Events []string `mapstructure:"events" json:"events,omitempty"`
+}
+
+// WatchMatch is a change of a note matching a rule.
+type WatchMatch struct {
+	Rule  string   `json:"rule"`
+	Event string   `json:"event"`
+	Note  Note     `json:"note"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type watchRule struct {
+	WatchRule
+	title  *regexp.Regexp
+	events map[string]bool
+	// folders holds the IDs of the folder of the rule and its sub-folders,
+	// resolved on every poll as folders move.
+	folders map[string]bool
+}
+
+// Watcher reports the changes of notes matching rules, from the change events
+// of Joplin.
+type Watcher struct {
+	client *Client
+	rules  []*watchRule
+	cursor string
+}
+
+// NewWatcher returns a watcher of the changes made from now on to the notes
+// matching rules.
+func (c *Client) NewWatcher(rules []WatchRule) (*Watcher, error) {
+	w := &Watcher{client: c}
+
+	for i, rule := range rules {
+		if len(rule.Name) == 0 {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		r := &watchRule{WatchRule: rule, events: make(map[string]bool)}
+
+		if len(rule.Title) != 0 {
+			re, err := regexp.Compile(rule.Title)
+			if err != nil {
+				return nil, fmt.Errorf("watch rule '%s': invalid title: %w", rule.Name, err)
+			}
+
+			r.title = re
+		}
+
+		events := rule.Events
+		if len(events) == 0 {
+			events = []string{TimelineCreated, TimelineUpdated}
+		}
+
+		for _, event := range events {
+			event = strings.ToLower(strings.TrimSpace(event))
+			if event != TimelineCreated && event != TimelineUpdated {
+				return nil, fmt.Errorf("watch rule '%s': unknown event '%s', expected %s or %s", rule.Name, event, TimelineCreated, TimelineUpdated)
+			}
+
+			r.events[event] = true
+		}
+
+		w.rules = append(w.rules, r)
+	}
+
+	_, cursor, err := c.GetEvents("")
+	if err != nil {
+		return nil, err
+	}
+
+	w.cursor = cursor
+
+	return w, nil
+}
+
+// resolveFolders looks up the folders of the rules having one.
+func (w *Watcher) resolveFolders() error {
+	var tree []*FolderNode
+
+	for _, r := range w.rules {
+		if len(r.Folder) == 0 {
+			continue
+		}
+
+		if tree == nil {
+			var err error
+
+			tree, err = w.client.GetFolderTree()
+			if err != nil {
+				return err
+			}
+		}
+
+		root, err := FindFolderNode(tree, r.Folder)
+		if err != nil {
+			return fmt.Errorf("watch rule '%s': %w", r.Name, err)
+		}
+
+		r.folders = map[string]bool{root.Folder.ID: true}
+
+		WalkFolders(root.Children, func(node *FolderNode, depth int) {
+			r.folders[node.Folder.ID] = true
+		})
+	}
+
+	return nil
+}
+
+func (r *watchRule) match(event string, note Note, tags []string) bool {
+	if !r.events[event] {
+		return false
+	}
+
+	if r.title != nil && !r.title.MatchString(note.Title) {
+		return false
+	}
+
+	if len(r.Folder) != 0 && !r.folders[note.ParentID] {
+		return false
+	}
+
+	if len(r.Tag) != 0 {
+		for _, tag := range tags {
+			if strings.EqualFold(tag, r.Tag) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// Poll returns the matches of the changes made since the last poll, a note
+// changed several times being reported once. When it fails, the changes are
+// reported by the next poll.
+func (w *Watcher) Poll() ([]WatchMatch, error) {
+	events, cursor, err := w.client.GetEvents(w.cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := w.Match(events)
+	if err != nil {
+		return nil, err
+	}
+
+	w.cursor = cursor
+
+	return matches, nil
+}
+
+// Next returns the change events recorded since the last call, to look at
+// them before passing them to Match.
+func (w *Watcher) Next() ([]Event, error) {
+	events, cursor, err := w.client.GetEvents(w.cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	w.cursor = cursor
+
+	return events, nil
+}
+
+// Match returns the matches of the changes of notes in events, a note changed
+// several times being reported once.
+func (w *Watcher) Match(events []Event) ([]WatchMatch, error) {
+	if len(w.rules) == 0 {
+		return nil, nil
+	}
+
+	// The kind of change of each note, in the order of the events. A note
+	// created then updated counts as created.
+	kinds := make(map[string]string)
+	var order []string
+
+	for _, event := range events {
+		if event.ItemType != EventItemNote {
+			continue
+		}
+
+		var kind string
+
+		switch event.Type {
+		case EventCreated:
+			kind = TimelineCreated
+		case EventUpdated:
+			kind = TimelineUpdated
+		default:
+			continue
+		}
+
+		previous, seen := kinds[event.ItemID]
+		if !seen {
+			order = append(order, event.ItemID)
+		}
+
+		if previous != TimelineCreated {
+			kinds[event.ItemID] = kind
+		}
+	}
+
+	var matches []WatchMatch
+
+	if len(order) != 0 {
+		err := w.resolveFolders()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	needTags := false
+	for _, r := range w.rules {
+		needTags = needTags || len(r.Tag) != 0
+	}
+
+	for _, id := range order {
+		note, err := w.client.GetNote(id, "id,parent_id,title,is_todo,updated_time")
+		if err != nil {
+			// Deleted since.
+			continue
+		}
+
+		var tags []string
+
+		if needTags {
+			noteTags, err := w.client.GetNoteTags(id, "", "")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, tag := range noteTags {
+				tags = append(tags, tag.Title)
+			}
+		}
+
+		for _, r := range w.rules {
+			if r.match(kinds[id], note, tags) {
+				matches = append(matches, WatchMatch{Rule: r.Name, Event: kinds[id], Note: note, Tags: tags})
+			}
+		}
+	}
+
+	return matches, nil
+}
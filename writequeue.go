@@ -0,0 +1,177 @@
+package goplin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWriteConcurrency is the number of write requests a client sends at
+// once.
+const DefaultWriteConcurrency = 2
+
+// WriteQueueOpts configure how a client queues its write requests: Joplin
+// may fail or lose changes under many parallel writes. Writes to the same
+// item never run at the same time; reads are never queued.
+type WriteQueueOpts struct {
+	// Concurrency is the number of writes in flight, DefaultWriteConcurrency
+	// when zero. One serializes every write.
+	Concurrency int `mapstructure:"concurrency"`
+	// Interval is the least time between the start of two writes, none when
+	// zero.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// itemLock serializes the writes to an item, dropped when unused. Its
+// channel holds a value while a write runs.
+type itemLock struct {
+	held  chan struct{}
+	users int
+}
+
+// writeQueue is a transport queuing the requests other than GET and HEAD,
+// shared by every request of a client, batches and importers included. The
+// time spent queued does not count towards the timeout of a request, applied
+// by next.
+type writeQueue struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	slots    chan struct{}
+	interval time.Duration
+	last     time.Time
+	items    map[string]*itemLock
+}
+
+func newWriteQueue(next http.RoundTripper, opts WriteQueueOpts) *writeQueue {
+	q := &writeQueue{next: next, items: make(map[string]*itemLock)}
+	q.configure(opts)
+
+	return q
+}
+
+func (q *writeQueue) configure(opts WriteQueueOpts) {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultWriteConcurrency
+	}
+
+	q.mu.Lock()
+	q.slots = make(chan struct{}, concurrency)
+	q.interval = opts.Interval
+	q.mu.Unlock()
+}
+
+func (q *writeQueue) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return q.next.RoundTrip(r)
+	}
+
+	ctx := r.Context()
+
+	key, ok := itemKey(r.URL.Path)
+	if ok {
+		unlock, err := q.lock(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	q.mu.Lock()
+	slots := q.slots
+	q.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	defer func() { <-slots }()
+
+	err := q.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.next.RoundTrip(r)
+}
+
+// itemKey returns the item a write goes to, /<type>/<id> from the start of
+// its path. Writes to a collection, such as creating a note, go to no item.
+func itemKey(path string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(parts) < 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", false
+	}
+
+	return "/" + parts[0] + "/" + parts[1], true
+}
+
+// lock waits until no other write to the item of key is running, unless ctx
+// is done first, and returns the function ending the write.
+func (q *writeQueue) lock(ctx context.Context, key string) (func(), error) {
+	q.mu.Lock()
+	lock, ok := q.items[key]
+	if !ok {
+		lock = &itemLock{held: make(chan struct{}, 1)}
+		q.items[key] = lock
+	}
+
+	lock.users++
+	q.mu.Unlock()
+
+	release := func() {
+		q.mu.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(q.items, key)
+		}
+		q.mu.Unlock()
+	}
+
+	select {
+	case lock.held <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-lock.held
+		release()
+	}, nil
+}
+
+// wait delays a write until Interval after the start of the previous one,
+// unless ctx is done first.
+func (q *writeQueue) wait(ctx context.Context) error {
+	q.mu.Lock()
+
+	start := time.Now()
+	if next := q.last.Add(q.interval); q.interval > 0 && next.After(start) {
+		start = next
+	}
+
+	q.last = start
+	q.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetWriteQueue configures the queue of write requests, see WriteQueueOpts.
+// Writes in flight finish under the former settings.
+func (c *Client) SetWriteQueue(opts WriteQueueOpts) {
+	c.writes.configure(opts)
+}
@@ -0,0 +1,70 @@
+package goplin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteQueueTimeoutStartsAfterQueue(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		json.NewEncoder(w).Encode(Folder{})
+	}))
+
+	// Each write fits in the timeout, all of them do not.
+	client.timeout = 300 * time.Millisecond
+	client.writes.next.(*timeoutTransport).timeout = client.timeout
+	client.SetWriteQueue(WriteQueueOpts{Concurrency: 1})
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 6)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := client.CreateFolderItem(Folder{Title: "folder"})
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteQueueLockCanceled(t *testing.T) {
+	q := newWriteQueue(http.DefaultTransport, WriteQueueOpts{})
+
+	unlock, err := q.lock(context.Background(), "/notes/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = q.lock(ctx, "/notes/1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the deadline to be exceeded", err)
+	}
+
+	unlock()
+
+	if len(q.items) != 0 {
+		t.Errorf("%d item locks left, want none", len(q.items))
+	}
+}